@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"vault/internal/audit"
+	"vault/internal/config"
+	"vault/internal/storage"
+	"vault/pkg/logger"
+)
+
+func main() {
+	var action, sink, path, head, value string
+
+	flag.StringVar(&action, "action", "", "audit action: verify or hmac")
+	flag.StringVar(&sink, "sink", "file", "audit sink to verify: file or database")
+	flag.StringVar(&path, "path", "", "audit file path, defaults to audit.file_path from the config")
+	flag.StringVar(&head, "head", "", "audit head path, defaults to audit.head_path from the config")
+	flag.StringVar(&value, "value", "", "value to hash with the audit hmac key")
+	flag.Parse()
+
+	if action != "verify" && action != "hmac" {
+		fmt.Println("action flag is required (example: --action=verify)")
+		os.Exit(2)
+	}
+
+	cfg := config.MustLoad()
+
+	if action == "hmac" {
+		if value == "" {
+			fmt.Println("value flag is required (example: --action=hmac --value=<jti>)")
+			os.Exit(2)
+		}
+		auditor, err := audit.New(context.Background(), logger.CreateLogger(cfg.Env), cfg.AuditHMACKey, nil)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(auditor.HMAC(value))
+		return
+	}
+
+	reader, err := openReader(cfg, sink, path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if head == "" {
+		head = cfg.Audit.HeadPath
+	}
+	var expected *audit.Head
+	if head != "" {
+		h, err := audit.NewHeadFile(head).Read()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		expected = &h
+	}
+
+	count, err := audit.Verify(context.Background(), reader, cfg.AuditHMACKey, expected)
+	if err != nil {
+		fmt.Printf("verification failed after %d entries: %s\n", count, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("audit log is intact, %d entries verified\n", count)
+}
+
+func openReader(cfg *config.Config, sink string, path string) (audit.Reader, error) {
+	switch sink {
+	case "file":
+		if path == "" {
+			path = cfg.Audit.FilePath
+		}
+		return audit.OpenFile(path)
+	case "database":
+		log := logger.CreateLogger(cfg.Env)
+		store, _, err := storage.New(context.Background(), log, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NewDBSink(store, log), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q", sink)
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"vault/internal/audit"
+	"vault/internal/auth"
 	"vault/internal/config"
-	"vault/internal/db"
 	"vault/internal/root"
+	"vault/internal/seal"
+	"vault/internal/signing"
+	"vault/internal/storage"
+	"vault/internal/sys"
+	"vault/internal/transit"
 	"vault/internal/user"
-	"vault/pkg/database/postgresql"
 	"vault/pkg/lib/logger/sl"
 	mwLogger "vault/pkg/lib/middleware"
 	"vault/pkg/logger"
@@ -30,37 +35,62 @@ func main() {
 	log.Warn("warn messages are available")
 	log.Error("error messages are available")
 
-	databaseConfig := postgresql.DatabaseConfig{
-		Host:     cfg.Database.Host,
-		Port:     cfg.Database.Port,
-		User:     cfg.Database.User,
-		Password: cfg.Database.Password,
-		Name:     cfg.Database.Name,
-		SSLMode:  cfg.Database.SSLMode,
-		Attemps:  cfg.Database.Attemps,
-		Timeout:  cfg.Database.Timeout,
-		Delay:    cfg.Database.Delay,
+	dbClient, closeStorage, err := storage.New(context.TODO(), log, cfg)
+	if err != nil {
+		log.Error("failed to open storage", sl.Err(err))
+		os.Exit(1)
 	}
+	defer closeStorage()
+	log.Info("storage successfully opened", slog.String("type", cfg.Storage.Type))
 
-	pool := postgresql.NewClient(context.TODO(), log, databaseConfig)
-	if pool == nil {
+	auditSinks, err := audit.NewSinks(cfg.Audit, dbClient, log)
+	if err != nil {
+		log.Error("failed to create audit sinks", sl.Err(err))
 		os.Exit(1)
 	}
-	log.Info("database successfully conected")
+	var auditHead *audit.HeadFile
+	if cfg.Audit.HeadPath != "" {
+		auditHead = audit.NewHeadFile(cfg.Audit.HeadPath)
+	}
+	auditor, err := audit.New(context.TODO(), log, cfg.AuditHMACKey, auditHead, auditSinks...)
+	if err != nil {
+		log.Error("failed to start audit log", sl.Err(err))
+		os.Exit(1)
+	}
+	log.Info("audit log enabled", slog.Any("sinks", cfg.Audit.Sinks))
+
+	go runPurge(context.TODO(), log, cfg.Purge, dbClient)
+	go runReaper(context.TODO(), log, cfg.Reaper, dbClient)
 
-	dbClient := db.NewClient(pool)
+	vaultSeal := seal.New()
+	log.Info("vault is sealed, provide unseal keys to /sys/unseal")
+
+	go runRewrap(context.TODO(), log, cfg.Rewrap, dbClient, vaultSeal)
+
+	signer := signing.New(dbClient, vaultSeal, log, cfg.Secret, cfg.JWT.Algorithm, cfg.JWT.Refresh, cfg.JWT.LegacyVerify)
+
+	trustedProxies, err := mwLogger.ParseProxies(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("invalid trusted proxies", sl.Err(err))
+		os.Exit(1)
+	}
 
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
+	router.Use(mwLogger.RealIP(trustedProxies))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 	router.Use(mwLogger.New(log))
+	router.Use(audit.Middleware(log, auditor))
 	log.Info("middleware successfully conected")
 
-	router.Route("/root", root.AddRootRouter(router, dbClient, log, cfg))
-	router.Route("/user", user.AddUserRouter(router, dbClient, log, cfg))
+	router.Route("/root", root.AddRootRouter(router, dbClient, dbClient, dbClient, log, cfg, vaultSeal, signer))
+	router.Route("/user", user.AddUserRouter(router, dbClient, log, cfg, vaultSeal, signer))
+	router.Route("/sys", sys.AddSysRouter(router, dbClient, log, cfg, vaultSeal, signer))
+	router.Route("/.well-known", sys.AddWellKnownRouter(router, dbClient, log, cfg))
+	router.Route("/transit", transit.AddTransitRouter(router, dbClient, log, cfg, vaultSeal, signer))
+	router.Route("/auth", auth.AddAuthRouter(router, dbClient, log, cfg, vaultSeal, signer))
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.HTTPServer.Port),
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"vault/internal/config"
+	"vault/internal/storage"
+	"vault/pkg/lib/logger/sl"
+)
+
+// runPurge removes soft deleted vaults and keys older than the retention on every interval.
+func runPurge(ctx context.Context, log *slog.Logger, cfg config.Purge, db storage.Storage) {
+	const op = "main.runPurge"
+
+	if cfg.Retention <= 0 || cfg.Interval <= 0 {
+		log.Info("purge of deleted vaults is disabled")
+		return
+	}
+	log.Info("purge of deleted vaults started", slog.Duration("retention", cfg.Retention), slog.Duration("interval", cfg.Interval))
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		vaults, values, err := db.PurgeDeleted(ctx, log, time.Now().Add(-cfg.Retention))
+		if err != nil {
+			log.Error("failed to purge deleted vaults", sl.OpErr(op, err))
+		} else if vaults > 0 || values > 0 {
+			log.Info("deleted vaults purged", slog.Int("vaults", vaults), slog.Int("values", values))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"vault/internal/config"
+	"vault/internal/storage"
+	"vault/pkg/lib/logger/sl"
+)
+
+// runReaper removes expired vaults and keys on every interval, batches are removed
+// one after another until a batch comes back short. Expired wrapped responses are removed at once.
+func runReaper(ctx context.Context, log *slog.Logger, cfg config.Reaper, db storage.Storage) {
+	const op = "main.runReaper"
+
+	if cfg.Interval <= 0 || cfg.BatchSize <= 0 {
+		log.Info("reaper of expired vaults is disabled")
+		return
+	}
+	log.Info("reaper of expired vaults started", slog.Duration("interval", cfg.Interval), slog.Int("batch_size", cfg.BatchSize))
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			vaults, values, err := db.PurgeExpired(ctx, log, time.Now(), cfg.BatchSize)
+			if err != nil {
+				log.Error("failed to purge expired vaults", sl.OpErr(op, err))
+				break
+			}
+			if vaults > 0 || values > 0 {
+				log.Info("expired vaults purged", slog.Int("vaults", vaults), slog.Int("values", values))
+			}
+			if vaults < cfg.BatchSize && values < cfg.BatchSize {
+				break
+			}
+		}
+
+		wrappings, err := db.PurgeWrappings(ctx, log, time.Now())
+		if err != nil {
+			log.Error("failed to purge expired wrappings", sl.OpErr(op, err))
+		} else if wrappings > 0 {
+			log.Info("expired wrappings purged", slog.Int("wrappings", wrappings))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
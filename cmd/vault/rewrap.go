@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"vault/internal/config"
+	"vault/internal/seal"
+	"vault/internal/storage"
+	"vault/pkg/lib/logger/sl"
+)
+
+// runRewrap moves wrapped keys to the latest keyring term on every interval while the vault
+// is unsealed. Terms added by other instances are installed first. Only keys still on older
+// terms are selected, so the job picks up where it stopped after a restart.
+func runRewrap(ctx context.Context, log *slog.Logger, cfg config.Rewrap, db storage.Storage, vaultSeal *seal.Seal) {
+	const op = "main.runRewrap"
+
+	if cfg.Interval <= 0 || cfg.BatchSize <= 0 {
+		log.Info("rewrap of data keys is disabled")
+		return
+	}
+	log.Info("rewrap of data keys started", slog.Duration("interval", cfg.Interval), slog.Int("batch_size", cfg.BatchSize))
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if !vaultSeal.Sealed() {
+			rewrap(ctx, log, op, cfg.BatchSize, db, vaultSeal)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rewrap runs batches until a batch comes back short or none of its keys could be rewrapped.
+func rewrap(ctx context.Context, log *slog.Logger, op string, batchSize int, db storage.Storage, vaultSeal *seal.Seal) {
+	keyring, err := db.GetKeyring(ctx, log)
+	if err != nil {
+		log.Error("failed to get keyring", sl.OpErr(op, err))
+		return
+	}
+	if err := vaultSeal.Install(keyring); err != nil {
+		log.Error("failed to install keyring", sl.OpErr(op, err))
+		return
+	}
+	envelope, err := vaultSeal.Envelope()
+	if err != nil {
+		return
+	}
+	if envelope.Prefix() == "" {
+		return
+	}
+
+	total := 0
+	for {
+		keys, err := db.ListStaleKeys(ctx, log, envelope.Prefix(), batchSize)
+		if err != nil {
+			log.Error("failed to list stale keys", sl.OpErr(op, err))
+			break
+		}
+
+		rewrapped := 0
+		for _, key := range keys {
+			old := key.Key
+			if key.Key, err = envelope.Rewrap(old); err != nil {
+				log.Error("failed to rewrap key", sl.OpErr(op, err), slog.String("kind", key.Kind), slog.Int("id", key.ID), slog.String("name", key.Name))
+				continue
+			}
+			if err := db.UpdateWrappedKey(ctx, log, key, old); err != nil {
+				// keys written since listing are picked up by the next batch
+				if err.Error() != "wrapped key changed" {
+					log.Error("failed to update wrapped key", sl.OpErr(op, err))
+				}
+				continue
+			}
+			rewrapped++
+		}
+		total += rewrapped
+
+		if len(keys) < batchSize || rewrapped == 0 {
+			break
+		}
+	}
+
+	if total > 0 {
+		pending, _, err := db.CountStaleKeys(ctx, log, envelope.Prefix())
+		if err != nil {
+			log.Error("failed to count stale keys", sl.OpErr(op, err))
+			return
+		}
+		log.Info("data keys rewrapped", slog.Int("term", envelope.Term()), slog.Int("rewrapped", total), slog.Int("pending", pending))
+	}
+}
@@ -0,0 +1,216 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"vault/pkg/lib/logger/sl"
+)
+
+// hmacPrefix marks values replaced by their HMAC.
+const hmacPrefix = "hmac-sha256:"
+
+var (
+	ErrEmpty       = errors.New("audit log is empty")
+	ErrBrokenChain = errors.New("audit hash chain is broken")
+)
+
+// Actor is who performed the request.
+type Actor struct {
+	// Type is root, identity, operator, token, approle, wrapping or anonymous.
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	// JTI of the user token, stored as HMAC.
+	JTI string `json:"jti,omitempty"`
+}
+
+// Entry is a single audit record, entries are chained by PrevHash.
+type Entry struct {
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Route      string    `json:"route,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	Actor      Actor     `json:"actor"`
+	VaultIDs   []int     `json:"vault_ids,omitempty"`
+	Version    int       `json:"version,omitempty"`
+	Keys       []string  `json:"keys,omitempty"`
+	// TokenJTI is the token the operation issued or revoked, stored as HMAC.
+	TokenJTI string `json:"token_jti,omitempty"`
+	Status   int    `json:"status"`
+	Result   string `json:"result"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the entry keyed with the audit hmac key, so the chain
+// can't be recomputed after editing the log without the key. The Hash field itself is ignored.
+func (e Entry) ComputeHash(key []byte) (string, error) {
+	e.Hash = ""
+	body, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(e.PrevHash))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Sink persists audit entries.
+type Sink interface {
+	Write(ctx context.Context, entry Entry) error
+}
+
+// Reader is implemented by sinks that can be read back to verify or resume the chain.
+type Reader interface {
+	Last(ctx context.Context) (Entry, error)
+	Entries(ctx context.Context, fn func(Entry) error) error
+}
+
+type Auditor struct {
+	mu       sync.Mutex
+	log      *slog.Logger
+	hmacKey  []byte
+	head     *HeadFile
+	sinks    []Sink
+	seq      int64
+	lastHash string
+}
+
+// New creates an auditor writing to every sink, the chain is resumed from the first readable sink.
+// The head of the chain is written to head after every entry, nil keeps no head.
+func New(ctx context.Context, log *slog.Logger, hmacKey []byte, head *HeadFile, sinks ...Sink) (*Auditor, error) {
+	a := &Auditor{
+		log:     log,
+		hmacKey: hmacKey,
+		head:    head,
+		sinks:   sinks,
+	}
+
+	for _, sink := range sinks {
+		reader, ok := sink.(Reader)
+		if !ok {
+			continue
+		}
+		last, err := reader.Last(ctx)
+		if err != nil {
+			if errors.Is(err, ErrEmpty) {
+				break
+			}
+			return nil, fmt.Errorf("failed to read last audit entry: %w", err)
+		}
+		a.seq = last.Seq
+		a.lastHash = last.Hash
+		break
+	}
+
+	return a, nil
+}
+
+// HMAC returns the keyed hash of a sensitive value, used to correlate entries without storing it.
+func (a *Auditor) HMAC(value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.hmacKey)
+	mac.Write([]byte(value))
+	return hmacPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Log hashes the sensitive fields, chains the entry and writes it to every sink. It fails when
+// any sink fails, the chain only advances when at least one sink took the entry so the sinks
+// which did keep a consistent chain and the gap in the others is found by Verify.
+func (a *Auditor) Log(ctx context.Context, entry Entry) error {
+	const op = "audit.Log"
+
+	entry.Actor.JTI = a.HMAC(entry.Actor.JTI)
+	entry.TokenJTI = a.HMAC(entry.TokenJTI)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry.Seq = a.seq + 1
+	entry.PrevHash = a.lastHash
+	hash, err := entry.ComputeHash(a.hmacKey)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	var errs []error
+	for _, sink := range a.sinks {
+		if err := sink.Write(ctx, entry); err != nil {
+			a.log.Error("failed to write audit entry", sl.OpErr(op, err), slog.Int64("seq", entry.Seq))
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(a.sinks) && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	a.seq = entry.Seq
+	a.lastHash = entry.Hash
+
+	if a.head != nil {
+		if err := a.head.Write(Head{Seq: entry.Seq, Hash: entry.Hash}); err != nil {
+			a.log.Error("failed to write audit head", sl.OpErr(op, err), slog.Int64("seq", entry.Seq))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Verify walks the log and checks every entry hash and link with the audit hmac key, it returns
+// the number of verified entries. With a head the log has to reach the entry it names, entries
+// removed from the end of the log are found this way. Entries after the head are allowed,
+// the head is written after the sinks.
+func Verify(ctx context.Context, reader Reader, key []byte, head *Head) (int64, error) {
+	var count int64
+	var prev Entry
+
+	err := reader.Entries(ctx, func(entry Entry) error {
+		if count > 0 {
+			if entry.Seq != prev.Seq+1 {
+				return fmt.Errorf("%w: entry %d follows %d", ErrBrokenChain, entry.Seq, prev.Seq)
+			}
+			if entry.PrevHash != prev.Hash {
+				return fmt.Errorf("%w: entry %d does not link to entry %d", ErrBrokenChain, entry.Seq, prev.Seq)
+			}
+		}
+
+		hash, err := entry.ComputeHash(key)
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			return fmt.Errorf("%w: entry %d was modified", ErrBrokenChain, entry.Seq)
+		}
+		if head != nil && entry.Seq == head.Seq && entry.Hash != head.Hash {
+			return fmt.Errorf("%w: entry %d does not match the head", ErrBrokenChain, entry.Seq)
+		}
+
+		prev = entry
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if head != nil && head.Seq > prev.Seq {
+		return count, fmt.Errorf("%w: log ends at entry %d before the head at entry %d", ErrBrokenChain, prev.Seq, head.Seq)
+	}
+	return count, nil
+}
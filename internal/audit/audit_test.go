@@ -0,0 +1,184 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"vault/internal/audit"
+	"vault/pkg/lib/logger/slogdiscard"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hmacKey = []byte("key")
+
+// writeEntries appends count entries to the log at path, the head is kept next to it.
+func writeEntries(t *testing.T, path string, count int) {
+	t.Helper()
+
+	sink, err := audit.NewFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	auditor, err := audit.New(context.Background(), slogdiscard.NewDiscardLogger(), hmacKey, audit.NewHeadFile(path+".head"), sink)
+	require.NoError(t, err)
+
+	for i := 0; i < count; i++ {
+		require.NoError(t, auditor.Log(context.Background(), audit.Entry{
+			Method: http.MethodGet,
+			Path:   "/user/get",
+			Actor:  audit.Actor{Type: "token", JTI: "jti"},
+			Status: 200,
+		}))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeEntries(t, path, 3)
+	// a restarted auditor continues the chain
+	writeEntries(t, path, 2)
+
+	reader, err := audit.OpenFile(path)
+	require.NoError(t, err)
+	head, err := audit.NewHeadFile(path + ".head").Read()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), head.Seq)
+
+	count, err := audit.Verify(context.Background(), reader, hmacKey, &head)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+
+	// the chain is keyed, it can't be recomputed without the hmac key
+	_, err = audit.Verify(context.Background(), reader, []byte("other key"), nil)
+	assert.ErrorIs(t, err, audit.ErrBrokenChain)
+
+	last, err := reader.Last(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), last.Seq)
+	assert.True(t, strings.HasPrefix(last.Actor.JTI, "hmac-sha256:"))
+}
+
+func TestVerifyTampered(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Tamper func(lines []string) []string
+	}{
+		{
+			Name: "deleted entry",
+			Tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+		},
+		{
+			Name: "modified entry",
+			Tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"status":200`, `"status":403`, 1)
+				return lines
+			},
+		},
+		{
+			Name: "reordered entries",
+			Tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+		},
+		{
+			Name: "removed last entries",
+			Tamper: func(lines []string) []string {
+				return lines[:2]
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			writeEntries(t, path, 4)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := tt.Tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+
+			reader, err := audit.OpenFile(path)
+			require.NoError(t, err)
+			head, err := audit.NewHeadFile(path + ".head").Read()
+			require.NoError(t, err)
+
+			_, err = audit.Verify(context.Background(), reader, hmacKey, &head)
+			assert.ErrorIs(t, err, audit.ErrBrokenChain)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	auditor, err := audit.New(context.Background(), slogdiscard.NewDiscardLogger(), hmacKey, nil, audit.NewWriterSink(&buf))
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(audit.Middleware(slogdiscard.NewDiscardLogger(), auditor))
+	router.Get("/root/get/{id}", func(w http.ResponseWriter, r *http.Request) {
+		record := audit.FromContext(r.Context())
+		record.SetActor(audit.Actor{Type: "identity", Name: "ci"})
+		record.SetKeys(audit.MapKeys(map[string]string{"b": "2", "a": "1"}))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/root/get/7", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry audit.Entry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "/root/get/{id}", entry.Route)
+	assert.Equal(t, []int{7}, entry.VaultIDs)
+	assert.Equal(t, []string{"a", "b"}, entry.Keys)
+	assert.Equal(t, "ci", entry.Actor.Name)
+	assert.Equal(t, "success", entry.Result)
+	assert.Equal(t, int64(1), entry.Seq)
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(ctx context.Context, entry audit.Entry) error {
+	return errors.New("disk full")
+}
+
+func TestMiddlewareSinkFailure(t *testing.T) {
+	var buf bytes.Buffer
+	auditor, err := audit.New(context.Background(), slogdiscard.NewDiscardLogger(), hmacKey, nil, audit.NewWriterSink(&buf), failingSink{})
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(audit.Middleware(slogdiscard.NewDiscardLogger(), auditor))
+	router.Get("/root/get/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"password":"secret"}}`))
+	})
+
+	for seq := int64(1); seq <= 2; seq++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/root/get/7", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "secret", "the response is not returned unaudited")
+	}
+
+	// the sink which took the entries keeps a consistent chain
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, second audit.Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, int64(2), second.Seq)
+	assert.Equal(t, first.Hash, second.PrevHash)
+}
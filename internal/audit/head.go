@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Head is the seq and hash of the last entry of the chain. It is kept apart from the sinks,
+// a log cut at the end still verifies on its own but no longer reaches its head.
+type Head struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// HeadFile keeps the head in a file which is replaced after every entry.
+type HeadFile struct {
+	path string
+}
+
+func NewHeadFile(path string) *HeadFile {
+	return &HeadFile{path: path}
+}
+
+// Write replaces the head, the file is written next to it first and renamed so a crash
+// never leaves a partial head behind.
+func (f *HeadFile) Write(head Head) error {
+	data, err := json.Marshal(head)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit head: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	return nil
+}
+
+func (f *HeadFile) Read() (Head, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return Head{}, fmt.Errorf("failed to read audit head: %w", err)
+	}
+
+	var head Head
+	if err := json.Unmarshal(data, &head); err != nil {
+		return Head{}, fmt.Errorf("failed to decode audit head: %w", err)
+	}
+	return head, nil
+}
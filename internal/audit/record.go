@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"vault/pkg/handlers"
+	"vault/pkg/lib/logger/sl"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type contextKey struct{}
+
+// Record collects the audit details of a request while it is handled.
+// All methods are safe to call on a nil Record, requests outside the audit middleware are not recorded.
+type Record struct {
+	mu    sync.Mutex
+	entry Entry
+}
+
+// FromContext returns the record of the request or nil.
+func FromContext(ctx context.Context) *Record {
+	record, _ := ctx.Value(contextKey{}).(*Record)
+	return record
+}
+
+func (r *Record) SetActor(actor Actor) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.Actor = actor
+}
+
+func (r *Record) SetVaults(ids ...int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.VaultIDs = ids
+}
+
+func (r *Record) SetVersion(version int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.Version = version
+}
+
+// SetKeys records the names of the keys returned or written, never the values.
+func (r *Record) SetKeys(keys []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.Keys = keys
+}
+
+// SetToken records the jti of the token issued or revoked by the request.
+func (r *Record) SetToken(jti string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.TokenJTI = jti
+}
+
+func (r *Record) snapshot() Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entry
+}
+
+// Middleware writes an audit entry for every request once it is handled. The response is held
+// back until the entry is written, when any sink fails the caller gets 500 instead of it.
+func Middleware(log *slog.Logger, auditor *Auditor) func(next http.Handler) http.Handler {
+	const op = "audit.Middleware"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			record := &Record{entry: Entry{
+				Time:       time.Now().UTC(),
+				RequestID:  middleware.GetReqID(r.Context()),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				RemoteAddr: r.RemoteAddr,
+				Actor:      Actor{Type: "anonymous"},
+			}}
+
+			buf := &responseBuffer{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), contextKey{}, record)))
+
+			entry := record.snapshot()
+			entry.Status = buf.status
+			entry.Result = "success"
+			if entry.Status >= 400 {
+				entry.Result = "error"
+			}
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				entry.Route = rctx.RoutePattern()
+				if entry.VaultIDs == nil {
+					if id, err := strconv.Atoi(rctx.URLParam("id")); err == nil {
+						entry.VaultIDs = []int{id}
+					}
+				}
+			}
+
+			if err := auditor.Log(r.Context(), entry); err != nil {
+				log.Error("failed to audit request", sl.OpErr(op, err), slog.String("request_id", entry.RequestID))
+				handlers.ErrorResponse(w, r, 500, "failed to audit request")
+				return
+			}
+			buf.flush(w)
+		})
+	}
+}
+
+// responseBuffer holds back the response of a handler until the request is audited.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.wrote {
+		return
+	}
+	b.status, b.wrote = status, true
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wrote = true
+	return b.body.Write(p)
+}
+
+// flush writes the held back response unchanged.
+func (b *responseBuffer) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
+
+// MapKeys returns the sorted keys of vault data for SetKeys.
+func MapKeys[V any](data map[string]V) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"vault/internal/config"
+)
+
+// WriterSink writes entries as JSON lines, it is used for stdout.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(ctx context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// FileSink appends entries as JSON lines to a file and can read them back.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileSink{path: path, file: file}, nil
+}
+
+// OpenFile opens an existing audit file for reading only.
+func OpenFile(path string) (*FileSink, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileSink{path: path}, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("audit file %s is opened read only", s.path)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Last(ctx context.Context) (Entry, error) {
+	var last Entry
+	found := false
+	err := s.Entries(ctx, func(entry Entry) error {
+		last = entry
+		found = true
+		return nil
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+	if !found {
+		return Entry{}, ErrEmpty
+	}
+	return last, nil
+}
+
+func (s *FileSink) Entries(ctx context.Context, fn func(Entry) error) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("failed to decode audit entry on line %d: %w", line, err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// Store persists audit entries in the database, documents are the JSON encoded entries.
+type Store interface {
+	CreateAuditEntry(ctx context.Context, log *slog.Logger, seq int64, document string) error
+	LastAuditEntry(ctx context.Context, log *slog.Logger) (string, error)
+	ListAuditEntries(ctx context.Context, log *slog.Logger, afterSeq int64, limit int) ([]string, error)
+}
+
+// DBSink writes entries to the audit_log table.
+type DBSink struct {
+	store Store
+	log   *slog.Logger
+}
+
+func NewDBSink(store Store, log *slog.Logger) *DBSink {
+	return &DBSink{store: store, log: log}
+}
+
+func (s *DBSink) Write(ctx context.Context, entry Entry) error {
+	document, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	return s.store.CreateAuditEntry(ctx, s.log, entry.Seq, string(document))
+}
+
+func (s *DBSink) Last(ctx context.Context) (Entry, error) {
+	document, err := s.store.LastAuditEntry(ctx, s.log)
+	if err != nil {
+		if err.Error() == ErrEmpty.Error() {
+			return Entry{}, ErrEmpty
+		}
+		return Entry{}, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(document), &entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (s *DBSink) Entries(ctx context.Context, fn func(Entry) error) error {
+	const pageSize = 500
+
+	var after int64
+	for {
+		documents, err := s.store.ListAuditEntries(ctx, s.log, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, document := range documents {
+			var entry Entry
+			if err := json.Unmarshal([]byte(document), &entry); err != nil {
+				return fmt.Errorf("failed to decode audit entry after %d: %w", after, err)
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+			after = entry.Seq
+		}
+		if len(documents) < pageSize {
+			return nil
+		}
+	}
+}
+
+// NewSinks creates the sinks named in the config: stdout, file and database.
+func NewSinks(cfg config.Audit, store Store, log *slog.Logger) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "stdout":
+			sinks = append(sinks, NewWriterSink(os.Stdout))
+		case "file":
+			sink, err := NewFileSink(cfg.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "database":
+			sinks = append(sinks, NewDBSink(store, log))
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+	return sinks, nil
+}
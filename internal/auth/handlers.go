@@ -0,0 +1,649 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+	"vault/internal/audit"
+	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/policy"
+	"vault/internal/seal"
+	"vault/internal/transit"
+	"vault/pkg/handlers"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+	mwAuth "vault/pkg/lib/middleware"
+	"vault/pkg/lib/opaque"
+	"vault/pkg/lib/password"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrRoleNotFound     = "approle not found"
+	ErrSecretIDNotFound = "secret id not found"
+	ErrVaultNotFound    = "vault not found"
+)
+
+var ErrOperatorNotFound = "operator not found"
+
+// ErrInvalidCredentials is returned by every failed login so callers can't probe roles or secret ids.
+const ErrInvalidCredentials = "invalid role_id or secret_id"
+
+// ErrInvalidPassword is returned by every failed userpass login, unknown and locked operators included.
+const ErrInvalidPassword = "invalid username or password"
+
+// RolePrefix starts the policy resource of a role, like auth/approle/billing.
+const RolePrefix = "auth/approle/"
+
+// RoleResource returns the policy resource of the role.
+func RoleResource(name string) string {
+	return RolePrefix + name
+}
+
+var identityKey mwAuth.ContextKey = "identity"
+
+// dummyHash is verified for unknown and locked operators so their logins take as long as a wrong password.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := password.Hash("dummy password of unknown operators")
+	return hash
+})
+
+type AuthHandlerClient struct {
+	authDBClient AuthDB
+	log          *slog.Logger
+	signer       jwt.Signer
+	userpass     config.Userpass
+	maxTTL       time.Duration
+}
+
+func AddAuthRouter(r chi.Router, authClient AuthDB, log *slog.Logger, cfg *config.Config, vaultSeal *seal.Seal, signer jwt.Signer) func(r chi.Router) {
+	client := NewAuthHandlerClient(authClient, log, signer, cfg.Userpass, cfg.Token.MaxTTL)
+
+	return func(r chi.Router) {
+		r.Use(mwAuth.Unsealed(log, vaultSeal))
+
+		wrap := mwAuth.Wrap(log, authClient, vaultSeal, cfg.Wrapping.MaxTTL)
+
+		r.With(wrap).Post("/approle/login", client.AppRoleLogin(context.TODO()))
+		r.Post("/userpass/login", client.UserpassLogin(context.TODO()))
+
+		r.Group(func(r chi.Router) {
+			r.Use(mwAuth.RootAuth(log, cfg.RootToken, authClient))
+
+			authorize := func(capability string, resolve mwAuth.Resolver) func(http.Handler) http.Handler {
+				return mwAuth.Authorize(log, capability, resolve)
+			}
+
+			r.Get("/approle/roles", client.ListRoles(context.TODO()))
+			r.With(authorize(policy.Read, roleFromParam)).Get("/approle/roles/{name}", client.GetRole(context.TODO()))
+			r.With(mwAuth.DecodeBody[models.PutAppRoleDTO](log), authorize(policy.Update, roleFromParam), authorize(policy.IssueToken, client.vaultsFromRoleBody)).Put("/approle/roles/{name}", client.PutRole(context.TODO()))
+			r.With(authorize(policy.Delete, roleFromParam)).Delete("/approle/roles/{name}", client.DeleteRole(context.TODO()))
+			r.With(authorize(policy.IssueToken, roleFromParam), wrap).Post("/approle/roles/{name}/secret-ids", client.CreateSecretID(context.TODO()))
+			r.With(authorize(policy.List, roleFromParam)).Get("/approle/roles/{name}/secret-ids", client.ListSecretIDs(context.TODO()))
+			r.With(authorize(policy.Delete, roleFromParam)).Delete("/approle/roles/{name}/secret-ids/{accessor}", client.DeleteSecretID(context.TODO()))
+		})
+	}
+}
+
+// NewAuthHandlerClient creates the login handlers, maxTTL limits the lifetime of user tokens (0 sets no limit).
+func NewAuthHandlerClient(authClient AuthDB, log *slog.Logger, signer jwt.Signer, userpass config.Userpass, maxTTL time.Duration) *AuthHandlerClient {
+	return &AuthHandlerClient{
+		authDBClient: authClient,
+		log:          log,
+		signer:       signer,
+		userpass:     userpass,
+		maxTTL:       maxTTL,
+	}
+}
+
+// roleFromParam resolves the role of the {name} url param.
+func roleFromParam(r *http.Request) ([]string, error) {
+	return []string{RoleResource(chi.URLParam(r, "name"))}, nil
+}
+
+// vaultsFromRoleBody resolves every vault, path and transit key the tokens of the role are bound to,
+// defining a role needs the same grants as issuing its tokens through /root/create-token.
+func (h *AuthHandlerClient) vaultsFromRoleBody(r *http.Request) ([]string, error) {
+	model, err := mwAuth.Body[models.PutAppRoleDTO](r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+	}
+
+	names := make([]string, 0, len(model.Vaults())+len(model.Paths)+len(model.TransitKeys))
+	for _, id := range model.Vaults() {
+		name, err := h.authDBClient.GetVaultName(r.Context(), h.log, id)
+		if err != nil {
+			if err.Error() == ErrVaultNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	names = append(names, model.Paths...)
+	for _, name := range model.TransitKeys {
+		names = append(names, transit.KeyResource(name))
+	}
+	return names, nil
+}
+
+func (h *AuthHandlerClient) ListRoles(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.ListRoles"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		identity, ok := r.Context().Value(identityKey).(policy.Identity)
+		if !ok {
+			h.log.Error("failed to get identity from context", slog.String("op", op))
+			handlers.ErrorResponse(w, r, 401, "unauthorized")
+			return
+		}
+
+		names, err := h.authDBClient.ListAppRoles(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list approles", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		roles := make([]string, 0, len(names))
+		for _, name := range names {
+			if identity.ACL.Allowed(policy.List, RoleResource(name)) {
+				roles = append(roles, name)
+			}
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"roles": roles,
+		})
+		h.log.Info("approles successfully listed", "count", len(roles))
+	}
+}
+
+func (h *AuthHandlerClient) GetRole(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.GetRole"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		role, ok := h.role(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, role)
+		h.log.Info("approle successfully received", "name", role.Name)
+	}
+}
+
+func (h *AuthHandlerClient) PutRole(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.PutRole"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+
+		model, err := mwAuth.Body[models.PutAppRoleDTO](r)
+		if err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(name); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		if err := model.CheckTTL(h.maxTTL); err != nil {
+			h.log.Error("token lifetime exceeds the server limit", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		for _, vaultID := range model.Vaults() {
+			if err := h.authDBClient.CheckVault(ctx, h.log, vaultID); err != nil {
+				if err.Error() == ErrVaultNotFound {
+					h.log.Error("vault not found", sl.Err(err), slog.Int("vault_id", vaultID))
+					handlers.ErrorResponse(w, r, 404, err.Error())
+					return
+				}
+				h.log.Error("failed to check vault", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 500, err.Error())
+				return
+			}
+		}
+
+		roleID, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create role id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to save approle")
+			return
+		}
+
+		if err := h.authDBClient.PutAppRole(ctx, h.log, model.Role(name, roleID)); err != nil {
+			h.log.Error("failed to save approle", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		role, ok := h.role(ctx, w, r, op, name)
+		if !ok {
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVaults(role.VaultIDs...)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "approle successfully saved",
+			"name":    role.Name,
+			"role_id": role.RoleID,
+		})
+		h.log.Info("approle successfully saved", "name", role.Name)
+	}
+}
+
+func (h *AuthHandlerClient) DeleteRole(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.DeleteRole"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.authDBClient.DeleteAppRole(ctx, h.log, name); err != nil {
+			if err.Error() == ErrRoleNotFound {
+				h.log.Error("approle not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete approle", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "approle successfully deleted",
+			"name":    name,
+		})
+		h.log.Info("approle successfully deleted", "name", name)
+	}
+}
+
+func (h *AuthHandlerClient) CreateSecretID(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.CreateSecretID"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.CreateSecretIDDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil && err != io.EOF {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		role, ok := h.role(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+
+		secretID, err := opaque.New("sid.")
+		if err != nil {
+			h.log.Error("failed to create secret id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create secret id")
+			return
+		}
+		accessor, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create secret id accessor", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create secret id")
+			return
+		}
+
+		secret := models.SecretIDModel{Accessor: accessor, SingleUse: model.SingleUse, CIDRs: model.CIDRs}
+		if role.SecretIDExpires > 0 {
+			expiresAt := time.Now().Add(role.SecretIDExpires * time.Second)
+			secret.ExpiresAt = &expiresAt
+		}
+
+		if err := h.authDBClient.CreateSecretID(ctx, h.log, role.Name, secret, opaque.Hash(secretID)); err != nil {
+			if err.Error() == ErrRoleNotFound {
+				h.log.Error("approle not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to save secret id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 201, map[string]any{
+			"message":    "secret id successfully created",
+			"secret_id":  secretID,
+			"accessor":   accessor,
+			"single_use": secret.SingleUse,
+			"expires_at": secret.ExpiresAt,
+		})
+		h.log.Info("secret id successfully created", "name", role.Name, "accessor", accessor)
+	}
+}
+
+func (h *AuthHandlerClient) ListSecretIDs(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.ListSecretIDs"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		role, ok := h.role(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+
+		secretIDs, err := h.authDBClient.ListSecretIDs(ctx, h.log, role.Name)
+		if err != nil {
+			h.log.Error("failed to list secret ids", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"secret_ids": secretIDs,
+		})
+		h.log.Info("secret ids successfully listed", "name", role.Name, "count", len(secretIDs))
+	}
+}
+
+func (h *AuthHandlerClient) DeleteSecretID(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.DeleteSecretID"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name, accessor := chi.URLParam(r, "name"), chi.URLParam(r, "accessor")
+		if err := h.authDBClient.DeleteSecretID(ctx, h.log, name, accessor); err != nil {
+			if err.Error() == ErrSecretIDNotFound {
+				h.log.Error("secret id not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete secret id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message":  "secret id successfully deleted",
+			"accessor": accessor,
+		})
+		h.log.Info("secret id successfully deleted", "name", name, "accessor", accessor)
+	}
+}
+
+// AppRoleLogin exchanges a role id and secret id for a user token bound like the role.
+func (h *AuthHandlerClient) AppRoleLogin(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.AppRoleLogin"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.AppRoleLoginDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		role, err := h.authDBClient.GetAppRoleByRoleID(ctx, h.log, model.RoleID)
+		if err != nil {
+			h.loginError(w, r, op, err)
+			return
+		}
+
+		record := audit.FromContext(r.Context())
+		record.SetActor(audit.Actor{Type: "approle", Name: role.Name})
+
+		secret, err := h.authDBClient.GetSecretID(ctx, h.log, role.Name, opaque.Hash(model.SecretID))
+		if err != nil {
+			h.loginError(w, r, op, err)
+			return
+		}
+		if secret.Expired(time.Now()) {
+			h.log.Error("secret id expired", slog.String("op", op), slog.String("accessor", secret.Accessor))
+			handlers.ErrorResponse(w, r, 401, ErrInvalidCredentials)
+			return
+		}
+		if addr, ok := clientAddr(r); !ok || !secret.AllowsAddr(addr) {
+			h.log.Error("secret id not allowed from address", slog.String("op", op), slog.String("accessor", secret.Accessor), slog.String("remote_addr", r.RemoteAddr))
+			handlers.ErrorResponse(w, r, 401, ErrInvalidCredentials)
+			return
+		}
+		if secret.SingleUse {
+			// only the login deleting the secret id may use it
+			if err := h.authDBClient.DeleteSecretID(ctx, h.log, role.Name, secret.Accessor); err != nil {
+				h.loginError(w, r, op, err)
+				return
+			}
+		}
+
+		jti, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create token id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create new token")
+			return
+		}
+
+		expires := role.TokenExpires(model.Expires)
+		if limit := h.maxTTL / time.Second; limit > 0 {
+			// roles defined before the limit was lowered still get tokens within it
+			expires = min(expires, limit)
+		}
+		claims := models.NewTokenModel(jti, role.VaultIDs, role.Paths, expires, role.TokenScope)
+		claims.TransitKeys = role.TransitKeys
+		if role.Renewable {
+			claims.Lease(models.LeaseTTL(role.MaxTTL, h.maxTTL))
+		}
+		token, err := h.signer.CreateToken(ctx, claims)
+		if err != nil {
+			h.log.Error("failed to create new token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create new token")
+			return
+		}
+
+		if err := h.authDBClient.CreateToken(ctx, h.log, claims.Info()); err != nil {
+			h.log.Error("failed to save token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		record.SetVaults(role.VaultIDs...)
+		record.SetToken(jti)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"token":   token,
+			"jti":     jti,
+			"expires": expires,
+		})
+		h.log.Info("approle login succeeded", "name", role.Name, "accessor", secret.Accessor, "jti", jti)
+	}
+}
+
+// UserpassLogin verifies the password of an operator and issues a session token.
+// MaxFailures wrong passwords in a row lock the operator for the lockout period.
+func (h *AuthHandlerClient) UserpassLogin(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "auth.handlers.UserpassLogin"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.UserpassLoginDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		audit.FromContext(r.Context()).SetActor(audit.Actor{Type: "operator", Name: model.Username})
+
+		now := time.Now()
+		operator, hash, err := h.authDBClient.GetOperator(ctx, h.log, model.Username)
+		if err != nil {
+			if err.Error() == ErrOperatorNotFound {
+				password.Verify(model.Password, dummyHash())
+				h.log.Error("login rejected", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 401, ErrInvalidPassword)
+				return
+			}
+			h.log.Error("failed to get operator", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to login")
+			return
+		}
+		if operator.Locked(now) {
+			password.Verify(model.Password, dummyHash())
+			h.log.Warn("login of locked operator rejected", slog.String("op", op), slog.String("name", operator.Name), slog.Time("locked_until", *operator.LockedUntil))
+			handlers.ErrorResponse(w, r, 401, ErrInvalidPassword)
+			return
+		}
+
+		ok, err := password.Verify(model.Password, hash)
+		if err != nil {
+			h.log.Error("failed to verify password", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to login")
+			return
+		}
+		if !ok {
+			if h.userpass.MaxFailures > 0 {
+				if err := h.authDBClient.RecordOperatorFailure(ctx, h.log, operator.Name, h.userpass.MaxFailures, now.Add(h.userpass.Lockout)); err != nil {
+					h.log.Error("failed to record failed login", sl.OpErr(op, err))
+				}
+			}
+			h.log.Error("wrong password", slog.String("op", op), slog.String("name", operator.Name))
+			handlers.ErrorResponse(w, r, 401, ErrInvalidPassword)
+			return
+		}
+
+		if operator.FailedLogins > 0 || operator.LockedUntil != nil {
+			if err := h.authDBClient.UnlockOperator(ctx, h.log, operator.Name); err != nil {
+				h.log.Error("failed to reset failed logins", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 500, "failed to login")
+				return
+			}
+		}
+
+		token, err := opaque.New(models.OperatorSessionPrefix)
+		if err != nil {
+			h.log.Error("failed to create session token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create session token")
+			return
+		}
+		accessor, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create accessor", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create session token")
+			return
+		}
+
+		expiresAt := now.Add(h.userpass.SessionTTL)
+		session := models.OperatorTokenModel{Accessor: accessor, Kind: models.OperatorSession, ExpiresAt: &expiresAt}
+		if err := h.authDBClient.CreateOperatorToken(ctx, h.log, operator.Name, session, opaque.Hash(token)); err != nil {
+			h.log.Error("failed to save session", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create session token")
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"token":      token,
+			"accessor":   accessor,
+			"expires_at": expiresAt,
+		})
+		h.log.Info("userpass login succeeded", "name", operator.Name, "accessor", accessor)
+	}
+}
+
+// role returns the role of the name, on failure the error response is already written.
+func (h *AuthHandlerClient) role(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, name string) (models.AppRoleModel, bool) {
+	role, err := h.authDBClient.GetAppRole(ctx, h.log, name)
+	if err != nil {
+		if err.Error() == ErrRoleNotFound {
+			h.log.Error("approle not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return models.AppRoleModel{}, false
+		}
+		h.log.Error("failed to get approle", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+		return models.AppRoleModel{}, false
+	}
+	return role, true
+}
+
+// loginError hides which of the credentials was rejected.
+func (h *AuthHandlerClient) loginError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	if err.Error() == ErrRoleNotFound || err.Error() == ErrSecretIDNotFound {
+		h.log.Error("login rejected", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 401, ErrInvalidCredentials)
+		return
+	}
+	h.log.Error("failed to login", sl.OpErr(op, err))
+	handlers.ErrorResponse(w, r, 500, "failed to login")
+}
+
+// clientAddr returns the address of the client, the socket peer unless the RealIP middleware
+// replaced it with the address forwarded by a trusted proxy.
+func clientAddr(r *http.Request) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addrPort.Addr(), true
+	}
+	addr, err := netip.ParseAddr(r.RemoteAddr)
+	return addr, err == nil
+}
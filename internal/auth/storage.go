@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+)
+
+type AuthDB interface {
+	// PutAppRole creates or replaces the role, the role id of an existing role is kept.
+	PutAppRole(ctx context.Context, log *slog.Logger, model models.AppRoleModel) error
+	GetAppRole(ctx context.Context, log *slog.Logger, name string) (models.AppRoleModel, error)
+	GetAppRoleByRoleID(ctx context.Context, log *slog.Logger, roleID string) (models.AppRoleModel, error)
+	ListAppRoles(ctx context.Context, log *slog.Logger) ([]string, error)
+	// DeleteAppRole removes the role with its secret ids.
+	DeleteAppRole(ctx context.Context, log *slog.Logger, name string) error
+	CreateSecretID(ctx context.Context, log *slog.Logger, role string, model models.SecretIDModel, secretHash string) error
+	ListSecretIDs(ctx context.Context, log *slog.Logger, role string) ([]models.SecretIDModel, error)
+	GetSecretID(ctx context.Context, log *slog.Logger, role string, secretHash string) (models.SecretIDModel, error)
+	DeleteSecretID(ctx context.Context, log *slog.Logger, role string, accessor string) error
+	CheckVault(ctx context.Context, log *slog.Logger, id int) error
+	GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error)
+	CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error
+	// GetOperator returns the operator with the hash of its password.
+	GetOperator(ctx context.Context, log *slog.Logger, name string) (models.OperatorModel, string, error)
+	// RecordOperatorFailure counts a failed login, the maxFailures-th failure in a row locks the operator until lockedUntil.
+	RecordOperatorFailure(ctx context.Context, log *slog.Logger, name string, maxFailures int, lockedUntil time.Time) error
+	UnlockOperator(ctx context.Context, log *slog.Logger, name string) error
+	CreateOperatorToken(ctx context.Context, log *slog.Logger, name string, model models.OperatorTokenModel, tokenHash string) error
+	GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error)
+	GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error)
+	GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error)
+	CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"log"
 	"os"
+	"slices"
 	"time"
+	"vault/pkg/lib/jwt"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -13,27 +17,107 @@ type Config struct {
 	Env            string `yaml:"env" env-required:"true"`
 	RootToken      string
 	Secret         string
+	MasterKey      []byte
+	AuditHMACKey   []byte
 	MigrationsPath string `yaml:"migrations_path" env-required:"true"`
-	Database       `yaml:"database" env-required:"true"`
+	MaxVersions    int    `yaml:"max_versions" env-default:"10"`
+	Storage        `yaml:"storage"`
+	Database       `yaml:"database"`
 	HTTPServer     `yaml:"http-server" env-required:"true"`
+	Audit          `yaml:"audit"`
+	Purge          `yaml:"purge"`
+	Reaper         `yaml:"reaper"`
+	Rewrap         `yaml:"rewrap"`
+	JWT            `yaml:"jwt"`
+	Userpass       `yaml:"userpass"`
+	Token          `yaml:"token"`
+	Wrapping       `yaml:"wrapping"`
+}
+
+type Purge struct {
+	// Retention is how long soft deleted vaults and keys are kept, 0 disables purging.
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
+	Interval  time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+type Reaper struct {
+	// Interval between runs of the reaper of expired vaults and keys, 0 disables it.
+	Interval  time.Duration `yaml:"interval" env-default:"1m"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+}
+
+type Rewrap struct {
+	// Interval between runs of the job moving wrapped keys to the latest keyring term, 0 disables it.
+	Interval  time.Duration `yaml:"interval" env-default:"1m"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+}
+
+type JWT struct {
+	// Algorithm of the signing keys created through /sys/jwt/keys: HS512, EdDSA or RS256.
+	Algorithm string `yaml:"algorithm" env-default:"HS512"`
+	// Refresh is how long the signing keys are cached before they are reloaded from the storage.
+	Refresh time.Duration `yaml:"refresh" env-default:"30s"`
+	// LegacyVerify keeps verifying tokens signed with SECRET after a signing key was promoted.
+	LegacyVerify bool `yaml:"legacy_verify" env-default:"false"`
+}
+
+type Token struct {
+	// MaxTTL caps expires and max_ttl of user tokens, renewals never extend a token past MaxTTL
+	// after it was issued. 0 disables the limit.
+	MaxTTL time.Duration `yaml:"max_ttl" env-default:"768h"`
+}
+
+type Wrapping struct {
+	// MaxTTL caps the lifetime of wrapping tokens asked for with X-Vault-Wrap-TTL, 0 disables the limit.
+	MaxTTL time.Duration `yaml:"max_ttl" env-default:"24h"`
+}
+
+type Userpass struct {
+	// SessionTTL is the lifetime of the session tokens issued by /auth/userpass/login.
+	SessionTTL time.Duration `yaml:"session_ttl" env-default:"8h"`
+	// MaxFailures failed logins in a row lock the operator for Lockout, 0 disables the lockout.
+	MaxFailures int           `yaml:"max_failures" env-default:"5"`
+	Lockout     time.Duration `yaml:"lockout" env-default:"15m"`
+}
+
+type Audit struct {
+	Sinks    []string `yaml:"sinks" env-default:"stdout"`
+	FilePath string   `yaml:"file_path" env-default:"./audit.log"`
+	// HeadPath keeps the seq and hash of the last entry apart from the sinks, empty keeps no head.
+	HeadPath string `yaml:"head_path" env-default:"./audit.head"`
+}
+
+type Storage struct {
+	// Type is postgres, sqlite or memory.
+	Type string `yaml:"type" env-default:"postgres"`
+	// Path of the sqlite database file.
+	Path string `yaml:"path" env-default:"./vault.db"`
 }
 
 type Database struct {
-	Host     string        `yaml:"host" env-required:"true"`
-	Port     int           `yaml:"port" env-required:"true"`
-	User     string        `yaml:"user" env-required:"true"`
-	Password string        `yaml:"password" env-required:"true"`
-	Name     string        `yaml:"name" env-required:"true"`
-	SSLMode  string        `yaml:"sslmode" env-required:"true"`
-	Attemps  int           `yaml:"attemps" env-required:"true"`
-	Delay    time.Duration `yaml:"delay" env-required:"true"`
-	Timeout  time.Duration `yaml:"timeout" env-required:"true"`
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	User     string        `yaml:"user"`
+	Password string        `yaml:"password"`
+	Name     string        `yaml:"name"`
+	SSLMode  string        `yaml:"sslmode"`
+	Attemps  int           `yaml:"attemps"`
+	Delay    time.Duration `yaml:"delay"`
+	Timeout  time.Duration `yaml:"timeout"`
 }
 
 type HTTPServer struct {
 	Port        int           `yaml:"port" env-required:"true"`
 	Timeout     time.Duration `yaml:"timeout" env-required:"true"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-required:"true"`
+	// TrustedProxies are the addresses or CIDRs of proxies whose X-Real-IP and X-Forwarded-For
+	// headers are trusted, the client address of other requests is the socket peer.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// sampleMasterKeys were published with the repository, anyone can unseal a vault initialized with them.
+var sampleMasterKeys = []string{
+	"/yZBv4e54/2my056pDrsFUD9qAeKzIAmpDKPymTO34A=",
 }
 
 func MustLoad() *Config {
@@ -44,6 +128,8 @@ func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	rootToken := os.Getenv("ROOT_TOKEN")
 	secret := os.Getenv("SECRET")
+	masterKey := os.Getenv("MASTER_KEY")
+	auditHMACKey := os.Getenv("AUDIT_HMAC_KEY")
 
 	if configPath == "" {
 		configPath = "config/default.yaml"
@@ -57,6 +143,19 @@ func MustLoad() *Config {
 		log.Fatal("failed to load secret from environment file")
 	}
 
+	// the master key is optional, when set it is split into unseal keys on init instead of a new one
+	var masterKeyBytes []byte
+	if masterKey != "" {
+		if slices.Contains(sampleMasterKeys, masterKey) {
+			log.Fatal("master key is a published sample key, unset MASTER_KEY to generate a new key on init")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(masterKey)
+		if err != nil || len(decoded) != 32 {
+			log.Fatal("master key must be a base64 encoded 32 byte key")
+		}
+		masterKeyBytes = decoded
+	}
+
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		log.Fatalf("config file not exists, path: %s", configPath)
 	}
@@ -67,7 +166,25 @@ func MustLoad() *Config {
 		log.Fatal("failed to read config file, error: ", err)
 	}
 
+	// the database section is only needed by the postgres backend
+	if (cfg.Storage.Type == "postgres" || cfg.Storage.Type == "") && (cfg.Database.Host == "" || cfg.Database.Name == "") {
+		log.Fatal("database config is required for the postgres storage")
+	}
+
+	if !jwt.ValidAlgorithm(cfg.JWT.Algorithm) {
+		log.Fatalf("unknown jwt algorithm %q, use HS512, EdDSA or RS256", cfg.JWT.Algorithm)
+	}
+
 	cfg.RootToken = rootToken
 	cfg.Secret = secret
+	cfg.MasterKey = masterKeyBytes
+
+	// sensitive audit fields are hashed with a dedicated key, without it the key is derived from the secret
+	if auditHMACKey != "" {
+		cfg.AuditHMACKey = []byte(auditHMACKey)
+	} else {
+		sum := sha256.Sum256([]byte("audit:" + secret))
+		cfg.AuditHMACKey = sum[:]
+	}
 	return &cfg
 }
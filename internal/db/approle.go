@@ -0,0 +1,376 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const selectAppRole = `
+	SELECT name, role_id, vault_ids, paths, transit_keys, keys, capabilities,
+		expires, max_expires, secret_id_expires, renewable, max_ttl, created_at
+	FROM approle
+`
+
+// PutAppRole creates or replaces the role, the role id of an existing role is kept.
+func (r *DBClient) PutAppRole(ctx context.Context, log *slog.Logger, model models.AppRoleModel) error {
+	const op = "db.postgresql.PutAppRole"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	putRoleQuery := `
+		INSERT INTO approle
+			(name, role_id, vault_ids, paths, transit_keys, keys, capabilities, expires, max_expires, secret_id_expires, renewable, max_ttl)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (name) DO UPDATE SET
+			vault_ids = EXCLUDED.vault_ids,
+			paths = EXCLUDED.paths,
+			transit_keys = EXCLUDED.transit_keys,
+			keys = EXCLUDED.keys,
+			capabilities = EXCLUDED.capabilities,
+			expires = EXCLUDED.expires,
+			max_expires = EXCLUDED.max_expires,
+			secret_id_expires = EXCLUDED.secret_id_expires,
+			renewable = EXCLUDED.renewable,
+			max_ttl = EXCLUDED.max_ttl;
+	`
+
+	log.Debug("put approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(putRoleQuery)))
+
+	vaultIDs := model.VaultIDs
+	if vaultIDs == nil {
+		vaultIDs = []int{}
+	}
+
+	if _, err := tx.Exec(ctx, putRoleQuery,
+		model.Name,
+		model.RoleID,
+		vaultIDs,
+		emptyList(model.Paths),
+		emptyList(model.TransitKeys),
+		emptyList(model.Keys),
+		emptyList(model.Capabilities),
+		int64(model.Expires),
+		int64(model.MaxExpires),
+		int64(model.SecretIDExpires),
+		model.Renewable,
+		int64(model.MaxTTL),
+	); err != nil {
+		log.Error("failed to save approle", sl.OpErr(op, err))
+		return errors.New("failed to save approle")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) GetAppRole(ctx context.Context, log *slog.Logger, name string) (models.AppRoleModel, error) {
+	const op = "db.postgresql.GetAppRole"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getRoleQuery := selectAppRole + `WHERE name = $1;`
+
+	log.Debug("get approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(getRoleQuery)))
+
+	role, err := scanAppRole(tx.QueryRow(ctx, getRoleQuery, name))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.AppRoleModel{}, errors.New("approle not found")
+		}
+		log.Error("failed to get approle", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to get approle")
+	}
+
+	return role, nil
+}
+
+func (r *DBClient) GetAppRoleByRoleID(ctx context.Context, log *slog.Logger, roleID string) (models.AppRoleModel, error) {
+	const op = "db.postgresql.GetAppRoleByRoleID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getRoleQuery := selectAppRole + `WHERE role_id = $1;`
+
+	log.Debug("get approle by role id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getRoleQuery)))
+
+	role, err := scanAppRole(tx.QueryRow(ctx, getRoleQuery, roleID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.AppRoleModel{}, errors.New("approle not found")
+		}
+		log.Error("failed to get approle", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to get approle")
+	}
+
+	return role, nil
+}
+
+func (r *DBClient) ListAppRoles(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.postgresql.ListAppRoles"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listRolesQuery := `
+		SELECT name FROM approle
+		ORDER BY name;
+	`
+
+	log.Debug("list approles query", slog.String("op", op), slog.String("query", utils.QueryConvert(listRolesQuery)))
+
+	rows, err := tx.Query(ctx, listRolesQuery)
+	if err != nil {
+		log.Error("failed to list approles", sl.OpErr(op, err))
+		return nil, errors.New("failed to list approles")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan approles", sl.OpErr(op, err))
+			return nil, errors.New("failed to list approles")
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list approles")
+	}
+
+	return names, nil
+}
+
+// DeleteAppRole removes the role, its secret ids are removed by the foreign key.
+func (r *DBClient) DeleteAppRole(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.DeleteAppRole"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteRoleQuery := `
+		DELETE FROM approle
+		WHERE name = $1;
+	`
+
+	log.Debug("delete approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteRoleQuery)))
+
+	tag, err := tx.Exec(ctx, deleteRoleQuery, name)
+	if err != nil {
+		log.Error("failed to delete approle", sl.OpErr(op, err))
+		return errors.New("failed to delete approle")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("approle not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) CreateSecretID(ctx context.Context, log *slog.Logger, role string, model models.SecretIDModel, secretHash string) error {
+	const op = "db.postgresql.CreateSecretID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createSecretIDQuery := `
+		INSERT INTO approle_secret_id
+			(approle_id, accessor, secret_hash, single_use, cidrs, expires_at)
+		SELECT id, $2, $3, $4, $5, $6 FROM approle
+		WHERE name = $1;
+	`
+
+	log.Debug("create secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(createSecretIDQuery)))
+
+	tag, err := tx.Exec(ctx, createSecretIDQuery, role, model.Accessor, secretHash, model.SingleUse, emptyList(model.CIDRs), model.ExpiresAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("secret id already exists")
+		}
+		log.Error("failed to save secret id", sl.OpErr(op, err))
+		return errors.New("failed to save secret id")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("approle not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) ListSecretIDs(ctx context.Context, log *slog.Logger, role string) ([]models.SecretIDModel, error) {
+	const op = "db.postgresql.ListSecretIDs"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listSecretIDsQuery := `
+		SELECT s.accessor, s.single_use, s.cidrs, s.expires_at, s.created_at FROM approle_secret_id s
+		JOIN approle a ON a.id = s.approle_id
+		WHERE a.name = $1
+		ORDER BY s.created_at, s.accessor;
+	`
+
+	log.Debug("list secret ids query", slog.String("op", op), slog.String("query", utils.QueryConvert(listSecretIDsQuery)))
+
+	rows, err := tx.Query(ctx, listSecretIDsQuery, role)
+	if err != nil {
+		log.Error("failed to list secret ids", sl.OpErr(op, err))
+		return nil, errors.New("failed to list secret ids")
+	}
+	defer rows.Close()
+
+	secretIDs := make([]models.SecretIDModel, 0)
+	for rows.Next() {
+		var model models.SecretIDModel
+		if err := rows.Scan(&model.Accessor, &model.SingleUse, &model.CIDRs, &model.ExpiresAt, &model.CreatedAt); err != nil {
+			log.Error("failed to scan secret ids", sl.OpErr(op, err))
+			return nil, errors.New("failed to list secret ids")
+		}
+		secretIDs = append(secretIDs, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list secret ids")
+	}
+
+	return secretIDs, nil
+}
+
+func (r *DBClient) GetSecretID(ctx context.Context, log *slog.Logger, role string, secretHash string) (models.SecretIDModel, error) {
+	const op = "db.postgresql.GetSecretID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.SecretIDModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getSecretIDQuery := `
+		SELECT s.accessor, s.single_use, s.cidrs, s.expires_at, s.created_at FROM approle_secret_id s
+		JOIN approle a ON a.id = s.approle_id
+		WHERE a.name = $1 AND s.secret_hash = $2;
+	`
+
+	log.Debug("get secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getSecretIDQuery)))
+
+	var model models.SecretIDModel
+	err = tx.QueryRow(ctx, getSecretIDQuery, role, secretHash).Scan(&model.Accessor, &model.SingleUse, &model.CIDRs, &model.ExpiresAt, &model.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.SecretIDModel{}, errors.New("secret id not found")
+		}
+		log.Error("failed to get secret id", sl.OpErr(op, err))
+		return models.SecretIDModel{}, errors.New("failed to get secret id")
+	}
+
+	return model, nil
+}
+
+func (r *DBClient) DeleteSecretID(ctx context.Context, log *slog.Logger, role string, accessor string) error {
+	const op = "db.postgresql.DeleteSecretID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteSecretIDQuery := `
+		DELETE FROM approle_secret_id
+		WHERE approle_id = (SELECT id FROM approle WHERE name = $1) AND accessor = $2;
+	`
+
+	log.Debug("delete secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteSecretIDQuery)))
+
+	tag, err := tx.Exec(ctx, deleteSecretIDQuery, role, accessor)
+	if err != nil {
+		log.Error("failed to delete secret id", sl.OpErr(op, err))
+		return errors.New("failed to delete secret id")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("secret id not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanAppRole(row pgx.Row) (models.AppRoleModel, error) {
+	var role models.AppRoleModel
+	var expires, maxExpires, secretIDExpires, maxTTL int64
+	if err := row.Scan(
+		&role.Name,
+		&role.RoleID,
+		&role.VaultIDs,
+		&role.Paths,
+		&role.TransitKeys,
+		&role.Keys,
+		&role.Capabilities,
+		&expires,
+		&maxExpires,
+		&secretIDExpires,
+		&role.Renewable,
+		&maxTTL,
+		&role.CreatedAt,
+	); err != nil {
+		return models.AppRoleModel{}, err
+	}
+
+	role.Expires = time.Duration(expires)
+	role.MaxExpires = time.Duration(maxExpires)
+	role.SecretIDExpires = time.Duration(secretIDExpires)
+	role.MaxTTL = time.Duration(maxTTL)
+	return role, nil
+}
+
+// emptyList stores nil lists as empty arrays.
+func emptyList(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
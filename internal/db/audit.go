@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAuditEntry appends an audit entry, the document is stored verbatim so its hash can be verified.
+func (r *DBClient) CreateAuditEntry(ctx context.Context, log *slog.Logger, seq int64, document string) error {
+	const op = "db.postgresql.CreateAuditEntry"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createAuditEntryQuery := `
+		INSERT INTO audit_log
+			(seq, document)
+		VALUES ($1, $2);
+	`
+
+	log.Debug("create audit entry query", slog.String("op", op), slog.String("query", utils.QueryConvert(createAuditEntryQuery)))
+
+	if _, err := tx.Exec(ctx, createAuditEntryQuery, seq, document); err != nil {
+		log.Error("failed to save audit entry", sl.OpErr(op, err))
+		return errors.New("failed to save audit entry")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) LastAuditEntry(ctx context.Context, log *slog.Logger) (string, error) {
+	const op = "db.postgresql.LastAuditEntry"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return "", errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	lastAuditEntryQuery := `
+		SELECT document FROM audit_log
+		ORDER BY seq DESC
+		LIMIT 1;
+	`
+
+	log.Debug("last audit entry query", slog.String("op", op), slog.String("query", utils.QueryConvert(lastAuditEntryQuery)))
+
+	var document string
+	if err := tx.QueryRow(ctx, lastAuditEntryQuery).Scan(&document); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.New("audit log is empty")
+		}
+		log.Error("failed to get last audit entry", sl.OpErr(op, err))
+		return "", errors.New("failed to get last audit entry")
+	}
+
+	return document, nil
+}
+
+func (r *DBClient) ListAuditEntries(ctx context.Context, log *slog.Logger, afterSeq int64, limit int) ([]string, error) {
+	const op = "db.postgresql.ListAuditEntries"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listAuditEntriesQuery := `
+		SELECT document FROM audit_log
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2;
+	`
+
+	log.Debug("list audit entries query", slog.String("op", op), slog.String("query", utils.QueryConvert(listAuditEntriesQuery)))
+
+	rows, err := tx.Query(ctx, listAuditEntriesQuery, afterSeq, limit)
+	if err != nil {
+		log.Error("failed to list audit entries", sl.OpErr(op, err))
+		return nil, errors.New("failed to list audit entries")
+	}
+	defer rows.Close()
+
+	documents := make([]string, 0, limit)
+	for rows.Next() {
+		var document string
+		if err := rows.Scan(&document); err != nil {
+			log.Error("failed to scan audit entries", sl.OpErr(op, err))
+			return nil, errors.New("failed to list audit entries")
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// UndeleteVault restores a soft deleted vault, it fails when its path was taken in the meantime.
+func (r *DBClient) UndeleteVault(ctx context.Context, log *slog.Logger, id int) error {
+	const op = "db.postgresql.UndeleteVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	undeleteVaultQuery := `
+		UPDATE vault
+		SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL;
+	`
+
+	log.Debug("undelete vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(undeleteVaultQuery)))
+
+	tag, err := tx.Exec(ctx, undeleteVaultQuery, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("vault already exists")
+		}
+		log.Error("failed to undelete vault", sl.OpErr(op, err))
+		return errors.New("failed to undelete vault")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("vault not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DestroyVault removes a live or soft deleted vault with all its versions, it can't be undone.
+// The values are crypto-shredded: the wrapped data key is discarded with the vault row, copies
+// of the ciphertext left in database pages can't be decrypted without it. Backups taken
+// before still hold the wrapped key.
+func (r *DBClient) DestroyVault(ctx context.Context, log *slog.Logger, id int) error {
+	const op = "db.postgresql.DestroyVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	destroyVaultQuery := `
+		DELETE FROM vault
+		WHERE id = $1;
+	`
+
+	log.Debug("destroy vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(destroyVaultQuery)))
+
+	tag, err := tx.Exec(ctx, destroyVaultQuery, id)
+	if err != nil {
+		log.Error("failed to destroy vault", sl.OpErr(op, err))
+		return errors.New("failed to destroy vault")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("vault not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteKeys soft deletes the keys in every version of the vault, unknown keys are ignored.
+func (r *DBClient) DeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.postgresql.DeleteKeys"
+
+	deleteKeysQuery := `
+		UPDATE value
+		SET deleted_at = NOW()
+		WHERE vault_id = $1 AND key = ANY($2) AND deleted_at IS NULL;
+	`
+
+	return r.updateKeys(ctx, log, op, id, keys, deleteKeysQuery)
+}
+
+// UndeleteKeys restores soft deleted keys in every version of the vault.
+func (r *DBClient) UndeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.postgresql.UndeleteKeys"
+
+	undeleteKeysQuery := `
+		UPDATE value
+		SET deleted_at = NULL
+		WHERE vault_id = $1 AND key = ANY($2);
+	`
+
+	return r.updateKeys(ctx, log, op, id, keys, undeleteKeysQuery)
+}
+
+// DestroyKeys blanks the ciphertext of the keys in every version of the vault and removes them.
+// The data key is shared with the other keys, old copies of the rows are not shredded.
+func (r *DBClient) DestroyKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.postgresql.DestroyKeys"
+
+	wipeKeysQuery := `
+		UPDATE value
+		SET value = ''
+		WHERE vault_id = $1 AND key = ANY($2);
+	`
+	destroyKeysQuery := `
+		DELETE FROM value
+		WHERE vault_id = $1 AND key = ANY($2);
+	`
+
+	return r.updateKeys(ctx, log, op, id, keys, wipeKeysQuery, destroyKeysQuery)
+}
+
+// updateKeys runs the key queries on a live vault, the queries take the vault id and the keys.
+func (r *DBClient) updateKeys(ctx context.Context, log *slog.Logger, op string, id int, keys []string, queries ...string) error {
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVault(ctx, tx, log, op, id); err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		log.Debug("update keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(query)))
+
+		if _, err := tx.Exec(ctx, query, id, keys); err != nil {
+			log.Error("failed to update vault keys", sl.OpErr(op, err))
+			return errors.New("failed to update vault keys")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// PurgeDeleted permanently removes vaults and keys soft deleted before the time,
+// it returns the number of removed vaults and values.
+func (r *DBClient) PurgeDeleted(ctx context.Context, log *slog.Logger, before time.Time) (int, int, error) {
+	const op = "db.postgresql.PurgeDeleted"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	purgeVaultsQuery := `
+		DELETE FROM vault
+		WHERE deleted_at < $1;
+	`
+
+	log.Debug("purge vaults query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeVaultsQuery)))
+
+	vaults, err := tx.Exec(ctx, purgeVaultsQuery, before)
+	if err != nil {
+		log.Error("failed to purge vaults", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge deleted vaults")
+	}
+
+	purgeValuesQuery := `
+		DELETE FROM value
+		WHERE deleted_at < $1;
+	`
+
+	log.Debug("purge values query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeValuesQuery)))
+
+	values, err := tx.Exec(ctx, purgeValuesQuery, before)
+	if err != nil {
+		log.Error("failed to purge values", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge deleted values")
+	}
+
+	return int(vaults.RowsAffected()), int(values.RowsAffected()), tx.Commit(ctx)
+}
+
+// lockVault locks the row of a live vault for the rest of the transaction.
+func lockVault(ctx context.Context, tx pgx.Tx, log *slog.Logger, op string, id int) error {
+	lockVaultQuery := `
+		SELECT id FROM vault
+		WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		FOR UPDATE;
+	`
+
+	log.Debug("lock vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(lockVaultQuery)))
+
+	if err := tx.QueryRow(ctx, lockVaultQuery, id).Scan(&id); err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.New("vault not found")
+		}
+		log.Error("failed to lock vault", sl.OpErr(op, err))
+		return errors.New("failed to get vault")
+	}
+	return nil
+}
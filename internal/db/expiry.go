@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PurgeExpired removes at most limit vaults and limit values which expired before the time,
+// it returns the number of removed vaults and values.
+func (r *DBClient) PurgeExpired(ctx context.Context, log *slog.Logger, before time.Time, limit int) (int, int, error) {
+	const op = "db.postgresql.PurgeExpired"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	purgeVaultsQuery := `
+		DELETE FROM vault
+		WHERE id IN (SELECT id FROM vault WHERE expires_at <= $1 LIMIT $2);
+	`
+
+	log.Debug("purge vaults query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeVaultsQuery)))
+
+	vaults, err := tx.Exec(ctx, purgeVaultsQuery, before, limit)
+	if err != nil {
+		log.Error("failed to purge vaults", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge expired vaults")
+	}
+
+	purgeValuesQuery := `
+		DELETE FROM value
+		WHERE id IN (SELECT id FROM value WHERE expires_at <= $1 LIMIT $2);
+	`
+
+	log.Debug("purge values query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeValuesQuery)))
+
+	values, err := tx.Exec(ctx, purgeValuesQuery, before, limit)
+	if err != nil {
+		log.Error("failed to purge values", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge expired values")
+	}
+
+	return int(vaults.RowsAffected()), int(values.RowsAffected()), tx.Commit(ctx)
+}
+
+// releaseExpiredPath removes the expired vault still holding the path, so the path can be taken before it is purged.
+func releaseExpiredPath(ctx context.Context, tx pgx.Tx, log *slog.Logger, op string, name string) error {
+	releasePathQuery := `
+		DELETE FROM vault
+		WHERE name = $1 AND deleted_at IS NULL AND expires_at <= NOW();
+	`
+
+	log.Debug("release path query", slog.String("op", op), slog.String("query", utils.QueryConvert(releasePathQuery)))
+
+	if _, err := tx.Exec(ctx, releasePathQuery, name); err != nil {
+		log.Error("failed to release expired vault path", sl.OpErr(op, err))
+		return errors.New("failed to release expired vault path")
+	}
+	return nil
+}
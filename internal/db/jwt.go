@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ListJWTKeys returns the signing keys ordered by creation.
+func (r *DBClient) ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error) {
+	const op = "db.postgresql.ListJWTKeys"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listKeysQuery := `
+		SELECT kid, algorithm, state, material, public_key, created_at FROM jwt_key
+		ORDER BY created_at, kid;
+	`
+
+	log.Debug("list jwt keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listKeysQuery)))
+
+	rows, err := tx.Query(ctx, listKeysQuery)
+	if err != nil {
+		log.Error("failed to list jwt keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list jwt keys")
+	}
+	defer rows.Close()
+
+	keys := make([]models.JWTKeyModel, 0)
+	for rows.Next() {
+		var key models.JWTKeyModel
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.State, &key.Key, &key.PublicKey, &key.CreatedAt); err != nil {
+			log.Error("failed to scan jwt keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list jwt keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list jwt keys")
+	}
+
+	return keys, nil
+}
+
+func (r *DBClient) CreateJWTKey(ctx context.Context, log *slog.Logger, model models.JWTKeyModel) error {
+	const op = "db.postgresql.CreateJWTKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createKeyQuery := `
+		INSERT INTO jwt_key
+			(kid, algorithm, state, material, public_key)
+		VALUES ($1, $2, $3, $4, $5);
+	`
+
+	log.Debug("create jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(createKeyQuery)))
+
+	if _, err := tx.Exec(ctx, createKeyQuery, model.ID, model.Algorithm, model.State, model.Key, model.PublicKey); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("jwt key already exists")
+		}
+		log.Error("failed to save jwt key", sl.OpErr(op, err))
+		return errors.New("failed to save jwt key")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) PromoteJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	const op = "db.postgresql.PromoteJWTKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := getJWTKeyState(ctx, tx, log, kid)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.New("jwt key not found")
+		}
+		log.Error("failed to get jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+	if state == models.JWTKeyRetired {
+		return errors.New("jwt key is retired")
+	}
+
+	demoteKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'verify'
+		WHERE state = 'active';
+	`
+
+	log.Debug("demote jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(demoteKeyQuery)))
+
+	if _, err := tx.Exec(ctx, demoteKeyQuery); err != nil {
+		log.Error("failed to demote jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+
+	promoteKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'active'
+		WHERE kid = $1;
+	`
+
+	log.Debug("promote jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(promoteKeyQuery)))
+
+	if _, err := tx.Exec(ctx, promoteKeyQuery, kid); err != nil {
+		log.Error("failed to promote jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) RetireJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	const op = "db.postgresql.RetireJWTKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := getJWTKeyState(ctx, tx, log, kid)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.New("jwt key not found")
+		}
+		log.Error("failed to get jwt key", sl.OpErr(op, err))
+		return errors.New("failed to retire jwt key")
+	}
+	if state == models.JWTKeyActive {
+		return errors.New("jwt key is active")
+	}
+
+	retireKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'retired'
+		WHERE kid = $1;
+	`
+
+	log.Debug("retire jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(retireKeyQuery)))
+
+	if _, err := tx.Exec(ctx, retireKeyQuery, kid); err != nil {
+		log.Error("failed to retire jwt key", sl.OpErr(op, err))
+		return errors.New("failed to retire jwt key")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// getJWTKeyState returns the state of the key and locks it until the transaction ends.
+func getJWTKeyState(ctx context.Context, tx pgx.Tx, log *slog.Logger, kid string) (string, error) {
+	getStateQuery := `
+		SELECT state FROM jwt_key
+		WHERE kid = $1
+		FOR UPDATE;
+	`
+
+	log.Debug("get jwt key state query", slog.String("query", utils.QueryConvert(getStateQuery)))
+
+	var state string
+	err := tx.QueryRow(ctx, getStateQuery, kid).Scan(&state)
+	return state, err
+}
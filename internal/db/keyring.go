@@ -0,0 +1,288 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// GetKeyring returns the stored keyring terms ordered by term.
+func (r *DBClient) GetKeyring(ctx context.Context, log *slog.Logger) ([]models.KeyringTerm, error) {
+	const op = "db.postgresql.GetKeyring"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getKeyringQuery := `
+		SELECT term, material, created_at FROM keyring
+		ORDER BY term;
+	`
+
+	log.Debug("get keyring query", slog.String("op", op), slog.String("query", utils.QueryConvert(getKeyringQuery)))
+
+	rows, err := tx.Query(ctx, getKeyringQuery)
+	if err != nil {
+		log.Error("failed to get keyring", sl.OpErr(op, err))
+		return nil, errors.New("failed to get keyring")
+	}
+	defer rows.Close()
+
+	terms := make([]models.KeyringTerm, 0)
+	for rows.Next() {
+		var term models.KeyringTerm
+		if err := rows.Scan(&term.Term, &term.Key, &term.CreatedAt); err != nil {
+			log.Error("failed to scan keyring", sl.OpErr(op, err))
+			return nil, errors.New("failed to get keyring")
+		}
+		terms = append(terms, term)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to get keyring")
+	}
+
+	return terms, nil
+}
+
+func (r *DBClient) AddKeyringTerm(ctx context.Context, log *slog.Logger, term models.KeyringTerm) error {
+	const op = "db.postgresql.AddKeyringTerm"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	addTermQuery := `
+		INSERT INTO keyring
+			(term, material)
+		VALUES ($1, $2);
+	`
+
+	log.Debug("add keyring term query", slog.String("op", op), slog.String("query", utils.QueryConvert(addTermQuery)))
+
+	if _, err := tx.Exec(ctx, addTermQuery, term.Term, term.Key); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("keyring term already exists")
+		}
+		log.Error("failed to save keyring term", sl.OpErr(op, err))
+		return errors.New("failed to save keyring term")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListStaleKeys returns vault data keys first, then transit key material and JWT keys, all in a stable order
+// so batches move forward as keys are rewrapped.
+func (r *DBClient) ListStaleKeys(ctx context.Context, log *slog.Logger, prefix string, limit int) ([]models.WrappedKey, error) {
+	const op = "db.postgresql.ListStaleKeys"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listVaultKeysQuery := `
+		SELECT id, data_key FROM vault
+		WHERE data_key IS NOT NULL AND data_key <> '' AND data_key NOT LIKE $1 || '%'
+		ORDER BY id
+		LIMIT $2;
+	`
+
+	log.Debug("list stale vault keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVaultKeysQuery)))
+
+	rows, err := tx.Query(ctx, listVaultKeysQuery, prefix, limit)
+	if err != nil {
+		log.Error("failed to list stale vault keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer rows.Close()
+
+	keys := make([]models.WrappedKey, 0)
+	for rows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyVault}
+		if err := rows.Scan(&key.ID, &key.Key); err != nil {
+			log.Error("failed to scan stale vault keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	if len(keys) == limit {
+		return keys, nil
+	}
+
+	listTransitKeysQuery := `
+		SELECT k.name, v.version, v.material FROM transit_key_version v
+		JOIN transit_key k ON k.id = v.key_id
+		WHERE v.material NOT LIKE $1 || '%'
+		ORDER BY k.id, v.version
+		LIMIT $2;
+	`
+
+	log.Debug("list stale transit keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTransitKeysQuery)))
+
+	rows, err = tx.Query(ctx, listTransitKeysQuery, prefix, limit-len(keys))
+	if err != nil {
+		log.Error("failed to list stale transit keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyTransit}
+		if err := rows.Scan(&key.Name, &key.Version, &key.Key); err != nil {
+			log.Error("failed to scan stale transit keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	if len(keys) == limit {
+		return keys, nil
+	}
+
+	listJWTKeysQuery := `
+		SELECT kid, material FROM jwt_key
+		WHERE material NOT LIKE $1 || '%'
+		ORDER BY kid
+		LIMIT $2;
+	`
+
+	log.Debug("list stale jwt keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listJWTKeysQuery)))
+
+	rows, err = tx.Query(ctx, listJWTKeysQuery, prefix, limit-len(keys))
+	if err != nil {
+		log.Error("failed to list stale jwt keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyJWT}
+		if err := rows.Scan(&key.Name, &key.Key); err != nil {
+			log.Error("failed to scan stale jwt keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	return keys, nil
+}
+
+func (r *DBClient) CountStaleKeys(ctx context.Context, log *slog.Logger, prefix string) (int, int, error) {
+	const op = "db.postgresql.CountStaleKeys"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	countKeysQuery := `
+		SELECT
+			(SELECT COUNT(*) FROM vault
+				WHERE data_key IS NOT NULL AND data_key <> '' AND data_key NOT LIKE $1 || '%') +
+			(SELECT COUNT(*) FROM transit_key_version
+				WHERE material NOT LIKE $1 || '%') +
+			(SELECT COUNT(*) FROM jwt_key
+				WHERE material NOT LIKE $1 || '%'),
+			(SELECT COUNT(*) FROM vault
+				WHERE data_key IS NOT NULL AND data_key <> '') +
+			(SELECT COUNT(*) FROM transit_key_version) +
+			(SELECT COUNT(*) FROM jwt_key);
+	`
+
+	log.Debug("count stale keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(countKeysQuery)))
+
+	var pending, total int
+	if err := tx.QueryRow(ctx, countKeysQuery, prefix).Scan(&pending, &total); err != nil {
+		log.Error("failed to count stale keys", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to count stale keys")
+	}
+
+	return pending, total, nil
+}
+
+func (r *DBClient) UpdateWrappedKey(ctx context.Context, log *slog.Logger, key models.WrappedKey, old string) error {
+	const op = "db.postgresql.UpdateWrappedKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var updateKeyQuery string
+	var args []any
+	switch key.Kind {
+	case models.WrappedKeyVault:
+		updateKeyQuery = `
+			UPDATE vault
+			SET data_key = $2
+			WHERE id = $1 AND data_key = $3;
+		`
+		args = []any{key.ID, key.Key, old}
+	case models.WrappedKeyTransit:
+		updateKeyQuery = `
+			UPDATE transit_key_version
+			SET material = $3
+			WHERE key_id = (SELECT id FROM transit_key WHERE name = $1) AND version = $2 AND material = $4;
+		`
+		args = []any{key.Name, key.Version, key.Key, old}
+	case models.WrappedKeyJWT:
+		updateKeyQuery = `
+			UPDATE jwt_key
+			SET material = $2
+			WHERE kid = $1 AND material = $3;
+		`
+		args = []any{key.Name, key.Key, old}
+	default:
+		return errors.New("unknown wrapped key kind")
+	}
+
+	log.Debug("update wrapped key query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateKeyQuery)))
+
+	tag, err := tx.Exec(ctx, updateKeyQuery, args...)
+	if err != nil {
+		log.Error("failed to update wrapped key", sl.OpErr(op, err))
+		return errors.New("failed to update wrapped key")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("wrapped key changed")
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// vaultSortColumns maps sorts to the columns of their (column, id) index.
+var vaultSortColumns = map[string]string{
+	models.SortName:      "v.name",
+	models.SortCreatedAt: "v.created_at",
+	models.SortID:        "v.id",
+}
+
+// ListVaults returns a page of vault summaries, the keyset condition of the cursor
+// and the filters are served by the name, created_at, tag, metadata and attribute indexes.
+func (r *DBClient) ListVaults(ctx context.Context, log *slog.Logger, filter models.VaultFilter) (models.VaultPage, error) {
+	const op = "db.postgresql.ListVaults"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.VaultPage{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"v.deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "v.deleted_at IS NOT NULL"
+	}
+	conditions = append(conditions, "(v.expires_at IS NULL OR v.expires_at > NOW())")
+	if filter.Prefix != "" {
+		conditions = append(conditions, "v.name >= "+arg(filter.Prefix))
+		if end := models.PrefixEnd(filter.Prefix); end != "" {
+			conditions = append(conditions, "v.name < "+arg(end))
+		}
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM vault_tag t WHERE t.vault_id = v.id AND t.tag = "+arg(tag)+")")
+	}
+	if filter.Owner != "" {
+		conditions = append(conditions, "m.owner = "+arg(filter.Owner))
+	}
+	if filter.CreatedBy != "" {
+		conditions = append(conditions, "m.created_by = "+arg(filter.CreatedBy))
+	}
+	for k, value := range filter.Attributes {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM vault_attribute a WHERE a.vault_id = v.id AND a.key = %s AND a.value = %s)", arg(k), arg(value)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "v.created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "v.created_at < "+arg(filter.CreatedBefore))
+	}
+
+	column := vaultSortColumns[filter.Sort]
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if after := filter.After; after != nil {
+		switch filter.Sort {
+		case models.SortName:
+			conditions = append(conditions, fmt.Sprintf("(v.name, v.id) %s (%s, %s)", compare, arg(after.Name), arg(after.ID)))
+		case models.SortCreatedAt:
+			conditions = append(conditions, fmt.Sprintf("(v.created_at, v.id) %s (%s, %s)", compare, arg(after.CreatedAt), arg(after.ID)))
+		default:
+			conditions = append(conditions, fmt.Sprintf("v.id %s %s", compare, arg(after.ID)))
+		}
+	}
+
+	order := fmt.Sprintf("%s %s", column, direction)
+	if filter.Sort != models.SortID {
+		order += fmt.Sprintf(", v.id %s", direction)
+	}
+
+	listVaultsQuery := fmt.Sprintf(`
+		SELECT v.id, v.name, v.current_version, v.created_at, v.deleted_at, v.expires_at,
+			(SELECT COUNT(*) FROM value val WHERE val.vault_id = v.id AND val.version = v.current_version AND val.deleted_at IS NULL
+				AND (val.expires_at IS NULL OR val.expires_at > NOW())),
+			COALESCE((SELECT array_agg(t.tag ORDER BY t.tag) FROM vault_tag t WHERE t.vault_id = v.id), '{}'),
+			m.description, m.owner, m.created_by,
+			COALESCE((SELECT jsonb_object_agg(a.key, a.value) FROM vault_attribute a WHERE a.vault_id = v.id), '{}')
+		FROM vault v
+		JOIN vault_metadata m ON m.vault_id = v.id
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s;
+	`, strings.Join(conditions, " AND "), order, arg(filter.Limit+1))
+
+	log.Debug("list vaults query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVaultsQuery)))
+
+	rows, err := tx.Query(ctx, listVaultsQuery, args...)
+	if err != nil {
+		log.Error("failed to list vaults", sl.OpErr(op, err))
+		return models.VaultPage{}, errors.New("failed to list vaults")
+	}
+	defer rows.Close()
+
+	vaults := make([]models.VaultModel, 0)
+	for rows.Next() {
+		var vault models.VaultModel
+		if err := rows.Scan(&vault.ID, &vault.Name, &vault.Version, &vault.CreatedAt, &vault.DeletedAt, &vault.ExpiresAt, &vault.KeyCount, &vault.Tags,
+			&vault.Description, &vault.Owner, &vault.CreatedBy, &vault.Attributes); err != nil {
+			log.Error("failed to scan vaults", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		vaults = append(vaults, vault)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return models.VaultPage{}, errors.New("failed to list vaults")
+	}
+
+	return filter.Page(vaults), nil
+}
+
+// ListChildren returns the next path segment of every vault below the prefix,
+// segments with vaults further down end with /.
+func (r *DBClient) ListChildren(ctx context.Context, log *slog.Logger, prefix string) ([]string, error) {
+	const op = "db.postgresql.ListChildren"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	listChildrenQuery := `
+		SELECT DISTINCT CASE WHEN strpos(rest, '/') > 0 THEN split_part(rest, '/', 1) || '/' ELSE rest END AS child
+		FROM (
+			SELECT substr(name, char_length($1) + 1) AS rest FROM vault
+			WHERE name >= $1 AND ($2 = '' OR name < $2) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		) children
+		ORDER BY child;
+	`
+
+	log.Debug("list children query", slog.String("op", op), slog.String("query", utils.QueryConvert(listChildrenQuery)))
+
+	rows, err := tx.Query(ctx, listChildrenQuery, prefix, models.PrefixEnd(prefix))
+	if err != nil {
+		log.Error("failed to list children", sl.OpErr(op, err))
+		return nil, errors.New("failed to list children")
+	}
+	defer rows.Close()
+
+	children := make([]string, 0)
+	for rows.Next() {
+		var child string
+		if err := rows.Scan(&child); err != nil {
+			log.Error("failed to scan children", sl.OpErr(op, err))
+			return nil, errors.New("failed to list children")
+		}
+		children = append(children, child)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list children")
+	}
+
+	return children, nil
+}
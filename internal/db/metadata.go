@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetMetadata returns the metadata of a live vault.
+func (r *DBClient) GetMetadata(ctx context.Context, log *slog.Logger, id int) (models.MetadataModel, error) {
+	const op = "db.postgresql.GetMetadata"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getMetadataQuery := `
+		SELECT v.id, v.name, m.description, m.owner, m.contact_email, m.created_by, v.created_at, m.updated_at,
+			COALESCE((SELECT array_agg(t.tag ORDER BY t.tag) FROM vault_tag t WHERE t.vault_id = v.id), '{}'),
+			COALESCE((SELECT jsonb_object_agg(a.key, a.value) FROM vault_attribute a WHERE a.vault_id = v.id), '{}')
+		FROM vault v
+		JOIN vault_metadata m ON m.vault_id = v.id
+		WHERE v.id = $1 AND v.deleted_at IS NULL AND (v.expires_at IS NULL OR v.expires_at > NOW());
+	`
+
+	log.Debug("get metadata query", slog.String("op", op), slog.String("query", utils.QueryConvert(getMetadataQuery)))
+
+	var metadata models.MetadataModel
+	err = tx.QueryRow(ctx, getMetadataQuery, id).Scan(&metadata.ID, &metadata.Name, &metadata.Description, &metadata.Owner,
+		&metadata.ContactEmail, &metadata.CreatedBy, &metadata.CreatedAt, &metadata.UpdatedAt, &metadata.Tags, &metadata.Attributes)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.MetadataModel{}, errors.New("vault not found")
+		}
+		log.Error("failed to get vault metadata", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+
+	return metadata, nil
+}
+
+// UpdateMetadata edits the metadata of a live vault, the vault data and version are left alone.
+func (r *DBClient) UpdateMetadata(ctx context.Context, log *slog.Logger, id int, model models.MetadataDTO) error {
+	const op = "db.postgresql.UpdateMetadata"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVault(ctx, tx, log, op, id); err != nil {
+		return err
+	}
+
+	updateMetadataQuery := `
+		UPDATE vault_metadata
+		SET description = COALESCE($2, description), owner = COALESCE($3, owner),
+			contact_email = COALESCE($4, contact_email), updated_at = NOW()
+		WHERE vault_id = $1;
+	`
+
+	log.Debug("update metadata query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateMetadataQuery)))
+
+	if _, err := tx.Exec(ctx, updateMetadataQuery, id, model.Description, model.Owner, model.ContactEmail); err != nil {
+		log.Error("failed to update vault metadata", sl.OpErr(op, err))
+		return errors.New("failed to update vault metadata")
+	}
+
+	if model.Tags != nil {
+		if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+			log.Error("failed to save vault tags", sl.OpErr(op, err))
+			return errors.New("failed to save vault tags")
+		}
+	}
+
+	if model.Attributes != nil {
+		if err := replaceAttributes(ctx, tx, log, id, model.Attributes); err != nil {
+			log.Error("failed to save vault attributes", sl.OpErr(op, err))
+			return errors.New("failed to save vault attributes")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertMetadata(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, createdBy string) error {
+	createMetadataQuery := `
+		INSERT INTO vault_metadata
+			(vault_id, created_by)
+		VALUES ($1, $2);
+	`
+
+	log.Debug("create metadata query", slog.String("query", utils.QueryConvert(createMetadataQuery)))
+
+	_, err := tx.Exec(ctx, createMetadataQuery, id, createdBy)
+	return err
+}
+
+// touchMetadata marks the metadata as updated when it is changed together with the vault data.
+func touchMetadata(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int) error {
+	touchMetadataQuery := `
+		UPDATE vault_metadata
+		SET updated_at = NOW()
+		WHERE vault_id = $1;
+	`
+
+	log.Debug("touch metadata query", slog.String("query", utils.QueryConvert(touchMetadataQuery)))
+
+	_, err := tx.Exec(ctx, touchMetadataQuery, id)
+	return err
+}
+
+// replaceAttributes sets the custom attributes of the vault, the previous attributes are removed.
+func replaceAttributes(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, attributes map[string]string) error {
+	deleteAttributesQuery := `
+		DELETE FROM vault_attribute
+		WHERE vault_id = $1;
+	`
+
+	log.Debug("delete attributes query", slog.String("query", utils.QueryConvert(deleteAttributesQuery)))
+
+	if _, err := tx.Exec(ctx, deleteAttributesQuery, id); err != nil {
+		return err
+	}
+
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	createAttributesQuery := `
+		INSERT INTO vault_attribute
+			(vault_id, key, value)
+		SELECT $1, key, value FROM UNNEST($2::VARCHAR[], $3::VARCHAR[]) AS a(key, value);
+	`
+
+	log.Debug("create attributes query", slog.String("query", utils.QueryConvert(createAttributesQuery)))
+
+	keys := make([]string, 0, len(attributes))
+	values := make([]string, 0, len(attributes))
+	for k, v := range attributes {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	_, err := tx.Exec(ctx, createAttributesQuery, id, keys, values)
+	return err
+}
@@ -0,0 +1,401 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CreateOperator stores a new operator account, passwordHash is the argon2id hash of its password.
+func (r *DBClient) CreateOperator(ctx context.Context, log *slog.Logger, model models.OperatorModel, passwordHash string) (int, error) {
+	const op = "db.postgresql.CreateOperator"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createOperatorQuery := `
+		INSERT INTO operator
+			(name, password_hash, policies)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`
+
+	log.Debug("create operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(createOperatorQuery)))
+
+	var id int
+	if err := tx.QueryRow(ctx, createOperatorQuery, model.Name, passwordHash, emptyList(model.Policies)).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return 0, errors.New("operator already exists")
+		}
+		log.Error("failed to save operator", sl.OpErr(op, err))
+		return 0, errors.New("failed to save operator")
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+func (r *DBClient) ListOperators(ctx context.Context, log *slog.Logger) ([]models.OperatorModel, error) {
+	const op = "db.postgresql.ListOperators"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listOperatorsQuery := `
+		SELECT id, name, policies, failed_logins, locked_until, created_at FROM operator
+		ORDER BY name;
+	`
+
+	log.Debug("list operators query", slog.String("op", op), slog.String("query", utils.QueryConvert(listOperatorsQuery)))
+
+	rows, err := tx.Query(ctx, listOperatorsQuery)
+	if err != nil {
+		log.Error("failed to list operators", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operators")
+	}
+	defer rows.Close()
+
+	operators := make([]models.OperatorModel, 0)
+	for rows.Next() {
+		var model models.OperatorModel
+		if err := rows.Scan(&model.ID, &model.Name, &model.Policies, &model.FailedLogins, &model.LockedUntil, &model.CreatedAt); err != nil {
+			log.Error("failed to scan operators", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operators")
+		}
+		operators = append(operators, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operators")
+	}
+
+	return operators, nil
+}
+
+// GetOperator returns the operator with the hash of its password.
+func (r *DBClient) GetOperator(ctx context.Context, log *slog.Logger, name string) (models.OperatorModel, string, error) {
+	const op = "db.postgresql.GetOperator"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.OperatorModel{}, "", errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getOperatorQuery := `
+		SELECT id, name, policies, failed_logins, locked_until, created_at, password_hash FROM operator
+		WHERE name = $1;
+	`
+
+	log.Debug("get operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(getOperatorQuery)))
+
+	var model models.OperatorModel
+	var passwordHash string
+	err = tx.QueryRow(ctx, getOperatorQuery, name).Scan(&model.ID, &model.Name, &model.Policies, &model.FailedLogins, &model.LockedUntil, &model.CreatedAt, &passwordHash)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.OperatorModel{}, "", errors.New("operator not found")
+		}
+		log.Error("failed to get operator", sl.OpErr(op, err))
+		return models.OperatorModel{}, "", errors.New("failed to get operator")
+	}
+
+	return model, passwordHash, nil
+}
+
+// DeleteOperator removes the operator, its sessions and API keys are removed by the foreign key.
+func (r *DBClient) DeleteOperator(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.DeleteOperator"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteOperatorQuery := `
+		DELETE FROM operator
+		WHERE name = $1;
+	`
+
+	log.Debug("delete operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteOperatorQuery)))
+
+	tag, err := tx.Exec(ctx, deleteOperatorQuery, name)
+	if err != nil {
+		log.Error("failed to delete operator", sl.OpErr(op, err))
+		return errors.New("failed to delete operator")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateOperatorPassword replaces the password hash, unlocks the operator and ends its sessions,
+// API keys are kept.
+func (r *DBClient) UpdateOperatorPassword(ctx context.Context, log *slog.Logger, name string, passwordHash string) error {
+	const op = "db.postgresql.UpdateOperatorPassword"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	updatePasswordQuery := `
+		UPDATE operator
+		SET password_hash = $2, failed_logins = 0, locked_until = NULL
+		WHERE name = $1;
+	`
+
+	log.Debug("update operator password query", slog.String("op", op), slog.String("query", utils.QueryConvert(updatePasswordQuery)))
+
+	tag, err := tx.Exec(ctx, updatePasswordQuery, name, passwordHash)
+	if err != nil {
+		log.Error("failed to update operator password", sl.OpErr(op, err))
+		return errors.New("failed to update operator password")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator not found")
+	}
+
+	deleteSessionsQuery := `
+		DELETE FROM operator_token
+		WHERE kind = $2 AND operator_id = (SELECT id FROM operator WHERE name = $1);
+	`
+
+	log.Debug("delete operator sessions query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteSessionsQuery)))
+
+	if _, err := tx.Exec(ctx, deleteSessionsQuery, name, models.OperatorSession); err != nil {
+		log.Error("failed to delete operator sessions", sl.OpErr(op, err))
+		return errors.New("failed to update operator password")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UnlockOperator resets the failed logins of the operator and lifts its lock.
+func (r *DBClient) UnlockOperator(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.UnlockOperator"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	unlockOperatorQuery := `
+		UPDATE operator
+		SET failed_logins = 0, locked_until = NULL
+		WHERE name = $1;
+	`
+
+	log.Debug("unlock operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(unlockOperatorQuery)))
+
+	tag, err := tx.Exec(ctx, unlockOperatorQuery, name)
+	if err != nil {
+		log.Error("failed to unlock operator", sl.OpErr(op, err))
+		return errors.New("failed to unlock operator")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RecordOperatorFailure counts a failed login, the maxFailures-th failure in a row locks
+// the operator until lockedUntil and starts counting again.
+func (r *DBClient) RecordOperatorFailure(ctx context.Context, log *slog.Logger, name string, maxFailures int, lockedUntil time.Time) error {
+	const op = "db.postgresql.RecordOperatorFailure"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	recordFailureQuery := `
+		UPDATE operator
+		SET failed_logins = CASE WHEN failed_logins + 1 >= $2 THEN 0 ELSE failed_logins + 1 END,
+			locked_until = CASE WHEN failed_logins + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE name = $1;
+	`
+
+	log.Debug("record operator failure query", slog.String("op", op), slog.String("query", utils.QueryConvert(recordFailureQuery)))
+
+	tag, err := tx.Exec(ctx, recordFailureQuery, name, maxFailures, lockedUntil)
+	if err != nil {
+		log.Error("failed to record operator failure", sl.OpErr(op, err))
+		return errors.New("failed to record operator failure")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) CreateOperatorToken(ctx context.Context, log *slog.Logger, name string, model models.OperatorTokenModel, tokenHash string) error {
+	const op = "db.postgresql.CreateOperatorToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createTokenQuery := `
+		INSERT INTO operator_token
+			(operator_id, accessor, token_hash, kind, name, expires_at)
+		SELECT id, $2, $3, $4, $5, $6 FROM operator
+		WHERE name = $1;
+	`
+
+	log.Debug("create operator token query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenQuery)))
+
+	tag, err := tx.Exec(ctx, createTokenQuery, name, model.Accessor, tokenHash, model.Kind, model.Name, model.ExpiresAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("operator token already exists")
+		}
+		log.Error("failed to save operator token", sl.OpErr(op, err))
+		return errors.New("failed to save operator token")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListOperatorTokens returns the sessions and API keys of the operator which did not expire.
+func (r *DBClient) ListOperatorTokens(ctx context.Context, log *slog.Logger, name string) ([]models.OperatorTokenModel, error) {
+	const op = "db.postgresql.ListOperatorTokens"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listTokensQuery := `
+		SELECT t.accessor, t.kind, t.name, t.expires_at, t.created_at FROM operator_token t
+		JOIN operator o ON o.id = t.operator_id
+		WHERE o.name = $1 AND (t.expires_at IS NULL OR t.expires_at > NOW())
+		ORDER BY t.created_at, t.accessor;
+	`
+
+	log.Debug("list operator tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTokensQuery)))
+
+	rows, err := tx.Query(ctx, listTokensQuery, name)
+	if err != nil {
+		log.Error("failed to list operator tokens", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operator tokens")
+	}
+	defer rows.Close()
+
+	tokens := make([]models.OperatorTokenModel, 0)
+	for rows.Next() {
+		var model models.OperatorTokenModel
+		if err := rows.Scan(&model.Accessor, &model.Kind, &model.Name, &model.ExpiresAt, &model.CreatedAt); err != nil {
+			log.Error("failed to scan operator tokens", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operator tokens")
+		}
+		tokens = append(tokens, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operator tokens")
+	}
+
+	return tokens, nil
+}
+
+func (r *DBClient) DeleteOperatorToken(ctx context.Context, log *slog.Logger, name string, accessor string) error {
+	const op = "db.postgresql.DeleteOperatorToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteTokenQuery := `
+		DELETE FROM operator_token
+		WHERE operator_id = (SELECT id FROM operator WHERE name = $1) AND accessor = $2;
+	`
+
+	log.Debug("delete operator token query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteTokenQuery)))
+
+	tag, err := tx.Exec(ctx, deleteTokenQuery, name, accessor)
+	if err != nil {
+		log.Error("failed to delete operator token", sl.OpErr(op, err))
+		return errors.New("failed to delete operator token")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("operator token not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetOperatorByToken returns the operator of a session or API key which did not expire.
+func (r *DBClient) GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error) {
+	const op = "db.postgresql.GetOperatorByToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.OperatorModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getOperatorQuery := `
+		SELECT o.id, o.name, o.policies, o.failed_logins, o.locked_until, o.created_at FROM operator o
+		JOIN operator_token t ON t.operator_id = o.id
+		WHERE t.token_hash = $1 AND (t.expires_at IS NULL OR t.expires_at > NOW());
+	`
+
+	log.Debug("get operator by token query", slog.String("op", op), slog.String("query", utils.QueryConvert(getOperatorQuery)))
+
+	var model models.OperatorModel
+	err = tx.QueryRow(ctx, getOperatorQuery, tokenHash).Scan(&model.ID, &model.Name, &model.Policies, &model.FailedLogins, &model.LockedUntil, &model.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.OperatorModel{}, errors.New("operator not found")
+		}
+		log.Error("failed to get operator", sl.OpErr(op, err))
+		return models.OperatorModel{}, errors.New("failed to get operator")
+	}
+
+	return model, nil
+}
@@ -0,0 +1,304 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *DBClient) ListPolicies(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.postgresql.ListPolicies"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listPoliciesQuery := `
+		SELECT name FROM policy
+		ORDER BY name;
+	`
+
+	log.Debug("list policies query", slog.String("op", op), slog.String("query", utils.QueryConvert(listPoliciesQuery)))
+
+	rows, err := tx.Query(ctx, listPoliciesQuery)
+	if err != nil {
+		log.Error("failed to list policies", sl.OpErr(op, err))
+		return nil, errors.New("failed to list policies")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan policies", sl.OpErr(op, err))
+			return nil, errors.New("failed to list policies")
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (r *DBClient) GetPolicy(ctx context.Context, log *slog.Logger, name string) (models.PolicyModel, error) {
+	const op = "db.postgresql.GetPolicy"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.PolicyModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getPolicyQuery := `
+		SELECT name, document, updated_at FROM policy
+		WHERE name = $1;
+	`
+
+	log.Debug("get policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(getPolicyQuery)))
+
+	var model models.PolicyModel
+	if err := tx.QueryRow(ctx, getPolicyQuery, name).Scan(&model.Name, &model.Document, &model.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.PolicyModel{}, errors.New("policy not found")
+		}
+		log.Error("failed to get policy", sl.OpErr(op, err))
+		return models.PolicyModel{}, errors.New("failed to get policy")
+	}
+
+	return model, nil
+}
+
+// GetPolicies returns the policies with the given names, unknown names are skipped.
+func (r *DBClient) GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error) {
+	const op = "db.postgresql.GetPolicies"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getPoliciesQuery := `
+		SELECT name, document, updated_at FROM policy
+		WHERE name = ANY($1);
+	`
+
+	log.Debug("get policies query", slog.String("op", op), slog.String("query", utils.QueryConvert(getPoliciesQuery)))
+
+	rows, err := tx.Query(ctx, getPoliciesQuery, names)
+	if err != nil {
+		log.Error("failed to get policies", sl.OpErr(op, err))
+		return nil, errors.New("failed to get policies")
+	}
+	defer rows.Close()
+
+	policies := make([]models.PolicyModel, 0, len(names))
+	for rows.Next() {
+		var model models.PolicyModel
+		if err := rows.Scan(&model.Name, &model.Document, &model.UpdatedAt); err != nil {
+			log.Error("failed to scan policies", sl.OpErr(op, err))
+			return nil, errors.New("failed to get policies")
+		}
+		policies = append(policies, model)
+	}
+
+	return policies, nil
+}
+
+// PutPolicy creates the policy or replaces the document of an existing one.
+func (r *DBClient) PutPolicy(ctx context.Context, log *slog.Logger, model models.PolicyModel) error {
+	const op = "db.postgresql.PutPolicy"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	putPolicyQuery := `
+		INSERT INTO policy
+			(name, document)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE
+		SET document = EXCLUDED.document, updated_at = NOW();
+	`
+
+	log.Debug("put policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(putPolicyQuery)))
+
+	if _, err := tx.Exec(ctx, putPolicyQuery, model.Name, model.Document); err != nil {
+		log.Error("failed to save policy", sl.OpErr(op, err))
+		return errors.New("failed to save policy")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) DeletePolicy(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.DeletePolicy"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deletePolicyQuery := `
+		DELETE FROM policy
+		WHERE name = $1;
+	`
+
+	log.Debug("delete policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(deletePolicyQuery)))
+
+	tag, err := tx.Exec(ctx, deletePolicyQuery, name)
+	if err != nil {
+		log.Error("failed to delete policy", sl.OpErr(op, err))
+		return errors.New("failed to delete policy")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("policy not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateIdentity stores a new admin identity, only the hash of its token is kept.
+func (r *DBClient) CreateIdentity(ctx context.Context, log *slog.Logger, model models.IdentityModel, tokenHash string) (int, error) {
+	const op = "db.postgresql.CreateIdentity"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createIdentityQuery := `
+		INSERT INTO admin_identity
+			(name, token_hash, policies)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`
+
+	log.Debug("create identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(createIdentityQuery)))
+
+	var id int
+	if err := tx.QueryRow(ctx, createIdentityQuery, model.Name, tokenHash, model.Policies).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return 0, errors.New("identity already exists")
+		}
+		log.Error("failed to save identity", sl.OpErr(op, err))
+		return 0, errors.New("failed to save identity")
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+func (r *DBClient) ListIdentities(ctx context.Context, log *slog.Logger) ([]models.IdentityModel, error) {
+	const op = "db.postgresql.ListIdentities"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listIdentitiesQuery := `
+		SELECT id, name, policies, created_at FROM admin_identity
+		ORDER BY name;
+	`
+
+	log.Debug("list identities query", slog.String("op", op), slog.String("query", utils.QueryConvert(listIdentitiesQuery)))
+
+	rows, err := tx.Query(ctx, listIdentitiesQuery)
+	if err != nil {
+		log.Error("failed to list identities", sl.OpErr(op, err))
+		return nil, errors.New("failed to list identities")
+	}
+	defer rows.Close()
+
+	identities := make([]models.IdentityModel, 0)
+	for rows.Next() {
+		var model models.IdentityModel
+		if err := rows.Scan(&model.ID, &model.Name, &model.Policies, &model.CreatedAt); err != nil {
+			log.Error("failed to scan identities", sl.OpErr(op, err))
+			return nil, errors.New("failed to list identities")
+		}
+		identities = append(identities, model)
+	}
+
+	return identities, nil
+}
+
+func (r *DBClient) DeleteIdentity(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.DeleteIdentity"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteIdentityQuery := `
+		DELETE FROM admin_identity
+		WHERE name = $1;
+	`
+
+	log.Debug("delete identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteIdentityQuery)))
+
+	tag, err := tx.Exec(ctx, deleteIdentityQuery, name)
+	if err != nil {
+		log.Error("failed to delete identity", sl.OpErr(op, err))
+		return errors.New("failed to delete identity")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("identity not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error) {
+	const op = "db.postgresql.GetIdentityByToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.IdentityModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getIdentityQuery := `
+		SELECT id, name, policies, created_at FROM admin_identity
+		WHERE token_hash = $1;
+	`
+
+	log.Debug("get identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(getIdentityQuery)))
+
+	var model models.IdentityModel
+	if err := tx.QueryRow(ctx, getIdentityQuery, tokenHash).Scan(&model.ID, &model.Name, &model.Policies, &model.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.IdentityModel{}, errors.New("identity not found")
+		}
+		log.Error("failed to get identity", sl.OpErr(op, err))
+		return models.IdentityModel{}, errors.New("failed to get identity")
+	}
+
+	return model, nil
+}
@@ -15,15 +15,45 @@ import (
 )
 
 type DBClient struct {
-	dbClient postgresql.Client
+	dbClient    postgresql.Client
+	maxVersions int
 }
 
-func NewClient(dbClient postgresql.Client) *DBClient {
+// NewClient creates the storage client, maxVersions limits the number of kept vault versions (0 keeps all).
+func NewClient(dbClient postgresql.Client, maxVersions int) *DBClient {
 	return &DBClient{
-		dbClient: dbClient,
+		dbClient:    dbClient,
+		maxVersions: maxVersions,
 	}
 }
 
+// NextVaultID reserves the id of a vault which is about to be created, it is passed
+// to CreateVault in the ID of the model. Reserved ids which are never used are skipped.
+func (r *DBClient) NextVaultID(ctx context.Context, log *slog.Logger) (int, error) {
+	const op = "db.postgresql.NextVaultID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	nextVaultIDQuery := `
+		SELECT nextval(pg_get_serial_sequence('vault', 'id'));
+	`
+
+	log.Debug("next vault id query", slog.String("op", op), slog.String("query", utils.QueryConvert(nextVaultIDQuery)))
+
+	var id int
+	if err := tx.QueryRow(ctx, nextVaultIDQuery).Scan(&id); err != nil {
+		log.Error("failed to reserve vault id", sl.OpErr(op, err))
+		return 0, errors.New("failed to reserve vault id")
+	}
+
+	return id, tx.Commit(ctx)
+}
+
 func (r *DBClient) CreateVault(ctx context.Context, log *slog.Logger, model models.SecretCreateDTO) (int, error) {
 	const op = "db.postgresql.CreateVault"
 
@@ -34,11 +64,15 @@ func (r *DBClient) CreateVault(ctx context.Context, log *slog.Logger, model mode
 	}
 	defer tx.Rollback(ctx)
 
+	if err := releaseExpiredPath(ctx, tx, log, op, model.Name); err != nil {
+		return 0, err
+	}
+
 	createVaultQuery := `
 		INSERT INTO vault
-			(name)
+			(id, name, data_key, cas_required, expires_at)
 		VALUES 
-			($1)
+			(COALESCE(NULLIF($5, 0), nextval(pg_get_serial_sequence('vault', 'id'))), $1, NULLIF($2, ''), COALESCE($3, FALSE), $4)
 		RETURNING id;
 	`
 
@@ -46,23 +80,42 @@ func (r *DBClient) CreateVault(ctx context.Context, log *slog.Logger, model mode
 
 	var id int
 
-	if err := tx.QueryRow(context.TODO(), createVaultQuery, model.Name).Scan(&id); err != nil {
+	if err := tx.QueryRow(context.TODO(), createVaultQuery, model.Name, model.DataKey, model.CASRequired, model.ExpiresAt, model.ID).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return 0, errors.New("vault already exists")
+		}
 		log.Error("failed to create new vault", sl.OpErr(op, err))
 		tx.Rollback(ctx)
 		return 0, errors.New("failed to create new vault")
 	}
 
+	if err := insertVersion(ctx, tx, log, id, 1); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+		log.Error("failed to save vault tags", sl.OpErr(op, err))
+		return 0, errors.New("failed to save vault tags")
+	}
+
+	if err := insertMetadata(ctx, tx, log, id, model.CreatedBy); err != nil {
+		log.Error("failed to create vault metadata", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault metadata")
+	}
+
 	createValueQuery := `
 		INSERT INTO value
-			(vault_id, key, value)
-		VALUES ($1, $2, $3);
+			(vault_id, version, key, value, encrypted, expires_at)
+		VALUES ($1, 1, $2, $3, $4, $5);
 	`
 
 	log.Debug("create value query", slog.String("op", op), slog.String("query", utils.QueryConvert(createValueQuery)))
 
 	rows := make([][]interface{}, len(model.Data))
 	for i, v := range model.Data {
-		rows[i] = []interface{}{id, v.Key, v.Value}
+		rows[i] = []interface{}{id, v.Key, v.Value, v.Encrypted, v.ExpiresAt}
 	}
 
 	log.Debug("print rows", "rows", rows)
@@ -83,6 +136,17 @@ func (r *DBClient) CreateVault(ctx context.Context, log *slog.Logger, model mode
 func (r *DBClient) GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error) {
 	const op = "db.postgresql.GetVault"
 
+	return r.getVault(ctx, log, op, id, 0)
+}
+
+func (r *DBClient) GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error) {
+	const op = "db.postgresql.GetVaultVersion"
+
+	return r.getVault(ctx, log, op, id, version)
+}
+
+// getVault reads the given version of the vault data, version 0 reads the current one.
+func (r *DBClient) getVault(ctx context.Context, log *slog.Logger, op string, id int, version int) (models.SecretModel, error) {
 	tx, err := r.dbClient.Begin(ctx)
 	if err != nil {
 		log.Error("failed to begin transaction", sl.OpErr(op, err))
@@ -91,14 +155,16 @@ func (r *DBClient) GetVault(ctx context.Context, log *slog.Logger, id int) (mode
 	defer tx.Rollback(ctx)
 
 	getVaultQuery := `
-		SELECT id, name FROM vault
-		WHERE id = $1;
+		SELECT id, name, COALESCE(data_key, ''), current_version, cas_required, expires_at FROM vault
+		WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW());
 	`
 
 	log.Debug("get vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultQuery)))
 
 	var vault models.VaultModel
-	err = tx.QueryRow(ctx, getVaultQuery, id).Scan(&vault.ID, &vault.Name)
+	var currentVersion int
+	var casRequired bool
+	err = tx.QueryRow(ctx, getVaultQuery, id).Scan(&vault.ID, &vault.Name, &vault.DataKey, &currentVersion, &casRequired, &vault.ExpiresAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			log.Error("failed to get vault", sl.OpErr(op, err))
@@ -108,14 +174,33 @@ func (r *DBClient) GetVault(ctx context.Context, log *slog.Logger, id int) (mode
 		return models.SecretModel{}, errors.New("failed to get vault")
 	}
 
+	if version == 0 {
+		version = currentVersion
+	}
+
+	checkVersionQuery := `
+		SELECT version FROM vault_version
+		WHERE vault_id = $1 AND version = $2;
+	`
+
+	log.Debug("check version query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVersionQuery)))
+
+	if err := tx.QueryRow(ctx, checkVersionQuery, id, version).Scan(&version); err != nil {
+		if err == pgx.ErrNoRows {
+			return models.SecretModel{}, errors.New("version not found")
+		}
+		log.Error("failed to get vault version", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault version")
+	}
+
 	getValuesQuery := `
-		SELECT key, value FROM value
-		WHERE vault_id = $1;
+		SELECT key, value, encrypted, expires_at FROM value
+		WHERE vault_id = $1 AND version = $2 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW());
 	`
 
 	log.Debug("get values query", slog.String("op", op), slog.String("query", utils.QueryConvert(getValuesQuery)))
 
-	rows, err := tx.Query(ctx, getValuesQuery, id)
+	rows, err := tx.Query(ctx, getValuesQuery, id, version)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgerrcode.IsNoData(pgErr.Error()) {
@@ -130,7 +215,7 @@ func (r *DBClient) GetVault(ctx context.Context, log *slog.Logger, id int) (mode
 	for rows.Next() {
 		var value models.ValueDTO
 
-		if err := rows.Scan(&value.Key, &value.Value); err != nil {
+		if err := rows.Scan(&value.Key, &value.Value, &value.Encrypted, &value.ExpiresAt); err != nil {
 			log.Error("failed to scan values", sl.OpErr(op, err))
 			return models.SecretModel{}, err
 		}
@@ -144,6 +229,8 @@ func (r *DBClient) GetVault(ctx context.Context, log *slog.Logger, id int) (mode
 	}
 
 	res := models.ConvertDTOToSecretModel(vault, values)
+	res.Version = version
+	res.CASRequired = casRequired
 	return res, nil
 }
 
@@ -159,7 +246,7 @@ func (r *DBClient) CheckVault(ctx context.Context, log *slog.Logger, id int) err
 
 	getVaultQuery := `
 		SELECT id, name FROM vault
-		WHERE id = $1;
+		WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW());
 	`
 
 	log.Debug("get vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultQuery)))
@@ -176,3 +263,462 @@ func (r *DBClient) CheckVault(ctx context.Context, log *slog.Logger, id int) err
 	}
 	return nil
 }
+
+// GetVaultName returns the name of the vault, it is used to evaluate admin policies
+// and resolves soft deleted vaults as well.
+func (r *DBClient) GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error) {
+	const op = "db.postgresql.GetVaultName"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return "", errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getVaultNameQuery := `
+		SELECT name FROM vault
+		WHERE id = $1;
+	`
+
+	log.Debug("get vault name query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultNameQuery)))
+
+	var name string
+	if err := tx.QueryRow(ctx, getVaultNameQuery, id).Scan(&name); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.New("vault not found")
+		}
+		log.Error("failed to get vault name", sl.OpErr(op, err))
+		return "", errors.New("failed to get vault name")
+	}
+
+	return name, nil
+}
+
+// GetVaultID returns the id of the vault stored under the path.
+func (r *DBClient) GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error) {
+	const op = "db.postgresql.GetVaultID"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getVaultIDQuery := `
+		SELECT id FROM vault
+		WHERE name = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW());
+	`
+
+	log.Debug("get vault id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultIDQuery)))
+
+	var id int
+	if err := tx.QueryRow(ctx, getVaultIDQuery, path).Scan(&id); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to get vault id", sl.OpErr(op, err))
+		return 0, errors.New("failed to get vault id")
+	}
+
+	return id, nil
+}
+
+func (r *DBClient) UpdateVault(ctx context.Context, log *slog.Logger, id int, model models.SecretCreateDTO) (int, error) {
+	const op = "db.postgresql.UpdateVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCAS(ctx, tx, log, op, id, model.CAS); err != nil {
+		return 0, err
+	}
+
+	if err := releaseExpiredPath(ctx, tx, log, op, model.Name); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET name = $2, data_key = COALESCE(NULLIF($3, ''), data_key), cas_required = COALESCE($4, cas_required),
+			expires_at = $5, current_version = current_version + 1
+		WHERE id = $1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var version int
+	if err := tx.QueryRow(ctx, updateVaultQuery, id, model.Name, model.DataKey, model.CASRequired, model.ExpiresAt).Scan(&version); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("vault not found")
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return 0, errors.New("vault already exists")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	if err := insertValues(ctx, tx, log, id, version, model.Data); err != nil {
+		log.Error("failed to insert values to database", sl.OpErr(op, err))
+		return 0, errors.New("failed to insert values to database")
+	}
+
+	if model.Tags != nil {
+		if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+			log.Error("failed to save vault tags", sl.OpErr(op, err))
+			return 0, errors.New("failed to save vault tags")
+		}
+		if err := touchMetadata(ctx, tx, log, id); err != nil {
+			log.Error("failed to update vault metadata", sl.OpErr(op, err))
+			return 0, errors.New("failed to update vault metadata")
+		}
+	}
+
+	if err := r.pruneVersions(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return version, tx.Commit(ctx)
+}
+
+func (r *DBClient) PatchVault(ctx context.Context, log *slog.Logger, id int, model models.SecretPatchDTO) (int, error) {
+	const op = "db.postgresql.PatchVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCAS(ctx, tx, log, op, id, model.CAS); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET data_key = COALESCE(NULLIF($2, ''), data_key), current_version = current_version + 1
+		WHERE id = $1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var version int
+	if err := tx.QueryRow(ctx, updateVaultQuery, id, model.DataKey).Scan(&version); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	keys := make([]string, 0, len(model.Data)+len(model.Remove))
+	for _, v := range model.Data {
+		keys = append(keys, v.Key)
+	}
+	keys = append(keys, model.Remove...)
+
+	copyValuesQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, deleted_at, expires_at)
+		SELECT vault_id, $2, key, value, encrypted, deleted_at, expires_at FROM value
+		WHERE vault_id = $1 AND version = $2 - 1 AND NOT (key = ANY($3));
+	`
+
+	log.Debug("copy values query", slog.String("op", op), slog.String("query", utils.QueryConvert(copyValuesQuery)))
+
+	if _, err := tx.Exec(ctx, copyValuesQuery, id, version, keys); err != nil {
+		log.Error("failed to copy vault values", sl.OpErr(op, err))
+		return 0, errors.New("failed to copy vault values")
+	}
+
+	if err := insertValues(ctx, tx, log, id, version, model.Data); err != nil {
+		log.Error("failed to insert values to database", sl.OpErr(op, err))
+		return 0, errors.New("failed to insert values to database")
+	}
+
+	if err := r.pruneVersions(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return version, tx.Commit(ctx)
+}
+
+// DeleteVault soft deletes the vault, it is kept until it is undeleted, destroyed or purged.
+func (r *DBClient) DeleteVault(ctx context.Context, log *slog.Logger, id int, cas int) error {
+	const op = "db.postgresql.DeleteVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCAS(ctx, tx, log, op, id, cas); err != nil {
+		return err
+	}
+
+	deleteVaultQuery := `
+		UPDATE vault
+		SET deleted_at = NOW()
+		WHERE id = $1;
+	`
+
+	log.Debug("delete vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteVaultQuery)))
+
+	tag, err := tx.Exec(ctx, deleteVaultQuery, id)
+	if err != nil {
+		log.Error("failed to delete vault", sl.OpErr(op, err))
+		return errors.New("failed to delete vault")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("vault not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) ListVersions(ctx context.Context, log *slog.Logger, id int) ([]models.VersionModel, error) {
+	const op = "db.postgresql.ListVersions"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listVersionsQuery := `
+		SELECT vv.version, vv.created_at, vv.version = v.current_version FROM vault_version vv
+		JOIN vault v ON v.id = vv.vault_id
+		WHERE vv.vault_id = $1 AND v.deleted_at IS NULL AND (v.expires_at IS NULL OR v.expires_at > NOW())
+		ORDER BY vv.version DESC;
+	`
+
+	log.Debug("list versions query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVersionsQuery)))
+
+	rows, err := tx.Query(ctx, listVersionsQuery, id)
+	if err != nil {
+		log.Error("failed to list vault versions", sl.OpErr(op, err))
+		return nil, errors.New("failed to list vault versions")
+	}
+	defer rows.Close()
+
+	versions := make([]models.VersionModel, 0)
+	for rows.Next() {
+		var version models.VersionModel
+		if err := rows.Scan(&version.Version, &version.CreatedAt, &version.Current); err != nil {
+			log.Error("failed to scan versions", sl.OpErr(op, err))
+			return nil, errors.New("failed to list vault versions")
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list vault versions")
+	}
+
+	if len(versions) == 0 {
+		return nil, errors.New("vault not found")
+	}
+
+	return versions, nil
+}
+
+// RollbackVault promotes a copy of an old version to a new current version and returns its number.
+func (r *DBClient) RollbackVault(ctx context.Context, log *slog.Logger, id int, version int, cas int) (int, error) {
+	const op = "db.postgresql.RollbackVault"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCAS(ctx, tx, log, op, id, cas); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET current_version = current_version + 1
+		WHERE id = $1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var newVersion int
+	if err := tx.QueryRow(ctx, updateVaultQuery, id).Scan(&newVersion); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	checkVersionQuery := `
+		SELECT version FROM vault_version
+		WHERE vault_id = $1 AND version = $2;
+	`
+
+	log.Debug("check version query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVersionQuery)))
+
+	if err := tx.QueryRow(ctx, checkVersionQuery, id, version).Scan(&version); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("version not found")
+		}
+		log.Error("failed to get vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to get vault version")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, newVersion); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	copyValuesQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, deleted_at, expires_at)
+		SELECT vault_id, $3, key, value, encrypted, deleted_at, expires_at FROM value
+		WHERE vault_id = $1 AND version = $2;
+	`
+
+	log.Debug("copy values query", slog.String("op", op), slog.String("query", utils.QueryConvert(copyValuesQuery)))
+
+	if _, err := tx.Exec(ctx, copyValuesQuery, id, version, newVersion); err != nil {
+		log.Error("failed to copy vault values", sl.OpErr(op, err))
+		return 0, errors.New("failed to copy vault values")
+	}
+
+	if err := r.pruneVersions(ctx, tx, log, id, newVersion); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return newVersion, tx.Commit(ctx)
+}
+
+// checkCAS locks the vault row and compares its current version with the check-and-set version,
+// cas 0 skips the comparison unless the vault requires check-and-set.
+func checkCAS(ctx context.Context, tx pgx.Tx, log *slog.Logger, op string, id int, cas int) error {
+	checkCASQuery := `
+		SELECT current_version, cas_required FROM vault
+		WHERE id = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		FOR UPDATE;
+	`
+
+	log.Debug("check cas query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkCASQuery)))
+
+	var current int
+	var required bool
+	if err := tx.QueryRow(ctx, checkCASQuery, id).Scan(&current, &required); err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.New("vault not found")
+		}
+		log.Error("failed to check vault version", sl.OpErr(op, err))
+		return errors.New("failed to check vault version")
+	}
+
+	return models.CheckCAS(current, required, cas)
+}
+
+func insertVersion(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, version int) error {
+	createVersionQuery := `
+		INSERT INTO vault_version
+			(vault_id, version)
+		VALUES ($1, $2);
+	`
+
+	log.Debug("create version query", slog.String("query", utils.QueryConvert(createVersionQuery)))
+
+	_, err := tx.Exec(ctx, createVersionQuery, id, version)
+	return err
+}
+
+func insertValues(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, version int, values []models.ValueDTO) error {
+	createValueQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	log.Debug("create value query", slog.String("query", utils.QueryConvert(createValueQuery)))
+
+	for _, v := range values {
+		if _, err := tx.Exec(ctx, createValueQuery, id, version, v.Key, v.Value, v.Encrypted, v.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceTags sets the tags of the vault, the previous tags are removed.
+func replaceTags(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, tags []string) error {
+	deleteTagsQuery := `
+		DELETE FROM vault_tag
+		WHERE vault_id = $1;
+	`
+
+	log.Debug("delete tags query", slog.String("query", utils.QueryConvert(deleteTagsQuery)))
+
+	if _, err := tx.Exec(ctx, deleteTagsQuery, id); err != nil {
+		return err
+	}
+
+	createTagsQuery := `
+		INSERT INTO vault_tag
+			(vault_id, tag)
+		SELECT $1, tag FROM UNNEST($2::VARCHAR[]) AS tag
+		ON CONFLICT DO NOTHING;
+	`
+
+	log.Debug("create tags query", slog.String("query", utils.QueryConvert(createTagsQuery)))
+
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, createTagsQuery, id, tags)
+	return err
+}
+
+// pruneVersions removes versions older than the configured limit, their values are removed by cascade.
+func (r *DBClient) pruneVersions(ctx context.Context, tx pgx.Tx, log *slog.Logger, id int, current int) error {
+	if r.maxVersions <= 0 {
+		return nil
+	}
+
+	pruneVersionsQuery := `
+		DELETE FROM vault_version
+		WHERE vault_id = $1 AND version <= $2;
+	`
+
+	log.Debug("prune versions query", slog.String("query", utils.QueryConvert(pruneVersionsQuery)))
+
+	_, err := tx.Exec(ctx, pruneVersionsQuery, id, current-r.maxVersions)
+	return err
+}
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"vault/internal/db"
+	"vault/internal/storage"
+	"vault/internal/storage/storagetest"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance runs the storage suite against the database of VAULT_TEST_POSTGRES_DSN,
+// the database is migrated and every table is truncated.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("VAULT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("VAULT_TEST_POSTGRES_DSN is not set")
+	}
+
+	m, err := migrate.New("file://../../migrations", dsn)
+	require.NoError(t, err)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(t, err)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		_, err := pool.Exec(context.Background(), `
+			TRUNCATE vault, vault_tag, seal_config, keyring, jwt_key, token, transit_key, policy, admin_identity, approle, operator, wrapping, audit_log RESTART IDENTITY CASCADE;
+		`)
+		require.NoError(t, err)
+		return db.NewClient(pool, storagetest.MaxVersions)
+	})
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *DBClient) GetSealConfig(ctx context.Context, log *slog.Logger) (models.SealConfig, error) {
+	const op = "db.postgresql.GetSealConfig"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.SealConfig{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getSealConfigQuery := `
+		SELECT shares, threshold, verification FROM seal_config
+		WHERE id = 1;
+	`
+
+	log.Debug("get seal config query", slog.String("op", op), slog.String("query", utils.QueryConvert(getSealConfigQuery)))
+
+	var config models.SealConfig
+	err = tx.QueryRow(ctx, getSealConfigQuery).Scan(&config.Shares, &config.Threshold, &config.Verification)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.SealConfig{}, errors.New("vault is not initialized")
+		}
+		log.Error("failed to get seal config", sl.OpErr(op, err))
+		return models.SealConfig{}, errors.New("failed to get seal config")
+	}
+
+	return config, nil
+}
+
+func (r *DBClient) CreateSealConfig(ctx context.Context, log *slog.Logger, config models.SealConfig) error {
+	const op = "db.postgresql.CreateSealConfig"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createSealConfigQuery := `
+		INSERT INTO seal_config
+			(id, shares, threshold, verification)
+		VALUES (1, $1, $2, $3);
+	`
+
+	log.Debug("create seal config query", slog.String("op", op), slog.String("query", utils.QueryConvert(createSealConfigQuery)))
+
+	if _, err := tx.Exec(ctx, createSealConfigQuery, config.Shares, config.Threshold, config.Verification); err != nil {
+		log.Error("failed to save seal config", sl.OpErr(op, err))
+		return errors.New("failed to save seal config")
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *DBClient) CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error {
+	const op = "db.postgresql.CreateToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createTokenQuery := `
+		INSERT INTO token
+			(jti, issued_at, expires_at, keys, capabilities, paths, transit_keys)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+
+	log.Debug("create token query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenQuery)))
+
+	keys := model.Keys
+	if keys == nil {
+		keys = []string{}
+	}
+	capabilities := model.Capabilities
+	if capabilities == nil {
+		capabilities = []string{}
+	}
+	paths := model.Paths
+	if paths == nil {
+		paths = []string{}
+	}
+	transitKeys := model.TransitKeys
+	if transitKeys == nil {
+		transitKeys = []string{}
+	}
+
+	if _, err := tx.Exec(ctx, createTokenQuery, model.JTI, model.IssuedAt, model.ExpiresAt, keys, capabilities, paths, transitKeys); err != nil {
+		log.Error("failed to save token", sl.OpErr(op, err))
+		return errors.New("failed to save token")
+	}
+
+	createTokenVaultQuery := `
+		INSERT INTO token_vault
+			(jti, vault_id)
+		VALUES ($1, $2);
+	`
+
+	log.Debug("create token vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenVaultQuery)))
+
+	for _, vaultID := range model.VaultIDs {
+		if _, err := tx.Exec(ctx, createTokenVaultQuery, model.JTI, vaultID); err != nil {
+			log.Error("failed to save token vault", sl.OpErr(op, err))
+			return errors.New("failed to save token")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CheckToken returns an error when the token is unknown, revoked or expired.
+func (r *DBClient) CheckToken(ctx context.Context, log *slog.Logger, jti string) error {
+	const op = "db.postgresql.CheckToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getTokenQuery := `
+		SELECT expires_at, revoked_at FROM token
+		WHERE jti = $1;
+	`
+
+	log.Debug("get token query", slog.String("op", op), slog.String("query", utils.QueryConvert(getTokenQuery)))
+
+	var expiresAt time.Time
+	var revokedAt *time.Time
+	if err := tx.QueryRow(ctx, getTokenQuery, jti).Scan(&expiresAt, &revokedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return errors.New("token not found")
+		}
+		log.Error("failed to get token", sl.OpErr(op, err))
+		return errors.New("failed to get token")
+	}
+
+	if revokedAt != nil {
+		return errors.New("token revoked")
+	}
+	if time.Now().After(expiresAt) {
+		return errors.New("token expired")
+	}
+
+	return nil
+}
+
+// RenewToken moves the expiry of an active token, revoked and expired tokens are not found.
+func (r *DBClient) RenewToken(ctx context.Context, log *slog.Logger, jti string, expiresAt time.Time) error {
+	const op = "db.postgresql.RenewToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	renewTokenQuery := `
+		UPDATE token
+		SET expires_at = $2
+		WHERE jti = $1 AND revoked_at IS NULL AND expires_at > NOW();
+	`
+
+	log.Debug("renew token query", slog.String("op", op), slog.String("query", utils.QueryConvert(renewTokenQuery)))
+
+	tag, err := tx.Exec(ctx, renewTokenQuery, jti, expiresAt)
+	if err != nil {
+		log.Error("failed to renew token", sl.OpErr(op, err))
+		return errors.New("failed to renew token")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("token not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) RevokeToken(ctx context.Context, log *slog.Logger, jti string) error {
+	const op = "db.postgresql.RevokeToken"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	revokeTokenQuery := `
+		UPDATE token
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE jti = $1;
+	`
+
+	log.Debug("revoke token query", slog.String("op", op), slog.String("query", utils.QueryConvert(revokeTokenQuery)))
+
+	tag, err := tx.Exec(ctx, revokeTokenQuery, jti)
+	if err != nil {
+		log.Error("failed to revoke token", sl.OpErr(op, err))
+		return errors.New("failed to revoke token")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("token not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeVaultTokens revokes every active token bound to the vault and returns their count.
+func (r *DBClient) RevokeVaultTokens(ctx context.Context, log *slog.Logger, vaultID int) (int, error) {
+	const op = "db.postgresql.RevokeVaultTokens"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	revokeTokensQuery := `
+		UPDATE token
+		SET revoked_at = NOW()
+		WHERE revoked_at IS NULL AND jti IN (
+			SELECT jti FROM token_vault WHERE vault_id = $1
+		);
+	`
+
+	log.Debug("revoke tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(revokeTokensQuery)))
+
+	tag, err := tx.Exec(ctx, revokeTokensQuery, vaultID)
+	if err != nil {
+		log.Error("failed to revoke tokens", sl.OpErr(op, err))
+		return 0, errors.New("failed to revoke tokens")
+	}
+
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+// ListTokens returns active tokens, vaultID 0 lists tokens of all vaults.
+func (r *DBClient) ListTokens(ctx context.Context, log *slog.Logger, vaultID int) ([]models.TokenInfoModel, error) {
+	const op = "db.postgresql.ListTokens"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listTokensQuery := `
+		SELECT t.jti, COALESCE(array_agg(tv.vault_id ORDER BY tv.vault_id) FILTER (WHERE tv.vault_id IS NOT NULL), '{}'),
+			t.issued_at, t.expires_at, t.keys, t.capabilities, t.paths, t.transit_keys FROM token t
+		LEFT JOIN token_vault tv ON tv.jti = t.jti
+		WHERE t.revoked_at IS NULL AND t.expires_at > NOW()
+			AND ($1 = 0 OR EXISTS (SELECT 1 FROM token_vault f WHERE f.jti = t.jti AND f.vault_id = $1))
+		GROUP BY t.jti
+		HAVING COUNT(tv.vault_id) > 0 OR cardinality(t.paths) > 0 OR cardinality(t.transit_keys) > 0
+		ORDER BY t.issued_at DESC;
+	`
+
+	log.Debug("list tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTokensQuery)))
+
+	rows, err := tx.Query(ctx, listTokensQuery, vaultID)
+	if err != nil {
+		log.Error("failed to list tokens", sl.OpErr(op, err))
+		return nil, errors.New("failed to list tokens")
+	}
+	defer rows.Close()
+
+	tokens := make([]models.TokenInfoModel, 0)
+	for rows.Next() {
+		var token models.TokenInfoModel
+		if err := rows.Scan(&token.JTI, &token.VaultIDs, &token.IssuedAt, &token.ExpiresAt, &token.Keys, &token.Capabilities, &token.Paths, &token.TransitKeys); err != nil {
+			log.Error("failed to scan tokens", sl.OpErr(op, err))
+			return nil, errors.New("failed to list tokens")
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list tokens")
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CreateTransitKey saves the key with its first version.
+func (r *DBClient) CreateTransitKey(ctx context.Context, log *slog.Logger, model models.TransitKeyModel) error {
+	const op = "db.postgresql.CreateTransitKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createKeyQuery := `
+		INSERT INTO transit_key
+			(name, type)
+		VALUES ($1, $2)
+		RETURNING id;
+	`
+
+	log.Debug("create transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(createKeyQuery)))
+
+	var id int
+	if err := tx.QueryRow(ctx, createKeyQuery, model.Name, model.Type).Scan(&id); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("transit key already exists")
+		}
+		log.Error("failed to save transit key", sl.OpErr(op, err))
+		return errors.New("failed to save transit key")
+	}
+
+	for _, version := range model.Versions {
+		if err := insertTransitKeyVersion(ctx, tx, log, id, 1, version); err != nil {
+			log.Error("failed to save transit key version", sl.OpErr(op, err))
+			return errors.New("failed to save transit key")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) GetTransitKey(ctx context.Context, log *slog.Logger, name string) (models.TransitKeyModel, error) {
+	const op = "db.postgresql.GetTransitKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	getKeyQuery := `
+		SELECT id, name, type, latest_version, min_decryption_version, created_at FROM transit_key
+		WHERE name = $1;
+	`
+
+	log.Debug("get transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(getKeyQuery)))
+
+	var key models.TransitKeyModel
+	var id int
+	err = tx.QueryRow(ctx, getKeyQuery, name).Scan(&id, &key.Name, &key.Type, &key.LatestVersion, &key.MinDecryptionVersion, &key.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.TransitKeyModel{}, errors.New("transit key not found")
+		}
+		log.Error("failed to get transit key", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+
+	getVersionsQuery := `
+		SELECT version, material, public_key, created_at FROM transit_key_version
+		WHERE key_id = $1
+		ORDER BY version;
+	`
+
+	log.Debug("get transit key versions query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVersionsQuery)))
+
+	rows, err := tx.Query(ctx, getVersionsQuery, id)
+	if err != nil {
+		log.Error("failed to get transit key versions", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+	defer rows.Close()
+
+	key.Versions = make([]models.TransitKeyVersion, 0, key.LatestVersion)
+	for rows.Next() {
+		var version models.TransitKeyVersion
+		if err := rows.Scan(&version.Version, &version.Key, &version.PublicKey, &version.CreatedAt); err != nil {
+			log.Error("failed to scan transit key versions", sl.OpErr(op, err))
+			return models.TransitKeyModel{}, errors.New("failed to get transit key")
+		}
+		key.Versions = append(key.Versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+
+	return key, nil
+}
+
+func (r *DBClient) ListTransitKeys(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.postgresql.ListTransitKeys"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return nil, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	listKeysQuery := `
+		SELECT name FROM transit_key
+		ORDER BY name;
+	`
+
+	log.Debug("list transit keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listKeysQuery)))
+
+	rows, err := tx.Query(ctx, listKeysQuery)
+	if err != nil {
+		log.Error("failed to list transit keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list transit keys")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan transit keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list transit keys")
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list transit keys")
+	}
+
+	return names, nil
+}
+
+// RotateTransitKey adds the version after the latest one and returns its number.
+func (r *DBClient) RotateTransitKey(ctx context.Context, log *slog.Logger, name string, version models.TransitKeyVersion) (int, error) {
+	const op = "db.postgresql.RotateTransitKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	rotateKeyQuery := `
+		UPDATE transit_key
+		SET latest_version = latest_version + 1
+		WHERE name = $1
+		RETURNING id, latest_version;
+	`
+
+	log.Debug("rotate transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(rotateKeyQuery)))
+
+	var id, latest int
+	if err := tx.QueryRow(ctx, rotateKeyQuery, name).Scan(&id, &latest); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("transit key not found")
+		}
+		log.Error("failed to rotate transit key", sl.OpErr(op, err))
+		return 0, errors.New("failed to rotate transit key")
+	}
+
+	if err := insertTransitKeyVersion(ctx, tx, log, id, latest, version); err != nil {
+		log.Error("failed to save transit key version", sl.OpErr(op, err))
+		return 0, errors.New("failed to rotate transit key")
+	}
+
+	return latest, tx.Commit(ctx)
+}
+
+func (r *DBClient) UpdateTransitKey(ctx context.Context, log *slog.Logger, name string, minDecryptionVersion int) error {
+	const op = "db.postgresql.UpdateTransitKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	updateKeyQuery := `
+		UPDATE transit_key
+		SET min_decryption_version = $2
+		WHERE name = $1;
+	`
+
+	log.Debug("update transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateKeyQuery)))
+
+	tag, err := tx.Exec(ctx, updateKeyQuery, name, minDecryptionVersion)
+	if err != nil {
+		log.Error("failed to update transit key", sl.OpErr(op, err))
+		return errors.New("failed to update transit key")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("transit key not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteTransitKey removes the key with every version, data encrypted with it can't be decrypted anymore.
+func (r *DBClient) DeleteTransitKey(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.postgresql.DeleteTransitKey"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	deleteKeyQuery := `
+		DELETE FROM transit_key
+		WHERE name = $1;
+	`
+
+	log.Debug("delete transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteKeyQuery)))
+
+	tag, err := tx.Exec(ctx, deleteKeyQuery, name)
+	if err != nil {
+		log.Error("failed to delete transit key", sl.OpErr(op, err))
+		return errors.New("failed to delete transit key")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("transit key not found")
+	}
+
+	return tx.Commit(ctx)
+}
+
+// insertTransitKeyVersion saves the wrapped key material of the version number.
+func insertTransitKeyVersion(ctx context.Context, tx pgx.Tx, log *slog.Logger, keyID, number int, version models.TransitKeyVersion) error {
+	createVersionQuery := `
+		INSERT INTO transit_key_version
+			(key_id, version, material, public_key)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	log.Debug("create transit key version query", slog.String("query", utils.QueryConvert(createVersionQuery)))
+
+	_, err := tx.Exec(ctx, createVersionQuery, keyID, number, version.Key, version.PublicKey)
+	return err
+}
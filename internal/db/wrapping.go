@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *DBClient) CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error {
+	const op = "db.postgresql.CreateWrapping"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	createWrappingQuery := `
+		INSERT INTO wrapping
+			(token_hash, accessor, creation_path, data_key, payload, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+
+	log.Debug("create wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(createWrappingQuery)))
+
+	_, err = tx.Exec(ctx, createWrappingQuery, tokenHash, model.Accessor, model.CreationPath, model.DataKey, model.Payload, model.ExpiresAt, model.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return errors.New("wrapping token already exists")
+		}
+		log.Error("failed to save wrapping", sl.OpErr(op, err))
+		return errors.New("failed to save wrapping")
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *DBClient) LookupWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappingModel, error) {
+	const op = "db.postgresql.LookupWrapping"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.WrappingModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	lookupWrappingQuery := `
+		SELECT accessor, creation_path, '', '', expires_at, created_at FROM wrapping
+		WHERE token_hash = $1 AND expires_at > NOW();
+	`
+
+	log.Debug("lookup wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(lookupWrappingQuery)))
+
+	model, err := scanWrapping(tx.QueryRow(ctx, lookupWrappingQuery, tokenHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.WrappingModel{}, errors.New("wrapping token not found")
+		}
+		log.Error("failed to lookup wrapping", sl.OpErr(op, err))
+		return models.WrappingModel{}, errors.New("failed to lookup wrapping")
+	}
+
+	return model.WrappingModel, nil
+}
+
+func (r *DBClient) TakeWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappedResponse, error) {
+	const op = "db.postgresql.TakeWrapping"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.WrappedResponse{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	takeWrappingQuery := `
+		DELETE FROM wrapping
+		WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING accessor, creation_path, data_key, payload, expires_at, created_at;
+	`
+
+	log.Debug("take wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(takeWrappingQuery)))
+
+	model, err := scanWrapping(tx.QueryRow(ctx, takeWrappingQuery, tokenHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return models.WrappedResponse{}, errors.New("wrapping token not found")
+		}
+		log.Error("failed to take wrapping", sl.OpErr(op, err))
+		return models.WrappedResponse{}, errors.New("failed to take wrapping")
+	}
+
+	return model, tx.Commit(ctx)
+}
+
+func (r *DBClient) PurgeWrappings(ctx context.Context, log *slog.Logger, before time.Time) (int, error) {
+	const op = "db.postgresql.PurgeWrappings"
+
+	tx, err := r.dbClient.Begin(ctx)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	purgeWrappingsQuery := `
+		DELETE FROM wrapping
+		WHERE expires_at <= $1;
+	`
+
+	log.Debug("purge wrappings query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeWrappingsQuery)))
+
+	tag, err := tx.Exec(ctx, purgeWrappingsQuery, before)
+	if err != nil {
+		log.Error("failed to purge wrappings", sl.OpErr(op, err))
+		return 0, errors.New("failed to purge expired wrappings")
+	}
+
+	return int(tag.RowsAffected()), tx.Commit(ctx)
+}
+
+func scanWrapping(row pgx.Row) (models.WrappedResponse, error) {
+	var model models.WrappedResponse
+	if err := row.Scan(&model.Accessor, &model.CreationPath, &model.DataKey, &model.Payload, &model.ExpiresAt, &model.CreatedAt); err != nil {
+		return models.WrappedResponse{}, err
+	}
+	model.TTL = model.ExpiresAt.Sub(model.CreatedAt) / time.Second
+	return model, nil
+}
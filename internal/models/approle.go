@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+	"vault/internal/policy"
+	"vault/pkg/validator"
+)
+
+// AppRoleModel is a machine login role, tokens issued through it are bound like the tokens of
+// /root/create-token. Expires is the default token lifetime and MaxExpires the longest lifetime
+// a login may ask for, SecretIDExpires is the lifetime of new secret ids (0 never expires).
+// Tokens of renewable roles can be renewed until MaxTTL after they were issued. All in seconds.
+type AppRoleModel struct {
+	Name        string   `json:"name"`
+	RoleID      string   `json:"role_id"`
+	VaultIDs    []int    `json:"vault_ids,omitempty"`
+	Paths       []string `json:"paths,omitempty"`
+	TransitKeys []string `json:"transit_keys,omitempty"`
+	TokenScope
+	Expires         time.Duration `json:"expires"`
+	MaxExpires      time.Duration `json:"max_expires"`
+	SecretIDExpires time.Duration `json:"secret_id_expires"`
+	Renewable       bool          `json:"renewable"`
+	MaxTTL          time.Duration `json:"max_ttl"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+// SecretIDModel is a credential of a role, only the hash of the secret id is stored.
+// Single use secret ids are removed by their first login, CIDRs limit the client addresses.
+type SecretIDModel struct {
+	Accessor  string     `json:"accessor"`
+	SingleUse bool       `json:"single_use"`
+	CIDRs     []string   `json:"cidrs,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PutAppRoleDTO binds the tokens of a role like CreateVaultTokenDTO, expires is the default token lifetime.
+type PutAppRoleDTO struct {
+	CreateVaultTokenDTO
+	MaxExpires      time.Duration `json:"max_expires"`
+	SecretIDExpires time.Duration `json:"secret_id_expires"`
+}
+
+type CreateSecretIDDTO struct {
+	SingleUse bool     `json:"single_use"`
+	CIDRs     []string `json:"cidrs"`
+}
+
+// AppRoleLoginDTO asks for a token of the role, expires 0 takes the lifetime of the role.
+type AppRoleLoginDTO struct {
+	RoleID   string        `json:"role_id" validate:"required"`
+	SecretID string        `json:"secret_id" validate:"required"`
+	Expires  time.Duration `json:"expires"`
+}
+
+func (p *PutAppRoleDTO) Validate(name string) error {
+	if !policy.ValidName(name) {
+		return fmt.Errorf("invalid role name: %q", name)
+	}
+	if err := p.CreateVaultTokenDTO.Validate(); err != nil {
+		return err
+	}
+	if p.Expires < 0 || p.MaxExpires < 0 || p.SecretIDExpires < 0 {
+		return fmt.Errorf("expires, max_expires and secret_id_expires must be positive")
+	}
+	if p.MaxExpires != 0 && p.MaxExpires < p.Expires {
+		return fmt.Errorf("max_expires must not be lower than expires")
+	}
+	if p.MaxTTL != 0 && p.MaxTTL < p.MaxExpires {
+		return fmt.Errorf("max_ttl must not be lower than max_expires")
+	}
+	return nil
+}
+
+// CheckTTL rejects lifetimes above the server limit, limit is a duration and 0 disables it.
+func (p *PutAppRoleDTO) CheckTTL(limit time.Duration) error {
+	if err := p.CreateVaultTokenDTO.CheckTTL(limit); err != nil {
+		return err
+	}
+	if seconds := limit / time.Second; seconds > 0 && p.MaxExpires > seconds {
+		return fmt.Errorf("max_expires must not exceed %d seconds", seconds)
+	}
+	return nil
+}
+
+// Role returns the role defined by the request, the role id is kept by storage on update.
+func (p *PutAppRoleDTO) Role(name, roleID string) AppRoleModel {
+	return AppRoleModel{
+		Name:            name,
+		RoleID:          roleID,
+		VaultIDs:        p.Vaults(),
+		Paths:           p.Paths,
+		TransitKeys:     p.TransitKeys,
+		TokenScope:      p.Scope(),
+		Expires:         p.Expires,
+		MaxExpires:      p.MaxExpires,
+		SecretIDExpires: p.SecretIDExpires,
+		Renewable:       p.Renewable,
+		MaxTTL:          p.MaxTTL,
+	}
+}
+
+func (c *CreateSecretIDDTO) Validate() error {
+	for _, cidr := range c.CIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid cidr: %q", cidr)
+		}
+	}
+	return nil
+}
+
+func (l *AppRoleLoginDTO) Validate() error {
+	if err := validator.Validate(l); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if l.Expires < 0 {
+		return fmt.Errorf("expires must be positive")
+	}
+	return nil
+}
+
+// TokenExpires returns the lifetime of a token asking for requested seconds,
+// 0 takes the role default and longer lifetimes are cut to the role maximum.
+func (r AppRoleModel) TokenExpires(requested time.Duration) time.Duration {
+	if requested == 0 {
+		requested = r.Expires
+	}
+	limit := r.MaxExpires
+	if limit == 0 {
+		limit = r.Expires
+	}
+	return min(requested, limit)
+}
+
+// AllowsAddr reports whether the client address matches one of the CIDRs, secret ids without CIDRs allow every address.
+func (s SecretIDModel) AllowsAddr(addr netip.Addr) bool {
+	if len(s.CIDRs) == 0 {
+		return true
+	}
+	for _, cidr := range s.CIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the secret id expired before now.
+func (s SecretIDModel) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
@@ -2,19 +2,58 @@ package models
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"strings"
 	"time"
+	"vault/internal/policy"
+	"vault/pkg/lib/encryption"
 	"vault/pkg/validator"
 )
 
+// SecretCreateModel takes the expiry of the whole vault in ttl or expires_at and the expiry of single keys in key_expiry.
+// Values of data given as generator directives are kept in Generate, see UnmarshalJSON.
 type SecretCreateModel struct {
-	Name string            `json:"name" validate:"required"`
-	Data map[string]string `json:"data" validate:"required"`
+	Name        string            `json:"name" validate:"required"`
+	Data        map[string]string `json:"data" validate:"required"`
+	Tags        []string          `json:"tags"`
+	CAS         int               `json:"cas"`
+	CASRequired *bool             `json:"cas_required"`
+	Expiry
+	KeyExpiry map[string]Expiry    `json:"key_expiry"`
+	Generate  map[string]Generator `json:"-"`
 }
 
+// SecretCreateDTO keeps the stored tags and cas_required flag on update when Tags and CASRequired are nil.
+// CAS is the version the vault must be at on update, 0 skips the check unless the vault requires it.
+// ExpiresAt replaces the vault expiry, nil keeps the vault forever. CreatedBy is the
+// admin identity creating the vault, it is ignored on update. ID is the id reserved for a
+// new vault with NextVaultID, the values are bound to it when they are encrypted.
 type SecretCreateDTO struct {
 	VaultDTO
-	Data []ValueDTO
+	ID          int
+	DataKey     string
+	Data        []ValueDTO
+	Tags        []string
+	CAS         int
+	CASRequired *bool
+	ExpiresAt   *time.Time
+	CreatedBy   string
+}
+
+// SecretPatchModel sets the expiry of the written keys in key_expiry, kept keys keep their expiry.
+type SecretPatchModel struct {
+	Data      map[string]*string `json:"data" validate:"required"`
+	CAS       int                `json:"cas"`
+	KeyExpiry map[string]Expiry  `json:"key_expiry"`
+}
+
+// SecretPatchDTO checks CAS the same way as SecretCreateDTO.
+type SecretPatchDTO struct {
+	DataKey string
+	Data    []ValueDTO
+	Remove  []string
+	CAS     int
 }
 
 type VaultDTO struct {
@@ -22,13 +61,59 @@ type VaultDTO struct {
 }
 
 type ValueDTO struct {
-	Key   string `json:"key" validate:"required"`
-	Value string `json:"value" validate:"required"`
+	Key       string     `json:"key" validate:"required"`
+	Value     string     `json:"value" validate:"required"`
+	Encrypted bool       `json:"-"`
+	ExpiresAt *time.Time `json:"-"`
 }
 
+// CreateVaultTokenDTO describes a user token, expires is its lifetime in seconds. Renewable tokens
+// can be extended through /user/token/renew until max_ttl seconds after they were issued.
 type CreateVaultTokenDTO struct {
-	VaultID int           `json:"vault_id" validate:"required"`
-	Expires time.Duration `json:"expires" validate:"required"`
+	VaultID      int           `json:"vault_id"`
+	VaultIDs     []int         `json:"vault_ids"`
+	Paths        []string      `json:"paths"`
+	TransitKeys  []string      `json:"transit_keys"`
+	Expires      time.Duration `json:"expires" validate:"required"`
+	Keys         []string      `json:"keys"`
+	Capabilities []string      `json:"capabilities"`
+	Renewable    bool          `json:"renewable"`
+	MaxTTL       time.Duration `json:"max_ttl"`
+}
+
+// RenewTokenDTO asks to extend a token by increment seconds, 0 extends it by its initial lifetime.
+type RenewTokenDTO struct {
+	Increment time.Duration `json:"increment"`
+}
+
+type RevokeTokenDTO struct {
+	Token string `json:"token"`
+	JTI   string `json:"jti"`
+}
+
+// KeysDTO selects keys of a vault, without keys undelete and destroy act on the whole vault.
+type KeysDTO struct {
+	Keys []string `json:"keys"`
+}
+
+type RollbackDTO struct {
+	Version int `json:"version" validate:"required"`
+	CAS     int `json:"cas"`
+}
+
+type InitSealDTO struct {
+	Shares    int `json:"shares" validate:"required"`
+	Threshold int `json:"threshold" validate:"required"`
+}
+
+type UnsealDTO struct {
+	Key   string `json:"key"`
+	Reset bool   `json:"reset"`
+}
+
+type CreateIdentityDTO struct {
+	Name     string   `json:"name" validate:"required"`
+	Policies []string `json:"policies" validate:"required"`
 }
 
 func (s *SecretCreateModel) Validate() error {
@@ -40,29 +125,293 @@ func (s *SecretCreateModel) Validate() error {
 			return fmt.Errorf("key or value length can't be 0")
 		}
 	}
-	return nil
+	for k := range s.Generate {
+		if strings.ReplaceAll(k, " ", "") == "" {
+			return fmt.Errorf("key or value length can't be 0")
+		}
+	}
+	generated, err := s.validateGenerate()
+	if err != nil {
+		return err
+	}
+	if !ValidPath(s.Name) {
+		return fmt.Errorf("invalid vault path: %q", s.Name)
+	}
+	if s.CAS < 0 {
+		return fmt.Errorf("cas must be a positive integer")
+	}
+	for _, tag := range s.Tags {
+		if !ValidTag(tag) {
+			return fmt.Errorf("invalid tag: %q", tag)
+		}
+	}
+	now := time.Now()
+	if err := s.Expiry.Validate(now); err != nil {
+		return err
+	}
+	keys := make(map[string]bool, len(s.Data)+len(generated))
+	for k := range s.Data {
+		keys[k] = true
+	}
+	for _, k := range generated {
+		keys[k] = true
+	}
+	return validateKeyExpiry(s.KeyExpiry, keys, now)
 }
 
 func (s *SecretCreateModel) ConvertToDTO() SecretCreateDTO {
+	now := time.Now()
 	data := make([]ValueDTO, 0)
 
 	for k, v := range s.Data {
 		value := ValueDTO{
-			Key:   k,
-			Value: v,
+			Key:       k,
+			Value:     v,
+			ExpiresAt: s.KeyExpiry[k].Time(now),
 		}
 		data = append(data, value)
 	}
 
 	return SecretCreateDTO{
-		VaultDTO: VaultDTO{Name: s.Name},
-		Data:     data,
+		VaultDTO:    VaultDTO{Name: s.Name},
+		Data:        data,
+		Tags:        NormalizeTags(s.Tags),
+		CAS:         s.CAS,
+		CASRequired: s.CASRequired,
+		ExpiresAt:   s.Expiry.Time(now),
 	}
 }
 
+// Encrypt replaces values with their ciphertext under the vault data key,
+// a new data key is generated when DataKey is empty. Each value is bound
+// to the vault id and its key, see ValueAAD.
+func (s *SecretCreateDTO) Encrypt(envelope *encryption.Envelope, vaultID int) error {
+	wrapped, err := encryptValues(envelope, s.DataKey, vaultID, s.Data)
+	if err != nil {
+		return err
+	}
+	s.DataKey = wrapped
+	return nil
+}
+
+func (s *SecretPatchModel) Validate() error {
+	if err := validator.Validate(s); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if len(s.Data) == 0 {
+		return fmt.Errorf("data can't be empty")
+	}
+	if s.CAS < 0 {
+		return fmt.Errorf("cas must be a positive integer")
+	}
+	for k, v := range s.Data {
+		if strings.ReplaceAll(k, " ", "") == "" || (v != nil && strings.ReplaceAll(*v, " ", "") == "") {
+			return fmt.Errorf("key or value length can't be 0")
+		}
+		if v == nil && s.KeyExpiry[k] != (Expiry{}) {
+			return fmt.Errorf("key_expiry of removed key %q", k)
+		}
+	}
+	return validateKeyExpiry(s.KeyExpiry, s.Data, time.Now())
+}
+
+// ConvertToDTO splits the patch into values to set and keys to remove, null values remove keys.
+func (s *SecretPatchModel) ConvertToDTO() SecretPatchDTO {
+	dto := SecretPatchDTO{
+		Data:   make([]ValueDTO, 0),
+		Remove: make([]string, 0),
+		CAS:    s.CAS,
+	}
+
+	now := time.Now()
+	for k, v := range s.Data {
+		if v == nil {
+			dto.Remove = append(dto.Remove, k)
+			continue
+		}
+		dto.Data = append(dto.Data, ValueDTO{Key: k, Value: *v, ExpiresAt: s.KeyExpiry[k].Time(now)})
+	}
+
+	return dto
+}
+
+// Encrypt works the same way as SecretCreateDTO.Encrypt.
+func (s *SecretPatchDTO) Encrypt(envelope *encryption.Envelope, vaultID int) error {
+	wrapped, err := encryptValues(envelope, s.DataKey, vaultID, s.Data)
+	if err != nil {
+		return err
+	}
+	s.DataKey = wrapped
+	return nil
+}
+
+func encryptValues(envelope *encryption.Envelope, wrapped string, vaultID int, values []ValueDTO) (string, error) {
+	var dataKey []byte
+	var err error
+
+	if wrapped == "" {
+		dataKey, wrapped, err = envelope.GenerateDataKey()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate data key: %w", err)
+		}
+	} else {
+		dataKey, err = envelope.UnwrapDataKey(wrapped)
+		if err != nil {
+			return "", fmt.Errorf("failed to unwrap data key: %w", err)
+		}
+	}
+
+	for i, v := range values {
+		value, err := encryption.EncryptValue(dataKey, v.Value, ValueAAD(vaultID, v.Key))
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt value: %w", err)
+		}
+		values[i].Value = value
+		values[i].Encrypted = true
+	}
+
+	return wrapped, nil
+}
+
 func (c *CreateVaultTokenDTO) Validate() error {
 	if err := validator.Validate(c); err != "" {
 		return fmt.Errorf("validation error: %s", err)
 	}
+	if len(c.Vaults()) == 0 && len(c.Paths) == 0 && len(c.TransitKeys) == 0 {
+		return fmt.Errorf("validation error: field vault_id, vault_ids, paths or transit_keys is a required")
+	}
+	for _, pattern := range c.Paths {
+		if !ValidPathPattern(pattern) {
+			return fmt.Errorf("invalid path: %q", pattern)
+		}
+	}
+	for _, name := range c.TransitKeys {
+		if !ValidTransitKey(name) {
+			return fmt.Errorf("invalid transit key name: %q", name)
+		}
+	}
+	for _, pattern := range c.Keys {
+		if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("invalid key pattern: %q", pattern)
+		}
+	}
+	for _, capability := range c.Capabilities {
+		if !slices.Contains(TokenCapabilities, capability) {
+			return fmt.Errorf("unknown capability: %q", capability)
+		}
+	}
+	if c.Expires < 0 || c.MaxTTL < 0 {
+		return fmt.Errorf("expires and max_ttl must be positive")
+	}
+	if c.MaxTTL != 0 && c.MaxTTL < c.Expires {
+		return fmt.Errorf("max_ttl must not be lower than expires")
+	}
+	return nil
+}
+
+// CheckTTL rejects lifetimes above the server limit, limit is a duration and 0 disables it.
+func (c *CreateVaultTokenDTO) CheckTTL(limit time.Duration) error {
+	seconds := limit / time.Second
+	if seconds > 0 && (c.Expires > seconds || c.MaxTTL > seconds) {
+		return fmt.Errorf("expires and max_ttl must not exceed %d seconds", seconds)
+	}
+	return nil
+}
+
+// Vaults merges vault_id and vault_ids into a list of unique ids.
+func (c *CreateVaultTokenDTO) Vaults() []int {
+	ids := make([]int, 0, len(c.VaultIDs)+1)
+	if c.VaultID != 0 {
+		ids = append(ids, c.VaultID)
+	}
+	for _, id := range c.VaultIDs {
+		if id != 0 && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (c *CreateVaultTokenDTO) Scope() TokenScope {
+	return TokenScope{
+		Keys:         c.Keys,
+		Capabilities: c.Capabilities,
+	}
+}
+
+func (i *InitSealDTO) Validate() error {
+	if err := validator.Validate(i); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if i.Threshold > i.Shares || i.Shares > 255 {
+		return fmt.Errorf("threshold must not exceed shares and shares must not exceed 255")
+	}
+	if i.Threshold == 1 && i.Shares != 1 {
+		return fmt.Errorf("threshold of 1 is only allowed with a single share")
+	}
+	return nil
+}
+
+func (u *UnsealDTO) Validate() error {
+	if !u.Reset && u.Key == "" {
+		return fmt.Errorf("validation error: field key is a required")
+	}
+	return nil
+}
+
+func (r *RollbackDTO) Validate() error {
+	if err := validator.Validate(r); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if r.Version < 1 {
+		return fmt.Errorf("version must be a positive integer")
+	}
+	if r.CAS < 0 {
+		return fmt.Errorf("cas must be a positive integer")
+	}
+	return nil
+}
+
+func (k *KeysDTO) Validate() error {
+	for _, key := range k.Keys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("key length can't be 0")
+		}
+	}
+	return nil
+}
+
+func (r *RenewTokenDTO) Validate() error {
+	if r.Increment < 0 {
+		return fmt.Errorf("increment must be positive")
+	}
+	if r.Increment > MaxIncrement {
+		return fmt.Errorf("increment must not exceed %d seconds", MaxIncrement)
+	}
+	return nil
+}
+
+func (r *RevokeTokenDTO) Validate() error {
+	if r.Token == "" && r.JTI == "" {
+		return fmt.Errorf("validation error: field token or jti is a required")
+	}
+	return nil
+}
+
+func (c *CreateIdentityDTO) Validate() error {
+	if err := validator.Validate(c); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if !policy.ValidName(c.Name) || c.Name == policy.RootName {
+		return fmt.Errorf("invalid identity name: %q", c.Name)
+	}
+	if len(c.Policies) == 0 {
+		return fmt.Errorf("validation error: field policies can't be empty")
+	}
+	for _, name := range c.Policies {
+		if !policy.ValidName(name) {
+			return fmt.Errorf("invalid policy name: %q", name)
+		}
+	}
 	return nil
 }
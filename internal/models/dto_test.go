@@ -1,20 +1,29 @@
 package models_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 	"vault/internal/models"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
 	ErrName       = errors.New("validation error: [field name is a required]")
 	ErrData       = errors.New("validation error: [field data is a required]")
 	ErrKeyOrValue = errors.New("key or value length can't be 0")
+	ErrTTL        = errors.New("ttl must be a positive integer")
+	ErrExpiry     = errors.New("ttl and expires_at are mutually exclusive")
+	ErrExpiresAt  = errors.New("expires_at must be in the future")
+	ErrKeyExpiry  = errors.New(`key_expiry of "other" which is not in data`)
 )
 
 func TestSecretCreateModel(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
 	tests := []struct {
 		Name   string
 		Model  models.SecretCreateModel
@@ -76,6 +85,52 @@ func TestSecretCreateModel(t *testing.T) {
 			},
 			ErrMsg: ErrKeyOrValue,
 		},
+		{
+			Name: "expiry",
+			Model: models.SecretCreateModel{
+				Name:      "test6",
+				Data:      map[string]string{"test": "test"},
+				Expiry:    models.Expiry{TTL: 3600},
+				KeyExpiry: map[string]models.Expiry{"test": {ExpiresAt: &future}},
+			},
+			ErrMsg: nil,
+		},
+		{
+			Name: "failed ttl",
+			Model: models.SecretCreateModel{
+				Name:   "test7",
+				Data:   map[string]string{"test": "test"},
+				Expiry: models.Expiry{TTL: -1},
+			},
+			ErrMsg: ErrTTL,
+		},
+		{
+			Name: "failed expiry",
+			Model: models.SecretCreateModel{
+				Name:   "test8",
+				Data:   map[string]string{"test": "test"},
+				Expiry: models.Expiry{TTL: 60, ExpiresAt: &future},
+			},
+			ErrMsg: ErrExpiry,
+		},
+		{
+			Name: "failed expires_at",
+			Model: models.SecretCreateModel{
+				Name:   "test9",
+				Data:   map[string]string{"test": "test"},
+				Expiry: models.Expiry{ExpiresAt: &past},
+			},
+			ErrMsg: ErrExpiresAt,
+		},
+		{
+			Name: "failed key expiry",
+			Model: models.SecretCreateModel{
+				Name:      "test10",
+				Data:      map[string]string{"test": "test"},
+				KeyExpiry: map[string]models.Expiry{"other": {TTL: 60}},
+			},
+			ErrMsg: ErrKeyExpiry,
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,3 +147,86 @@ func TestSecretCreateModel(t *testing.T) {
 	}
 
 }
+
+func TestSecretCreateModelGenerate(t *testing.T) {
+	var model models.SecretCreateModel
+	err := json.Unmarshal([]byte(`{
+		"name": "payments",
+		"data": {
+			"user": "admin",
+			"password": {"generate": {"type": "password", "length": 16, "charset": "digits"}},
+			"id": {"generate": {"type": "uuid"}},
+			"signing": {"generate": {"type": "ed25519"}}
+		},
+		"key_expiry": {"signing": {"ttl": 60}}
+	}`), &model)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "admin"}, model.Data)
+	require.Len(t, model.Generate, 3)
+	require.NoError(t, model.Validate())
+
+	generated, err := model.GenerateValues()
+	require.NoError(t, err)
+	assert.Len(t, generated, 4)
+	assert.Regexp(t, `^[0-9]{16}$`, generated["password"])
+	assert.Len(t, generated["id"], 36)
+	assert.Contains(t, generated["signing"], "PRIVATE KEY")
+	assert.Contains(t, generated["signing.pub"], "PUBLIC KEY")
+	assert.Len(t, model.Data, 5)
+	assert.Equal(t, generated["password"], model.Data["password"])
+	assert.Equal(t, 60, model.KeyExpiry["signing.pub"].TTL, "the public key expires with the private key")
+
+	tests := []struct {
+		Name   string
+		Input  string
+		ErrMsg string
+	}{
+		{
+			Name:   "unknown type",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "otp"}}}}`,
+			ErrMsg: `key "k": unknown generator type: "otp"`,
+		},
+		{
+			Name:   "short password",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "password", "length": 4}}}}`,
+			ErrMsg: "key \"k\": password length must be between 8 and 1024",
+		},
+		{
+			Name:   "unknown charset",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "password", "charset": "emoji"}}}}`,
+			ErrMsg: `key "k": unknown charset: "emoji"`,
+		},
+		{
+			Name:   "rsa bits",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "rsa", "length": 1024}}}}`,
+			ErrMsg: `key "k": rsa length must be one of [2048 3072 4096] bits`,
+		},
+		{
+			Name:   "charset of hex",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "hex", "charset": "digits"}}}}`,
+			ErrMsg: `key "k": hex doesn't take a charset`,
+		},
+		{
+			Name:   "public key taken",
+			Input:  `{"name": "a", "data": {"k": {"generate": {"type": "ed25519"}}, "k.pub": "key"}}`,
+			ErrMsg: `key "k.pub" is taken by the public key of "k"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var model models.SecretCreateModel
+			require.NoError(t, json.Unmarshal([]byte(tt.Input), &model))
+			assert.EqualError(t, model.Validate(), tt.ErrMsg)
+		})
+	}
+
+	for _, input := range []string{
+		`{"name": "a", "data": {"k": {"type": "uuid"}}}`,
+		`{"name": "a", "data": {"k": 1}}`,
+		`{"name": "a", "data": {"k": {"generate": {"type": "uuid"}, "other": 1}}}`,
+	} {
+		var model models.SecretCreateModel
+		assert.EqualError(t, json.Unmarshal([]byte(input), &model), `value of "k" must be a string or a generator`, input)
+	}
+}
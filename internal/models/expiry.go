@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expiry sets when a vault or a key disappears, either as a ttl in seconds or as an absolute time.
+// In responses TTL is the number of seconds left.
+type Expiry struct {
+	TTL       int        `json:"ttl,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (e Expiry) Validate(now time.Time) error {
+	if e.TTL < 0 {
+		return fmt.Errorf("ttl must be a positive integer")
+	}
+	if e.TTL > 0 && e.ExpiresAt != nil {
+		return fmt.Errorf("ttl and expires_at are mutually exclusive")
+	}
+	if e.ExpiresAt != nil && !e.ExpiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+// Time resolves the expiry against now, nil means the entry never expires.
+func (e Expiry) Time(now time.Time) *time.Time {
+	if e.ExpiresAt != nil {
+		t := e.ExpiresAt.UTC()
+		return &t
+	}
+	if e.TTL > 0 {
+		t := now.Add(time.Duration(e.TTL) * time.Second).UTC()
+		return &t
+	}
+	return nil
+}
+
+// NewExpiry describes the expiry time with the seconds left until it, rounded up.
+func NewExpiry(expiresAt *time.Time, now time.Time) Expiry {
+	if expiresAt == nil {
+		return Expiry{}
+	}
+	left := expiresAt.Sub(now)
+	ttl := int(left / time.Second)
+	if left%time.Second > 0 {
+		ttl++
+	}
+	return Expiry{TTL: ttl, ExpiresAt: expiresAt}
+}
+
+// Expired reports whether the expiry time has passed, entries without expiry never expire.
+func Expired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(now)
+}
+
+// validateKeyExpiry checks that the expiry of every key is valid and the key is written by the request.
+func validateKeyExpiry[V any](keyExpiry map[string]Expiry, data map[string]V, now time.Time) error {
+	for k, e := range keyExpiry {
+		if _, ok := data[k]; !ok {
+			return fmt.Errorf("key_expiry of %q which is not in data", k)
+		}
+		if err := e.Validate(now); err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+	}
+	return nil
+}
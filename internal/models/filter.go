@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	SortName      = "name"
+	SortCreatedAt = "created_at"
+	SortID        = "id"
+
+	DefaultVaultLimit = 50
+	MaxVaultLimit     = 1000
+	maxTagLength      = 64
+)
+
+var (
+	VaultSorts = []string{SortName, SortCreatedAt, SortID}
+	tagRegexp  = regexp.MustCompile(`^[A-Za-z0-9_.:/=-]+$`)
+)
+
+// VaultFilter selects a page of vaults, the zero values of the fields disable them.
+// Vaults have to carry every tag of Tags and every attribute of Attributes, CreatedBefore
+// is exclusive. Deleted lists soft deleted vaults instead of live ones.
+type VaultFilter struct {
+	Deleted       bool
+	Prefix        string
+	Tags          []string
+	Owner         string
+	CreatedBy     string
+	Attributes    map[string]string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          string
+	Desc          bool
+	Limit         int
+	After         *VaultCursor
+}
+
+// VaultCursor is the position of the last vault of a page in the sort order of the filter.
+type VaultCursor struct {
+	Sort      string    `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	ID        int       `json:"i"`
+	Name      string    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"c"`
+}
+
+type VaultPage struct {
+	Vaults     []VaultModel `json:"vaults"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// ValidTag reports whether the tag is a non empty word of at most 64 characters.
+func ValidTag(tag string) bool {
+	return len(tag) <= maxTagLength && tagRegexp.MatchString(tag)
+}
+
+// NormalizeTags sorts the tags and drops duplicates, nil stays nil.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+func (f *VaultFilter) Validate() error {
+	if !slices.Contains(VaultSorts, f.Sort) {
+		return fmt.Errorf("unknown sort: %q", f.Sort)
+	}
+	if f.Limit < 1 || f.Limit > MaxVaultLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxVaultLimit)
+	}
+	for _, tag := range f.Tags {
+		if !ValidTag(tag) {
+			return fmt.Errorf("invalid tag: %q", tag)
+		}
+	}
+	for k, v := range f.Attributes {
+		if !ValidAttribute(k, v) {
+			return fmt.Errorf("invalid attribute: %q", k)
+		}
+	}
+	if f.After != nil && (f.After.Sort != f.Sort || f.After.Desc != f.Desc) {
+		return fmt.Errorf("cursor does not match the sort order")
+	}
+	return nil
+}
+
+// PrefixEnd returns the smallest name greater than every name starting with prefix,
+// together with prefix it bounds an index range. It is empty when there is no bound.
+func PrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
+
+// Match reports whether the vault passes the deleted, prefix, tag, metadata and date filters.
+func (f *VaultFilter) Match(v VaultModel) bool {
+	if (v.DeletedAt != nil) != f.Deleted || !strings.HasPrefix(v.Name, f.Prefix) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(v.Tags, tag) {
+			return false
+		}
+	}
+	if (f.Owner != "" && v.Owner != f.Owner) || (f.CreatedBy != "" && v.CreatedBy != f.CreatedBy) {
+		return false
+	}
+	for k, value := range f.Attributes {
+		if stored, ok := v.Attributes[k]; !ok || stored != value {
+			return false
+		}
+	}
+	if !f.CreatedAfter.IsZero() && v.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !v.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Less orders vaults by the sort field of the filter, ties are broken by id.
+func (f *VaultFilter) Less(a, b VaultModel) bool {
+	if f.Desc {
+		a, b = b, a
+	}
+	switch f.Sort {
+	case SortName:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	case SortCreatedAt:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	}
+	return a.ID < b.ID
+}
+
+// Page cuts vaults queried with a limit of Limit+1 to a page, the cursor is set when more vaults follow.
+func (f *VaultFilter) Page(vaults []VaultModel) VaultPage {
+	page := VaultPage{Vaults: vaults}
+	if len(vaults) > f.Limit {
+		page.Vaults = vaults[:f.Limit]
+		last := page.Vaults[f.Limit-1]
+		page.NextCursor = VaultCursor{
+			Sort:      f.Sort,
+			Desc:      f.Desc,
+			ID:        last.ID,
+			Name:      last.Name,
+			CreatedAt: last.CreatedAt,
+		}.Encode()
+	}
+	return page
+}
+
+// Vault returns the cursor position as a vault to compare with Less.
+func (c VaultCursor) Vault() VaultModel {
+	return VaultModel{ID: c.ID, Name: c.Name, CreatedAt: c.CreatedAt}
+}
+
+func (c VaultCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeVaultCursor(cursor string) (*VaultCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var c VaultCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &c, nil
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"vault/pkg/lib/generate"
+)
+
+const (
+	GeneratePassword = "password"
+	GenerateUUID     = "uuid"
+	GenerateHex      = "hex"
+	GenerateBase64   = "base64"
+	GenerateRSA      = "rsa"
+	GenerateEd25519  = "ed25519"
+)
+
+const (
+	defaultPasswordLength = 32
+	defaultCharset        = "alnum+symbols"
+	defaultBytes          = 32
+	defaultRSABits        = 2048
+	minPasswordLength     = 8
+	maxGenerateLength     = 1024
+)
+
+// PublicKeySuffix is appended to the key of a generated key pair to store its public key.
+const PublicKeySuffix = ".pub"
+
+var rsaBits = []int{2048, 3072, 4096}
+
+// Generator is a directive in place of a value which the server expands with crypto/rand.
+// Length is the number of characters of passwords, of random bytes of hex and base64
+// values and of bits of rsa keys. Key pairs store the private key under the key and
+// the public key under the key with PublicKeySuffix.
+type Generator struct {
+	Type    string `json:"type"`
+	Length  int    `json:"length"`
+	Charset string `json:"charset"`
+}
+
+func (g Generator) Validate() error {
+	switch g.Type {
+	case GeneratePassword:
+		if g.Length != 0 && (g.Length < minPasswordLength || g.Length > maxGenerateLength) {
+			return fmt.Errorf("password length must be between %d and %d", minPasswordLength, maxGenerateLength)
+		}
+		if _, ok := generate.Charsets[g.Charset]; g.Charset != "" && !ok {
+			return fmt.Errorf("unknown charset: %q", g.Charset)
+		}
+		return nil
+	case GenerateHex, GenerateBase64:
+		if g.Length < 0 || g.Length > maxGenerateLength {
+			return fmt.Errorf("%s length must be between 1 and %d bytes", g.Type, maxGenerateLength)
+		}
+	case GenerateRSA:
+		if g.Length != 0 && !slices.Contains(rsaBits, g.Length) {
+			return fmt.Errorf("rsa length must be one of %v bits", rsaBits)
+		}
+	case GenerateUUID, GenerateEd25519:
+		if g.Length != 0 {
+			return fmt.Errorf("%s doesn't take a length", g.Type)
+		}
+	default:
+		return fmt.Errorf("unknown generator type: %q", g.Type)
+	}
+	if g.Charset != "" {
+		return fmt.Errorf("%s doesn't take a charset", g.Type)
+	}
+	return nil
+}
+
+// Generate returns the value and, for key pairs, the public key.
+func (g Generator) Generate() (string, string, error) {
+	switch g.Type {
+	case GeneratePassword:
+		length, charset := g.Length, g.Charset
+		if length == 0 {
+			length = defaultPasswordLength
+		}
+		if charset == "" {
+			charset = defaultCharset
+		}
+		value, err := generate.Password(length, charset)
+		return value, "", err
+	case GenerateUUID:
+		value, err := generate.UUID()
+		return value, "", err
+	case GenerateHex:
+		value, err := generate.Hex(orDefault(g.Length, defaultBytes))
+		return value, "", err
+	case GenerateBase64:
+		value, err := generate.Base64(orDefault(g.Length, defaultBytes))
+		return value, "", err
+	case GenerateRSA:
+		return generate.RSA(orDefault(g.Length, defaultRSABits))
+	case GenerateEd25519:
+		return generate.Ed25519()
+	}
+	return "", "", fmt.Errorf("unknown generator type: %q", g.Type)
+}
+
+func (g Generator) keyPair() bool {
+	return g.Type == GenerateRSA || g.Type == GenerateEd25519
+}
+
+func orDefault(value, fallback int) int {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+// UnmarshalJSON takes every value of data either as a string or as a {"generate": {...}} directive,
+// directives are kept in Generate until GenerateValues expands them.
+func (s *SecretCreateModel) UnmarshalJSON(b []byte) error {
+	type model SecretCreateModel
+	aux := struct {
+		*model
+		Data map[string]json.RawMessage `json:"data"`
+	}{model: (*model)(s)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if aux.Data == nil {
+		s.Data = nil
+		return nil
+	}
+
+	s.Data = make(map[string]string, len(aux.Data))
+	for k, raw := range aux.Data {
+		if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			var value *string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("value of %q must be a string or a generator", k)
+			}
+			if value != nil {
+				s.Data[k] = *value
+			} else {
+				s.Data[k] = ""
+			}
+			continue
+		}
+
+		var directive struct {
+			Generate *Generator `json:"generate"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&directive); err != nil || directive.Generate == nil {
+			return fmt.Errorf("value of %q must be a string or a generator", k)
+		}
+		if s.Generate == nil {
+			s.Generate = map[string]Generator{}
+		}
+		s.Generate[k] = *directive.Generate
+	}
+	return nil
+}
+
+// validateGenerate checks the directives and returns every key they write.
+func (s *SecretCreateModel) validateGenerate() ([]string, error) {
+	keys := make([]string, 0, len(s.Generate))
+	for k, g := range s.Generate {
+		if err := g.Validate(); err != nil {
+			return nil, fmt.Errorf("key %q: %w", k, err)
+		}
+		keys = append(keys, k)
+		if g.keyPair() {
+			if _, ok := s.Data[k+PublicKeySuffix]; ok {
+				return nil, fmt.Errorf("key %q is taken by the public key of %q", k+PublicKeySuffix, k)
+			}
+			if _, ok := s.Generate[k+PublicKeySuffix]; ok {
+				return nil, fmt.Errorf("key %q is taken by the public key of %q", k+PublicKeySuffix, k)
+			}
+			keys = append(keys, k+PublicKeySuffix)
+		}
+	}
+	return keys, nil
+}
+
+// GenerateValues expands the directives into Data and returns the generated values,
+// the expiry of a key pair applies to its public key as well.
+func (s *SecretCreateModel) GenerateValues() (map[string]string, error) {
+	generated := make(map[string]string)
+	for k, g := range s.Generate {
+		value, public, err := g.Generate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %q: %w", k, err)
+		}
+		generated[k] = value
+		if g.keyPair() {
+			generated[k+PublicKeySuffix] = public
+			if expiry, ok := s.KeyExpiry[k]; ok {
+				s.KeyExpiry[k+PublicKeySuffix] = expiry
+			}
+		}
+	}
+
+	for k, v := range generated {
+		s.Data[k] = v
+	}
+	s.Generate = nil
+	return generated, nil
+}
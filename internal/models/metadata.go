@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxDescriptionLength    = 1024
+	maxAttributes           = 64
+	maxAttributeValueLength = 256
+)
+
+var attributeRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// MetadataModel describes a vault apart from its data. It is stored in plaintext next to
+// the vault so listings can filter on it, it must never hold secrets.
+type MetadataModel struct {
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Owner        string            `json:"owner"`
+	ContactEmail string            `json:"contact_email"`
+	Tags         []string          `json:"tags"`
+	Attributes   map[string]string `json:"attributes"`
+	CreatedBy    string            `json:"created_by"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// MetadataDTO edits the metadata of a vault, nil fields keep the stored value
+// and empty ones clear it. Tags and Attributes are replaced as a whole.
+type MetadataDTO struct {
+	Description  *string           `json:"description"`
+	Owner        *string           `json:"owner"`
+	ContactEmail *string           `json:"contact_email"`
+	Tags         []string          `json:"tags"`
+	Attributes   map[string]string `json:"attributes"`
+}
+
+// ValidAttribute reports whether the attribute key is a word of at most 64 characters
+// and the value is not longer than 256 characters.
+func ValidAttribute(key, value string) bool {
+	return attributeRegexp.MatchString(key) && utf8.RuneCountInString(value) <= maxAttributeValueLength
+}
+
+func (m *MetadataDTO) Validate() error {
+	if m.Description == nil && m.Owner == nil && m.ContactEmail == nil && m.Tags == nil && m.Attributes == nil {
+		return fmt.Errorf("metadata can't be empty")
+	}
+	if m.Description != nil && utf8.RuneCountInString(*m.Description) > maxDescriptionLength {
+		return fmt.Errorf("description must not exceed %d characters", maxDescriptionLength)
+	}
+	if m.Owner != nil && *m.Owner != "" && !ValidTag(*m.Owner) {
+		return fmt.Errorf("invalid owner: %q", *m.Owner)
+	}
+	if m.ContactEmail != nil && *m.ContactEmail != "" {
+		if address, err := mail.ParseAddress(*m.ContactEmail); err != nil || address.Address != *m.ContactEmail {
+			return fmt.Errorf("invalid contact email: %q", *m.ContactEmail)
+		}
+	}
+	for _, tag := range m.Tags {
+		if !ValidTag(tag) {
+			return fmt.Errorf("invalid tag: %q", tag)
+		}
+	}
+	if len(m.Attributes) > maxAttributes {
+		return fmt.Errorf("at most %d attributes are allowed", maxAttributes)
+	}
+	for k, v := range m.Attributes {
+		if !ValidAttribute(k, v) {
+			return fmt.Errorf("invalid attribute: %q", k)
+		}
+	}
+	return nil
+}
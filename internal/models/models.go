@@ -1,36 +1,405 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
 	"time"
+	"vault/pkg/lib/encryption"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// SecretModel carries the expiry of the vault and of the keys that expire with the seconds left.
 type SecretModel struct {
-	ID   int               `json:"id"`
-	Name string            `json:"name"`
-	Data map[string]string `json:"data"`
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Version     int               `json:"version,omitempty"`
+	CASRequired bool              `json:"cas_required,omitempty"`
+	Data        map[string]string `json:"data"`
+	Expiry
+	KeyExpiry map[string]Expiry `json:"key_expiry,omitempty"`
+	DataKey   string            `json:"-"`
+	Plaintext map[string]bool   `json:"-"`
 }
 
+// VaultModel is the vault summary returned by listings, it never carries values.
 type VaultModel struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+	Tags        []string          `json:"tags"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	CreatedBy   string            `json:"created_by,omitempty"`
+	Version     int               `json:"version"`
+	KeyCount    int               `json:"key_count"`
+	CreatedAt   time.Time         `json:"created_at"`
+	DeletedAt   *time.Time        `json:"deleted_at,omitempty"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	DataKey     string            `json:"-"`
 }
 
+const (
+	CapabilityRead = "read"
+	CapabilityList = "list"
+)
+
+var TokenCapabilities = []string{
+	CapabilityRead, CapabilityList,
+	CapabilityEncrypt, CapabilityDecrypt, CapabilityRewrap, CapabilitySign, CapabilityVerify,
+}
+
+// TokenModel holds the claims of a user token. Renewable tokens carry their initial lifetime
+// in expires and may be renewed until max_exp.
 type TokenModel struct {
 	jwt.RegisteredClaims
-	ID      int           `json:"vault_id"`
-	Expires time.Duration `json:"expires"`
+	TokenScope
+	ID           int              `json:"vault_id,omitempty"`
+	VaultIDs     []int            `json:"vault_ids,omitempty"`
+	Paths        []string         `json:"paths,omitempty"`
+	TransitKeys  []string         `json:"transit_keys,omitempty"`
+	Expires      time.Duration    `json:"expires,omitempty"`
+	Renewable    bool             `json:"renewable,omitempty"`
+	MaxExpiresAt *jwt.NumericDate `json:"max_exp,omitempty"`
+}
+
+// TokenScope limits a user token to key patterns and capabilities, empty lists keep the defaults.
+type TokenScope struct {
+	Keys         []string `json:"keys,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+type TokenInfoModel struct {
+	JTI         string    `json:"jti"`
+	VaultIDs    []int     `json:"vault_ids"`
+	Paths       []string  `json:"paths,omitempty"`
+	TransitKeys []string  `json:"transit_keys,omitempty"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	TokenScope
+}
+
+// TokenLookupModel describes a user token to its holder, TTL is the remaining lifetime in seconds.
+type TokenLookupModel struct {
+	TokenInfoModel
+	TTL          int64      `json:"ttl"`
+	Renewable    bool       `json:"renewable"`
+	MaxExpiresAt *time.Time `json:"max_expires_at,omitempty"`
+}
+
+type VersionModel struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+// CheckCAS compares the current vault version with the check-and-set version of a write,
+// cas 0 skips the comparison unless the vault requires check-and-set.
+func CheckCAS(current int, required bool, cas int) error {
+	if cas == 0 {
+		if required {
+			return errors.New("check-and-set version required")
+		}
+		return nil
+	}
+	if cas != current {
+		return errors.New("vault version mismatch")
+	}
+	return nil
+}
+
+type SealConfig struct {
+	Shares       int    `json:"shares"`
+	Threshold    int    `json:"threshold"`
+	Verification string `json:"-"`
+}
+
+// KeyringTerm is an encryption key installed by a rotation, the key is wrapped with the
+// master key. The master key itself is term 1 and is never stored.
+type KeyringTerm struct {
+	Term      int       `json:"term"`
+	Key       string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// States of JWT signing keys, exactly one key at most is active and signs new tokens.
+// Verify keys only validate tokens, retired keys are rejected.
+const (
+	JWTKeyActive  = "active"
+	JWTKeyVerify  = "verify"
+	JWTKeyRetired = "retired"
+)
+
+// JWTKeyModel is a signing key of user tokens, tokens name it in their kid header.
+// The secret is wrapped like the data keys of vaults, EdDSA and RS256 keys keep
+// their base64 PKIX public key in plaintext.
+type JWTKeyModel struct {
+	ID        string    `json:"kid"`
+	Algorithm string    `json:"algorithm"`
+	State     string    `json:"state"`
+	Key       string    `json:"-"`
+	PublicKey string    `json:"public_key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Kinds of wrapped keys the rewrap job moves to the latest keyring term.
+const (
+	WrappedKeyVault   = "vault"
+	WrappedKeyTransit = "transit"
+	WrappedKeyJWT     = "jwt"
+)
+
+// WrappedKey is a data key of a vault, the material of a transit key version or a JWT signing key.
+// Vault keys are identified by ID, transit keys by Name and Version, JWT keys by Name.
+type WrappedKey struct {
+	Kind    string
+	ID      int
+	Name    string
+	Version int
+	Key     string
+}
+
+// KeyStatus reports the latest keyring term and how many wrapped keys still use older terms.
+type KeyStatus struct {
+	Term        int            `json:"term"`
+	InstallTime *time.Time     `json:"install_time,omitempty"`
+	Rewrap      RewrapProgress `json:"rewrap"`
+}
+
+type RewrapProgress struct {
+	Pending int `json:"pending"`
+	Total   int `json:"total"`
+}
+
+type PolicyModel struct {
+	Name      string    `json:"name"`
+	Document  string    `json:"policy"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IdentityModel struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Policies  []string  `json:"policies"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SealStatus struct {
+	Initialized bool `json:"initialized"`
+	Sealed      bool `json:"sealed"`
+	Shares      int  `json:"shares"`
+	Threshold   int  `json:"threshold"`
+	Progress    int  `json:"progress"`
+}
+
+// NewTokenModel builds the claims of a new vault token, expires is the lifetime in seconds.
+// Tokens bound to a single vault keep the vault_id claim as well, paths bind the token to vault paths and subtrees.
+func NewTokenModel(jti string, vaultIDs []int, paths []string, expires time.Duration, scope TokenScope) TokenModel {
+	now := time.Now()
+	model := TokenModel{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expires * time.Second)),
+		},
+		TokenScope: scope,
+		VaultIDs:   vaultIDs,
+		Paths:      paths,
+	}
+	if len(vaultIDs) == 1 {
+		model.ID = vaultIDs[0]
+	}
+	return model
+}
+
+// Vaults returns the ids of the vaults the token is bound to.
+func (t TokenModel) Vaults() []int {
+	if len(t.VaultIDs) > 0 {
+		return t.VaultIDs
+	}
+	if t.ID != 0 {
+		return []int{t.ID}
+	}
+	return nil
+}
+
+// Info returns the token data kept in the token store.
+func (t TokenModel) Info() TokenInfoModel {
+	return TokenInfoModel{
+		JTI:         t.RegisteredClaims.ID,
+		VaultIDs:    t.Vaults(),
+		Paths:       t.Paths,
+		TransitKeys: t.TransitKeys,
+		IssuedAt:    t.IssuedAt.Time,
+		ExpiresAt:   t.ExpiresAt.Time,
+		TokenScope:  t.TokenScope,
+	}
+}
+
+// Lease makes the token renewable until maxTTL seconds after it was issued, 0 sets no bound.
+func (t *TokenModel) Lease(maxTTL time.Duration) {
+	t.Renewable = true
+	t.Expires = t.ExpiresAt.Sub(t.IssuedAt.Time) / time.Second
+	if maxTTL > 0 {
+		t.MaxExpiresAt = jwt.NewNumericDate(t.IssuedAt.Add(maxTTL * time.Second))
+	}
+}
+
+// MaxIncrement is the longest renewal in seconds, longer ones overflow time.Duration.
+const MaxIncrement = time.Duration(math.MaxInt64 / int64(time.Second))
+
+// Renew returns the claims expiring increment seconds after now, 0 takes the initial lifetime.
+// The new expiry never passes max_exp.
+func (t TokenModel) Renew(now time.Time, increment time.Duration) (TokenModel, error) {
+	if !t.Renewable {
+		return TokenModel{}, errors.New("token is not renewable")
+	}
+	if increment == 0 {
+		increment = t.Expires
+	}
+	increment = min(increment, MaxIncrement)
+
+	expiresAt := now.Add(increment * time.Second)
+	if t.MaxExpiresAt != nil && expiresAt.After(t.MaxExpiresAt.Time) {
+		expiresAt = t.MaxExpiresAt.Time
+	}
+	if !expiresAt.After(now) {
+		return TokenModel{}, errors.New("token reached its max_ttl")
+	}
+
+	t.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	return t, nil
+}
+
+// Lookup describes the token at now.
+func (t TokenModel) Lookup(now time.Time) TokenLookupModel {
+	lookup := TokenLookupModel{
+		TokenInfoModel: t.Info(),
+		TTL:            max(int64(t.ExpiresAt.Sub(now)/time.Second), 0),
+		Renewable:      t.Renewable,
+	}
+	if t.MaxExpiresAt != nil {
+		lookup.MaxExpiresAt = &t.MaxExpiresAt.Time
+	}
+	return lookup
+}
+
+// LeaseTTL returns the renewal bound in seconds of a token asking for maxTTL seconds under the
+// server limit, limit is a duration. 0 means neither is set.
+func LeaseTTL(maxTTL, limit time.Duration) time.Duration {
+	seconds := limit / time.Second
+	if maxTTL == 0 || (seconds > 0 && maxTTL > seconds) {
+		return seconds
+	}
+	return maxTTL
+}
+
+// Allows reports whether the scope grants the capability, tokens without capabilities can only read.
+func (s TokenScope) Allows(capability string) bool {
+	if len(s.Capabilities) == 0 {
+		return capability == CapabilityRead
+	}
+	return slices.Contains(s.Capabilities, capability)
+}
+
+// AllowsKey reports whether the key matches one of the scope patterns, tokens without patterns allow every key.
+func (s TokenScope) AllowsKey(key string) bool {
+	if len(s.Keys) == 0 {
+		return true
+	}
+	for _, pattern := range s.Keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the part of data allowed by the scope.
+func (s TokenScope) Filter(data map[string]string) map[string]string {
+	res := make(map[string]string, len(data))
+	for k, v := range data {
+		if s.AllowsKey(k) {
+			res[k] = v
+		}
+	}
+	return res
 }
 
 func ConvertDTOToSecretModel(vault VaultModel, data []ValueDTO) SecretModel {
+	now := time.Now()
 	modelData := map[string]string{}
+	var keyExpiry map[string]Expiry
+	var plaintext map[string]bool
 	for _, v := range data {
 		modelData[v.Key] = v.Value
+		if !v.Encrypted {
+			if plaintext == nil {
+				plaintext = make(map[string]bool)
+			}
+			plaintext[v.Key] = true
+		}
+		if v.ExpiresAt != nil {
+			if keyExpiry == nil {
+				keyExpiry = make(map[string]Expiry)
+			}
+			keyExpiry[v.Key] = NewExpiry(v.ExpiresAt, now)
+		}
 	}
 	return SecretModel{
-		ID:   vault.ID,
-		Name: vault.Name,
-		Data: modelData,
+		ID:        vault.ID,
+		Name:      vault.Name,
+		Data:      modelData,
+		Expiry:    NewExpiry(vault.ExpiresAt, now),
+		KeyExpiry: keyExpiry,
+		DataKey:   vault.DataKey,
+		Plaintext: plaintext,
+	}
+}
+
+// TrimKeyExpiry drops the expiry of keys which are no longer in data.
+func (s *SecretModel) TrimKeyExpiry() {
+	for k := range s.KeyExpiry {
+		if _, ok := s.Data[k]; !ok {
+			delete(s.KeyExpiry, k)
+		}
+	}
+	if len(s.KeyExpiry) == 0 {
+		s.KeyExpiry = nil
+	}
+}
+
+// ValueAAD is the additional data a value is encrypted with, it binds the ciphertext
+// to the vault and key so it can't be moved to another one.
+func ValueAAD(vaultID int, key string) []byte {
+	return []byte("vault:" + strconv.Itoa(vaultID) + ":" + key)
+}
+
+// Decrypt replaces encrypted values with plaintext, the keys in Plaintext were stored
+// before encryption was introduced and are left untouched.
+func (s *SecretModel) Decrypt(envelope *encryption.Envelope) error {
+	var dataKey []byte
+	if s.DataKey != "" {
+		key, err := envelope.UnwrapDataKey(s.DataKey)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap data key: %w", err)
+		}
+		dataKey = key
+	}
+
+	for k, v := range s.Data {
+		if s.Plaintext[k] {
+			continue
+		}
+		value, err := encryption.DecryptValue(dataKey, v, ValueAAD(s.ID, k))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt value: %w", err)
+		}
+		s.Data[k] = value
 	}
+	return nil
 }
@@ -0,0 +1,234 @@
+package models_test
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+	"vault/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenScope(t *testing.T) {
+	data := map[string]string{
+		"db_user":     "user",
+		"db_password": "password",
+		"api_key":     "key",
+	}
+
+	tests := []struct {
+		Name   string
+		Scope  models.TokenScope
+		Read   bool
+		List   bool
+		Result map[string]string
+	}{
+		{
+			Name:   "default scope",
+			Scope:  models.TokenScope{},
+			Read:   true,
+			Result: data,
+		},
+		{
+			Name: "glob keys",
+			Scope: models.TokenScope{
+				Keys: []string{"db_*"},
+			},
+			Read: true,
+			Result: map[string]string{
+				"db_user":     "user",
+				"db_password": "password",
+			},
+		},
+		{
+			Name: "list only",
+			Scope: models.TokenScope{
+				Keys:         []string{"api_key"},
+				Capabilities: []string{models.CapabilityList},
+			},
+			List: true,
+			Result: map[string]string{
+				"api_key": "key",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Read, tt.Scope.Allows(models.CapabilityRead))
+			assert.Equal(t, tt.List, tt.Scope.Allows(models.CapabilityList))
+			assert.Equal(t, tt.Result, tt.Scope.Filter(data))
+		})
+	}
+}
+
+func TestSecretModelExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(90 * time.Second)
+
+	vault := models.ConvertDTOToSecretModel(models.VaultModel{ID: 1, Name: "partners", ExpiresAt: &expiresAt}, []models.ValueDTO{
+		{Key: "a", Value: "1"},
+		{Key: "b", Value: "2", ExpiresAt: &expiresAt},
+		{Key: "c", Value: "3", ExpiresAt: &expiresAt},
+	})
+	assert.Equal(t, 90, vault.TTL, "the seconds left are rounded up")
+	assert.Equal(t, &expiresAt, vault.ExpiresAt)
+	assert.Len(t, vault.KeyExpiry, 2)
+
+	delete(vault.Data, "c")
+	vault.TrimKeyExpiry()
+	assert.Len(t, vault.KeyExpiry, 1)
+	assert.Contains(t, vault.KeyExpiry, "b")
+
+	delete(vault.Data, "b")
+	vault.TrimKeyExpiry()
+	assert.Nil(t, vault.KeyExpiry)
+
+	assert.Equal(t, models.Expiry{}, models.NewExpiry(nil, time.Now()))
+}
+
+func TestAppRole(t *testing.T) {
+	role := models.AppRoleModel{Expires: 300, MaxExpires: 3600}
+	assert.Equal(t, time.Duration(300), role.TokenExpires(0), "logins without expires get the role default")
+	assert.Equal(t, time.Duration(60), role.TokenExpires(60))
+	assert.Equal(t, time.Duration(3600), role.TokenExpires(86400), "longer lifetimes are cut to max_expires")
+
+	role.MaxExpires = 0
+	assert.Equal(t, time.Duration(300), role.TokenExpires(3600), "without max_expires the default is the maximum")
+
+	now := time.Now()
+	secretID := models.SecretIDModel{CIDRs: []string{"10.0.0.0/8", "192.168.1.0/24"}}
+	assert.True(t, secretID.AllowsAddr(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, secretID.AllowsAddr(netip.MustParseAddr("::ffff:192.168.1.7")), "mapped ipv4 addresses match")
+	assert.False(t, secretID.AllowsAddr(netip.MustParseAddr("192.168.2.1")))
+	assert.True(t, models.SecretIDModel{}.AllowsAddr(netip.MustParseAddr("192.168.2.1")))
+	assert.False(t, secretID.Expired(now))
+
+	secretID.ExpiresAt = &now
+	assert.True(t, secretID.Expired(now))
+}
+
+func TestOperator(t *testing.T) {
+	valid := models.CreateOperatorDTO{Name: "alice", Password: "correct horse battery", Policies: []string{"admin"}}
+	assert.NoError(t, valid.Validate())
+
+	short := valid
+	short.Password = "short"
+	assert.EqualError(t, short.Validate(), "password must be at least 12 characters long")
+
+	root := valid
+	root.Name = "root"
+	assert.Error(t, root.Validate(), "operators can't take the name of the root token")
+
+	noPolicies := valid
+	noPolicies.Policies = nil
+	assert.Error(t, noPolicies.Validate())
+
+	now := time.Now()
+	operator := models.OperatorModel{}
+	assert.False(t, operator.Locked(now))
+	lockedUntil := now.Add(time.Minute)
+	operator.LockedUntil = &lockedUntil
+	assert.True(t, operator.Locked(now))
+	assert.False(t, operator.Locked(lockedUntil), "the lock ends at locked_until")
+}
+
+func TestTokenRenewal(t *testing.T) {
+	claims := models.NewTokenModel("jti", []int{1}, nil, 60, models.TokenScope{})
+	_, err := claims.Renew(time.Now(), 0)
+	assert.EqualError(t, err, "token is not renewable")
+
+	issuedAt := claims.IssuedAt.Time
+	claims.Lease(300)
+	assert.True(t, claims.Renewable)
+	assert.Equal(t, time.Duration(60), claims.Expires, "renewals default to the initial lifetime")
+	require.NotNil(t, claims.MaxExpiresAt)
+	assert.Equal(t, issuedAt.Add(300*time.Second).Unix(), claims.MaxExpiresAt.Unix())
+
+	renewed, err := claims.Renew(issuedAt.Add(30*time.Second), 0)
+	require.NoError(t, err)
+	assert.Equal(t, issuedAt.Add(90*time.Second).Unix(), renewed.ExpiresAt.Unix())
+	assert.Equal(t, "jti", renewed.RegisteredClaims.ID)
+
+	renewed, err = claims.Renew(issuedAt.Add(30*time.Second), 3600)
+	require.NoError(t, err)
+	assert.Equal(t, claims.MaxExpiresAt.Unix(), renewed.ExpiresAt.Unix(), "renewals stop at max_ttl")
+
+	_, err = claims.Renew(issuedAt.Add(300*time.Second), 0)
+	assert.EqualError(t, err, "token reached its max_ttl")
+
+	unbounded := claims
+	unbounded.MaxExpiresAt = nil
+	far, err := unbounded.Renew(issuedAt, math.MaxInt64)
+	require.NoError(t, err)
+	assert.True(t, far.ExpiresAt.After(issuedAt), "huge increments don't overflow")
+
+	dtoRenew := models.RenewTokenDTO{Increment: models.MaxIncrement}
+	assert.NoError(t, dtoRenew.Validate())
+	dtoRenew.Increment++
+	assert.EqualError(t, dtoRenew.Validate(), fmt.Sprintf("increment must not exceed %d seconds", models.MaxIncrement))
+
+	lookup := renewed.Lookup(issuedAt.Add(100 * time.Second))
+	assert.Equal(t, int64(200), lookup.TTL)
+	assert.True(t, lookup.Renewable)
+	assert.Equal(t, []int{1}, lookup.VaultIDs)
+
+	assert.Equal(t, time.Duration(600), models.LeaseTTL(0, 10*time.Minute), "without max_ttl the server limit applies")
+	assert.Equal(t, time.Duration(600), models.LeaseTTL(3600, 10*time.Minute))
+	assert.Equal(t, time.Duration(300), models.LeaseTTL(300, 10*time.Minute))
+	assert.Equal(t, time.Duration(0), models.LeaseTTL(0, 0))
+
+	dto := models.CreateVaultTokenDTO{VaultID: 1, Expires: 60, MaxTTL: 30}
+	assert.EqualError(t, dto.Validate(), "max_ttl must not be lower than expires")
+	dto.MaxTTL = 3600
+	assert.NoError(t, dto.Validate())
+	assert.EqualError(t, dto.CheckTTL(10*time.Minute), "expires and max_ttl must not exceed 600 seconds")
+	assert.NoError(t, dto.CheckTTL(0))
+}
+
+func TestParseWrapTTL(t *testing.T) {
+	ttl, err := models.ParseWrapTTL("300", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	ttl, err = models.ParseWrapTTL("15m", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, ttl)
+
+	ttl, err = models.ParseWrapTTL("48h", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, ttl, "0 disables the limit")
+
+	_, err = models.ParseWrapTTL("2h", time.Hour)
+	assert.EqualError(t, err, "X-Vault-Wrap-TTL must not exceed 3600 seconds")
+	_, err = models.ParseWrapTTL("0", time.Hour)
+	assert.EqualError(t, err, "X-Vault-Wrap-TTL must be at least 1 second")
+	_, err = models.ParseWrapTTL("soon", time.Hour)
+	assert.EqualError(t, err, `invalid X-Vault-Wrap-TTL header: "soon"`)
+}
+
+func TestValidPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{path: "team/payments", valid: true},
+		{path: "system/db", valid: true},
+		{path: "team/sys/db", valid: true},
+		{path: "sys", valid: false},
+		{path: "sys/tokens", valid: false},
+		{path: "transit/payments", valid: false},
+		{path: "auth/approle/ci", valid: false},
+		{path: "team/*", valid: false},
+		{path: "team//db", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.valid, models.ValidPath(tt.path))
+			assert.Equal(t, tt.valid, models.ValidPathPattern(tt.path+"/*"))
+		})
+	}
+}
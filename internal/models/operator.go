@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"vault/internal/policy"
+	"vault/pkg/validator"
+)
+
+// MinPasswordLength is the shortest accepted operator password.
+const MinPasswordLength = 12
+
+// Kinds of operator tokens, sessions are issued by /auth/userpass/login and expire,
+// API keys are created by admins and live until they are deleted or expire.
+const (
+	OperatorSession = "session"
+	OperatorAPIKey  = "api_key"
+)
+
+// Prefixes of operator tokens, they tell the admin auth middleware to look the token up as an operator.
+const (
+	OperatorSessionPrefix = "ses."
+	OperatorAPIKeyPrefix  = "opk."
+)
+
+// OperatorModel is a human admin account, it is bound to policies like an admin identity.
+// FailedLogins counts the failed password logins since the last successful one.
+type OperatorModel struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Policies     []string   `json:"policies"`
+	FailedLogins int        `json:"failed_logins"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// OperatorTokenModel is a session or API key of an operator, only the hash of the token is stored.
+type OperatorTokenModel struct {
+	Accessor  string     `json:"accessor"`
+	Kind      string     `json:"kind"`
+	Name      string     `json:"name,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CreateOperatorDTO struct {
+	Name     string   `json:"name" validate:"required"`
+	Password string   `json:"password" validate:"required"`
+	Policies []string `json:"policies"`
+}
+
+type OperatorPasswordDTO struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// CreateAPIKeyDTO names the key, expires is its lifetime in seconds and 0 keeps it until it is deleted.
+type CreateAPIKeyDTO struct {
+	Name    string        `json:"name"`
+	Expires time.Duration `json:"expires"`
+}
+
+type UserpassLoginDTO struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (c *CreateOperatorDTO) Validate() error {
+	if err := validator.Validate(c); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if !policy.ValidName(c.Name) || c.Name == policy.RootName {
+		return fmt.Errorf("invalid operator name: %q", c.Name)
+	}
+	if err := validatePassword(c.Password); err != nil {
+		return err
+	}
+	if len(c.Policies) == 0 {
+		return fmt.Errorf("validation error: field policies can't be empty")
+	}
+	for _, name := range c.Policies {
+		if !policy.ValidName(name) {
+			return fmt.Errorf("invalid policy name: %q", name)
+		}
+	}
+	return nil
+}
+
+func (o *OperatorPasswordDTO) Validate() error {
+	if err := validator.Validate(o); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	return validatePassword(o.Password)
+}
+
+func (c *CreateAPIKeyDTO) Validate() error {
+	if c.Expires < 0 {
+		return fmt.Errorf("expires must be positive")
+	}
+	return nil
+}
+
+func (u *UserpassLoginDTO) Validate() error {
+	if err := validator.Validate(u); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	return nil
+}
+
+// Locked reports whether password logins of the operator are locked at now.
+func (o OperatorModel) Locked(now time.Time) bool {
+	return o.LockedUntil != nil && now.Before(*o.LockedUntil)
+}
+
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long", MinPasswordLength)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const maxPathLength = 512
+
+// reservedSegments start the policy resources which are not vaults, like sys/tokens,
+// transit/<key> and auth/approle/<role>. Vault paths share the namespace and can't use them.
+var reservedSegments = []string{"sys", "transit", "auth"}
+
+// ValidPath reports whether the vault name is a path of non empty segments separated by /,
+// the policy wildcards * and +, the segments . and .. and reserved first segments are not allowed.
+func ValidPath(path string) bool {
+	if path == "" || len(path) > maxPathLength {
+		return false
+	}
+	if first, _, _ := strings.Cut(path, "/"); slices.Contains(reservedSegments, first) {
+		return false
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return !strings.ContainsFunc(path, func(r rune) bool {
+		return r == '*' || r == '+' || unicode.IsControl(r)
+	})
+}
+
+// ValidPathPattern reports whether the token path is a vault path or a subtree pattern like team/payments/*.
+func ValidPathPattern(pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return ValidPath(prefix)
+	}
+	return ValidPath(pattern)
+}
+
+// MatchPath reports whether the vault path is the pattern path or is below a subtree pattern.
+func MatchPath(pattern string, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return pattern == path
+}
+
+// MatchPaths reports whether one of the patterns matches the vault path.
+func MatchPaths(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanPrefix trims the slashes around a listing prefix, the root prefix is empty.
+func CleanPrefix(prefix string) string {
+	return strings.Trim(prefix, "/")
+}
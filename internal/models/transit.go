@@ -0,0 +1,148 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"time"
+	"vault/pkg/lib/transit"
+	"vault/pkg/validator"
+)
+
+const (
+	CapabilityEncrypt = "encrypt"
+	CapabilityDecrypt = "decrypt"
+	CapabilityRewrap  = "rewrap"
+	CapabilitySign    = "sign"
+	CapabilityVerify  = "verify"
+)
+
+var transitKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// TransitKeyModel is a named transit key, every rotation adds a version. Ciphertexts and
+// signatures of versions below MinDecryptionVersion are rejected.
+type TransitKeyModel struct {
+	Name                 string              `json:"name"`
+	Type                 string              `json:"type"`
+	LatestVersion        int                 `json:"latest_version"`
+	MinDecryptionVersion int                 `json:"min_decryption_version"`
+	CreatedAt            time.Time           `json:"created_at"`
+	Versions             []TransitKeyVersion `json:"versions"`
+}
+
+// TransitKeyVersion holds the key material wrapped with the master key,
+// it never leaves the server. Ed25519 versions expose their public key.
+type TransitKeyVersion struct {
+	Version   int       `json:"version"`
+	Key       string    `json:"-"`
+	PublicKey string    `json:"public_key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Version returns the key version or false when it does not exist.
+func (k TransitKeyModel) Version(version int) (TransitKeyVersion, bool) {
+	for _, v := range k.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return TransitKeyVersion{}, false
+}
+
+// ValidTransitKey reports whether the transit key name is a word of at most 64 characters.
+func ValidTransitKey(name string) bool {
+	return transitKeyRegexp.MatchString(name)
+}
+
+type CreateTransitKeyDTO struct {
+	Name string `json:"name" validate:"required"`
+	Type string `json:"type"`
+}
+
+// TransitKeyConfigDTO moves the oldest version still allowed to decrypt and verify.
+type TransitKeyConfigDTO struct {
+	MinDecryptionVersion int `json:"min_decryption_version" validate:"required"`
+}
+
+// EncryptDTO carries base64 plaintext, context is optional base64 authenticated data
+// which has to be passed again to decrypt.
+type EncryptDTO struct {
+	Plaintext string `json:"plaintext"`
+	Context   string `json:"context"`
+}
+
+// DecryptDTO carries a vault:v<N>: ciphertext of encrypt, rewrap takes it as well.
+type DecryptDTO struct {
+	Ciphertext string `json:"ciphertext" validate:"required"`
+	Context    string `json:"context"`
+}
+
+// SignDTO carries the base64 input, verify takes the vault:v<N>: signature of sign as well.
+type SignDTO struct {
+	Input     string `json:"input"`
+	Signature string `json:"signature"`
+}
+
+func (c *CreateTransitKeyDTO) Validate() error {
+	if err := validator.Validate(c); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if !ValidTransitKey(c.Name) {
+		return fmt.Errorf("invalid transit key name: %q", c.Name)
+	}
+	if c.Type == "" {
+		c.Type = transit.TypeAES256GCM
+	}
+	if !transit.ValidType(c.Type) {
+		return fmt.Errorf("unknown transit key type: %q", c.Type)
+	}
+	return nil
+}
+
+func (c *TransitKeyConfigDTO) Validate() error {
+	if err := validator.Validate(c); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	if c.MinDecryptionVersion < 1 {
+		return fmt.Errorf("min_decryption_version must be a positive integer")
+	}
+	return nil
+}
+
+// Decode returns the raw plaintext and context.
+func (e *EncryptDTO) Decode() ([]byte, []byte, error) {
+	plaintext, err := base64.StdEncoding.DecodeString(e.Plaintext)
+	if err != nil {
+		return nil, nil, fmt.Errorf("plaintext must be base64")
+	}
+	context, err := base64.StdEncoding.DecodeString(e.Context)
+	if err != nil {
+		return nil, nil, fmt.Errorf("context must be base64")
+	}
+	return plaintext, context, nil
+}
+
+func (d *DecryptDTO) Validate() error {
+	if err := validator.Validate(d); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	return nil
+}
+
+// Decode returns the raw context.
+func (d *DecryptDTO) Decode() ([]byte, error) {
+	context, err := base64.StdEncoding.DecodeString(d.Context)
+	if err != nil {
+		return nil, fmt.Errorf("context must be base64")
+	}
+	return context, nil
+}
+
+// Decode returns the raw input.
+func (s *SignDTO) Decode() ([]byte, error) {
+	input, err := base64.StdEncoding.DecodeString(s.Input)
+	if err != nil {
+		return nil, fmt.Errorf("input must be base64")
+	}
+	return input, nil
+}
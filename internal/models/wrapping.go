@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"vault/pkg/validator"
+)
+
+// WrapTTLHeader asks for the response to be wrapped, its value is the lifetime of the
+// wrapping token in seconds or as a duration like 5m.
+const WrapTTLHeader = "X-Vault-Wrap-TTL"
+
+// WrappingTokenPrefix is the prefix of wrapping tokens.
+const WrappingTokenPrefix = "wrp."
+
+// WrappingModel describes a wrapped response, ttl is the lifetime of the wrapping token in seconds.
+type WrappingModel struct {
+	Accessor     string        `json:"accessor"`
+	CreationPath string        `json:"creation_path"`
+	TTL          time.Duration `json:"ttl"`
+	CreatedAt    time.Time     `json:"creation_time"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+// WrappedResponse is a stored wrapped response, the payload is encrypted with the data key
+// which is wrapped with the keyring like the data keys of vaults.
+type WrappedResponse struct {
+	WrappingModel
+	DataKey string
+	Payload string
+}
+
+// WrappingAAD is the additional data the payload of a wrapped response is encrypted with.
+func WrappingAAD(accessor string) []byte {
+	return []byte(WrappingTokenPrefix + accessor)
+}
+
+// WrapInfoModel is returned instead of a wrapped response, the token is shown only once.
+type WrapInfoModel struct {
+	Token string `json:"token"`
+	WrappingModel
+}
+
+type WrappingTokenDTO struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (w *WrappingTokenDTO) Validate() error {
+	if err := validator.Validate(w); err != "" {
+		return fmt.Errorf("validation error: %s", err)
+	}
+	return nil
+}
+
+// ParseWrapTTL parses the value of the wrap ttl header, limit caps it unless it is 0.
+func ParseWrapTTL(value string, limit time.Duration) (time.Duration, error) {
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid %s header: %q", WrapTTLHeader, value)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+	if ttl < time.Second {
+		return 0, fmt.Errorf("%s must be at least 1 second", WrapTTLHeader)
+	}
+	if limit > 0 && ttl > limit {
+		return 0, fmt.Errorf("%s must not exceed %d seconds", WrapTTLHeader, limit/time.Second)
+	}
+	return ttl.Truncate(time.Second), nil
+}
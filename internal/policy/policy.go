@@ -0,0 +1,189 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Create     = "create"
+	Read       = "read"
+	Update     = "update"
+	Delete     = "delete"
+	List       = "list"
+	IssueToken = "issue-token"
+	Destroy    = "destroy"
+)
+
+// Capabilities lists every capability a rule can grant.
+var Capabilities = []string{Create, Read, Update, Delete, List, IssueToken, Destroy}
+
+// RootName is reserved for the break-glass root token identity.
+const RootName = "root"
+
+var (
+	ErrEmptyPolicy = errors.New("policy must contain at least one rule")
+	ErrPolicyName  = errors.New("policy name must contain only lowercase letters, digits, '-' and '_'")
+
+	namePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+// Rule grants capabilities on resources matching Path.
+// A trailing '*' matches any suffix, '+' matches exactly one path segment.
+type Rule struct {
+	Path         string   `yaml:"path" json:"path"`
+	Capabilities []string `yaml:"capabilities" json:"capabilities"`
+}
+
+type Policy struct {
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Parse reads a YAML (or JSON) policy document.
+func Parse(name string, document []byte) (Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(document, &p); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	p.Name = name
+
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+func (p Policy) Validate() error {
+	if !ValidName(p.Name) || p.Name == RootName {
+		return ErrPolicyName
+	}
+	if len(p.Rules) == 0 {
+		return ErrEmptyPolicy
+	}
+	for _, rule := range p.Rules {
+		if strings.TrimSpace(rule.Path) == "" {
+			return fmt.Errorf("rule path can't be empty")
+		}
+		if strings.Contains(strings.TrimSuffix(rule.Path, "*"), "*") {
+			return fmt.Errorf("rule path %q may only end with '*'", rule.Path)
+		}
+		if len(rule.Capabilities) == 0 {
+			return fmt.Errorf("rule %q has no capabilities", rule.Path)
+		}
+		for _, capability := range rule.Capabilities {
+			if !slices.Contains(Capabilities, capability) {
+				return fmt.Errorf("unknown capability %q in rule %q", capability, rule.Path)
+			}
+		}
+	}
+	return nil
+}
+
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// ACL is the union of the rules of every policy bound to an identity.
+type ACL struct {
+	root  bool
+	rules []Rule
+}
+
+func NewACL(policies ...Policy) *ACL {
+	acl := &ACL{}
+	for _, p := range policies {
+		acl.rules = append(acl.rules, p.Rules...)
+	}
+	return acl
+}
+
+// RootACL allows everything, it is only granted to the break-glass root token.
+func RootACL() *ACL {
+	return &ACL{root: true}
+}
+
+func (a *ACL) IsRoot() bool {
+	return a.root
+}
+
+// Allowed reports whether any rule grants the capability on the resource.
+// A resource ending with * stands for every resource starting with the rest,
+// only rules ending with * can grant it.
+func (a *ACL) Allowed(capability string, resource string) bool {
+	if a.root {
+		return true
+	}
+	prefix, tree := strings.CutSuffix(resource, "*")
+	for _, rule := range a.rules {
+		if !slices.Contains(rule.Capabilities, capability) {
+			continue
+		}
+		if tree && !strings.HasSuffix(rule.Path, "*") {
+			continue
+		}
+		if Match(rule.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the ACL grants every capability of every rule of the policy,
+// binding the policy to someone else then grants nothing the ACL does not have.
+func (a *ACL) Covers(p Policy) bool {
+	for _, rule := range p.Rules {
+		for _, capability := range rule.Capabilities {
+			if !a.Allowed(capability, rule.Path) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Match reports whether the resource matches the rule path.
+func Match(pattern string, resource string) bool {
+	prefix := strings.HasSuffix(pattern, "*")
+	pattern = strings.TrimSuffix(pattern, "*")
+
+	if !strings.Contains(pattern, "+") {
+		if prefix {
+			return strings.HasPrefix(resource, pattern)
+		}
+		return pattern == resource
+	}
+
+	patternSegments := strings.Split(pattern, "/")
+	resourceSegments := strings.Split(resource, "/")
+	if len(resourceSegments) < len(patternSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		last := i == len(patternSegments)-1
+		switch {
+		case segment == "+":
+			continue
+		case last && prefix:
+			if !strings.HasPrefix(resourceSegments[i], segment) {
+				return false
+			}
+		case segment != resourceSegments[i]:
+			return false
+		}
+	}
+
+	return prefix || len(resourceSegments) == len(patternSegments)
+}
+
+// Identity is the authenticated admin of a request.
+type Identity struct {
+	Name string
+	ACL  *ACL
+}
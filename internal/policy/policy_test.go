@@ -0,0 +1,110 @@
+package policy_test
+
+import (
+	"testing"
+	"vault/internal/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		Pattern  string
+		Resource string
+		Match    bool
+	}{
+		{"payments", "payments", true},
+		{"payments", "payments-prod", false},
+		{"payments*", "payments-prod", true},
+		{"payments/*", "payments/prod/db", true},
+		{"payments/*", "billing/prod", false},
+		{"*", "anything", true},
+		{"team/+/prod", "team/payments/prod", true},
+		{"team/+/prod", "team/payments/dev", false},
+		{"team/+/prod", "team/payments/prod/db", false},
+		{"team/+/prod*", "team/payments/prod/db", true},
+		{"team/+/p*", "team/payments/prod", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Pattern+" "+tt.Resource, func(t *testing.T) {
+			assert.Equal(t, tt.Match, policy.Match(tt.Pattern, tt.Resource))
+		})
+	}
+}
+
+func TestACL(t *testing.T) {
+	payments, err := policy.Parse("payments", []byte(`
+rules:
+  - path: "payments*"
+    capabilities: [read, list, issue-token]
+`))
+	require.NoError(t, err)
+
+	admin, err := policy.Parse("admin", []byte(`{"rules": [{"path": "sys/policies", "capabilities": ["create", "read"]}]}`))
+	require.NoError(t, err)
+
+	acl := policy.NewACL(payments, admin)
+	assert.True(t, acl.Allowed(policy.Read, "payments-prod"))
+	assert.True(t, acl.Allowed(policy.IssueToken, "payments-dev"))
+	assert.False(t, acl.Allowed(policy.Update, "payments-prod"))
+	assert.False(t, acl.Allowed(policy.Read, "billing"))
+	assert.True(t, acl.Allowed(policy.Create, "sys/policies"))
+	assert.False(t, acl.IsRoot())
+
+	assert.True(t, policy.RootACL().Allowed(policy.Delete, "anything"))
+}
+
+func TestACLTree(t *testing.T) {
+	team, err := policy.Parse("team", []byte(`
+rules:
+  - path: "team/payments/*"
+    capabilities: [list]
+  - path: "team/+/prod"
+    capabilities: [list]
+  - path: "team/billing/+"
+    capabilities: [issue-token]
+`))
+	require.NoError(t, err)
+
+	acl := policy.NewACL(team)
+	assert.True(t, acl.Allowed(policy.List, "team/payments/*"))
+	assert.True(t, acl.Allowed(policy.List, "team/payments/prod/*"))
+	assert.False(t, acl.Allowed(policy.List, "team/*"))
+	assert.False(t, acl.Allowed(policy.List, "team/billing/prod/*"), "rules without * grant single paths only")
+	assert.True(t, acl.Allowed(policy.IssueToken, "team/billing/db"))
+	assert.False(t, acl.Allowed(policy.IssueToken, "team/billing/*"))
+}
+
+func TestACLCovers(t *testing.T) {
+	parse := func(document string) policy.Policy {
+		p, err := policy.Parse("p", []byte(document))
+		require.NoError(t, err)
+		return p
+	}
+
+	acl := policy.NewACL(parse(`{"rules": [{"path": "team/*", "capabilities": ["read", "update"]}, {"path": "sys/identities", "capabilities": ["create"]}]}`))
+
+	assert.True(t, acl.Covers(parse(`{"rules": [{"path": "team/payments/*", "capabilities": ["read"]}]}`)))
+	assert.True(t, acl.Covers(parse(`{"rules": [{"path": "team/+/prod", "capabilities": ["read", "update"]}]}`)))
+	assert.False(t, acl.Covers(parse(`{"rules": [{"path": "team/payments/*", "capabilities": ["read", "delete"]}]}`)))
+	assert.False(t, acl.Covers(parse(`{"rules": [{"path": "*", "capabilities": ["read"]}]}`)))
+	assert.False(t, acl.Covers(parse(`{"rules": [{"path": "team*", "capabilities": ["read"]}]}`)))
+	assert.False(t, acl.Covers(parse(`{"rules": [{"path": "sys/*", "capabilities": ["create"]}]}`)))
+	assert.True(t, policy.RootACL().Covers(parse(`{"rules": [{"path": "*", "capabilities": ["destroy"]}]}`)))
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := policy.Parse("bad", []byte(`rules: [{path: "a", capabilities: [sudo]}]`))
+	assert.Error(t, err)
+
+	_, err = policy.Parse("bad", []byte(`rules: []`))
+	assert.ErrorIs(t, err, policy.ErrEmptyPolicy)
+
+	_, err = policy.Parse("root", []byte(`rules: [{path: "a", capabilities: [read]}]`))
+	assert.ErrorIs(t, err, policy.ErrPolicyName)
+
+	_, err = policy.Parse("bad", []byte(`rules: [{path: "a*b", capabilities: [read]}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"vault/internal/models"
+	"vault/internal/transit"
+	mwAuth "vault/pkg/lib/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Resolvers map admin requests to the vault names policies are evaluated against.
+// Requests that can't be resolved are denied, only missing vaults pass through so
+// the handler reports them.
+
+// vaultFromID resolves the vault of the {id} url param.
+func (h *RootHandlerClient) vaultFromID(r *http.Request) ([]string, error) {
+	param := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid vault id %q", mwAuth.ErrInvalidRequest, param)
+	}
+	return h.vaultNames(r.Context(), []int{id})
+}
+
+// vaultFromQuery resolves the vault of the ?vault_id query param, fallback is used without it.
+func (h *RootHandlerClient) vaultFromQuery(fallback string) func(r *http.Request) ([]string, error) {
+	return func(r *http.Request) ([]string, error) {
+		param := r.URL.Query().Get("vault_id")
+		if param == "" {
+			return []string{fallback}, nil
+		}
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid vault_id %q", mwAuth.ErrInvalidRequest, param)
+		}
+		return h.vaultNames(r.Context(), []int{id})
+	}
+}
+
+// vaultFromBody resolves the name of a vault about to be created.
+func (h *RootHandlerClient) vaultFromBody(r *http.Request) ([]string, error) {
+	model, err := mwAuth.Body[models.SecretCreateModel](r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+	}
+	if model.Name == "" {
+		return nil, fmt.Errorf("%w: vault name is required", mwAuth.ErrInvalidRequest)
+	}
+	return []string{model.Name}, nil
+}
+
+// renameFromBody resolves the name a vault is renamed to by an update, it is empty when the
+// name stays the same. Moving a vault needs create on the new name like creating it there.
+func (h *RootHandlerClient) renameFromBody(r *http.Request) ([]string, error) {
+	model, err := mwAuth.Body[models.SecretCreateModel](r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+	}
+	names, err := h.vaultFromID(r)
+	if err != nil || names == nil {
+		return names, err
+	}
+	if model.Name == "" || model.Name == names[0] {
+		return []string{}, nil
+	}
+	return []string{model.Name}, nil
+}
+
+// vaultsFromTokenBody resolves every vault, path and transit key a new token is bound to,
+// subtree paths ending with * have to be granted by rules ending with *.
+func (h *RootHandlerClient) vaultsFromTokenBody(r *http.Request) ([]string, error) {
+	model, err := mwAuth.Body[models.CreateVaultTokenDTO](r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+	}
+	names, err := h.vaultNames(r.Context(), model.Vaults())
+	if err != nil || names == nil {
+		return names, err
+	}
+	names = append(names, model.Paths...)
+	for _, name := range model.TransitKeys {
+		names = append(names, transit.KeyResource(name))
+	}
+	return names, nil
+}
+
+// childrenResource resolves the subtree below the path of the * url param.
+func (h *RootHandlerClient) childrenResource(r *http.Request) ([]string, error) {
+	prefix := models.CleanPrefix(chi.URLParam(r, "*"))
+	if prefix == "" {
+		return []string{"*"}, nil
+	}
+	return []string{prefix + "/*"}, nil
+}
+
+// vaultNames returns nil when any of the vaults does not exist.
+func (h *RootHandlerClient) vaultNames(ctx context.Context, ids []int) ([]string, error) {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		name, err := h.rootDBClient.GetVaultName(ctx, h.log, id)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -2,12 +2,21 @@ package root
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"vault/internal/audit"
 	"vault/internal/config"
 	"vault/internal/models"
+	"vault/internal/policy"
+	"vault/internal/seal"
 	"vault/pkg/handlers"
+	"vault/pkg/lib/encryption"
 	"vault/pkg/lib/jwt"
 	"vault/pkg/lib/logger/sl"
 	mwAuth "vault/pkg/lib/middleware"
@@ -18,32 +27,72 @@ import (
 )
 
 var (
-	ErrNotFound = "vault not found"
+	ErrNotFound        = "vault not found"
+	ErrVaultExists     = "vault already exists"
+	ErrVersionNotFound = "version not found"
+	ErrTokenNotFound   = "token not found"
+	ErrVersionMismatch = "vault version mismatch"
+	ErrCASRequired     = "check-and-set version required"
 )
 
+// TokensResource is the policy resource of token revocation by jti and listing across vaults.
+const TokensResource = "sys/tokens"
+
+var identityKey mwAuth.ContextKey = "identity"
+
 type RootHandlerClient struct {
 	rootDBClient RootDB
 	log          *slog.Logger
-	secret       string
+	signer       jwt.Signer
+	keeper       encryption.Keeper
+	maxTTL       time.Duration
 }
 
-func AddRootRouter(r chi.Router, rootClient RootDB, log *slog.Logger, cfg *config.Config) func(r chi.Router) {
-	client := NewRootHandlerClient(rootClient, log, cfg.Secret)
+func AddRootRouter(r chi.Router, rootClient RootDB, identities mwAuth.IdentityStore, wrappings mwAuth.WrappingStore, log *slog.Logger, cfg *config.Config, vaultSeal *seal.Seal, signer jwt.Signer) func(r chi.Router) {
+	client := NewRootHandlerClient(rootClient, log, signer, vaultSeal, cfg.Token.MaxTTL)
 
 	return func(r chi.Router) {
-		r.Use(mwAuth.RootAuth(log, cfg.RootToken))
+		r.Use(mwAuth.Unsealed(log, vaultSeal))
+		r.Use(mwAuth.RootAuth(log, cfg.RootToken, identities))
+
+		authorize := func(capability string, resolve mwAuth.Resolver) func(http.Handler) http.Handler {
+			return mwAuth.Authorize(log, capability, resolve)
+		}
+		wrap := mwAuth.Wrap(log, wrappings, vaultSeal, cfg.Wrapping.MaxTTL)
 
-		r.Post("/create", client.CreateVault(context.TODO()))
-		r.Get("/get/{id}", client.GetVault(context.TODO()))
-		r.Post("/create-token", client.CreateVaultToken(context.TODO()))
+		r.With(mwAuth.DecodeBody[models.SecretCreateModel](log), authorize(policy.Create, client.vaultFromBody)).Post("/create", client.CreateVault(context.TODO()))
+		r.Get("/vaults", client.ListVaults(context.TODO()))
+		r.With(authorize(policy.List, client.childrenResource)).Get("/vault/children/*", client.ListChildren(context.TODO()))
+		r.With(client.vaultByPath, authorize(policy.Read, client.vaultFromID), wrap).Get("/vault/by-path/*", client.GetVault(context.TODO()))
+		r.With(client.vaultByPath, mwAuth.DecodeBody[models.SecretCreateModel](log), authorize(policy.Update, client.vaultFromID), authorize(policy.Create, client.renameFromBody)).Put("/vault/by-path/*", client.UpdateVault(context.TODO()))
+		r.With(client.vaultByPath, authorize(policy.Update, client.vaultFromID)).Patch("/vault/by-path/*", client.PatchVault(context.TODO()))
+		r.With(client.vaultByPath, authorize(policy.Delete, client.vaultFromID)).Delete("/vault/by-path/*", client.DeleteVault(context.TODO()))
+		r.With(authorize(policy.Read, client.vaultFromID), wrap).Get("/get/{id}", client.GetVault(context.TODO()))
+		r.With(mwAuth.DecodeBody[models.CreateVaultTokenDTO](log), authorize(policy.IssueToken, client.vaultsFromTokenBody), wrap).Post("/create-token", client.CreateVaultToken(context.TODO()))
+		r.With(mwAuth.DecodeBody[models.SecretCreateModel](log), authorize(policy.Update, client.vaultFromID), authorize(policy.Create, client.renameFromBody)).Put("/vault/{id}", client.UpdateVault(context.TODO()))
+		r.With(authorize(policy.Update, client.vaultFromID)).Patch("/vault/{id}", client.PatchVault(context.TODO()))
+		r.With(authorize(policy.Delete, client.vaultFromID)).Delete("/vault/{id}", client.DeleteVault(context.TODO()))
+		r.With(authorize(policy.Read, client.vaultFromID)).Get("/vault/{id}/versions", client.ListVersions(context.TODO()))
+		r.With(authorize(policy.Read, client.vaultFromID)).Get("/vault/{id}/metadata", client.GetMetadata(context.TODO()))
+		r.With(authorize(policy.Update, client.vaultFromID)).Put("/vault/{id}/metadata", client.UpdateMetadata(context.TODO()))
+		r.With(authorize(policy.Update, client.vaultFromID)).Post("/vault/{id}/rollback", client.RollbackVault(context.TODO()))
+		r.With(authorize(policy.Delete, client.vaultFromID)).Post("/vault/{id}/delete", client.DeleteKeys(context.TODO()))
+		r.With(authorize(policy.Update, client.vaultFromID)).Post("/vault/{id}/undelete", client.UndeleteVault(context.TODO()))
+		r.With(authorize(policy.Destroy, client.vaultFromID)).Post("/vault/{id}/destroy", client.DestroyVault(context.TODO()))
+		r.With(authorize(policy.Delete, mwAuth.Resource(TokensResource))).Post("/token/revoke", client.RevokeToken(context.TODO()))
+		r.With(authorize(policy.IssueToken, client.vaultFromID)).Post("/token/revoke-vault/{id}", client.RevokeVaultTokens(context.TODO()))
+		r.With(authorize(policy.List, client.vaultFromQuery(TokensResource))).Get("/tokens", client.ListTokens(context.TODO()))
 	}
 }
 
-func NewRootHandlerClient(rootClient RootDB, log *slog.Logger, secret string) *RootHandlerClient {
+// NewRootHandlerClient creates the admin handlers, maxTTL limits the lifetime of user tokens (0 sets no limit).
+func NewRootHandlerClient(rootClient RootDB, log *slog.Logger, signer jwt.Signer, keeper encryption.Keeper, maxTTL time.Duration) *RootHandlerClient {
 	return &RootHandlerClient{
 		rootDBClient: rootClient,
 		log:          log,
-		secret:       secret,
+		signer:       signer,
+		keeper:       keeper,
+		maxTTL:       maxTTL,
 	}
 }
 
@@ -56,8 +105,7 @@ func (h *RootHandlerClient) CreateVault(ctx context.Context) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		var model models.SecretCreateModel
-		err := render.DecodeJSON(r.Body, &model)
+		model, err := mwAuth.Body[models.SecretCreateModel](r)
 		if err != nil {
 			h.log.Error("failed to decode model", sl.OpErr(op, err))
 			handlers.ErrorResponse(w, r, 400, "failed to decode model")
@@ -69,20 +117,63 @@ func (h *RootHandlerClient) CreateVault(ctx context.Context) http.HandlerFunc {
 			return
 		}
 
-		//TODO: add data encode
+		envelope, err := h.keeper.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, err.Error())
+			return
+		}
+
+		generated, err := model.GenerateValues()
+		if err != nil {
+			h.log.Error("failed to generate values", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to generate values")
+			return
+		}
+
+		dto := model.ConvertToDTO()
+		if identity, ok := r.Context().Value(identityKey).(policy.Identity); ok {
+			dto.CreatedBy = identity.Name
+		}
+		// the id is reserved up front so the values can be bound to it when they are encrypted
+		dto.ID, err = h.rootDBClient.NextVaultID(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to reserve vault id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create vault")
+			return
+		}
+		if err := dto.Encrypt(envelope, dto.ID); err != nil {
+			h.log.Error("failed to encrypt vault data", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to encrypt vault data")
+			return
+		}
 
-		id, err := h.rootDBClient.CreateVault(ctx, h.log, model.ConvertToDTO())
+		id, err := h.rootDBClient.CreateVault(ctx, h.log, dto)
 		if err != nil {
+			if err.Error() == ErrVaultExists {
+				h.log.Error("vault already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
 			h.log.Error("failed to save new vault on database", sl.OpErr(op, err))
 			handlers.ErrorResponse(w, r, 500, err.Error())
 			return
 		}
 
-		handlers.SuccessResponse(w, r, 201, map[string]any{
+		record := audit.FromContext(r.Context())
+		record.SetVaults(id)
+		record.SetKeys(audit.MapKeys(model.Data))
+
+		res := map[string]any{
 			"message": "new vault successfully created",
 			"id":      id,
-		})
-		h.log.Info("new vault successfully created", "id", id)
+		}
+		if len(generated) > 0 {
+			res["generated"] = generated
+		}
+		setETag(w, 1)
+		handlers.SuccessResponse(w, r, 201, res)
+		h.log.Info("new vault successfully created", "id", id, "generated", len(generated))
 	}
 }
 
@@ -95,21 +186,24 @@ func (h *RootHandlerClient) GetVault(ctx context.Context) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		id := chi.URLParam(r, "id")
-		if id == "" {
-			h.log.Error("query param id is empty")
-			handlers.ErrorResponse(w, r, 400, "query param id is empty")
+		idInt, ok := h.vaultID(w, r, op)
+		if !ok {
 			return
 		}
-		idInt, err := strconv.Atoi(id)
-		if err != nil {
-			h.log.Error("failed to convert id to integer", sl.OpErr(op, err))
-			handlers.ErrorResponse(w, r, 400, "query parameter must be int")
+		version, ok := h.versionParam(w, r, op)
+		if !ok {
 			return
 		}
-		model, err := h.rootDBClient.GetVault(ctx, h.log, idInt)
+
+		var model models.SecretModel
+		var err error
+		if version == 0 {
+			model, err = h.rootDBClient.GetVault(ctx, h.log, idInt)
+		} else {
+			model, err = h.rootDBClient.GetVaultVersion(ctx, h.log, idInt, version)
+		}
 		if err != nil {
-			if err.Error() == ErrNotFound {
+			if err.Error() == ErrNotFound || err.Error() == ErrVersionNotFound {
 				h.log.Error("vault not found", sl.OpErr(op, err))
 				handlers.ErrorResponse(w, r, 404, err.Error())
 				return
@@ -119,13 +213,139 @@ func (h *RootHandlerClient) GetVault(ctx context.Context) http.HandlerFunc {
 			return
 		}
 
-		//TODO: add data decode
+		envelope, err := h.keeper.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, err.Error())
+			return
+		}
+
+		if err := model.Decrypt(envelope); err != nil {
+			h.log.Error("failed to decrypt vault data", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to decrypt vault data")
+			return
+		}
 
+		record := audit.FromContext(r.Context())
+		record.SetVersion(model.Version)
+		record.SetKeys(audit.MapKeys(model.Data))
+
+		setETag(w, model.Version)
 		handlers.SuccessResponse(w, r, 200, model)
 		h.log.Info("secret vault successfully getted")
 	}
 }
 
+// ListVaults returns a page of vault summaries, vaults the identity can't list are left out of the page.
+func (h *RootHandlerClient) ListVaults(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.ListVaults"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		identity, ok := r.Context().Value(identityKey).(policy.Identity)
+		if !ok {
+			h.log.Error("failed to get identity from context", slog.String("op", op))
+			handlers.ErrorResponse(w, r, 401, "unauthorized")
+			return
+		}
+
+		filter, err := vaultFilter(r)
+		if err != nil {
+			h.log.Error("invalid query params", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, err.Error())
+			return
+		}
+		if err := filter.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, err.Error())
+			return
+		}
+
+		page, err := h.listVisible(ctx, identity, filter)
+		if err != nil {
+			h.log.Error("failed to list vaults", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		ids := make([]int, 0, len(page.Vaults))
+		for _, vault := range page.Vaults {
+			ids = append(ids, vault.ID)
+		}
+		audit.FromContext(r.Context()).SetVaults(ids...)
+
+		handlers.SuccessResponse(w, r, 200, page)
+		h.log.Info("vaults successfully listed", "count", len(page.Vaults))
+	}
+}
+
+// listVisible pages through the vaults matching the filter until a full page of vaults the identity
+// may list is found, hidden vaults neither shorten the page nor decide whether a cursor is returned.
+func (h *RootHandlerClient) listVisible(ctx context.Context, identity policy.Identity, filter models.VaultFilter) (models.VaultPage, error) {
+	if identity.ACL.IsRoot() {
+		return h.rootDBClient.ListVaults(ctx, h.log, filter)
+	}
+
+	visible := make([]models.VaultModel, 0, filter.Limit+1)
+	batch := filter
+	batch.Limit = models.MaxVaultLimit
+	for {
+		page, err := h.rootDBClient.ListVaults(ctx, h.log, batch)
+		if err != nil {
+			return models.VaultPage{}, err
+		}
+		for _, vault := range page.Vaults {
+			if identity.ACL.Allowed(policy.List, vault.Name) {
+				visible = append(visible, vault)
+			}
+		}
+		if len(visible) > filter.Limit || page.NextCursor == "" {
+			break
+		}
+		if batch.After, err = models.DecodeVaultCursor(page.NextCursor); err != nil {
+			return models.VaultPage{}, err
+		}
+	}
+
+	return filter.Page(visible), nil
+}
+
+// ListChildren returns the next path segments below the path, segments ending with / have vaults further down.
+func (h *RootHandlerClient) ListChildren(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.ListChildren"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		prefix := models.CleanPrefix(chi.URLParam(r, "*"))
+		if prefix != "" && !models.ValidPath(prefix) {
+			h.log.Error("invalid vault path", slog.String("path", prefix))
+			handlers.ErrorResponse(w, r, 400, "invalid vault path")
+			return
+		}
+
+		children, err := h.rootDBClient.ListChildren(ctx, h.log, prefix)
+		if err != nil {
+			h.log.Error("failed to list children", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"path":     prefix,
+			"children": children,
+		})
+		h.log.Info("vault children successfully listed", "path", prefix, "count", len(children))
+	}
+}
+
 func (h *RootHandlerClient) CreateVaultToken(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "root.handlers.CreateVaultToken"
@@ -135,9 +355,8 @@ func (h *RootHandlerClient) CreateVaultToken(ctx context.Context) http.HandlerFu
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		var model models.CreateVaultTokenDTO
-
-		if err := render.Decode(r, &model); err != nil {
+		model, err := mwAuth.Body[models.CreateVaultTokenDTO](r)
+		if err != nil {
 			h.log.Error("failed to decode model", sl.OpErr(op, err))
 			handlers.ErrorResponse(w, r, 400, "failed to decode model")
 			return
@@ -148,30 +367,926 @@ func (h *RootHandlerClient) CreateVaultToken(ctx context.Context) http.HandlerFu
 			handlers.ErrorResponse(w, r, 422, err.Error())
 			return
 		}
+		if err := model.CheckTTL(h.maxTTL); err != nil {
+			h.log.Error("token lifetime exceeds the server limit", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
 
-		checkVault := h.rootDBClient.CheckVault(ctx, h.log, model.VaultID)
-		if checkVault != nil {
-			h.log.Error("failed to check vault", sl.Err(checkVault))
-			if checkVault.Error() == ErrNotFound {
-				h.log.Error("vault not found", sl.Err(checkVault))
-				handlers.ErrorResponse(w, r, 404, checkVault.Error())
+		vaultIDs := model.Vaults()
+		for _, vaultID := range vaultIDs {
+			checkVault := h.rootDBClient.CheckVault(ctx, h.log, vaultID)
+			if checkVault != nil {
+				h.log.Error("failed to check vault", sl.Err(checkVault))
+				if checkVault.Error() == ErrNotFound {
+					h.log.Error("vault not found", sl.Err(checkVault), slog.Int("vault_id", vaultID))
+					handlers.ErrorResponse(w, r, 404, checkVault.Error())
+					return
+				}
+				h.log.Error("failed to check vault", sl.OpErr(op, checkVault))
+				handlers.ErrorResponse(w, r, 500, checkVault.Error())
 				return
 			}
-			h.log.Error("failed to check vault", sl.OpErr(op, checkVault))
-			handlers.ErrorResponse(w, r, 500, checkVault.Error())
+		}
+
+		jti, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create token id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create new token")
 			return
 		}
 
-		token, err := jwt.CreateToken(model.VaultID, h.secret, model.Expires)
+		claims := models.NewTokenModel(jti, vaultIDs, model.Paths, model.Expires, model.Scope())
+		claims.TransitKeys = model.TransitKeys
+		if model.Renewable {
+			claims.Lease(models.LeaseTTL(model.MaxTTL, h.maxTTL))
+		}
+		token, err := h.signer.CreateToken(ctx, claims)
 		if err != nil {
 			h.log.Error("failed to create new token", sl.OpErr(op, err))
 			handlers.ErrorResponse(w, r, 500, "failed to create new token")
 			return
 		}
 
+		if err := h.rootDBClient.CreateToken(ctx, h.log, claims.Info()); err != nil {
+			h.log.Error("failed to save token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		record := audit.FromContext(r.Context())
+		record.SetVaults(vaultIDs...)
+		record.SetToken(jti)
+
 		handlers.SuccessResponse(w, r, 201,
-			map[string]string{"token": token},
+			map[string]string{"token": token, "jti": jti},
+		)
+		h.log.Info("vault token successfully created", "jti", jti)
+	}
+}
+
+func (h *RootHandlerClient) RevokeToken(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.RevokeToken"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.RevokeTokenDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		jti := model.JTI
+		if jti == "" {
+			claims, err := h.signer.DecodeToken(ctx, model.Token)
+			if err != nil {
+				h.log.Error("failed to decode token", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 400, "invalid token")
+				return
+			}
+			jti = claims.RegisteredClaims.ID
+		}
+
+		audit.FromContext(r.Context()).SetToken(jti)
+
+		if err := h.rootDBClient.RevokeToken(ctx, h.log, jti); err != nil {
+			if err.Error() == ErrTokenNotFound {
+				h.log.Error("token not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to revoke token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "token successfully revoked",
+			"jti":     jti,
+		})
+		h.log.Info("token successfully revoked", "jti", jti)
+	}
+}
+
+func (h *RootHandlerClient) RevokeVaultTokens(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.RevokeVaultTokens"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		if err := h.rootDBClient.CheckVault(ctx, h.log, id); err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to check vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		count, err := h.rootDBClient.RevokeVaultTokens(ctx, h.log, id)
+		if err != nil {
+			h.log.Error("failed to revoke vault tokens", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault tokens successfully revoked",
+			"id":      id,
+			"revoked": count,
+		})
+		h.log.Info("vault tokens successfully revoked", "id", id, "revoked", count)
+	}
+}
+
+func (h *RootHandlerClient) ListTokens(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.ListTokens"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var vaultID int
+		if param := r.URL.Query().Get("vault_id"); param != "" {
+			id, err := strconv.Atoi(param)
+			if err != nil {
+				h.log.Error("failed to convert vault_id to integer", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 400, "query parameter vault_id must be int")
+				return
+			}
+			vaultID = id
+		}
+
+		tokens, err := h.rootDBClient.ListTokens(ctx, h.log, vaultID)
+		if err != nil {
+			h.log.Error("failed to list tokens", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"tokens": tokens,
+		})
+		h.log.Info("tokens successfully listed", "count", len(tokens))
+	}
+}
+
+func (h *RootHandlerClient) UpdateVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.UpdateVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
-		h.log.Info("vault token successfully created")
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		model, err := mwAuth.Body[models.SecretCreateModel](r)
+		if err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		if model.CAS, ok = h.casParam(w, r, op, model.CAS); !ok {
+			return
+		}
+
+		vault, ok := h.currentVault(ctx, w, r, op, id)
+		if !ok {
+			return
+		}
+
+		envelope, err := h.keeper.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, err.Error())
+			return
+		}
+
+		generated, err := model.GenerateValues()
+		if err != nil {
+			h.log.Error("failed to generate values", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to generate values")
+			return
+		}
+
+		dto := model.ConvertToDTO()
+		dto.DataKey = vault.DataKey
+		if err := dto.Encrypt(envelope, id); err != nil {
+			h.log.Error("failed to encrypt vault data", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to encrypt vault data")
+			return
+		}
+
+		version, err := h.rootDBClient.UpdateVault(ctx, h.log, id, dto)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			if err.Error() == ErrVaultExists {
+				h.log.Error("vault already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			if h.casFailed(w, r, op, err) {
+				return
+			}
+			h.log.Error("failed to update vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		record := audit.FromContext(r.Context())
+		record.SetVersion(version)
+		record.SetKeys(audit.MapKeys(model.Data))
+
+		res := map[string]any{
+			"message": "vault successfully updated",
+			"id":      id,
+			"version": version,
+		}
+		if len(generated) > 0 {
+			res["generated"] = generated
+		}
+		setETag(w, version)
+		handlers.SuccessResponse(w, r, 200, res)
+		h.log.Info("vault successfully updated", "id", id, "version", version, "generated", len(generated))
+	}
+}
+
+func (h *RootHandlerClient) PatchVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.PatchVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		var model models.SecretPatchModel
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		if model.CAS, ok = h.casParam(w, r, op, model.CAS); !ok {
+			return
+		}
+
+		vault, ok := h.currentVault(ctx, w, r, op, id)
+		if !ok {
+			return
+		}
+
+		envelope, err := h.keeper.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, err.Error())
+			return
+		}
+
+		dto := model.ConvertToDTO()
+		dto.DataKey = vault.DataKey
+		if err := dto.Encrypt(envelope, id); err != nil {
+			h.log.Error("failed to encrypt vault data", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to encrypt vault data")
+			return
+		}
+
+		version, err := h.rootDBClient.PatchVault(ctx, h.log, id, dto)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			if h.casFailed(w, r, op, err) {
+				return
+			}
+			h.log.Error("failed to patch vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		record := audit.FromContext(r.Context())
+		record.SetVersion(version)
+		record.SetKeys(audit.MapKeys(model.Data))
+
+		setETag(w, version)
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault successfully updated",
+			"id":      id,
+			"version": version,
+		})
+		h.log.Info("vault successfully patched", "id", id, "version", version)
+	}
+}
+
+func (h *RootHandlerClient) DeleteVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.DeleteVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		cas, ok := h.casParam(w, r, op, 0)
+		if !ok {
+			return
+		}
+
+		if err := h.rootDBClient.DeleteVault(ctx, h.log, id, cas); err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			if h.casFailed(w, r, op, err) {
+				return
+			}
+			h.log.Error("failed to delete vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault successfully deleted",
+			"id":      id,
+		})
+		h.log.Info("vault successfully deleted", "id", id)
+	}
+}
+
+// DeleteKeys soft deletes keys of the vault, they are hidden until undeleted, destroyed or purged.
+func (h *RootHandlerClient) DeleteKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.DeleteKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+		model, ok := h.keysBody(w, r, op)
+		if !ok {
+			return
+		}
+		if len(model.Keys) == 0 {
+			h.log.Error("validate error", slog.String("op", op), slog.String("error", "keys are empty"))
+			handlers.ErrorResponse(w, r, 422, "validation error: field keys is a required")
+			return
+		}
+
+		if err := h.rootDBClient.DeleteKeys(ctx, h.log, id, model.Keys); err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete vault keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		audit.FromContext(r.Context()).SetKeys(model.Keys)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault keys successfully deleted",
+			"id":      id,
+			"keys":    model.Keys,
+		})
+		h.log.Info("vault keys successfully deleted", "id", id, "keys", len(model.Keys))
+	}
+}
+
+// UndeleteVault restores a soft deleted vault, or soft deleted keys of a live vault when keys are given.
+func (h *RootHandlerClient) UndeleteVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.UndeleteVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+		model, ok := h.keysBody(w, r, op)
+		if !ok {
+			return
+		}
+
+		var err error
+		if len(model.Keys) == 0 {
+			err = h.rootDBClient.UndeleteVault(ctx, h.log, id)
+		} else {
+			err = h.rootDBClient.UndeleteKeys(ctx, h.log, id, model.Keys)
+		}
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			if err.Error() == ErrVaultExists {
+				h.log.Error("vault path is taken", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			h.log.Error("failed to undelete vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		audit.FromContext(r.Context()).SetKeys(model.Keys)
+
+		res := map[string]any{
+			"message": "vault successfully undeleted",
+			"id":      id,
+		}
+		if len(model.Keys) > 0 {
+			res["keys"] = model.Keys
+		}
+		handlers.SuccessResponse(w, r, 200, res)
+		h.log.Info("vault successfully undeleted", "id", id, "keys", len(model.Keys))
+	}
+}
+
+// DestroyVault irreversibly wipes a live or soft deleted vault, or keys of a live vault when keys are given.
+func (h *RootHandlerClient) DestroyVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.DestroyVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+		model, ok := h.keysBody(w, r, op)
+		if !ok {
+			return
+		}
+
+		var err error
+		if len(model.Keys) == 0 {
+			err = h.rootDBClient.DestroyVault(ctx, h.log, id)
+		} else {
+			err = h.rootDBClient.DestroyKeys(ctx, h.log, id, model.Keys)
+		}
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to destroy vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		audit.FromContext(r.Context()).SetKeys(model.Keys)
+
+		res := map[string]any{
+			"message": "vault successfully destroyed",
+			"id":      id,
+		}
+		if len(model.Keys) > 0 {
+			res["keys"] = model.Keys
+		}
+		handlers.SuccessResponse(w, r, 200, res)
+		h.log.Info("vault successfully destroyed", "id", id, "keys", len(model.Keys))
+	}
+}
+
+func (h *RootHandlerClient) ListVersions(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.ListVersions"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		versions, err := h.rootDBClient.ListVersions(ctx, h.log, id)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to list vault versions", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"id":       id,
+			"versions": versions,
+		})
+		h.log.Info("vault versions successfully listed", "id", id)
+	}
+}
+
+// GetMetadata returns the plaintext metadata of a vault, it never carries values.
+func (h *RootHandlerClient) GetMetadata(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.GetMetadata"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		metadata, err := h.rootDBClient.GetMetadata(ctx, h.log, id)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to get vault metadata", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, metadata)
+		h.log.Info("vault metadata successfully got", "id", id)
+	}
+}
+
+// UpdateMetadata edits the metadata of a vault without creating a new version.
+func (h *RootHandlerClient) UpdateMetadata(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.UpdateMetadata"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		var model models.MetadataDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		model.Tags = models.NormalizeTags(model.Tags)
+
+		if err := h.rootDBClient.UpdateMetadata(ctx, h.log, id, model); err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to update vault metadata", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault metadata successfully updated",
+			"id":      id,
+		})
+		h.log.Info("vault metadata successfully updated", "id", id)
+	}
+}
+
+func (h *RootHandlerClient) RollbackVault(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "root.handlers.RollbackVault"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.vaultID(w, r, op)
+		if !ok {
+			return
+		}
+
+		var model models.RollbackDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		if model.CAS, ok = h.casParam(w, r, op, model.CAS); !ok {
+			return
+		}
+
+		version, err := h.rootDBClient.RollbackVault(ctx, h.log, id, model.Version, model.CAS)
+		if err != nil {
+			if err.Error() == ErrNotFound || err.Error() == ErrVersionNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			if h.casFailed(w, r, op, err) {
+				return
+			}
+			h.log.Error("failed to rollback vault", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		setETag(w, version)
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "vault successfully rolled back",
+			"id":      id,
+			"version": version,
+		})
+		h.log.Info("vault successfully rolled back", "id", id, "from", model.Version, "version", version)
+	}
+}
+
+// vaultFilter reads the listing query params, without sort and order params the order of the cursor is kept.
+func vaultFilter(r *http.Request) (models.VaultFilter, error) {
+	query := r.URL.Query()
+	filter := models.VaultFilter{
+		Prefix:    query.Get("prefix"),
+		Tags:      query["tag"],
+		Owner:     query.Get("owner"),
+		CreatedBy: query.Get("created_by"),
+		Sort:      models.SortName,
+		Limit:     models.DefaultVaultLimit,
+	}
+
+	for _, attr := range query["attr"] {
+		key, value, found := strings.Cut(attr, ":")
+		if !found {
+			return filter, fmt.Errorf("attr must be key:value")
+		}
+		if filter.Attributes == nil {
+			filter.Attributes = map[string]string{}
+		}
+		filter.Attributes[key] = value
+	}
+
+	switch query.Get("deleted") {
+	case "", "false":
+	case "true":
+		filter.Deleted = true
+	default:
+		return filter, fmt.Errorf("deleted must be true or false")
+	}
+	if sort := query.Get("sort"); sort != "" {
+		filter.Sort = sort
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+	if limit := query.Get("limit"); limit != "" {
+		limitInt, err := strconv.Atoi(limit)
+		if err != nil {
+			return filter, fmt.Errorf("limit must be int")
+		}
+		filter.Limit = limitInt
+	}
+	for param, value := range map[string]*time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if query.Get(param) == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, query.Get(param))
+		if err != nil {
+			return filter, fmt.Errorf("%s must be a RFC 3339 timestamp", param)
+		}
+		*value = t
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := models.DecodeVaultCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = after
+		if query.Get("sort") == "" {
+			filter.Sort = after.Sort
+		}
+		if query.Get("order") == "" {
+			filter.Desc = after.Desc
+		}
+	}
+
+	return filter, nil
+}
+
+// versionParam reads the optional ?version query param, 0 means the current version.
+func (h *RootHandlerClient) versionParam(w http.ResponseWriter, r *http.Request, op string) (int, bool) {
+	version := r.URL.Query().Get("version")
+	if version == "" {
+		return 0, true
+	}
+	versionInt, err := strconv.Atoi(version)
+	if err != nil || versionInt < 1 {
+		h.log.Error("invalid version query param", slog.String("op", op), slog.String("version", version))
+		handlers.ErrorResponse(w, r, 400, "version must be a positive integer")
+		return 0, false
+	}
+	return versionInt, true
+}
+
+// keysBody reads the optional keys body, on failure the error response is already written.
+func (h *RootHandlerClient) keysBody(w http.ResponseWriter, r *http.Request, op string) (models.KeysDTO, bool) {
+	var model models.KeysDTO
+	if err := render.DecodeJSON(r.Body, &model); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error("failed to decode model", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, "failed to decode model")
+		return model, false
+	}
+	if err := model.Validate(); err != nil {
+		h.log.Error("validate error", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 422, err.Error())
+		return model, false
+	}
+	return model, true
+}
+
+// casParam merges the If-Match header into the cas body field, the header carries the vault version
+// as returned in the ETag header and * leaves the body field. On failure the error response is already written.
+func (h *RootHandlerClient) casParam(w http.ResponseWriter, r *http.Request, op string, cas int) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" || header == "*" {
+		return cas, true
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 1 {
+		h.log.Error("invalid If-Match header", slog.String("op", op), slog.String("if_match", header))
+		handlers.ErrorResponse(w, r, 400, "If-Match must be a vault version ETag")
+		return 0, false
+	}
+	if cas != 0 && cas != version {
+		h.log.Error("If-Match header and cas field differ", slog.String("op", op), slog.String("if_match", header), slog.Int("cas", cas))
+		handlers.ErrorResponse(w, r, 400, "If-Match header and cas field differ")
+		return 0, false
+	}
+	return version, true
+}
+
+// casFailed writes the response of a failed check-and-set, it reports whether err was one.
+func (h *RootHandlerClient) casFailed(w http.ResponseWriter, r *http.Request, op string, err error) bool {
+	switch err.Error() {
+	case ErrVersionMismatch:
+		h.log.Error("vault version mismatch", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 409, err.Error())
+	case ErrCASRequired:
+		h.log.Error("check-and-set version required", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 428, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// setETag sets the vault version as the ETag accepted by If-Match.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// vaultID reads the {id} url param, on failure the error response is already written.
+func (h *RootHandlerClient) vaultID(w http.ResponseWriter, r *http.Request, op string) (int, bool) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.log.Error("query param id is empty")
+		handlers.ErrorResponse(w, r, 400, "query param id is empty")
+		return 0, false
+	}
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.log.Error("failed to convert id to integer", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, "query parameter must be int")
+		return 0, false
+	}
+	return idInt, true
+}
+
+// vaultByPath resolves the vault path of the * url param to the {id} url param of the vault handlers,
+// on failure the error response is already written.
+func (h *RootHandlerClient) vaultByPath(next http.Handler) http.Handler {
+	const op = "root.handlers.vaultByPath"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := chi.URLParam(r, "*")
+
+		id, err := h.rootDBClient.GetVaultID(r.Context(), h.log, path)
+		if err != nil {
+			if err.Error() == ErrNotFound {
+				h.log.Error("vault not found", sl.OpErr(op, err), slog.String("path", path))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to get vault id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		chi.RouteContext(r.Context()).URLParams.Add("id", strconv.Itoa(id))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// currentVault loads the stored vault, on failure the error response is already written.
+func (h *RootHandlerClient) currentVault(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, id int) (models.SecretModel, bool) {
+	vault, err := h.rootDBClient.GetVault(ctx, h.log, id)
+	if err != nil {
+		if err.Error() == ErrNotFound {
+			h.log.Error("vault not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return models.SecretModel{}, false
+		}
+		h.log.Error("failed to get vault", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+		return models.SecretModel{}, false
 	}
+	return vault, true
 }
@@ -3,16 +3,27 @@ package root_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"vault/internal/config"
 	"vault/internal/models"
+	"vault/internal/policy"
 	"vault/internal/root"
 	"vault/internal/root/mocks"
+	"vault/internal/seal"
+	"vault/internal/storage/memory"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
 	"vault/pkg/lib/logger/slogdiscard"
+	mwAuth "vault/pkg/lib/middleware"
+	"vault/pkg/lib/opaque"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -51,6 +62,12 @@ func TestCreateVault(t *testing.T) {
 			Code:     400,
 			Error:    `{"status":"error","detail":"failed to decode model"}`,
 		},
+		{
+			TestName: "trailing data",
+			Input:    `{"name": "test", "data": {"some": "data"}} x`,
+			Code:     400,
+			Error:    `{"status":"error","detail":"failed to decode model"}`,
+		},
 		{
 			TestName: "failed decode",
 			Input:    `{"name": "test", "data": {"": ""}}`,
@@ -64,6 +81,19 @@ func TestCreateVault(t *testing.T) {
 			Error:     `{"status":"error","detail":"failed to save new vault on database"}`,
 			MockError: errors.New("failed to save new vault on database"),
 		},
+		{
+			TestName:  "duplicate path",
+			Input:     `{"name": "team/payments", "data": {"fd": "fasf"}}`,
+			Code:      409,
+			Error:     `{"status":"error","detail":"vault already exists"}`,
+			MockError: errors.New("vault already exists"),
+		},
+		{
+			TestName: "invalid path",
+			Input:    `{"name": "team//payments", "data": {"fd": "fasf"}}`,
+			Code:     422,
+			Error:    `{"status":"error","detail":"invalid vault path: \"team//payments\""}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -72,12 +102,15 @@ func TestCreateVault(t *testing.T) {
 			rootDb := mocks.NewRootDB(t)
 
 			if tt.Error == "" || tt.MockError != nil {
+				rootDb.On("NextVaultID", context.Background(), log).
+					Return(int(1), nil).
+					Once()
 				rootDb.On("CreateVault", context.Background(), log, mock.Anything).
 					Return(int(1), tt.MockError).
 					Once()
 			}
 
-			rootHandlers := root.NewRootHandlerClient(rootDb, log, "")
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
 			handler := rootHandlers.CreateVault(context.Background())
 
 			req, err := http.NewRequest(http.MethodPost, "/create", bytes.NewReader([]byte(tt.Input)))
@@ -98,6 +131,61 @@ func TestCreateVault(t *testing.T) {
 	}
 }
 
+func TestCreateVaultGenerate(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	rootDb := mocks.NewRootDB(t)
+
+	var stored models.SecretCreateDTO
+	rootDb.On("NextVaultID", context.Background(), log).
+		Return(int(7), nil).
+		Once()
+	rootDb.On("CreateVault", context.Background(), log, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(models.SecretCreateDTO) }).
+		Return(int(7), nil).
+		Once()
+
+	keeper := newKeeper(t)
+	rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), keeper, 0)
+	handler := rootHandlers.CreateVault(context.Background())
+
+	input := `{"name": "test", "data": {"user": "admin", "password": {"generate": {"type": "password", "length": 24}}}}`
+	req, err := http.NewRequest(http.MethodPost, "/create", strings.NewReader(input))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, 201, rr.Code)
+
+	var res struct {
+		Generated map[string]string `json:"generated"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.Len(t, res.Generated, 1, "only generated values are returned")
+	password := res.Generated["password"]
+	assert.Len(t, password, 24)
+
+	require.Len(t, stored.Data, 2)
+	for _, value := range stored.Data {
+		assert.True(t, value.Encrypted)
+		assert.NotEqual(t, password, value.Value)
+	}
+
+	// values are bound to the reserved vault id
+	require.Equal(t, 7, stored.ID)
+	envelope, err := keeper.Envelope()
+	require.NoError(t, err)
+	data := make(map[string]string)
+	for _, value := range stored.Data {
+		data[value.Key] = value.Value
+	}
+	vault := models.SecretModel{ID: 7, Data: data, DataKey: stored.DataKey}
+	require.NoError(t, vault.Decrypt(envelope))
+	assert.Equal(t, password, vault.Data["password"])
+
+	vault = models.SecretModel{ID: 8, Data: data, DataKey: stored.DataKey}
+	assert.Error(t, vault.Decrypt(envelope), "values can't be moved to another vault")
+}
+
 func TestGetVault(t *testing.T) {
 	tests := []struct {
 		testName  string
@@ -152,6 +240,7 @@ func TestGetVault(t *testing.T) {
 							Data: map[string]string{
 								"test": "some sekret key",
 							},
+							Plaintext: map[string]bool{"test": true},
 						},
 						tt.mockErr,
 					).Once()
@@ -162,7 +251,7 @@ func TestGetVault(t *testing.T) {
 					Once()
 			}
 
-			rootHandlers := root.NewRootHandlerClient(rootDb, log, "")
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
 			handler := rootHandlers.GetVault(context.Background())
 
 			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/get/%v", tt.id), nil)
@@ -182,3 +271,1331 @@ func TestGetVault(t *testing.T) {
 		})
 	}
 }
+
+type staticKeeper struct {
+	envelope *encryption.Envelope
+}
+
+func (k staticKeeper) Envelope() (*encryption.Envelope, error) {
+	return k.envelope, nil
+}
+
+func newKeeper(t *testing.T) encryption.Keeper {
+	key, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	envelope, err := encryption.NewEnvelope(key)
+	require.NoError(t, err)
+	return staticKeeper{envelope: envelope}
+}
+
+func TestUpdateVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		id        int
+		input     string
+		ifMatch   string
+		cas       int
+		code      int
+		outputStr string
+		getErr    error
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}}`,
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully updated","version":2}`,
+		},
+		{
+			testName:  "failed data",
+			id:        2,
+			input:     `{"name": "test"}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"validation error: field data is a required"}`,
+		},
+		{
+			testName:  "not found",
+			id:        99,
+			input:     `{"name": "test", "data": {"some": "data"}}`,
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			getErr:    errors.New("vault not found"),
+		},
+		{
+			testName:  "failed to update vault",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}}`,
+			code:      500,
+			outputStr: `{"status":"error","detail":"failed to update vault"}`,
+			mockErr:   errors.New("failed to update vault"),
+		},
+		{
+			testName:  "cas field",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}, "cas": 1}`,
+			cas:       1,
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully updated","version":2}`,
+		},
+		{
+			testName:  "version mismatch",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}}`,
+			ifMatch:   `"1"`,
+			cas:       1,
+			code:      409,
+			outputStr: `{"status":"error","detail":"vault version mismatch"}`,
+			mockErr:   errors.New("vault version mismatch"),
+		},
+		{
+			testName:  "cas required",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}}`,
+			code:      428,
+			outputStr: `{"status":"error","detail":"check-and-set version required"}`,
+			mockErr:   errors.New("check-and-set version required"),
+		},
+		{
+			testName:  "if match and cas differ",
+			id:        2,
+			input:     `{"name": "test", "data": {"some": "data"}, "cas": 2}`,
+			ifMatch:   `"1"`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"If-Match header and cas field differ"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code != 422 && tt.code != 400 {
+				rootDb.On("GetVault", context.Background(), log, tt.id).
+					Return(models.SecretModel{ID: tt.id}, tt.getErr).
+					Once()
+			}
+			if tt.getErr == nil && tt.code != 422 && tt.code != 400 {
+				rootDb.On("UpdateVault", context.Background(), log, tt.id, mock.MatchedBy(func(dto models.SecretCreateDTO) bool {
+					return dto.DataKey != "" && len(dto.Data) == 1 && dto.Data[0].Value != "data" && dto.CAS == tt.cas
+				})).
+					Return(2, tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.UpdateVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("/vault/%v", tt.id), bytes.NewReader([]byte(tt.input)))
+			require.NoError(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", fmt.Sprintf("%v", tt.id))
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestUpdateVaultRename(t *testing.T) {
+	router := newRouter(t, map[string]string{
+		"scoped": "rules:\n  - path: team/a/*\n    capabilities: [create, update]\n",
+		"update": "rules:\n  - path: team/a/*\n    capabilities: [update]\n",
+	})
+
+	rr := serve(t, router, http.MethodPost, "/root/create", "root", `{"name": "team/a/x", "data": {"k": "v"}}`)
+	require.Equal(t, 201, rr.Code)
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	tests := []struct {
+		testName string
+		token    string
+		path     string
+		name     string
+		code     int
+	}{
+		{
+			testName: "out of scope",
+			token:    "scoped-token",
+			path:     fmt.Sprintf("/root/vault/%d", created.ID),
+			name:     "team/b/x",
+			code:     403,
+		},
+		{
+			testName: "reserved path",
+			token:    "scoped-token",
+			path:     "/root/vault/by-path/team/a/x",
+			name:     "sys/x",
+			code:     403,
+		},
+		{
+			testName: "same name without create",
+			token:    "update-token",
+			path:     fmt.Sprintf("/root/vault/%d", created.ID),
+			name:     "team/a/x",
+			code:     200,
+		},
+		{
+			testName: "rename without create",
+			token:    "update-token",
+			path:     fmt.Sprintf("/root/vault/%d", created.ID),
+			name:     "team/a/y",
+			code:     403,
+		},
+		{
+			testName: "rename in scope",
+			token:    "scoped-token",
+			path:     "/root/vault/by-path/team/a/x",
+			name:     "team/a/y",
+			code:     200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			rr := serve(t, router, http.MethodPut, tt.path, tt.token, fmt.Sprintf(`{"name": %q, "data": {"k": "v"}}`, tt.name))
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestPatchVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		code      int
+		outputStr string
+		set       int
+		remove    []string
+	}{
+		{
+			testName:  "set and remove",
+			input:     `{"data": {"new": "value", "old": null}}`,
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully updated","version":3}`,
+			set:       1,
+			remove:    []string{"old"},
+		},
+		{
+			testName:  "empty value",
+			input:     `{"data": {"new": " "}}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"key or value length can't be 0"}`,
+		},
+		{
+			testName:  "empty data",
+			input:     `{"data": {}}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"data can't be empty"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code == 200 {
+				rootDb.On("GetVault", context.Background(), log, 2).
+					Return(models.SecretModel{ID: 2}, nil).
+					Once()
+				rootDb.On("PatchVault", context.Background(), log, 2, mock.MatchedBy(func(dto models.SecretPatchDTO) bool {
+					return len(dto.Data) == tt.set && assert.ObjectsAreEqual(tt.remove, dto.Remove)
+				})).
+					Return(3, nil).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.PatchVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodPatch, "/vault/2", bytes.NewReader([]byte(tt.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestDeleteVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		id        string
+		ifMatch   string
+		cas       int
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			id:        "2",
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully deleted"}`,
+		},
+		{
+			testName:  "not found",
+			id:        "99",
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+		{
+			testName:  "invalid id",
+			id:        "abc",
+			code:      400,
+			outputStr: `{"status":"error","detail":"query parameter must be int"}`,
+		},
+		{
+			testName:  "if match",
+			id:        "2",
+			ifMatch:   `"3"`,
+			cas:       3,
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully deleted"}`,
+		},
+		{
+			testName:  "version mismatch",
+			id:        "2",
+			ifMatch:   `W/"3"`,
+			cas:       3,
+			code:      409,
+			outputStr: `{"status":"error","detail":"vault version mismatch"}`,
+			mockErr:   errors.New("vault version mismatch"),
+		},
+		{
+			testName:  "cas required",
+			id:        "2",
+			code:      428,
+			outputStr: `{"status":"error","detail":"check-and-set version required"}`,
+			mockErr:   errors.New("check-and-set version required"),
+		},
+		{
+			testName:  "invalid if match",
+			id:        "2",
+			ifMatch:   `"abc"`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"If-Match must be a vault version ETag"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code != 400 {
+				id, _ := strconv.Atoi(tt.id)
+				rootDb.On("DeleteVault", context.Background(), log, id, tt.cas).
+					Return(tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.DeleteVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/vault/%v", tt.id), nil)
+			require.NoError(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", tt.id)
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestDeleteKeys(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			input:     `{"keys": ["a", "b"]}`,
+			code:      200,
+			outputStr: `{"id":2,"keys":["a","b"],"message":"vault keys successfully deleted"}`,
+		},
+		{
+			testName:  "not found",
+			input:     `{"keys": ["a", "b"]}`,
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+		{
+			testName:  "missing keys",
+			code:      422,
+			outputStr: `{"status":"error","detail":"validation error: field keys is a required"}`,
+		},
+		{
+			testName:  "empty key",
+			input:     `{"keys": [""]}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"key length can't be 0"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code != 422 {
+				rootDb.On("DeleteKeys", context.Background(), log, 2, []string{"a", "b"}).
+					Return(tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.DeleteKeys(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/vault/2/delete", strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestUndeleteVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		method    string
+		args      []any
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "vault",
+			method:    "UndeleteVault",
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully undeleted"}`,
+		},
+		{
+			testName:  "path taken",
+			method:    "UndeleteVault",
+			code:      409,
+			outputStr: `{"status":"error","detail":"vault already exists"}`,
+			mockErr:   errors.New("vault already exists"),
+		},
+		{
+			testName:  "not found",
+			method:    "UndeleteVault",
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+		{
+			testName:  "keys",
+			input:     `{"keys": ["a"]}`,
+			method:    "UndeleteKeys",
+			args:      []any{[]string{"a"}},
+			code:      200,
+			outputStr: `{"id":2,"keys":["a"],"message":"vault successfully undeleted"}`,
+		},
+		{
+			testName:  "invalid body",
+			input:     `{"keys": "a"}`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"failed to decode model"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.method != "" {
+				rootDb.On(tt.method, append([]any{context.Background(), log, 2}, tt.args...)...).
+					Return(tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.UndeleteVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/vault/2/undelete", strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestDestroyVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		method    string
+		args      []any
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "vault",
+			method:    "DestroyVault",
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully destroyed"}`,
+		},
+		{
+			testName:  "not found",
+			method:    "DestroyVault",
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+		{
+			testName:  "keys",
+			input:     `{"keys": ["a", "b"]}`,
+			method:    "DestroyKeys",
+			args:      []any{[]string{"a", "b"}},
+			code:      200,
+			outputStr: `{"id":2,"keys":["a","b"],"message":"vault successfully destroyed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			rootDb.On(tt.method, append([]any{context.Background(), log, 2}, tt.args...)...).
+				Return(tt.mockErr).
+				Once()
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.DestroyVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/vault/2/destroy", strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestGetVaultVersion(t *testing.T) {
+	tests := []struct {
+		testName  string
+		version   string
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			version:   "1",
+			code:      200,
+			outputStr: `{"id":2,"name":"Test","version":1,"data":{"test":"old value"}}`,
+		},
+		{
+			testName:  "version not found",
+			version:   "7",
+			code:      404,
+			outputStr: `{"status":"error","detail":"version not found"}`,
+			mockErr:   errors.New("version not found"),
+		},
+		{
+			testName:  "invalid version",
+			version:   "-1",
+			code:      400,
+			outputStr: `{"status":"error","detail":"version must be a positive integer"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code != 400 {
+				version, _ := strconv.Atoi(tt.version)
+				rootDb.On("GetVaultVersion", context.Background(), log, 2, version).
+					Return(models.SecretModel{
+						ID:        2,
+						Name:      "Test",
+						Version:   version,
+						Data:      map[string]string{"test": "old value"},
+						Plaintext: map[string]bool{"test": true},
+					}, tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.GetVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodGet, "/get/2?version="+tt.version, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+			if tt.code == 200 {
+				assert.Equal(t, `"`+tt.version+`"`, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestRollbackVault(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			input:     `{"version": 1}`,
+			code:      200,
+			outputStr: `{"id":2,"message":"vault successfully rolled back","version":4}`,
+		},
+		{
+			testName:  "version not found",
+			input:     `{"version": 9}`,
+			code:      404,
+			outputStr: `{"status":"error","detail":"version not found"}`,
+			mockErr:   errors.New("version not found"),
+		},
+		{
+			testName:  "missing version",
+			input:     `{}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"validation error: field version is a required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code != 422 {
+				rootDb.On("RollbackVault", context.Background(), log, 2, mock.AnythingOfType("int"), 0).
+					Return(4, tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.RollbackVault(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/vault/2/rollback", bytes.NewReader([]byte(tt.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := models.MetadataModel{
+		ID:          2,
+		Name:        "payments",
+		Description: "card processing",
+		Owner:       "team-payments",
+		Tags:        []string{"prod"},
+		Attributes:  map[string]string{"tier": "1"},
+		CreatedBy:   "ci",
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}
+
+	tests := []struct {
+		testName  string
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			code:      200,
+			outputStr: `{"id":2,"name":"payments","description":"card processing","owner":"team-payments","contact_email":"","tags":["prod"],"attributes":{"tier":"1"},"created_by":"ci","created_at":"2024-01-02T03:04:05Z","updated_at":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			testName:  "not found",
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			rootDb.On("GetMetadata", context.Background(), log, 2).
+				Return(stored, tt.mockErr).
+				Once()
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.GetMetadata(context.Background())
+
+			req, err := http.NewRequest(http.MethodGet, "/vault/2/metadata", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+// newRouter serves the admin routes from memory storage, every policy is bound to an
+// identity of the same name whose token is the name with a -token suffix.
+func newRouter(t *testing.T, policies map[string]string) http.Handler {
+	t.Helper()
+
+	log := slogdiscard.NewDiscardLogger()
+	store := memory.New(10)
+
+	sealConfig, keys, err := seal.Initialize(nil, 1, 1)
+	require.NoError(t, err)
+	vaultSeal := seal.New()
+	require.NoError(t, vaultSeal.Unseal(sealConfig, nil, keys[0]))
+
+	for name, document := range policies {
+		require.NoError(t, store.PutPolicy(context.Background(), log, models.PolicyModel{Name: name, Document: document}))
+		_, err := store.CreateIdentity(context.Background(), log, models.IdentityModel{Name: name, Policies: []string{name}}, opaque.Hash(name+"-token"))
+		require.NoError(t, err)
+	}
+
+	router := chi.NewRouter()
+	router.Route("/root", root.AddRootRouter(router, store, store, store, log, &config.Config{RootToken: "root"}, vaultSeal, jwt.Static("")))
+	return router
+}
+
+func serve(t *testing.T, router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetMetadataRequiresRead(t *testing.T) {
+	router := newRouter(t, map[string]string{
+		"list": "rules:\n  - path: payments\n    capabilities: [list]\n",
+		"read": "rules:\n  - path: payments\n    capabilities: [read]\n",
+	})
+
+	rr := serve(t, router, http.MethodPost, "/root/create", "root", `{"name": "payments", "data": {"k": "v"}}`)
+	require.Equal(t, 201, rr.Code)
+	var created struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+
+	tests := []struct {
+		testName string
+		token    string
+		code     int
+	}{
+		{
+			testName: "list only",
+			token:    "list-token",
+			code:     403,
+		},
+		{
+			testName: "read",
+			token:    "read-token",
+			code:     200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			rr := serve(t, router, http.MethodGet, fmt.Sprintf("/root/vault/%d/metadata", created.ID), tt.token, "")
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	owner := "team-payments"
+
+	tests := []struct {
+		testName  string
+		input     string
+		dto       *models.MetadataDTO
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			input:     `{"owner": "team-payments", "tags": ["prod", "pci", "prod"], "attributes": {"tier": "1"}}`,
+			dto:       &models.MetadataDTO{Owner: &owner, Tags: []string{"pci", "prod"}, Attributes: map[string]string{"tier": "1"}},
+			code:      200,
+			outputStr: `{"id":2,"message":"vault metadata successfully updated"}`,
+		},
+		{
+			testName:  "not found",
+			input:     `{"owner": "team-payments"}`,
+			dto:       &models.MetadataDTO{Owner: &owner},
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			mockErr:   errors.New("vault not found"),
+		},
+		{
+			testName:  "empty",
+			input:     `{}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"metadata can't be empty"}`,
+		},
+		{
+			testName:  "invalid email",
+			input:     `{"contact_email": "payments"}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"invalid contact email: \"payments\""}`,
+		},
+		{
+			testName:  "invalid attribute",
+			input:     `{"attributes": {"cost center": "42"}}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"invalid attribute: \"cost center\""}`,
+		},
+		{
+			testName:  "invalid body",
+			input:     `{"tags": "prod"}`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"failed to decode model"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.dto != nil {
+				rootDb.On("UpdateMetadata", context.Background(), log, 2, *tt.dto).
+					Return(tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.UpdateMetadata(context.Background())
+
+			req, err := http.NewRequest(http.MethodPut, "/vault/2/metadata", strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("id", "2")
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestCreateVaultToken(t *testing.T) {
+	tests := []struct {
+		testName    string
+		input       string
+		code        int
+		outputStr   string
+		checkErr    error
+		saveErr     error
+		vaultIDs    []int
+		transitKeys []string
+	}{
+		{
+			testName: "success",
+			input:    `{"vault_id": 2, "expires": 3600}`,
+			code:     201,
+			vaultIDs: []int{2},
+		},
+		{
+			testName: "multiple vaults",
+			input:    `{"vault_ids": [2, 3, 2], "expires": 3600}`,
+			code:     201,
+			vaultIDs: []int{2, 3},
+		},
+		{
+			testName:  "vault not found",
+			input:     `{"vault_id": 99, "expires": 3600}`,
+			code:      404,
+			outputStr: `{"status":"error","detail":"vault not found"}`,
+			checkErr:  errors.New("vault not found"),
+			vaultIDs:  []int{99},
+		},
+		{
+			testName:  "failed to save token",
+			input:     `{"vault_id": 2, "expires": 3600}`,
+			code:      500,
+			outputStr: `{"status":"error","detail":"failed to save token"}`,
+			saveErr:   errors.New("failed to save token"),
+			vaultIDs:  []int{2},
+		},
+		{
+			testName:  "missing vault",
+			input:     `{"expires": 3600}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"validation error: field vault_id, vault_ids, paths or transit_keys is a required"}`,
+		},
+		{
+			testName:    "transit keys",
+			input:       `{"transit_keys": ["payments"], "expires": 3600, "capabilities": ["encrypt", "decrypt"]}`,
+			code:        201,
+			transitKeys: []string{"payments"},
+		},
+		{
+			testName:  "invalid transit key",
+			input:     `{"transit_keys": ["pay ments"], "expires": 3600}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"invalid transit key name: \"pay ments\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			for _, id := range tt.vaultIDs {
+				rootDb.On("CheckVault", context.Background(), log, id).
+					Return(tt.checkErr).
+					Once()
+			}
+			if tt.checkErr == nil && (tt.vaultIDs != nil || tt.transitKeys != nil) {
+				rootDb.On("CreateToken", context.Background(), log, mock.MatchedBy(func(info models.TokenInfoModel) bool {
+					return info.JTI != "" && assert.ObjectsAreEqual(tt.vaultIDs, info.VaultIDs) &&
+						assert.ObjectsAreEqual(tt.transitKeys, info.TransitKeys) && info.ExpiresAt.After(info.IssuedAt)
+				})).
+					Return(tt.saveErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static("secret"), newKeeper(t), 0)
+			handler := rootHandlers.CreateVaultToken(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/create-token", bytes.NewReader([]byte(tt.input)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			if tt.outputStr != "" {
+				assert.Equal(t, tt.outputStr, body)
+			} else {
+				assert.Contains(t, body, `"token":`)
+			}
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	tests := []struct {
+		testName  string
+		input     string
+		code      int
+		outputStr string
+		mockErr   error
+	}{
+		{
+			testName:  "success",
+			input:     `{"jti": "abc"}`,
+			code:      200,
+			outputStr: `{"jti":"abc","message":"token successfully revoked"}`,
+		},
+		{
+			testName:  "not found",
+			input:     `{"jti": "abc"}`,
+			code:      404,
+			outputStr: `{"status":"error","detail":"token not found"}`,
+			mockErr:   errors.New("token not found"),
+		},
+		{
+			testName:  "invalid token",
+			input:     `{"token": "not a jwt"}`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"invalid token"}`,
+		},
+		{
+			testName:  "empty body",
+			input:     `{}`,
+			code:      422,
+			outputStr: `{"status":"error","detail":"validation error: field token or jti is a required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code == 200 || tt.code == 404 {
+				rootDb.On("RevokeToken", context.Background(), log, "abc").
+					Return(tt.mockErr).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static("secret"), newKeeper(t), 0)
+			handler := rootHandlers.RevokeToken(context.Background())
+
+			req, err := http.NewRequest(http.MethodPost, "/token/revoke", bytes.NewReader([]byte(tt.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestListVaults(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := models.VaultPage{
+		Vaults: []models.VaultModel{
+			{ID: 1, Name: "payments", Tags: []string{"prod"}, Version: 2, KeyCount: 3, CreatedAt: created},
+			{ID: 2, Name: "billing", Tags: []string{}, Version: 1, KeyCount: 1, CreatedAt: created},
+		},
+		NextCursor: "next",
+	}
+	cursor := models.VaultCursor{Sort: models.SortCreatedAt, Desc: true, ID: 5}
+
+	tests := []struct {
+		testName  string
+		query     string
+		identity  policy.Identity
+		filter    models.VaultFilter
+		code      int
+		outputStr string
+	}{
+		{
+			testName: "root",
+			query:    "?prefix=pay&tag=prod&tag=team&limit=2&sort=id&order=desc&created_after=2024-01-01T00:00:00Z",
+			identity: policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			filter: models.VaultFilter{
+				Prefix:       "pay",
+				Tags:         []string{"prod", "team"},
+				CreatedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Sort:         models.SortID,
+				Desc:         true,
+				Limit:        2,
+			},
+			code:      200,
+			outputStr: `{"vaults":[{"id":1,"name":"payments","tags":["prod"],"version":2,"key_count":3,"created_at":"2024-01-02T03:04:05Z"},{"id":2,"name":"billing","tags":[],"version":1,"key_count":1,"created_at":"2024-01-02T03:04:05Z"}],"next_cursor":"next"}`,
+		},
+		{
+			testName: "metadata",
+			query:    "?owner=team-payments&created_by=ci&attr=tier:1&attr=cost-center:42",
+			identity: policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			filter: models.VaultFilter{
+				Owner:      "team-payments",
+				CreatedBy:  "ci",
+				Attributes: map[string]string{"tier": "1", "cost-center": "42"},
+				Sort:       models.SortName,
+				Limit:      models.DefaultVaultLimit,
+			},
+			code:      200,
+			outputStr: `{"vaults":[{"id":1,"name":"payments","tags":["prod"],"version":2,"key_count":3,"created_at":"2024-01-02T03:04:05Z"},{"id":2,"name":"billing","tags":[],"version":1,"key_count":1,"created_at":"2024-01-02T03:04:05Z"}],"next_cursor":"next"}`,
+		},
+		{
+			testName:  "invalid attr",
+			query:     "?attr=tier",
+			identity:  policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			code:      400,
+			outputStr: `{"status":"error","detail":"attr must be key:value"}`,
+		},
+		{
+			testName:  "unknown sort",
+			query:     "?sort=size",
+			identity:  policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			code:      400,
+			outputStr: `{"status":"error","detail":"unknown sort: \"size\""}`,
+		},
+		{
+			testName:  "cursor of another order",
+			query:     "?sort=name&cursor=" + cursor.Encode(),
+			identity:  policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			code:      400,
+			outputStr: `{"status":"error","detail":"cursor does not match the sort order"}`,
+		},
+		{
+			testName:  "invalid date",
+			query:     "?created_before=yesterday",
+			identity:  policy.Identity{Name: policy.RootName, ACL: policy.RootACL()},
+			code:      400,
+			outputStr: `{"status":"error","detail":"created_before must be a RFC 3339 timestamp"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code == 200 {
+				rootDb.On("ListVaults", context.Background(), log, tt.filter).
+					Return(stored, nil).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.ListVaults(context.Background())
+
+			req, err := http.NewRequest(http.MethodGet, "/vaults"+tt.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), mwAuth.ContextKey("identity"), tt.identity))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
+
+func TestListVaultsFilteredByPolicy(t *testing.T) {
+	identity := policy.Identity{Name: "ci", ACL: policy.NewACL(policy.Policy{Name: "ci", Rules: []policy.Rule{
+		{Path: "pay*", Capabilities: []string{policy.List}},
+	}})}
+	vault := func(id int, name string) models.VaultModel {
+		return models.VaultModel{ID: id, Name: name, Tags: []string{}}
+	}
+	cursorAt := func(v models.VaultModel) *models.VaultCursor {
+		return &models.VaultCursor{Sort: models.SortName, ID: v.ID, Name: v.Name}
+	}
+
+	tests := []struct {
+		testName  string
+		limit     int
+		pages     []models.VaultPage
+		outputStr string
+	}{
+		{
+			testName: "hidden vaults don't shorten the page",
+			limit:    2,
+			pages: []models.VaultPage{
+				{Vaults: []models.VaultModel{vault(1, "billing"), vault(2, "payments")}, NextCursor: cursorAt(vault(2, "payments")).Encode()},
+				{Vaults: []models.VaultModel{vault(3, "payouts"), vault(4, "payroll")}},
+			},
+			outputStr: `{"vaults":[{"id":2,"name":"payments","tags":[],"version":0,"key_count":0,"created_at":"0001-01-01T00:00:00Z"},{"id":3,"name":"payouts","tags":[],"version":0,"key_count":0,"created_at":"0001-01-01T00:00:00Z"}],"next_cursor":"` + cursorAt(vault(3, "payouts")).Encode() + `"}`,
+		},
+		{
+			testName: "hidden vaults don't return a cursor",
+			limit:    1,
+			pages: []models.VaultPage{
+				{Vaults: []models.VaultModel{vault(2, "payments"), vault(5, "secrets")}},
+			},
+			outputStr: `{"vaults":[{"id":2,"name":"payments","tags":[],"version":0,"key_count":0,"created_at":"0001-01-01T00:00:00Z"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			filter := models.VaultFilter{Sort: models.SortName, Limit: models.MaxVaultLimit}
+			for _, page := range tt.pages {
+				rootDb.On("ListVaults", context.Background(), log, filter).
+					Return(page, nil).
+					Once()
+				if page.NextCursor != "" {
+					after, err := models.DecodeVaultCursor(page.NextCursor)
+					require.NoError(t, err)
+					filter.After = after
+				}
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.ListVaults(context.Background())
+
+			req, err := http.NewRequest(http.MethodGet, "/vaults?limit="+strconv.Itoa(tt.limit), nil)
+			require.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), mwAuth.ContextKey("identity"), identity))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, 200, rr.Code)
+			assert.Equal(t, tt.outputStr, strings.ReplaceAll(rr.Body.String(), "\n", ""))
+		})
+	}
+}
+
+func TestListChildren(t *testing.T) {
+	tests := []struct {
+		testName  string
+		path      string
+		prefix    string
+		code      int
+		outputStr string
+	}{
+		{
+			testName:  "root",
+			path:      "",
+			prefix:    "",
+			code:      200,
+			outputStr: `{"children":["shared","team/"],"path":""}`,
+		},
+		{
+			testName:  "trailing slash",
+			path:      "team/payments/",
+			prefix:    "team/payments",
+			code:      200,
+			outputStr: `{"children":["shared","team/"],"path":"team/payments"}`,
+		},
+		{
+			testName:  "invalid path",
+			path:      "team/../payments",
+			code:      400,
+			outputStr: `{"status":"error","detail":"invalid vault path"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			log := slogdiscard.NewDiscardLogger()
+			rootDb := mocks.NewRootDB(t)
+
+			if tt.code == 200 {
+				rootDb.On("ListChildren", context.Background(), log, tt.prefix).
+					Return([]string{"shared", "team/"}, nil).
+					Once()
+			}
+
+			rootHandlers := root.NewRootHandlerClient(rootDb, log, jwt.Static(""), newKeeper(t), 0)
+			handler := rootHandlers.ListChildren(context.Background())
+
+			req, err := http.NewRequest(http.MethodGet, "/vault/children/"+tt.path, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			chiCtx := chi.NewRouteContext()
+			reqChi := req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+			chiCtx.URLParams.Add("*", tt.path)
+
+			handler.ServeHTTP(rr, reqChi)
+
+			body := strings.ReplaceAll(rr.Body.String(), "\n", "")
+
+			require.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, body)
+		})
+	}
+}
@@ -4,11 +4,10 @@ package mocks
 
 import (
 	context "context"
+	slog "log/slog"
 	models "vault/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
-
-	slog "log/slog"
 )
 
 // RootDB is an autogenerated mock type for the RootDB type
@@ -34,6 +33,24 @@ func (_m *RootDB) CheckVault(ctx context.Context, log *slog.Logger, id int) erro
 	return r0
 }
 
+// CreateToken provides a mock function with given fields: ctx, log, model
+func (_m *RootDB) CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error {
+	ret := _m.Called(ctx, log, model)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, models.TokenInfoModel) error); ok {
+		r0 = rf(ctx, log, model)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateVault provides a mock function with given fields: ctx, log, model
 func (_m *RootDB) CreateVault(ctx context.Context, log *slog.Logger, model models.SecretCreateDTO) (int, error) {
 	ret := _m.Called(ctx, log, model)
@@ -62,6 +79,106 @@ func (_m *RootDB) CreateVault(ctx context.Context, log *slog.Logger, model model
 	return r0, r1
 }
 
+// DeleteKeys provides a mock function with given fields: ctx, log, id, keys
+func (_m *RootDB) DeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	ret := _m.Called(ctx, log, id, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, []string) error); ok {
+		r0 = rf(ctx, log, id, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteVault provides a mock function with given fields: ctx, log, id, cas
+func (_m *RootDB) DeleteVault(ctx context.Context, log *slog.Logger, id int, cas int) error {
+	ret := _m.Called(ctx, log, id, cas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteVault")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, int) error); ok {
+		r0 = rf(ctx, log, id, cas)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DestroyKeys provides a mock function with given fields: ctx, log, id, keys
+func (_m *RootDB) DestroyKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	ret := _m.Called(ctx, log, id, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DestroyKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, []string) error); ok {
+		r0 = rf(ctx, log, id, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DestroyVault provides a mock function with given fields: ctx, log, id
+func (_m *RootDB) DestroyVault(ctx context.Context, log *slog.Logger, id int) error {
+	ret := _m.Called(ctx, log, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DestroyVault")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) error); ok {
+		r0 = rf(ctx, log, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetMetadata provides a mock function with given fields: ctx, log, id
+func (_m *RootDB) GetMetadata(ctx context.Context, log *slog.Logger, id int) (models.MetadataModel, error) {
+	ret := _m.Called(ctx, log, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMetadata")
+	}
+
+	var r0 models.MetadataModel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) (models.MetadataModel, error)); ok {
+		return rf(ctx, log, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) models.MetadataModel); ok {
+		r0 = rf(ctx, log, id)
+	} else {
+		r0 = ret.Get(0).(models.MetadataModel)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int) error); ok {
+		r1 = rf(ctx, log, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetVault provides a mock function with given fields: ctx, log, id
 func (_m *RootDB) GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error) {
 	ret := _m.Called(ctx, log, id)
@@ -90,6 +207,420 @@ func (_m *RootDB) GetVault(ctx context.Context, log *slog.Logger, id int) (model
 	return r0, r1
 }
 
+// GetVaultID provides a mock function with given fields: ctx, log, path
+func (_m *RootDB) GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error) {
+	ret := _m.Called(ctx, log, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVaultID")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) (int, error)); ok {
+		return rf(ctx, log, path)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) int); ok {
+		r0 = rf(ctx, log, path)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string) error); ok {
+		r1 = rf(ctx, log, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVaultName provides a mock function with given fields: ctx, log, id
+func (_m *RootDB) GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error) {
+	ret := _m.Called(ctx, log, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVaultName")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) (string, error)); ok {
+		return rf(ctx, log, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) string); ok {
+		r0 = rf(ctx, log, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int) error); ok {
+		r1 = rf(ctx, log, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVaultVersion provides a mock function with given fields: ctx, log, id, version
+func (_m *RootDB) GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error) {
+	ret := _m.Called(ctx, log, id, version)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVaultVersion")
+	}
+
+	var r0 models.SecretModel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, int) (models.SecretModel, error)); ok {
+		return rf(ctx, log, id, version)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, int) models.SecretModel); ok {
+		r0 = rf(ctx, log, id, version)
+	} else {
+		r0 = ret.Get(0).(models.SecretModel)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int, int) error); ok {
+		r1 = rf(ctx, log, id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListChildren provides a mock function with given fields: ctx, log, prefix
+func (_m *RootDB) ListChildren(ctx context.Context, log *slog.Logger, prefix string) ([]string, error) {
+	ret := _m.Called(ctx, log, prefix)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChildren")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) ([]string, error)); ok {
+		return rf(ctx, log, prefix)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) []string); ok {
+		r0 = rf(ctx, log, prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, string) error); ok {
+		r1 = rf(ctx, log, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTokens provides a mock function with given fields: ctx, log, vaultID
+func (_m *RootDB) ListTokens(ctx context.Context, log *slog.Logger, vaultID int) ([]models.TokenInfoModel, error) {
+	ret := _m.Called(ctx, log, vaultID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTokens")
+	}
+
+	var r0 []models.TokenInfoModel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) ([]models.TokenInfoModel, error)); ok {
+		return rf(ctx, log, vaultID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) []models.TokenInfoModel); ok {
+		r0 = rf(ctx, log, vaultID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TokenInfoModel)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int) error); ok {
+		r1 = rf(ctx, log, vaultID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListVaults provides a mock function with given fields: ctx, log, filter
+func (_m *RootDB) ListVaults(ctx context.Context, log *slog.Logger, filter models.VaultFilter) (models.VaultPage, error) {
+	ret := _m.Called(ctx, log, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListVaults")
+	}
+
+	var r0 models.VaultPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, models.VaultFilter) (models.VaultPage, error)); ok {
+		return rf(ctx, log, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, models.VaultFilter) models.VaultPage); ok {
+		r0 = rf(ctx, log, filter)
+	} else {
+		r0 = ret.Get(0).(models.VaultPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, models.VaultFilter) error); ok {
+		r1 = rf(ctx, log, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListVersions provides a mock function with given fields: ctx, log, id
+func (_m *RootDB) ListVersions(ctx context.Context, log *slog.Logger, id int) ([]models.VersionModel, error) {
+	ret := _m.Called(ctx, log, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListVersions")
+	}
+
+	var r0 []models.VersionModel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) ([]models.VersionModel, error)); ok {
+		return rf(ctx, log, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) []models.VersionModel); ok {
+		r0 = rf(ctx, log, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.VersionModel)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int) error); ok {
+		r1 = rf(ctx, log, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NextVaultID provides a mock function with given fields: ctx, log
+func (_m *RootDB) NextVaultID(ctx context.Context, log *slog.Logger) (int, error) {
+	ret := _m.Called(ctx, log)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextVaultID")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger) (int, error)); ok {
+		return rf(ctx, log)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger) int); ok {
+		r0 = rf(ctx, log)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger) error); ok {
+		r1 = rf(ctx, log)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchVault provides a mock function with given fields: ctx, log, id, model
+func (_m *RootDB) PatchVault(ctx context.Context, log *slog.Logger, id int, model models.SecretPatchDTO) (int, error) {
+	ret := _m.Called(ctx, log, id, model)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchVault")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, models.SecretPatchDTO) (int, error)); ok {
+		return rf(ctx, log, id, model)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, models.SecretPatchDTO) int); ok {
+		r0 = rf(ctx, log, id, model)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int, models.SecretPatchDTO) error); ok {
+		r1 = rf(ctx, log, id, model)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeToken provides a mock function with given fields: ctx, log, jti
+func (_m *RootDB) RevokeToken(ctx context.Context, log *slog.Logger, jti string) error {
+	ret := _m.Called(ctx, log, jti)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, string) error); ok {
+		r0 = rf(ctx, log, jti)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeVaultTokens provides a mock function with given fields: ctx, log, vaultID
+func (_m *RootDB) RevokeVaultTokens(ctx context.Context, log *slog.Logger, vaultID int) (int, error) {
+	ret := _m.Called(ctx, log, vaultID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeVaultTokens")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) (int, error)); ok {
+		return rf(ctx, log, vaultID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) int); ok {
+		r0 = rf(ctx, log, vaultID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int) error); ok {
+		r1 = rf(ctx, log, vaultID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RollbackVault provides a mock function with given fields: ctx, log, id, version, cas
+func (_m *RootDB) RollbackVault(ctx context.Context, log *slog.Logger, id int, version int, cas int) (int, error) {
+	ret := _m.Called(ctx, log, id, version, cas)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackVault")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, int, int) (int, error)); ok {
+		return rf(ctx, log, id, version, cas)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, int, int) int); ok {
+		r0 = rf(ctx, log, id, version, cas)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int, int, int) error); ok {
+		r1 = rf(ctx, log, id, version, cas)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UndeleteKeys provides a mock function with given fields: ctx, log, id, keys
+func (_m *RootDB) UndeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	ret := _m.Called(ctx, log, id, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UndeleteKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, []string) error); ok {
+		r0 = rf(ctx, log, id, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UndeleteVault provides a mock function with given fields: ctx, log, id
+func (_m *RootDB) UndeleteVault(ctx context.Context, log *slog.Logger, id int) error {
+	ret := _m.Called(ctx, log, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UndeleteVault")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int) error); ok {
+		r0 = rf(ctx, log, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateMetadata provides a mock function with given fields: ctx, log, id, model
+func (_m *RootDB) UpdateMetadata(ctx context.Context, log *slog.Logger, id int, model models.MetadataDTO) error {
+	ret := _m.Called(ctx, log, id, model)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMetadata")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, models.MetadataDTO) error); ok {
+		r0 = rf(ctx, log, id, model)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateVault provides a mock function with given fields: ctx, log, id, model
+func (_m *RootDB) UpdateVault(ctx context.Context, log *slog.Logger, id int, model models.SecretCreateDTO) (int, error) {
+	ret := _m.Called(ctx, log, id, model)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateVault")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, models.SecretCreateDTO) (int, error)); ok {
+		return rf(ctx, log, id, model)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *slog.Logger, int, models.SecretCreateDTO) int); ok {
+		r0 = rf(ctx, log, id, model)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *slog.Logger, int, models.SecretCreateDTO) error); ok {
+		r1 = rf(ctx, log, id, model)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRootDB creates a new instance of RootDB. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRootDB(t interface {
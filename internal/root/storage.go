@@ -8,7 +8,29 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2@v2.43.2 --name=RootDB
 type RootDB interface {
+	NextVaultID(ctx context.Context, log *slog.Logger) (int, error)
 	CreateVault(ctx context.Context, log *slog.Logger, model models.SecretCreateDTO) (int, error)
 	GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error)
+	GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error)
 	CheckVault(ctx context.Context, log *slog.Logger, id int) error
+	GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error)
+	GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error)
+	ListChildren(ctx context.Context, log *slog.Logger, prefix string) ([]string, error)
+	ListVaults(ctx context.Context, log *slog.Logger, filter models.VaultFilter) (models.VaultPage, error)
+	GetMetadata(ctx context.Context, log *slog.Logger, id int) (models.MetadataModel, error)
+	UpdateMetadata(ctx context.Context, log *slog.Logger, id int, model models.MetadataDTO) error
+	UpdateVault(ctx context.Context, log *slog.Logger, id int, model models.SecretCreateDTO) (int, error)
+	PatchVault(ctx context.Context, log *slog.Logger, id int, model models.SecretPatchDTO) (int, error)
+	DeleteVault(ctx context.Context, log *slog.Logger, id int, cas int) error
+	UndeleteVault(ctx context.Context, log *slog.Logger, id int) error
+	DestroyVault(ctx context.Context, log *slog.Logger, id int) error
+	DeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error
+	UndeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error
+	DestroyKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error
+	ListVersions(ctx context.Context, log *slog.Logger, id int) ([]models.VersionModel, error)
+	RollbackVault(ctx context.Context, log *slog.Logger, id int, version int, cas int) (int, error)
+	CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error
+	RevokeToken(ctx context.Context, log *slog.Logger, jti string) error
+	RevokeVaultTokens(ctx context.Context, log *slog.Logger, vaultID int) (int, error)
+	ListTokens(ctx context.Context, log *slog.Logger, vaultID int) ([]models.TokenInfoModel, error)
 }
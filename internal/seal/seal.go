@@ -0,0 +1,193 @@
+package seal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"vault/internal/models"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/shamir"
+)
+
+// verificationText is encrypted with the master key on init to check reconstructed keys.
+const verificationText = "secret-vault-seal-verification"
+
+var (
+	ErrSealed        = errors.New("vault is sealed")
+	ErrInvalidShares = errors.New("unseal keys do not reconstruct the master key")
+)
+
+// Seal holds the master key in memory only while the vault is unsealed.
+type Seal struct {
+	mu       sync.RWMutex
+	envelope *encryption.Envelope
+	shares   [][]byte
+}
+
+func New() *Seal {
+	return &Seal{}
+}
+
+// Envelope implements encryption.Keeper.
+func (s *Seal) Envelope() (*encryption.Envelope, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.envelope == nil {
+		return nil, ErrSealed
+	}
+	return s.envelope, nil
+}
+
+func (s *Seal) Sealed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.envelope == nil
+}
+
+// Progress returns the number of unseal keys provided so far.
+func (s *Seal) Progress() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.shares)
+}
+
+// Seal drops the master key and any collected unseal keys from memory.
+func (s *Seal) Seal() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.envelope = nil
+	s.shares = nil
+}
+
+// Reset discards unseal keys collected so far.
+func (s *Seal) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shares = nil
+}
+
+// Unseal adds a key share, once threshold shares are collected the master key is reconstructed
+// and verified, then the stored keyring terms are installed.
+func (s *Seal) Unseal(config models.SealConfig, keyring []models.KeyringTerm, share []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.envelope != nil {
+		return nil
+	}
+
+	for _, existing := range s.shares {
+		if bytes.Equal(existing, share) {
+			return nil
+		}
+	}
+	s.shares = append(s.shares, share)
+
+	if len(s.shares) < config.Threshold {
+		return nil
+	}
+
+	var masterKey []byte
+	if config.Threshold == 1 {
+		masterKey = s.shares[0]
+	} else {
+		key, err := shamir.Combine(s.shares)
+		if err != nil {
+			s.shares = nil
+			return ErrInvalidShares
+		}
+		masterKey = key
+	}
+	s.shares = nil
+
+	if err := Verify(masterKey, config.Verification); err != nil {
+		return err
+	}
+
+	envelope, err := encryption.NewEnvelope(masterKey)
+	if err != nil {
+		return ErrInvalidShares
+	}
+	envelope, err = envelope.WithTerms(terms(keyring))
+	if err != nil {
+		return err
+	}
+	s.envelope = envelope
+	return nil
+}
+
+// Install adds keyring terms created since unsealing.
+func (s *Seal) Install(keyring []models.KeyringTerm) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.envelope == nil {
+		return ErrSealed
+	}
+	envelope, err := s.envelope.WithTerms(terms(keyring))
+	if err != nil {
+		return err
+	}
+	s.envelope = envelope
+	return nil
+}
+
+func terms(keyring []models.KeyringTerm) map[int]string {
+	terms := make(map[int]string, len(keyring))
+	for _, term := range keyring {
+		terms[term.Term] = term.Key
+	}
+	return terms
+}
+
+// Initialize splits the master key into unseal keys and returns the config to persist.
+// A nil master key generates a new one.
+func Initialize(masterKey []byte, shares int, threshold int) (models.SealConfig, [][]byte, error) {
+	if masterKey == nil {
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			return models.SealConfig{}, nil, err
+		}
+		masterKey = key
+	}
+
+	verification, err := encryption.Encrypt(masterKey, []byte(verificationText))
+	if err != nil {
+		return models.SealConfig{}, nil, err
+	}
+
+	var keys [][]byte
+	if threshold == 1 {
+		keys = [][]byte{masterKey}
+	} else {
+		keys, err = shamir.Split(masterKey, shares, threshold)
+		if err != nil {
+			return models.SealConfig{}, nil, err
+		}
+	}
+
+	config := models.SealConfig{
+		Shares:       shares,
+		Threshold:    threshold,
+		Verification: base64.StdEncoding.EncodeToString(verification),
+	}
+	return config, keys, nil
+}
+
+func Verify(masterKey []byte, verification string) error {
+	raw, err := base64.StdEncoding.DecodeString(verification)
+	if err != nil {
+		return ErrInvalidShares
+	}
+	plaintext, err := encryption.Decrypt(masterKey, raw)
+	if err != nil || string(plaintext) != verificationText {
+		return ErrInvalidShares
+	}
+	return nil
+}
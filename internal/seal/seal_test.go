@@ -0,0 +1,80 @@
+package seal_test
+
+import (
+	"testing"
+	"vault/internal/models"
+	"vault/internal/seal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnseal(t *testing.T) {
+	config, keys, err := seal.Initialize(nil, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, keys, 5)
+
+	vaultSeal := seal.New()
+	require.True(t, vaultSeal.Sealed())
+
+	_, err = vaultSeal.Envelope()
+	assert.ErrorIs(t, err, seal.ErrSealed)
+
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[0]))
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[0]))
+	assert.Equal(t, 1, vaultSeal.Progress())
+
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[3]))
+	assert.True(t, vaultSeal.Sealed())
+
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[4]))
+	assert.False(t, vaultSeal.Sealed())
+	assert.Equal(t, 0, vaultSeal.Progress())
+
+	_, err = vaultSeal.Envelope()
+	require.NoError(t, err)
+
+	vaultSeal.Seal()
+	assert.True(t, vaultSeal.Sealed())
+}
+
+func TestUnsealInvalidKeys(t *testing.T) {
+	config, keys, err := seal.Initialize(nil, 3, 2)
+	require.NoError(t, err)
+
+	_, otherKeys, err := seal.Initialize(nil, 3, 2)
+	require.NoError(t, err)
+
+	vaultSeal := seal.New()
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[0]))
+	assert.ErrorIs(t, vaultSeal.Unseal(config, nil, otherKeys[1]), seal.ErrInvalidShares)
+	assert.True(t, vaultSeal.Sealed())
+	assert.Equal(t, 0, vaultSeal.Progress())
+}
+
+func TestInstall(t *testing.T) {
+	config, keys, err := seal.Initialize(nil, 1, 1)
+	require.NoError(t, err)
+
+	vaultSeal := seal.New()
+	assert.ErrorIs(t, vaultSeal.Install(nil), seal.ErrSealed)
+
+	require.NoError(t, vaultSeal.Unseal(config, nil, keys[0]))
+	envelope, err := vaultSeal.Envelope()
+	require.NoError(t, err)
+
+	term, key, err := envelope.NewTerm()
+	require.NoError(t, err)
+	keyring := []models.KeyringTerm{{Term: term, Key: key}}
+	require.NoError(t, vaultSeal.Install(keyring))
+
+	rotated, err := vaultSeal.Envelope()
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Term())
+
+	vaultSeal.Seal()
+	require.NoError(t, vaultSeal.Unseal(config, keyring, keys[0]))
+	unsealed, err := vaultSeal.Envelope()
+	require.NoError(t, err)
+	assert.Equal(t, 2, unsealed.Term(), "stored terms are installed on unseal")
+}
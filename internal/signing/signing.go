@@ -0,0 +1,183 @@
+// Package signing keeps the keyring of user token signing keys in sync with the storage.
+package signing
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+)
+
+// minReload limits reloads forced by tokens naming unknown keys.
+const minReload = time.Second
+
+type Store interface {
+	ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error)
+}
+
+// Signer implements jwt.Signer with the keys of the store. The keyring is cached and reloaded
+// after the refresh interval or when a token names an unknown kid, so keys managed through
+// another instance are picked up without a restart. The legacy secret signs and verifies tokens
+// without a kid while no key is active, afterwards it only verifies them with legacyVerify.
+type Signer struct {
+	store        Store
+	keeper       encryption.Keeper
+	log          *slog.Logger
+	legacy       jwt.Key
+	legacyVerify bool
+	algorithm    string
+	refresh      time.Duration
+
+	mu       sync.Mutex
+	keyring  *jwt.Keyring
+	loadedAt time.Time
+}
+
+// New returns a signer whose new keys use the algorithm, secret is the legacy HS512 secret.
+// legacyVerify keeps verifying tokens signed with the secret once a key has been promoted.
+func New(store Store, keeper encryption.Keeper, log *slog.Logger, secret, algorithm string, refresh time.Duration, legacyVerify bool) *Signer {
+	return &Signer{
+		store:        store,
+		keeper:       keeper,
+		log:          log,
+		legacy:       jwt.Key{Algorithm: jwt.AlgHS512, Secret: []byte(secret)},
+		legacyVerify: legacyVerify,
+		algorithm:    algorithm,
+		refresh:      refresh,
+	}
+}
+
+func (s *Signer) CreateToken(ctx context.Context, model models.TokenModel) (string, error) {
+	keyring, err := s.current(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	return keyring.CreateToken(ctx, model)
+}
+
+func (s *Signer) DecodeToken(ctx context.Context, token string) (models.TokenModel, error) {
+	keyring, err := s.current(ctx, false)
+	if err != nil {
+		return models.TokenModel{}, err
+	}
+
+	model, err := keyring.DecodeToken(ctx, token)
+	if errors.Is(err, jwt.ErrUnknownKey) {
+		if keyring, err = s.current(ctx, true); err != nil {
+			return models.TokenModel{}, err
+		}
+		return keyring.DecodeToken(ctx, token)
+	}
+	return model, err
+}
+
+// Invalidate drops the cached keyring, the next token reloads it.
+func (s *Signer) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keyring = nil
+}
+
+// NewKey generates a key of the configured algorithm in the verify state, its secret is wrapped by the envelope.
+func (s *Signer) NewKey() (models.JWTKeyModel, error) {
+	envelope, err := s.keeper.Envelope()
+	if err != nil {
+		return models.JWTKeyModel{}, err
+	}
+
+	kid, err := jwt.NewID()
+	if err != nil {
+		return models.JWTKeyModel{}, err
+	}
+	secret, public, err := jwt.GenerateKey(s.algorithm)
+	if err != nil {
+		return models.JWTKeyModel{}, err
+	}
+	wrapped, err := envelope.WrapKey(secret)
+	if err != nil {
+		return models.JWTKeyModel{}, err
+	}
+
+	model := models.JWTKeyModel{ID: kid, Algorithm: s.algorithm, State: models.JWTKeyVerify, Key: wrapped}
+	if public != nil {
+		model.PublicKey = base64.StdEncoding.EncodeToString(public)
+	}
+	return model, nil
+}
+
+// current returns the cached keyring, force reloads it unless it was loaded moments ago.
+func (s *Signer) current(ctx context.Context, force bool) (*jwt.Keyring, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.loadedAt)
+	if s.keyring != nil && age < s.refresh && (!force || age < minReload) {
+		return s.keyring, nil
+	}
+
+	keys, err := s.store.ListJWTKeys(ctx, s.log)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := s.load(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keyring, s.loadedAt = keyring, time.Now()
+	return keyring, nil
+}
+
+// load unwraps the keys which are not retired, the envelope is only needed once keys exist.
+// A key which only verifies and can't be unwrapped is skipped, its tokens are rejected.
+func (s *Signer) load(stored []models.JWTKeyModel) (*jwt.Keyring, error) {
+	const op = "signing.load"
+
+	var (
+		active   *jwt.Key
+		keys     []jwt.Key
+		envelope *encryption.Envelope
+	)
+	for _, model := range stored {
+		if model.State == models.JWTKeyRetired {
+			continue
+		}
+		if envelope == nil {
+			e, err := s.keeper.Envelope()
+			if err != nil {
+				return nil, err
+			}
+			envelope = e
+		}
+
+		secret, err := envelope.UnwrapDataKey(model.Key)
+		if err != nil {
+			if model.State == models.JWTKeyActive {
+				return nil, fmt.Errorf("failed to unwrap jwt key %s: %w", model.ID, err)
+			}
+			s.log.Error("failed to unwrap jwt key, skipping it", sl.OpErr(op, err), slog.String("kid", model.ID))
+			continue
+		}
+		key := jwt.Key{ID: model.ID, Algorithm: model.Algorithm, Secret: secret}
+		if model.State == models.JWTKeyActive {
+			active = &key
+		}
+		keys = append(keys, key)
+	}
+
+	if active == nil {
+		return jwt.NewKeyring(s.legacy, keys...)
+	}
+	if s.legacyVerify {
+		keys = append(keys, s.legacy)
+	}
+	return jwt.NewKeyring(*active, keys...)
+}
@@ -0,0 +1,118 @@
+package signing_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	"vault/internal/models"
+	"vault/internal/signing"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type store []models.JWTKeyModel
+
+func (s store) ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error) {
+	return s, nil
+}
+
+type keeper struct {
+	envelope *encryption.Envelope
+}
+
+func (k keeper) Envelope() (*encryption.Envelope, error) {
+	return k.envelope, nil
+}
+
+func newKeeper(t *testing.T) keeper {
+	t.Helper()
+
+	key, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	envelope, err := encryption.NewEnvelope(key)
+	require.NoError(t, err)
+	return keeper{envelope: envelope}
+}
+
+func TestLegacySecret(t *testing.T) {
+	ctx := context.Background()
+	log := slogdiscard.NewDiscardLogger()
+	model := models.NewTokenModel("jti", []int{1}, nil, time.Minute, models.TokenScope{})
+	keeper := newKeeper(t)
+
+	legacyToken, err := jwt.Static("secret").CreateToken(ctx, model)
+	require.NoError(t, err)
+
+	key, err := signing.New(nil, keeper, log, "secret", jwt.AlgHS512, time.Minute, false).NewKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		testName     string
+		state        string
+		legacyVerify bool
+		valid        bool
+	}{
+		{
+			testName: "before the first promotion",
+			state:    models.JWTKeyVerify,
+			valid:    true,
+		},
+		{
+			testName: "after the first promotion",
+			state:    models.JWTKeyActive,
+		},
+		{
+			testName:     "after the first promotion with legacy verify",
+			state:        models.JWTKeyActive,
+			legacyVerify: true,
+			valid:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			key.State = tt.state
+			signer := signing.New(store{key}, keeper, log, "secret", jwt.AlgHS512, time.Minute, tt.legacyVerify)
+
+			_, err := signer.DecodeToken(ctx, legacyToken)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, jwt.ErrUnknownKey)
+			}
+		})
+	}
+}
+
+func TestUndecryptableKeys(t *testing.T) {
+	ctx := context.Background()
+	log := slogdiscard.NewDiscardLogger()
+	model := models.NewTokenModel("jti", []int{1}, nil, time.Minute, models.TokenScope{})
+	keeper := newKeeper(t)
+
+	generate := func(state string) models.JWTKeyModel {
+		key, err := signing.New(nil, keeper, log, "secret", jwt.AlgHS512, time.Minute, false).NewKey()
+		require.NoError(t, err)
+		key.State = state
+		return key
+	}
+	active := generate(models.JWTKeyActive)
+	broken := generate(models.JWTKeyVerify)
+	broken.Key = newKeeper(t).envelope.Prefix() + "AAAA"
+
+	signer := signing.New(store{broken, active}, keeper, log, "secret", jwt.AlgHS512, time.Minute, false)
+	token, err := signer.CreateToken(ctx, model)
+	require.NoError(t, err, "keys which only verify don't stop the keyring from loading")
+	_, err = signer.DecodeToken(ctx, token)
+	require.NoError(t, err)
+
+	active.Key = broken.Key
+	signer = signing.New(store{active}, keeper, log, "secret", jwt.AlgHS512, time.Minute, false)
+	_, err = signer.CreateToken(ctx, model)
+	assert.Error(t, err, "the active key is required")
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) PutAppRole(ctx context.Context, log *slog.Logger, model models.AppRoleModel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model = cloneAppRole(model)
+	if role, ok := c.appRoles[model.Name]; ok {
+		model.RoleID, model.CreatedAt = role.model.RoleID, role.model.CreatedAt
+		role.model = model
+		return nil
+	}
+
+	model.CreatedAt = time.Now()
+	c.appRoles[model.Name] = &appRole{model: model, secretIDs: map[string]models.SecretIDModel{}}
+	return nil
+}
+
+func (c *Client) GetAppRole(ctx context.Context, log *slog.Logger, name string) (models.AppRoleModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	role, ok := c.appRoles[name]
+	if !ok {
+		return models.AppRoleModel{}, errors.New("approle not found")
+	}
+	return cloneAppRole(role.model), nil
+}
+
+func (c *Client) GetAppRoleByRoleID(ctx context.Context, log *slog.Logger, roleID string) (models.AppRoleModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, role := range c.appRoles {
+		if role.model.RoleID == roleID {
+			return cloneAppRole(role.model), nil
+		}
+	}
+	return models.AppRoleModel{}, errors.New("approle not found")
+}
+
+func (c *Client) ListAppRoles(ctx context.Context, log *slog.Logger) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.appRoles))
+	for name := range c.appRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Client) DeleteAppRole(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.appRoles[name]; !ok {
+		return errors.New("approle not found")
+	}
+	delete(c.appRoles, name)
+	return nil
+}
+
+func (c *Client) CreateSecretID(ctx context.Context, log *slog.Logger, role string, model models.SecretIDModel, secretHash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.appRoles[role]
+	if !ok {
+		return errors.New("approle not found")
+	}
+	for hash, secretID := range r.secretIDs {
+		if hash == secretHash || secretID.Accessor == model.Accessor {
+			return errors.New("secret id already exists")
+		}
+	}
+
+	model.CIDRs = slices.Clone(model.CIDRs)
+	model.CreatedAt = time.Now()
+	r.secretIDs[secretHash] = model
+	return nil
+}
+
+func (c *Client) ListSecretIDs(ctx context.Context, log *slog.Logger, role string) ([]models.SecretIDModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	secretIDs := make([]models.SecretIDModel, 0)
+	if r, ok := c.appRoles[role]; ok {
+		for _, secretID := range r.secretIDs {
+			secretID.CIDRs = slices.Clone(secretID.CIDRs)
+			secretIDs = append(secretIDs, secretID)
+		}
+	}
+	sort.Slice(secretIDs, func(i, j int) bool {
+		if !secretIDs[i].CreatedAt.Equal(secretIDs[j].CreatedAt) {
+			return secretIDs[i].CreatedAt.Before(secretIDs[j].CreatedAt)
+		}
+		return secretIDs[i].Accessor < secretIDs[j].Accessor
+	})
+	return secretIDs, nil
+}
+
+func (c *Client) GetSecretID(ctx context.Context, log *slog.Logger, role string, secretHash string) (models.SecretIDModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r, ok := c.appRoles[role]
+	if !ok {
+		return models.SecretIDModel{}, errors.New("secret id not found")
+	}
+	secretID, ok := r.secretIDs[secretHash]
+	if !ok {
+		return models.SecretIDModel{}, errors.New("secret id not found")
+	}
+	secretID.CIDRs = slices.Clone(secretID.CIDRs)
+	return secretID, nil
+}
+
+func (c *Client) DeleteSecretID(ctx context.Context, log *slog.Logger, role string, accessor string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.appRoles[role]; ok {
+		for hash, secretID := range r.secretIDs {
+			if secretID.Accessor == accessor {
+				delete(r.secretIDs, hash)
+				return nil
+			}
+		}
+	}
+	return errors.New("secret id not found")
+}
+
+func cloneAppRole(model models.AppRoleModel) models.AppRoleModel {
+	model.VaultIDs = slices.Clone(model.VaultIDs)
+	model.Paths = slices.Clone(model.Paths)
+	model.TransitKeys = slices.Clone(model.TransitKeys)
+	model.Keys = slices.Clone(model.Keys)
+	model.Capabilities = slices.Clone(model.Capabilities)
+	return model
+}
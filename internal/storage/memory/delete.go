@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) UndeleteVault(ctx context.Context, log *slog.Logger, id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.vaults[id]
+	if !ok || v.deletedAt == nil {
+		return errors.New("vault not found")
+	}
+	if c.vaultByName(v.name) != nil {
+		return errors.New("vault already exists")
+	}
+	v.deletedAt = nil
+	return nil
+}
+
+func (c *Client) DestroyVault(ctx context.Context, log *slog.Logger, id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.vaults[id]; !ok {
+		return errors.New("vault not found")
+	}
+	c.removeVault(id)
+	return nil
+}
+
+func (c *Client) DeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	now := time.Now()
+	return c.updateKeys(id, func(ver *version) {
+		for _, value := range ver.values {
+			if _, ok := ver.deleted[value.Key]; !ok && slices.Contains(keys, value.Key) {
+				ver.deleted[value.Key] = now
+			}
+		}
+	})
+}
+
+func (c *Client) UndeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	return c.updateKeys(id, func(ver *version) {
+		for _, key := range keys {
+			delete(ver.deleted, key)
+		}
+	})
+}
+
+func (c *Client) DestroyKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	return c.updateKeys(id, func(ver *version) {
+		for i := range ver.values {
+			if slices.Contains(keys, ver.values[i].Key) {
+				ver.values[i].Value = ""
+			}
+		}
+		ver.values = slices.DeleteFunc(ver.values, func(value models.ValueDTO) bool { return slices.Contains(keys, value.Key) })
+		for _, key := range keys {
+			delete(ver.deleted, key)
+		}
+	})
+}
+
+// updateKeys applies update to every version of a live vault.
+func (c *Client) updateKeys(id int, update func(ver *version)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return errors.New("vault not found")
+	}
+	for _, ver := range v.versions {
+		update(ver)
+	}
+	return nil
+}
+
+func (c *Client) PurgeDeleted(ctx context.Context, log *slog.Logger, before time.Time) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var vaults, values int
+	for id, v := range c.vaults {
+		if v.deletedAt != nil && v.deletedAt.Before(before) {
+			c.removeVault(id)
+			vaults++
+			continue
+		}
+		for _, ver := range v.versions {
+			ver.values = slices.DeleteFunc(ver.values, func(value models.ValueDTO) bool {
+				deletedAt, ok := ver.deleted[value.Key]
+				if ok && deletedAt.Before(before) {
+					delete(ver.deleted, value.Key)
+					values++
+					return true
+				}
+				return false
+			})
+		}
+	}
+	return vaults, values, nil
+}
+
+// removeVault drops the vault and unbinds it from tokens.
+func (c *Client) removeVault(id int) {
+	delete(c.vaults, id)
+
+	for _, t := range c.tokens {
+		t.info.VaultIDs = slices.DeleteFunc(t.info.VaultIDs, func(vaultID int) bool { return vaultID == id })
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+	"vault/internal/models"
+)
+
+// PurgeExpired removes at most limit vaults and limit values which expired before the time,
+// it returns the number of removed vaults and values.
+func (c *Client) PurgeExpired(ctx context.Context, log *slog.Logger, before time.Time, limit int) (int, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]int, 0, len(c.vaults))
+	for id := range c.vaults {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var vaults, values int
+	for _, id := range ids {
+		if vaults < limit && models.Expired(c.vaults[id].expiresAt, before) {
+			c.removeVault(id)
+			vaults++
+		}
+	}
+	for _, id := range ids {
+		v, ok := c.vaults[id]
+		if !ok {
+			continue
+		}
+		for _, ver := range v.versions {
+			ver.values = slices.DeleteFunc(ver.values, func(value models.ValueDTO) bool {
+				if values < limit && models.Expired(value.ExpiresAt, before) {
+					values++
+					return true
+				}
+				return false
+			})
+		}
+	}
+	return vaults, values, nil
+}
+
+// releaseExpiredPath removes the expired vault still holding the path, so the path can be taken before it is purged.
+func (c *Client) releaseExpiredPath(name string) {
+	now := time.Now()
+	for id, v := range c.vaults {
+		if v.name == name && v.deletedAt == nil && models.Expired(v.expiresAt, now) {
+			c.removeVault(id)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := slices.Clone(c.jwtKeys)
+	if keys == nil {
+		keys = make([]models.JWTKeyModel, 0)
+	}
+	return keys, nil
+}
+
+func (c *Client) CreateJWTKey(ctx context.Context, log *slog.Logger, model models.JWTKeyModel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.jwtKey(model.ID) >= 0 {
+		return errors.New("jwt key already exists")
+	}
+	model.CreatedAt = time.Now()
+	c.jwtKeys = append(c.jwtKeys, model)
+	return nil
+}
+
+func (c *Client) PromoteJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.jwtKey(kid)
+	if i < 0 {
+		return errors.New("jwt key not found")
+	}
+	if c.jwtKeys[i].State == models.JWTKeyRetired {
+		return errors.New("jwt key is retired")
+	}
+
+	for j := range c.jwtKeys {
+		if c.jwtKeys[j].State == models.JWTKeyActive {
+			c.jwtKeys[j].State = models.JWTKeyVerify
+		}
+	}
+	c.jwtKeys[i].State = models.JWTKeyActive
+	return nil
+}
+
+func (c *Client) RetireJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.jwtKey(kid)
+	if i < 0 {
+		return errors.New("jwt key not found")
+	}
+	if c.jwtKeys[i].State == models.JWTKeyActive {
+		return errors.New("jwt key is active")
+	}
+	c.jwtKeys[i].State = models.JWTKeyRetired
+	return nil
+}
+
+// jwtKey returns the index of the key or -1, the caller holds the lock.
+func (c *Client) jwtKey(kid string) int {
+	return slices.IndexFunc(c.jwtKeys, func(key models.JWTKeyModel) bool { return key.ID == kid })
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) GetKeyring(ctx context.Context, log *slog.Logger) ([]models.KeyringTerm, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	terms := slices.Clone(c.keyring)
+	if terms == nil {
+		terms = make([]models.KeyringTerm, 0)
+	}
+	return terms, nil
+}
+
+func (c *Client) AddKeyringTerm(ctx context.Context, log *slog.Logger, term models.KeyringTerm) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.keyring {
+		if existing.Term == term.Term {
+			return errors.New("keyring term already exists")
+		}
+	}
+	term.CreatedAt = time.Now()
+	c.keyring = append(c.keyring, term)
+	sort.Slice(c.keyring, func(i, j int) bool { return c.keyring[i].Term < c.keyring[j].Term })
+	return nil
+}
+
+func (c *Client) ListStaleKeys(ctx context.Context, log *slog.Logger, prefix string, limit int) ([]models.WrappedKey, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]models.WrappedKey, 0)
+	for _, key := range c.wrappedKeys() {
+		if len(keys) == limit {
+			break
+		}
+		if stale(key.Key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (c *Client) CountStaleKeys(ctx context.Context, log *slog.Logger, prefix string) (int, int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := c.wrappedKeys()
+	pending := 0
+	for _, key := range keys {
+		if stale(key.Key, prefix) {
+			pending++
+		}
+	}
+	return pending, len(keys), nil
+}
+
+func (c *Client) UpdateWrappedKey(ctx context.Context, log *slog.Logger, key models.WrappedKey, old string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch key.Kind {
+	case models.WrappedKeyVault:
+		v, ok := c.vaults[key.ID]
+		if !ok || v.dataKey != old {
+			return errors.New("wrapped key changed")
+		}
+		v.dataKey = key.Key
+	case models.WrappedKeyTransit:
+		transitKey, ok := c.transitKeys[key.Name]
+		if !ok {
+			return errors.New("wrapped key changed")
+		}
+		i := slices.IndexFunc(transitKey.Versions, func(v models.TransitKeyVersion) bool {
+			return v.Version == key.Version && v.Key == old
+		})
+		if i < 0 {
+			return errors.New("wrapped key changed")
+		}
+		transitKey.Versions[i].Key = key.Key
+	case models.WrappedKeyJWT:
+		i := c.jwtKey(key.Name)
+		if i < 0 || c.jwtKeys[i].Key != old {
+			return errors.New("wrapped key changed")
+		}
+		c.jwtKeys[i].Key = key.Key
+	default:
+		return errors.New("unknown wrapped key kind")
+	}
+	return nil
+}
+
+// wrappedKeys returns the data keys of vaults ordered by id followed by the transit key material
+// ordered by key name and version and the JWT keys ordered by kid, the caller holds the lock.
+func (c *Client) wrappedKeys() []models.WrappedKey {
+	ids := make([]int, 0, len(c.vaults))
+	for id, v := range c.vaults {
+		if v.dataKey != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	keys := make([]models.WrappedKey, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, models.WrappedKey{Kind: models.WrappedKeyVault, ID: id, Key: c.vaults[id].dataKey})
+	}
+
+	names := make([]string, 0, len(c.transitKeys))
+	for name := range c.transitKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range c.transitKeys[name].Versions {
+			keys = append(keys, models.WrappedKey{Kind: models.WrappedKeyTransit, Name: name, Version: v.Version, Key: v.Key})
+		}
+	}
+
+	jwtKeys := slices.Clone(c.jwtKeys)
+	sort.Slice(jwtKeys, func(i, j int) bool { return jwtKeys[i].ID < jwtKeys[j].ID })
+	for _, key := range jwtKeys {
+		keys = append(keys, models.WrappedKey{Kind: models.WrappedKeyJWT, Name: key.ID, Key: key.Key})
+	}
+	return keys
+}
+
+// stale reports whether the key is wrapped with a term older than the one of the prefix,
+// an empty prefix means the master key is the only term.
+func stale(key, prefix string) bool {
+	return prefix != "" && !strings.HasPrefix(key, prefix)
+}
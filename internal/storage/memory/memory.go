@@ -0,0 +1,728 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"vault/internal/models"
+)
+
+// Client keeps every record in memory, it is meant for local development and tests.
+// Errors mirror the messages of the postgres backend.
+type Client struct {
+	mu          sync.RWMutex
+	maxVersions int
+
+	nextVaultID    int
+	vaults         map[int]*vault
+	sealConfig     *models.SealConfig
+	keyring        []models.KeyringTerm
+	jwtKeys        []models.JWTKeyModel
+	tokens         map[string]*token
+	policies       map[string]models.PolicyModel
+	nextIdentityID int
+	identities     map[string]*identity
+	transitKeys    map[string]*models.TransitKeyModel
+	appRoles       map[string]*appRole
+	nextOperatorID int
+	operators      map[string]*operator
+	wrappings      map[string]models.WrappedResponse
+	audit          []auditEntry
+}
+
+type vault struct {
+	id          int
+	name        string
+	dataKey     string
+	tags        []string
+	metadata    metadata
+	createdAt   time.Time
+	current     int
+	casRequired bool
+	deletedAt   *time.Time
+	expiresAt   *time.Time
+	versions    map[int]*version
+}
+
+// version keeps soft deleted values with their deletion time in deleted.
+type version struct {
+	createdAt time.Time
+	values    []models.ValueDTO
+	deleted   map[string]time.Time
+}
+
+type token struct {
+	info      models.TokenInfoModel
+	revokedAt *time.Time
+}
+
+type identity struct {
+	model     models.IdentityModel
+	tokenHash string
+}
+
+// appRole keeps the secret ids of the role by the hash of the secret id.
+type appRole struct {
+	model     models.AppRoleModel
+	secretIDs map[string]models.SecretIDModel
+}
+
+// operator keeps the sessions and API keys of the operator by the hash of the token.
+type operator struct {
+	model        models.OperatorModel
+	passwordHash string
+	tokens       map[string]models.OperatorTokenModel
+}
+
+type auditEntry struct {
+	seq      int64
+	document string
+}
+
+// New creates an empty store, maxVersions limits the number of kept vault versions (0 keeps all).
+func New(maxVersions int) *Client {
+	return &Client{
+		maxVersions: maxVersions,
+		vaults:      map[int]*vault{},
+		tokens:      map[string]*token{},
+		policies:    map[string]models.PolicyModel{},
+		identities:  map[string]*identity{},
+		transitKeys: map[string]*models.TransitKeyModel{},
+		appRoles:    map[string]*appRole{},
+		operators:   map[string]*operator{},
+		wrappings:   map[string]models.WrappedResponse{},
+	}
+}
+
+// NextVaultID reserves the id of a vault which is about to be created.
+func (c *Client) NextVaultID(ctx context.Context, log *slog.Logger) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextVaultID++
+	return c.nextVaultID, nil
+}
+
+func (c *Client) CreateVault(ctx context.Context, log *slog.Logger, model models.SecretCreateDTO) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.releaseExpiredPath(model.Name)
+	if c.vaultByName(model.Name) != nil {
+		return 0, errors.New("vault already exists")
+	}
+
+	id := model.ID
+	if id == 0 {
+		c.nextVaultID++
+		id = c.nextVaultID
+	} else if _, ok := c.vaults[id]; ok {
+		return 0, errors.New("vault already exists")
+	}
+	c.nextVaultID = max(c.nextVaultID, id)
+	now := time.Now()
+	v := &vault{
+		id:          id,
+		name:        model.Name,
+		dataKey:     model.DataKey,
+		tags:        slices.Clone(model.Tags),
+		metadata:    metadata{createdBy: model.CreatedBy, updatedAt: now},
+		createdAt:   now,
+		current:     1,
+		casRequired: model.CASRequired != nil && *model.CASRequired,
+		expiresAt:   model.ExpiresAt,
+		versions:    map[int]*version{},
+	}
+	v.versions[1] = &version{createdAt: now, values: slices.Clone(model.Data), deleted: map[string]time.Time{}}
+	c.vaults[v.id] = v
+
+	return v.id, nil
+}
+
+func (c *Client) GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error) {
+	return c.getVault(id, 0)
+}
+
+func (c *Client) GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error) {
+	return c.getVault(id, version)
+}
+
+// getVault reads the given version of the vault data, version 0 reads the current one.
+func (c *Client) getVault(id int, version int) (models.SecretModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return models.SecretModel{}, errors.New("vault not found")
+	}
+	if version == 0 {
+		version = v.current
+	}
+	ver, ok := v.versions[version]
+	if !ok {
+		return models.SecretModel{}, errors.New("version not found")
+	}
+
+	res := models.ConvertDTOToSecretModel(models.VaultModel{ID: v.id, Name: v.name, DataKey: v.dataKey, ExpiresAt: v.expiresAt}, ver.live())
+	res.Version = version
+	res.CASRequired = v.casRequired
+	return res, nil
+}
+
+func (c *Client) CheckVault(ctx context.Context, log *slog.Logger, id int) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.liveVault(id); !ok {
+		return errors.New("vault not found")
+	}
+	return nil
+}
+
+func (c *Client) GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.vaults[id]
+	if !ok {
+		return "", errors.New("vault not found")
+	}
+	return v.name, nil
+}
+
+func (c *Client) GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v := c.vaultByName(path)
+	if v == nil {
+		return 0, errors.New("vault not found")
+	}
+	return v.id, nil
+}
+
+// liveVault returns the vault unless it is missing or soft deleted.
+func (c *Client) liveVault(id int) (*vault, bool) {
+	v, ok := c.vaults[id]
+	if !ok || !v.live() {
+		return nil, false
+	}
+	return v, true
+}
+
+// live reports whether the vault is neither soft deleted nor expired.
+func (v *vault) live() bool {
+	return v.deletedAt == nil && !models.Expired(v.expiresAt, time.Now())
+}
+
+// vaultByName returns nil when there is no live vault with the name.
+func (c *Client) vaultByName(name string) *vault {
+	for _, v := range c.vaults {
+		if v.name == name && v.live() {
+			return v
+		}
+	}
+	return nil
+}
+
+func (c *Client) ListChildren(ctx context.Context, log *slog.Logger, prefix string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	children := make([]string, 0)
+	for _, v := range c.vaults {
+		rest, ok := strings.CutPrefix(v.name, prefix)
+		if !ok || !v.live() {
+			continue
+		}
+		if segment, _, found := strings.Cut(rest, "/"); found {
+			rest = segment + "/"
+		}
+		if !slices.Contains(children, rest) {
+			children = append(children, rest)
+		}
+	}
+	sort.Strings(children)
+
+	return children, nil
+}
+
+func (c *Client) ListVaults(ctx context.Context, log *slog.Logger, filter models.VaultFilter) (models.VaultPage, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	vaults := make([]models.VaultModel, 0)
+	for _, v := range c.vaults {
+		if models.Expired(v.expiresAt, now) {
+			continue
+		}
+		model := models.VaultModel{
+			ID:          v.id,
+			Name:        v.name,
+			Description: v.metadata.description,
+			Owner:       v.metadata.owner,
+			Tags:        slices.Clone(v.tags),
+			Attributes:  maps.Clone(v.metadata.attributes),
+			CreatedBy:   v.metadata.createdBy,
+			Version:     v.current,
+			CreatedAt:   v.createdAt,
+			DeletedAt:   v.deletedAt,
+			ExpiresAt:   v.expiresAt,
+		}
+		if model.Tags == nil {
+			model.Tags = []string{}
+		}
+		if current, ok := v.versions[v.current]; ok {
+			model.KeyCount = len(current.live())
+		}
+		if !filter.Match(model) || (filter.After != nil && !filter.Less(filter.After.Vault(), model)) {
+			continue
+		}
+		vaults = append(vaults, model)
+	}
+	sort.Slice(vaults, func(i, j int) bool { return filter.Less(vaults[i], vaults[j]) })
+
+	if len(vaults) > filter.Limit+1 {
+		vaults = vaults[:filter.Limit+1]
+	}
+	return filter.Page(vaults), nil
+}
+
+func (c *Client) UpdateVault(ctx context.Context, log *slog.Logger, id int, model models.SecretCreateDTO) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return 0, errors.New("vault not found")
+	}
+
+	if err := models.CheckCAS(v.current, v.casRequired, model.CAS); err != nil {
+		return 0, err
+	}
+	c.releaseExpiredPath(model.Name)
+	if other := c.vaultByName(model.Name); other != nil && other != v {
+		return 0, errors.New("vault already exists")
+	}
+
+	v.name = model.Name
+	v.expiresAt = model.ExpiresAt
+	if model.Tags != nil {
+		v.tags = slices.Clone(model.Tags)
+		v.metadata.updatedAt = time.Now()
+	}
+	if model.CASRequired != nil {
+		v.casRequired = *model.CASRequired
+	}
+	if model.DataKey != "" {
+		v.dataKey = model.DataKey
+	}
+	return c.addVersion(v, slices.Clone(model.Data), map[string]time.Time{}), nil
+}
+
+func (c *Client) PatchVault(ctx context.Context, log *slog.Logger, id int, model models.SecretPatchDTO) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return 0, errors.New("vault not found")
+	}
+	if err := models.CheckCAS(v.current, v.casRequired, model.CAS); err != nil {
+		return 0, err
+	}
+
+	changed := make([]string, 0, len(model.Data)+len(model.Remove))
+	for _, value := range model.Data {
+		changed = append(changed, value.Key)
+	}
+	changed = append(changed, model.Remove...)
+
+	values := make([]models.ValueDTO, 0)
+	deleted := map[string]time.Time{}
+	if current, ok := v.versions[v.current]; ok {
+		for _, value := range current.values {
+			if !slices.Contains(changed, value.Key) {
+				values = append(values, value)
+				if deletedAt, ok := current.deleted[value.Key]; ok {
+					deleted[value.Key] = deletedAt
+				}
+			}
+		}
+	}
+	values = append(values, model.Data...)
+
+	if model.DataKey != "" {
+		v.dataKey = model.DataKey
+	}
+	return c.addVersion(v, values, deleted), nil
+}
+
+func (c *Client) DeleteVault(ctx context.Context, log *slog.Logger, id int, cas int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return errors.New("vault not found")
+	}
+	if err := models.CheckCAS(v.current, v.casRequired, cas); err != nil {
+		return err
+	}
+	now := time.Now()
+	v.deletedAt = &now
+	return nil
+}
+
+func (c *Client) ListVersions(ctx context.Context, log *slog.Logger, id int) ([]models.VersionModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.liveVault(id)
+	if !ok || len(v.versions) == 0 {
+		return nil, errors.New("vault not found")
+	}
+
+	versions := make([]models.VersionModel, 0, len(v.versions))
+	for number, ver := range v.versions {
+		versions = append(versions, models.VersionModel{
+			Version:   number,
+			CreatedAt: ver.createdAt,
+			Current:   number == v.current,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+
+	return versions, nil
+}
+
+func (c *Client) RollbackVault(ctx context.Context, log *slog.Logger, id int, version int, cas int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return 0, errors.New("vault not found")
+	}
+	if err := models.CheckCAS(v.current, v.casRequired, cas); err != nil {
+		return 0, err
+	}
+	old, ok := v.versions[version]
+	if !ok {
+		return 0, errors.New("version not found")
+	}
+
+	return c.addVersion(v, slices.Clone(old.values), maps.Clone(old.deleted)), nil
+}
+
+// live returns the values that are not soft deleted.
+func (ver *version) live() []models.ValueDTO {
+	now := time.Now()
+	return slices.DeleteFunc(slices.Clone(ver.values), func(value models.ValueDTO) bool {
+		_, ok := ver.deleted[value.Key]
+		return ok || models.Expired(value.ExpiresAt, now)
+	})
+}
+
+// addVersion stores the values as the new current version and prunes old versions.
+func (c *Client) addVersion(v *vault, values []models.ValueDTO, deleted map[string]time.Time) int {
+	if deleted == nil {
+		deleted = map[string]time.Time{}
+	}
+	v.current++
+	v.versions[v.current] = &version{createdAt: time.Now(), values: values, deleted: deleted}
+
+	if c.maxVersions > 0 {
+		for number := range v.versions {
+			if number <= v.current-c.maxVersions {
+				delete(v.versions, number)
+			}
+		}
+	}
+	return v.current
+}
+
+func (c *Client) GetSealConfig(ctx context.Context, log *slog.Logger) (models.SealConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.sealConfig == nil {
+		return models.SealConfig{}, errors.New("vault is not initialized")
+	}
+	return *c.sealConfig, nil
+}
+
+func (c *Client) CreateSealConfig(ctx context.Context, log *slog.Logger, config models.SealConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sealConfig != nil {
+		return errors.New("failed to save seal config")
+	}
+	c.sealConfig = &config
+	return nil
+}
+
+func (c *Client) CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.tokens[model.JTI]; ok {
+		return errors.New("failed to save token")
+	}
+	for _, vaultID := range model.VaultIDs {
+		if _, ok := c.vaults[vaultID]; !ok {
+			return errors.New("failed to save token")
+		}
+	}
+
+	model.VaultIDs = slices.Clone(model.VaultIDs)
+	sort.Ints(model.VaultIDs)
+	model.Paths = slices.Clone(model.Paths)
+	model.TransitKeys = slices.Clone(model.TransitKeys)
+	c.tokens[model.JTI] = &token{info: model}
+	return nil
+}
+
+func (c *Client) CheckToken(ctx context.Context, log *slog.Logger, jti string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	t, ok := c.tokens[jti]
+	if !ok {
+		return errors.New("token not found")
+	}
+	if t.revokedAt != nil {
+		return errors.New("token revoked")
+	}
+	if time.Now().After(t.info.ExpiresAt) {
+		return errors.New("token expired")
+	}
+	return nil
+}
+
+func (c *Client) RenewToken(ctx context.Context, log *slog.Logger, jti string, expiresAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[jti]
+	if !ok || t.revokedAt != nil || time.Now().After(t.info.ExpiresAt) {
+		return errors.New("token not found")
+	}
+	t.info.ExpiresAt = expiresAt
+	return nil
+}
+
+func (c *Client) RevokeToken(ctx context.Context, log *slog.Logger, jti string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.tokens[jti]
+	if !ok {
+		return errors.New("token not found")
+	}
+	if t.revokedAt == nil {
+		now := time.Now()
+		t.revokedAt = &now
+	}
+	return nil
+}
+
+func (c *Client) RevokeVaultTokens(ctx context.Context, log *slog.Logger, vaultID int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for _, t := range c.tokens {
+		if t.revokedAt == nil && slices.Contains(t.info.VaultIDs, vaultID) {
+			t.revokedAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (c *Client) ListTokens(ctx context.Context, log *slog.Logger, vaultID int) ([]models.TokenInfoModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	tokens := make([]models.TokenInfoModel, 0)
+	for _, t := range c.tokens {
+		if t.revokedAt != nil || !t.info.ExpiresAt.After(now) || len(t.info.VaultIDs)+len(t.info.Paths)+len(t.info.TransitKeys) == 0 {
+			continue
+		}
+		if vaultID != 0 && !slices.Contains(t.info.VaultIDs, vaultID) {
+			continue
+		}
+		info := t.info
+		info.VaultIDs = append([]int{}, info.VaultIDs...)
+		info.Paths = slices.Clone(info.Paths)
+		info.TransitKeys = slices.Clone(info.TransitKeys)
+		tokens = append(tokens, info)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].IssuedAt.After(tokens[j].IssuedAt) })
+
+	return tokens, nil
+}
+
+func (c *Client) ListPolicies(ctx context.Context, log *slog.Logger) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.policies))
+	for name := range c.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Client) GetPolicy(ctx context.Context, log *slog.Logger, name string) (models.PolicyModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.policies[name]
+	if !ok {
+		return models.PolicyModel{}, errors.New("policy not found")
+	}
+	return p, nil
+}
+
+func (c *Client) GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	policies := make([]models.PolicyModel, 0, len(names))
+	for _, name := range names {
+		if p, ok := c.policies[name]; ok {
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+func (c *Client) PutPolicy(ctx context.Context, log *slog.Logger, model models.PolicyModel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	model.UpdatedAt = time.Now()
+	c.policies[model.Name] = model
+	return nil
+}
+
+func (c *Client) DeletePolicy(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.policies[name]; !ok {
+		return errors.New("policy not found")
+	}
+	delete(c.policies, name)
+	return nil
+}
+
+func (c *Client) CreateIdentity(ctx context.Context, log *slog.Logger, model models.IdentityModel, tokenHash string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.identities[model.Name]; ok {
+		return 0, errors.New("identity already exists")
+	}
+	for _, i := range c.identities {
+		if i.tokenHash == tokenHash {
+			return 0, errors.New("identity already exists")
+		}
+	}
+
+	c.nextIdentityID++
+	model.ID = c.nextIdentityID
+	model.Policies = slices.Clone(model.Policies)
+	model.CreatedAt = time.Now()
+	c.identities[model.Name] = &identity{model: model, tokenHash: tokenHash}
+	return model.ID, nil
+}
+
+func (c *Client) ListIdentities(ctx context.Context, log *slog.Logger) ([]models.IdentityModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	identities := make([]models.IdentityModel, 0, len(c.identities))
+	for _, i := range c.identities {
+		identities = append(identities, i.model)
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].Name < identities[j].Name })
+	return identities, nil
+}
+
+func (c *Client) DeleteIdentity(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.identities[name]; !ok {
+		return errors.New("identity not found")
+	}
+	delete(c.identities, name)
+	return nil
+}
+
+func (c *Client) GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, i := range c.identities {
+		if i.tokenHash == tokenHash {
+			return i.model, nil
+		}
+	}
+	return models.IdentityModel{}, errors.New("identity not found")
+}
+
+func (c *Client) CreateAuditEntry(ctx context.Context, log *slog.Logger, seq int64, document string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := len(c.audit); n > 0 && c.audit[n-1].seq >= seq {
+		return errors.New("failed to save audit entry")
+	}
+	c.audit = append(c.audit, auditEntry{seq: seq, document: document})
+	return nil
+}
+
+func (c *Client) LastAuditEntry(ctx context.Context, log *slog.Logger) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.audit) == 0 {
+		return "", errors.New("audit log is empty")
+	}
+	return c.audit[len(c.audit)-1].document, nil
+}
+
+func (c *Client) ListAuditEntries(ctx context.Context, log *slog.Logger, afterSeq int64, limit int) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	start := sort.Search(len(c.audit), func(i int) bool { return c.audit[i].seq > afterSeq })
+	documents := make([]string, 0, limit)
+	for _, entry := range c.audit[start:] {
+		if len(documents) == limit {
+			break
+		}
+		documents = append(documents, entry.document)
+	}
+	return documents, nil
+}
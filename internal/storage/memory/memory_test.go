@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"testing"
+	"vault/internal/storage"
+	"vault/internal/storage/memory"
+	"vault/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return memory.New(storagetest.MaxVersions)
+	})
+}
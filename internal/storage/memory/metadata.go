@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+	"vault/internal/models"
+)
+
+// metadata describes a vault apart from its data, tags are kept on the vault.
+type metadata struct {
+	description  string
+	owner        string
+	contactEmail string
+	createdBy    string
+	attributes   map[string]string
+	updatedAt    time.Time
+}
+
+func (c *Client) GetMetadata(ctx context.Context, log *slog.Logger, id int) (models.MetadataModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return models.MetadataModel{}, errors.New("vault not found")
+	}
+
+	res := models.MetadataModel{
+		ID:           v.id,
+		Name:         v.name,
+		Description:  v.metadata.description,
+		Owner:        v.metadata.owner,
+		ContactEmail: v.metadata.contactEmail,
+		Tags:         slices.Clone(v.tags),
+		Attributes:   maps.Clone(v.metadata.attributes),
+		CreatedBy:    v.metadata.createdBy,
+		CreatedAt:    v.createdAt,
+		UpdatedAt:    v.metadata.updatedAt,
+	}
+	if res.Tags == nil {
+		res.Tags = []string{}
+	}
+	if res.Attributes == nil {
+		res.Attributes = map[string]string{}
+	}
+	return res, nil
+}
+
+func (c *Client) UpdateMetadata(ctx context.Context, log *slog.Logger, id int, model models.MetadataDTO) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.liveVault(id)
+	if !ok {
+		return errors.New("vault not found")
+	}
+
+	if model.Description != nil {
+		v.metadata.description = *model.Description
+	}
+	if model.Owner != nil {
+		v.metadata.owner = *model.Owner
+	}
+	if model.ContactEmail != nil {
+		v.metadata.contactEmail = *model.ContactEmail
+	}
+	if model.Tags != nil {
+		v.tags = slices.Clone(model.Tags)
+	}
+	if model.Attributes != nil {
+		v.metadata.attributes = maps.Clone(model.Attributes)
+	}
+	v.metadata.updatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) CreateOperator(ctx context.Context, log *slog.Logger, model models.OperatorModel, passwordHash string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.operators[model.Name]; ok {
+		return 0, errors.New("operator already exists")
+	}
+
+	c.nextOperatorID++
+	model.ID = c.nextOperatorID
+	model.Policies = slices.Clone(model.Policies)
+	model.FailedLogins, model.LockedUntil = 0, nil
+	model.CreatedAt = time.Now()
+	c.operators[model.Name] = &operator{model: model, passwordHash: passwordHash, tokens: map[string]models.OperatorTokenModel{}}
+	return model.ID, nil
+}
+
+func (c *Client) ListOperators(ctx context.Context, log *slog.Logger) ([]models.OperatorModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	operators := make([]models.OperatorModel, 0, len(c.operators))
+	for _, o := range c.operators {
+		operators = append(operators, o.clone())
+	}
+	sort.Slice(operators, func(i, j int) bool { return operators[i].Name < operators[j].Name })
+	return operators, nil
+}
+
+func (c *Client) GetOperator(ctx context.Context, log *slog.Logger, name string) (models.OperatorModel, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	o, ok := c.operators[name]
+	if !ok {
+		return models.OperatorModel{}, "", errors.New("operator not found")
+	}
+	return o.clone(), o.passwordHash, nil
+}
+
+func (c *Client) DeleteOperator(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.operators[name]; !ok {
+		return errors.New("operator not found")
+	}
+	delete(c.operators, name)
+	return nil
+}
+
+func (c *Client) UpdateOperatorPassword(ctx context.Context, log *slog.Logger, name string, passwordHash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.operators[name]
+	if !ok {
+		return errors.New("operator not found")
+	}
+	o.passwordHash = passwordHash
+	o.model.FailedLogins, o.model.LockedUntil = 0, nil
+	for hash, t := range o.tokens {
+		if t.Kind == models.OperatorSession {
+			delete(o.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (c *Client) UnlockOperator(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.operators[name]
+	if !ok {
+		return errors.New("operator not found")
+	}
+	o.model.FailedLogins, o.model.LockedUntil = 0, nil
+	return nil
+}
+
+func (c *Client) RecordOperatorFailure(ctx context.Context, log *slog.Logger, name string, maxFailures int, lockedUntil time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.operators[name]
+	if !ok {
+		return errors.New("operator not found")
+	}
+	o.model.FailedLogins++
+	if o.model.FailedLogins >= maxFailures {
+		o.model.FailedLogins, o.model.LockedUntil = 0, &lockedUntil
+	}
+	return nil
+}
+
+func (c *Client) CreateOperatorToken(ctx context.Context, log *slog.Logger, name string, model models.OperatorTokenModel, tokenHash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.operators[name]
+	if !ok {
+		return errors.New("operator not found")
+	}
+	for _, other := range c.operators {
+		if _, ok := other.tokens[tokenHash]; ok {
+			return errors.New("operator token already exists")
+		}
+	}
+	for _, t := range o.tokens {
+		if t.Accessor == model.Accessor {
+			return errors.New("operator token already exists")
+		}
+	}
+
+	model.CreatedAt = time.Now()
+	o.tokens[tokenHash] = model
+	return nil
+}
+
+func (c *Client) ListOperatorTokens(ctx context.Context, log *slog.Logger, name string) ([]models.OperatorTokenModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	tokens := make([]models.OperatorTokenModel, 0)
+	if o, ok := c.operators[name]; ok {
+		for _, t := range o.tokens {
+			if !models.Expired(t.ExpiresAt, now) {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+		}
+		return tokens[i].Accessor < tokens[j].Accessor
+	})
+	return tokens, nil
+}
+
+func (c *Client) DeleteOperatorToken(ctx context.Context, log *slog.Logger, name string, accessor string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if o, ok := c.operators[name]; ok {
+		for hash, t := range o.tokens {
+			if t.Accessor == accessor {
+				delete(o.tokens, hash)
+				return nil
+			}
+		}
+	}
+	return errors.New("operator token not found")
+}
+
+func (c *Client) GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, o := range c.operators {
+		if t, ok := o.tokens[tokenHash]; ok && !models.Expired(t.ExpiresAt, time.Now()) {
+			return o.clone(), nil
+		}
+	}
+	return models.OperatorModel{}, errors.New("operator not found")
+}
+
+func (o *operator) clone() models.OperatorModel {
+	model := o.model
+	model.Policies = slices.Clone(model.Policies)
+	if model.LockedUntil != nil {
+		lockedUntil := *model.LockedUntil
+		model.LockedUntil = &lockedUntil
+	}
+	return model
+}
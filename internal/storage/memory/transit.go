@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) CreateTransitKey(ctx context.Context, log *slog.Logger, model models.TransitKeyModel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.transitKeys[model.Name]; ok {
+		return errors.New("transit key already exists")
+	}
+
+	now := time.Now()
+	key := &models.TransitKeyModel{
+		Name:                 model.Name,
+		Type:                 model.Type,
+		LatestVersion:        1,
+		MinDecryptionVersion: 1,
+		CreatedAt:            now,
+	}
+	for _, v := range model.Versions {
+		v.Version, v.CreatedAt = 1, now
+		key.Versions = []models.TransitKeyVersion{v}
+	}
+	c.transitKeys[model.Name] = key
+	return nil
+}
+
+func (c *Client) GetTransitKey(ctx context.Context, log *slog.Logger, name string) (models.TransitKeyModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.transitKeys[name]
+	if !ok {
+		return models.TransitKeyModel{}, errors.New("transit key not found")
+	}
+	res := *key
+	res.Versions = slices.Clone(key.Versions)
+	return res, nil
+}
+
+func (c *Client) ListTransitKeys(ctx context.Context, log *slog.Logger) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.transitKeys))
+	for name := range c.transitKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Client) RotateTransitKey(ctx context.Context, log *slog.Logger, name string, version models.TransitKeyVersion) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.transitKeys[name]
+	if !ok {
+		return 0, errors.New("transit key not found")
+	}
+	key.LatestVersion++
+	version.Version, version.CreatedAt = key.LatestVersion, time.Now()
+	key.Versions = append(key.Versions, version)
+	return key.LatestVersion, nil
+}
+
+func (c *Client) UpdateTransitKey(ctx context.Context, log *slog.Logger, name string, minDecryptionVersion int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.transitKeys[name]
+	if !ok {
+		return errors.New("transit key not found")
+	}
+	key.MinDecryptionVersion = minDecryptionVersion
+	return nil
+}
+
+func (c *Client) DeleteTransitKey(ctx context.Context, log *slog.Logger, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.transitKeys[name]; !ok {
+		return errors.New("transit key not found")
+	}
+	delete(c.transitKeys, name)
+	return nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+)
+
+func (c *Client) CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.wrappings[tokenHash]; ok {
+		return errors.New("wrapping token already exists")
+	}
+	for _, w := range c.wrappings {
+		if w.Accessor == model.Accessor {
+			return errors.New("wrapping token already exists")
+		}
+	}
+
+	model.TTL = model.ExpiresAt.Sub(model.CreatedAt) / time.Second
+	c.wrappings[tokenHash] = model
+	return nil
+}
+
+func (c *Client) LookupWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappingModel, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	w, ok := c.wrappings[tokenHash]
+	if !ok || !w.ExpiresAt.After(time.Now()) {
+		return models.WrappingModel{}, errors.New("wrapping token not found")
+	}
+	return w.WrappingModel, nil
+}
+
+func (c *Client) TakeWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.wrappings[tokenHash]
+	if !ok || !w.ExpiresAt.After(time.Now()) {
+		return models.WrappedResponse{}, errors.New("wrapping token not found")
+	}
+	delete(c.wrappings, tokenHash)
+	return w, nil
+}
+
+func (c *Client) PurgeWrappings(ctx context.Context, log *slog.Logger, before time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for hash, w := range c.wrappings {
+		if !w.ExpiresAt.After(before) {
+			delete(c.wrappings, hash)
+			purged++
+		}
+	}
+	return purged, nil
+}
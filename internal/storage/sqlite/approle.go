@@ -0,0 +1,342 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+const selectAppRole = `
+	SELECT name, role_id, vault_ids, paths, transit_keys, keys, capabilities,
+		expires, max_expires, secret_id_expires, renewable, max_ttl, created_at
+	FROM approle
+`
+
+const selectSecretID = `
+	SELECT s.accessor, s.single_use, s.cidrs, s.expires_at, s.created_at FROM approle_secret_id s
+	JOIN approle a ON a.id = s.approle_id
+`
+
+// PutAppRole creates or replaces the role, the role id of an existing role is kept.
+func (c *Client) PutAppRole(ctx context.Context, log *slog.Logger, model models.AppRoleModel) error {
+	const op = "db.sqlite.PutAppRole"
+
+	putRoleQuery := `
+		INSERT INTO approle
+			(name, role_id, vault_ids, paths, transit_keys, keys, capabilities, expires, max_expires, secret_id_expires, renewable, max_ttl, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)
+		ON CONFLICT (name) DO UPDATE SET
+			vault_ids = excluded.vault_ids,
+			paths = excluded.paths,
+			transit_keys = excluded.transit_keys,
+			keys = excluded.keys,
+			capabilities = excluded.capabilities,
+			expires = excluded.expires,
+			max_expires = excluded.max_expires,
+			secret_id_expires = excluded.secret_id_expires,
+			renewable = excluded.renewable,
+			max_ttl = excluded.max_ttl;
+	`
+
+	log.Debug("put approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(putRoleQuery)))
+
+	if _, err := c.db.ExecContext(ctx, putRoleQuery,
+		model.Name,
+		model.RoleID,
+		encodeInts(model.VaultIDs),
+		encodeList(model.Paths),
+		encodeList(model.TransitKeys),
+		encodeList(model.Keys),
+		encodeList(model.Capabilities),
+		int64(model.Expires),
+		int64(model.MaxExpires),
+		int64(model.SecretIDExpires),
+		model.Renewable,
+		int64(model.MaxTTL),
+		formatTime(time.Now()),
+	); err != nil {
+		log.Error("failed to save approle", sl.OpErr(op, err))
+		return errors.New("failed to save approle")
+	}
+
+	return nil
+}
+
+func (c *Client) GetAppRole(ctx context.Context, log *slog.Logger, name string) (models.AppRoleModel, error) {
+	const op = "db.sqlite.GetAppRole"
+
+	getRoleQuery := selectAppRole + `WHERE name = ?1;`
+
+	log.Debug("get approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(getRoleQuery)))
+
+	role, err := scanAppRole(c.db.QueryRowContext(ctx, getRoleQuery, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AppRoleModel{}, errors.New("approle not found")
+		}
+		log.Error("failed to get approle", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to get approle")
+	}
+
+	return role, nil
+}
+
+func (c *Client) GetAppRoleByRoleID(ctx context.Context, log *slog.Logger, roleID string) (models.AppRoleModel, error) {
+	const op = "db.sqlite.GetAppRoleByRoleID"
+
+	getRoleQuery := selectAppRole + `WHERE role_id = ?1;`
+
+	log.Debug("get approle by role id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getRoleQuery)))
+
+	role, err := scanAppRole(c.db.QueryRowContext(ctx, getRoleQuery, roleID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AppRoleModel{}, errors.New("approle not found")
+		}
+		log.Error("failed to get approle", sl.OpErr(op, err))
+		return models.AppRoleModel{}, errors.New("failed to get approle")
+	}
+
+	return role, nil
+}
+
+func (c *Client) ListAppRoles(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.sqlite.ListAppRoles"
+
+	listRolesQuery := `
+		SELECT name FROM approle
+		ORDER BY name;
+	`
+
+	log.Debug("list approles query", slog.String("op", op), slog.String("query", utils.QueryConvert(listRolesQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listRolesQuery)
+	if err != nil {
+		log.Error("failed to list approles", sl.OpErr(op, err))
+		return nil, errors.New("failed to list approles")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan approles", sl.OpErr(op, err))
+			return nil, errors.New("failed to list approles")
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list approles")
+	}
+
+	return names, nil
+}
+
+// DeleteAppRole removes the role, its secret ids are removed by the foreign key.
+func (c *Client) DeleteAppRole(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.DeleteAppRole"
+
+	deleteRoleQuery := `
+		DELETE FROM approle
+		WHERE name = ?1;
+	`
+
+	log.Debug("delete approle query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteRoleQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteRoleQuery, name)
+	if err != nil {
+		log.Error("failed to delete approle", sl.OpErr(op, err))
+		return errors.New("failed to delete approle")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("approle not found")
+	}
+
+	return nil
+}
+
+func (c *Client) CreateSecretID(ctx context.Context, log *slog.Logger, role string, model models.SecretIDModel, secretHash string) error {
+	const op = "db.sqlite.CreateSecretID"
+
+	createSecretIDQuery := `
+		INSERT INTO approle_secret_id
+			(approle_id, accessor, secret_hash, single_use, cidrs, expires_at, created_at)
+		SELECT id, ?2, ?3, ?4, ?5, ?6, ?7 FROM approle
+		WHERE name = ?1;
+	`
+
+	log.Debug("create secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(createSecretIDQuery)))
+
+	res, err := c.db.ExecContext(ctx, createSecretIDQuery,
+		role,
+		model.Accessor,
+		secretHash,
+		model.SingleUse,
+		encodeList(model.CIDRs),
+		formatNullTime(model.ExpiresAt),
+		formatTime(time.Now()),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("secret id already exists")
+		}
+		log.Error("failed to save secret id", sl.OpErr(op, err))
+		return errors.New("failed to save secret id")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("approle not found")
+	}
+
+	return nil
+}
+
+func (c *Client) ListSecretIDs(ctx context.Context, log *slog.Logger, role string) ([]models.SecretIDModel, error) {
+	const op = "db.sqlite.ListSecretIDs"
+
+	listSecretIDsQuery := selectSecretID + `
+		WHERE a.name = ?1
+		ORDER BY s.created_at, s.accessor;
+	`
+
+	log.Debug("list secret ids query", slog.String("op", op), slog.String("query", utils.QueryConvert(listSecretIDsQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listSecretIDsQuery, role)
+	if err != nil {
+		log.Error("failed to list secret ids", sl.OpErr(op, err))
+		return nil, errors.New("failed to list secret ids")
+	}
+	defer rows.Close()
+
+	secretIDs := make([]models.SecretIDModel, 0)
+	for rows.Next() {
+		model, err := scanSecretID(rows)
+		if err != nil {
+			log.Error("failed to scan secret ids", sl.OpErr(op, err))
+			return nil, errors.New("failed to list secret ids")
+		}
+		secretIDs = append(secretIDs, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list secret ids")
+	}
+
+	return secretIDs, nil
+}
+
+func (c *Client) GetSecretID(ctx context.Context, log *slog.Logger, role string, secretHash string) (models.SecretIDModel, error) {
+	const op = "db.sqlite.GetSecretID"
+
+	getSecretIDQuery := selectSecretID + `
+		WHERE a.name = ?1 AND s.secret_hash = ?2;
+	`
+
+	log.Debug("get secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getSecretIDQuery)))
+
+	model, err := scanSecretID(c.db.QueryRowContext(ctx, getSecretIDQuery, role, secretHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SecretIDModel{}, errors.New("secret id not found")
+		}
+		log.Error("failed to get secret id", sl.OpErr(op, err))
+		return models.SecretIDModel{}, errors.New("failed to get secret id")
+	}
+
+	return model, nil
+}
+
+func (c *Client) DeleteSecretID(ctx context.Context, log *slog.Logger, role string, accessor string) error {
+	const op = "db.sqlite.DeleteSecretID"
+
+	deleteSecretIDQuery := `
+		DELETE FROM approle_secret_id
+		WHERE approle_id = (SELECT id FROM approle WHERE name = ?1) AND accessor = ?2;
+	`
+
+	log.Debug("delete secret id query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteSecretIDQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteSecretIDQuery, role, accessor)
+	if err != nil {
+		log.Error("failed to delete secret id", sl.OpErr(op, err))
+		return errors.New("failed to delete secret id")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("secret id not found")
+	}
+
+	return nil
+}
+
+func scanAppRole(row scanner) (models.AppRoleModel, error) {
+	var role models.AppRoleModel
+	var vaultIDs, paths, transitKeys, keys, capabilities, createdAt string
+	var expires, maxExpires, secretIDExpires, maxTTL int64
+	if err := row.Scan(
+		&role.Name,
+		&role.RoleID,
+		&vaultIDs,
+		&paths,
+		&transitKeys,
+		&keys,
+		&capabilities,
+		&expires,
+		&maxExpires,
+		&secretIDExpires,
+		&role.Renewable,
+		&maxTTL,
+		&createdAt,
+	); err != nil {
+		return models.AppRoleModel{}, err
+	}
+
+	var err error
+	if role.VaultIDs, err = decodeInts(vaultIDs); err != nil {
+		return models.AppRoleModel{}, err
+	}
+	if role.Paths, err = decodeList(paths); err != nil {
+		return models.AppRoleModel{}, err
+	}
+	if role.TransitKeys, err = decodeList(transitKeys); err != nil {
+		return models.AppRoleModel{}, err
+	}
+	if role.Keys, err = decodeList(keys); err != nil {
+		return models.AppRoleModel{}, err
+	}
+	if role.Capabilities, err = decodeList(capabilities); err != nil {
+		return models.AppRoleModel{}, err
+	}
+	role.Expires = time.Duration(expires)
+	role.MaxExpires = time.Duration(maxExpires)
+	role.SecretIDExpires = time.Duration(secretIDExpires)
+	role.MaxTTL = time.Duration(maxTTL)
+	role.CreatedAt, err = parseTime(createdAt)
+	return role, err
+}
+
+func scanSecretID(row scanner) (models.SecretIDModel, error) {
+	var model models.SecretIDModel
+	var cidrs, createdAt string
+	var expiresAt sql.NullString
+	if err := row.Scan(&model.Accessor, &model.SingleUse, &cidrs, &expiresAt, &createdAt); err != nil {
+		return models.SecretIDModel{}, err
+	}
+
+	var err error
+	if model.CIDRs, err = decodeList(cidrs); err != nil {
+		return models.SecretIDModel{}, err
+	}
+	if model.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+		return models.SecretIDModel{}, err
+	}
+	model.CreatedAt, err = parseTime(createdAt)
+	return model, err
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// CreateAuditEntry appends an audit entry, the document is stored verbatim so its hash can be verified.
+func (c *Client) CreateAuditEntry(ctx context.Context, log *slog.Logger, seq int64, document string) error {
+	const op = "db.sqlite.CreateAuditEntry"
+
+	createAuditEntryQuery := `
+		INSERT INTO audit_log
+			(seq, document, created_at)
+		VALUES (?1, ?2, ?3);
+	`
+
+	log.Debug("create audit entry query", slog.String("op", op), slog.String("query", utils.QueryConvert(createAuditEntryQuery)))
+
+	if _, err := c.db.ExecContext(ctx, createAuditEntryQuery, seq, document, formatTime(time.Now())); err != nil {
+		log.Error("failed to save audit entry", sl.OpErr(op, err))
+		return errors.New("failed to save audit entry")
+	}
+
+	return nil
+}
+
+func (c *Client) LastAuditEntry(ctx context.Context, log *slog.Logger) (string, error) {
+	const op = "db.sqlite.LastAuditEntry"
+
+	lastAuditEntryQuery := `
+		SELECT document FROM audit_log
+		ORDER BY seq DESC
+		LIMIT 1;
+	`
+
+	log.Debug("last audit entry query", slog.String("op", op), slog.String("query", utils.QueryConvert(lastAuditEntryQuery)))
+
+	var document string
+	if err := c.db.QueryRowContext(ctx, lastAuditEntryQuery).Scan(&document); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("audit log is empty")
+		}
+		log.Error("failed to get last audit entry", sl.OpErr(op, err))
+		return "", errors.New("failed to get last audit entry")
+	}
+
+	return document, nil
+}
+
+func (c *Client) ListAuditEntries(ctx context.Context, log *slog.Logger, afterSeq int64, limit int) ([]string, error) {
+	const op = "db.sqlite.ListAuditEntries"
+
+	listAuditEntriesQuery := `
+		SELECT document FROM audit_log
+		WHERE seq > ?1
+		ORDER BY seq
+		LIMIT ?2;
+	`
+
+	log.Debug("list audit entries query", slog.String("op", op), slog.String("query", utils.QueryConvert(listAuditEntriesQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listAuditEntriesQuery, afterSeq, limit)
+	if err != nil {
+		log.Error("failed to list audit entries", sl.OpErr(op, err))
+		return nil, errors.New("failed to list audit entries")
+	}
+	defer rows.Close()
+
+	documents := make([]string, 0, limit)
+	for rows.Next() {
+		var document string
+		if err := rows.Scan(&document); err != nil {
+			log.Error("failed to scan audit entries", sl.OpErr(op, err))
+			return nil, errors.New("failed to list audit entries")
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, rows.Err()
+}
@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// UndeleteVault restores a soft deleted vault, it fails when its path was taken in the meantime.
+func (c *Client) UndeleteVault(ctx context.Context, log *slog.Logger, id int) error {
+	const op = "db.sqlite.UndeleteVault"
+
+	undeleteVaultQuery := `
+		UPDATE vault
+		SET deleted_at = NULL
+		WHERE id = ?1 AND deleted_at IS NOT NULL;
+	`
+
+	log.Debug("undelete vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(undeleteVaultQuery)))
+
+	res, err := c.db.ExecContext(ctx, undeleteVaultQuery, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("vault already exists")
+		}
+		log.Error("failed to undelete vault", sl.OpErr(op, err))
+		return errors.New("failed to undelete vault")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("vault not found")
+	}
+
+	return nil
+}
+
+// DestroyVault removes a live or soft deleted vault with all its versions, it can't be undone.
+// The values are crypto-shredded: the wrapped data key is discarded with the vault row, copies
+// of the ciphertext left in database pages can't be decrypted without it. Backups taken
+// before still hold the wrapped key.
+func (c *Client) DestroyVault(ctx context.Context, log *slog.Logger, id int) error {
+	const op = "db.sqlite.DestroyVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	destroyVaultQuery := `
+		DELETE FROM vault
+		WHERE id = ?1;
+	`
+
+	log.Debug("destroy vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(destroyVaultQuery)))
+
+	res, err := tx.ExecContext(ctx, destroyVaultQuery, id)
+	if err != nil {
+		log.Error("failed to destroy vault", sl.OpErr(op, err))
+		return errors.New("failed to destroy vault")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("vault not found")
+	}
+
+	return tx.Commit()
+}
+
+// DeleteKeys soft deletes the keys in every version of the vault, unknown keys are ignored.
+func (c *Client) DeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.sqlite.DeleteKeys"
+
+	deleteKeysQuery := `
+		UPDATE value
+		SET deleted_at = ?3
+		WHERE vault_id = ?1 AND key IN (SELECT value FROM json_each(?2)) AND deleted_at IS NULL;
+	`
+
+	return c.updateKeys(ctx, log, op, id, keys, deleteKeysQuery)
+}
+
+// UndeleteKeys restores soft deleted keys in every version of the vault.
+func (c *Client) UndeleteKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.sqlite.UndeleteKeys"
+
+	undeleteKeysQuery := `
+		UPDATE value
+		SET deleted_at = NULL
+		WHERE vault_id = ?1 AND key IN (SELECT value FROM json_each(?2));
+	`
+
+	return c.updateKeys(ctx, log, op, id, keys, undeleteKeysQuery)
+}
+
+// DestroyKeys blanks the ciphertext of the keys in every version of the vault and removes them.
+// The data key is shared with the other keys, old copies of the rows are not shredded.
+func (c *Client) DestroyKeys(ctx context.Context, log *slog.Logger, id int, keys []string) error {
+	const op = "db.sqlite.DestroyKeys"
+
+	wipeKeysQuery := `
+		UPDATE value
+		SET value = ''
+		WHERE vault_id = ?1 AND key IN (SELECT value FROM json_each(?2));
+	`
+	destroyKeysQuery := `
+		DELETE FROM value
+		WHERE vault_id = ?1 AND key IN (SELECT value FROM json_each(?2));
+	`
+
+	return c.updateKeys(ctx, log, op, id, keys, wipeKeysQuery, destroyKeysQuery)
+}
+
+// updateKeys runs the key queries on a live vault, the queries take the vault id, the keys and the current time.
+func (c *Client) updateKeys(ctx context.Context, log *slog.Logger, op string, id int, keys []string, queries ...string) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	checkVaultQuery := `
+		SELECT id FROM vault
+		WHERE id = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2);
+	`
+
+	log.Debug("check vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVaultQuery)))
+
+	now := formatTime(time.Now())
+	if err := tx.QueryRowContext(ctx, checkVaultQuery, id, now).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("vault not found")
+		}
+		log.Error("failed to get vault", sl.OpErr(op, err))
+		return errors.New("failed to get vault")
+	}
+
+	for _, query := range queries {
+		log.Debug("update keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(query)))
+
+		if _, err := tx.ExecContext(ctx, query, id, encodeList(keys), now); err != nil {
+			log.Error("failed to update vault keys", sl.OpErr(op, err))
+			return errors.New("failed to update vault keys")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PurgeDeleted permanently removes vaults and keys soft deleted before the time,
+// it returns the number of removed vaults and values.
+func (c *Client) PurgeDeleted(ctx context.Context, log *slog.Logger, before time.Time) (int, int, error) {
+	const op = "db.sqlite.PurgeDeleted"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	purgeVaultsQuery := `
+		DELETE FROM vault
+		WHERE deleted_at < ?1;
+	`
+
+	log.Debug("purge vaults query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeVaultsQuery)))
+
+	vaults, err := tx.ExecContext(ctx, purgeVaultsQuery, formatTime(before))
+	if err != nil {
+		log.Error("failed to purge vaults", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge deleted vaults")
+	}
+
+	purgeValuesQuery := `
+		DELETE FROM value
+		WHERE deleted_at < ?1;
+	`
+
+	log.Debug("purge values query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeValuesQuery)))
+
+	values, err := tx.ExecContext(ctx, purgeValuesQuery, formatTime(before))
+	if err != nil {
+		log.Error("failed to purge values", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to purge deleted values")
+	}
+
+	purgedVaults, _ := vaults.RowsAffected()
+	purgedValues, _ := values.RowsAffected()
+	return int(purgedVaults), int(purgedValues), tx.Commit()
+}
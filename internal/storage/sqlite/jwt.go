@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// ListJWTKeys returns the signing keys ordered by creation.
+func (c *Client) ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error) {
+	const op = "db.sqlite.ListJWTKeys"
+
+	listKeysQuery := `
+		SELECT kid, algorithm, state, material, public_key, created_at FROM jwt_key
+		ORDER BY created_at, kid;
+	`
+
+	log.Debug("list jwt keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listKeysQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listKeysQuery)
+	if err != nil {
+		log.Error("failed to list jwt keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list jwt keys")
+	}
+	defer rows.Close()
+
+	keys := make([]models.JWTKeyModel, 0)
+	for rows.Next() {
+		var key models.JWTKeyModel
+		var createdAt string
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.State, &key.Key, &key.PublicKey, &createdAt); err != nil {
+			log.Error("failed to scan jwt keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list jwt keys")
+		}
+		if key.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse jwt key time", sl.OpErr(op, err))
+			return nil, errors.New("failed to list jwt keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list jwt keys")
+	}
+
+	return keys, nil
+}
+
+func (c *Client) CreateJWTKey(ctx context.Context, log *slog.Logger, model models.JWTKeyModel) error {
+	const op = "db.sqlite.CreateJWTKey"
+
+	createKeyQuery := `
+		INSERT INTO jwt_key
+			(kid, algorithm, state, material, public_key, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6);
+	`
+
+	log.Debug("create jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(createKeyQuery)))
+
+	if _, err := c.db.ExecContext(ctx, createKeyQuery, model.ID, model.Algorithm, model.State, model.Key, model.PublicKey, formatTime(time.Now())); err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("jwt key already exists")
+		}
+		log.Error("failed to save jwt key", sl.OpErr(op, err))
+		return errors.New("failed to save jwt key")
+	}
+
+	return nil
+}
+
+func (c *Client) PromoteJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	const op = "db.sqlite.PromoteJWTKey"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	state, err := getJWTKeyState(ctx, tx, log, kid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("jwt key not found")
+		}
+		log.Error("failed to get jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+	if state == models.JWTKeyRetired {
+		return errors.New("jwt key is retired")
+	}
+
+	demoteKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'verify'
+		WHERE state = 'active';
+	`
+
+	log.Debug("demote jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(demoteKeyQuery)))
+
+	if _, err := tx.ExecContext(ctx, demoteKeyQuery); err != nil {
+		log.Error("failed to demote jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+
+	promoteKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'active'
+		WHERE kid = ?1;
+	`
+
+	log.Debug("promote jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(promoteKeyQuery)))
+
+	if _, err := tx.ExecContext(ctx, promoteKeyQuery, kid); err != nil {
+		log.Error("failed to promote jwt key", sl.OpErr(op, err))
+		return errors.New("failed to promote jwt key")
+	}
+
+	return tx.Commit()
+}
+
+func (c *Client) RetireJWTKey(ctx context.Context, log *slog.Logger, kid string) error {
+	const op = "db.sqlite.RetireJWTKey"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	state, err := getJWTKeyState(ctx, tx, log, kid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("jwt key not found")
+		}
+		log.Error("failed to get jwt key", sl.OpErr(op, err))
+		return errors.New("failed to retire jwt key")
+	}
+	if state == models.JWTKeyActive {
+		return errors.New("jwt key is active")
+	}
+
+	retireKeyQuery := `
+		UPDATE jwt_key
+		SET state = 'retired'
+		WHERE kid = ?1;
+	`
+
+	log.Debug("retire jwt key query", slog.String("op", op), slog.String("query", utils.QueryConvert(retireKeyQuery)))
+
+	if _, err := tx.ExecContext(ctx, retireKeyQuery, kid); err != nil {
+		log.Error("failed to retire jwt key", sl.OpErr(op, err))
+		return errors.New("failed to retire jwt key")
+	}
+
+	return tx.Commit()
+}
+
+func getJWTKeyState(ctx context.Context, tx *sql.Tx, log *slog.Logger, kid string) (string, error) {
+	getStateQuery := `
+		SELECT state FROM jwt_key
+		WHERE kid = ?1;
+	`
+
+	log.Debug("get jwt key state query", slog.String("query", utils.QueryConvert(getStateQuery)))
+
+	var state string
+	err := tx.QueryRowContext(ctx, getStateQuery, kid).Scan(&state)
+	return state, err
+}
@@ -0,0 +1,255 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// GetKeyring returns the stored keyring terms ordered by term.
+func (c *Client) GetKeyring(ctx context.Context, log *slog.Logger) ([]models.KeyringTerm, error) {
+	const op = "db.sqlite.GetKeyring"
+
+	getKeyringQuery := `
+		SELECT term, material, created_at FROM keyring
+		ORDER BY term;
+	`
+
+	log.Debug("get keyring query", slog.String("op", op), slog.String("query", utils.QueryConvert(getKeyringQuery)))
+
+	rows, err := c.db.QueryContext(ctx, getKeyringQuery)
+	if err != nil {
+		log.Error("failed to get keyring", sl.OpErr(op, err))
+		return nil, errors.New("failed to get keyring")
+	}
+	defer rows.Close()
+
+	terms := make([]models.KeyringTerm, 0)
+	for rows.Next() {
+		var term models.KeyringTerm
+		var createdAt string
+		if err := rows.Scan(&term.Term, &term.Key, &createdAt); err != nil {
+			log.Error("failed to scan keyring", sl.OpErr(op, err))
+			return nil, errors.New("failed to get keyring")
+		}
+		if term.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse keyring time", sl.OpErr(op, err))
+			return nil, errors.New("failed to get keyring")
+		}
+		terms = append(terms, term)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to get keyring")
+	}
+
+	return terms, nil
+}
+
+func (c *Client) AddKeyringTerm(ctx context.Context, log *slog.Logger, term models.KeyringTerm) error {
+	const op = "db.sqlite.AddKeyringTerm"
+
+	addTermQuery := `
+		INSERT INTO keyring
+			(term, material, created_at)
+		VALUES (?1, ?2, ?3);
+	`
+
+	log.Debug("add keyring term query", slog.String("op", op), slog.String("query", utils.QueryConvert(addTermQuery)))
+
+	if _, err := c.db.ExecContext(ctx, addTermQuery, term.Term, term.Key, formatTime(time.Now())); err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("keyring term already exists")
+		}
+		log.Error("failed to save keyring term", sl.OpErr(op, err))
+		return errors.New("failed to save keyring term")
+	}
+
+	return nil
+}
+
+// ListStaleKeys returns vault data keys first, then transit key material and JWT keys, all in a stable order
+// so batches move forward as keys are rewrapped.
+func (c *Client) ListStaleKeys(ctx context.Context, log *slog.Logger, prefix string, limit int) ([]models.WrappedKey, error) {
+	const op = "db.sqlite.ListStaleKeys"
+
+	listVaultKeysQuery := `
+		SELECT id, data_key FROM vault
+		WHERE data_key IS NOT NULL AND data_key <> '' AND data_key NOT LIKE ?1 || '%'
+		ORDER BY id
+		LIMIT ?2;
+	`
+
+	log.Debug("list stale vault keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVaultKeysQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listVaultKeysQuery, prefix, limit)
+	if err != nil {
+		log.Error("failed to list stale vault keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer rows.Close()
+
+	keys := make([]models.WrappedKey, 0)
+	for rows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyVault}
+		if err := rows.Scan(&key.ID, &key.Key); err != nil {
+			log.Error("failed to scan stale vault keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	if len(keys) == limit {
+		return keys, nil
+	}
+
+	listTransitKeysQuery := `
+		SELECT k.name, v.version, v.material FROM transit_key_version v
+		JOIN transit_key k ON k.id = v.key_id
+		WHERE v.material NOT LIKE ?1 || '%'
+		ORDER BY k.id, v.version
+		LIMIT ?2;
+	`
+
+	log.Debug("list stale transit keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTransitKeysQuery)))
+
+	transitRows, err := c.db.QueryContext(ctx, listTransitKeysQuery, prefix, limit-len(keys))
+	if err != nil {
+		log.Error("failed to list stale transit keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer transitRows.Close()
+
+	for transitRows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyTransit}
+		if err := transitRows.Scan(&key.Name, &key.Version, &key.Key); err != nil {
+			log.Error("failed to scan stale transit keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := transitRows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	if len(keys) == limit {
+		return keys, nil
+	}
+
+	listJWTKeysQuery := `
+		SELECT kid, material FROM jwt_key
+		WHERE material NOT LIKE ?1 || '%'
+		ORDER BY kid
+		LIMIT ?2;
+	`
+
+	log.Debug("list stale jwt keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listJWTKeysQuery)))
+
+	jwtRows, err := c.db.QueryContext(ctx, listJWTKeysQuery, prefix, limit-len(keys))
+	if err != nil {
+		log.Error("failed to list stale jwt keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+	defer jwtRows.Close()
+
+	for jwtRows.Next() {
+		key := models.WrappedKey{Kind: models.WrappedKeyJWT}
+		if err := jwtRows.Scan(&key.Name, &key.Key); err != nil {
+			log.Error("failed to scan stale jwt keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list stale keys")
+		}
+		keys = append(keys, key)
+	}
+
+	if err := jwtRows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list stale keys")
+	}
+
+	return keys, nil
+}
+
+func (c *Client) CountStaleKeys(ctx context.Context, log *slog.Logger, prefix string) (int, int, error) {
+	const op = "db.sqlite.CountStaleKeys"
+
+	countKeysQuery := `
+		SELECT
+			(SELECT COUNT(*) FROM vault
+				WHERE data_key IS NOT NULL AND data_key <> '' AND data_key NOT LIKE ?1 || '%') +
+			(SELECT COUNT(*) FROM transit_key_version
+				WHERE material NOT LIKE ?1 || '%') +
+			(SELECT COUNT(*) FROM jwt_key
+				WHERE material NOT LIKE ?1 || '%'),
+			(SELECT COUNT(*) FROM vault
+				WHERE data_key IS NOT NULL AND data_key <> '') +
+			(SELECT COUNT(*) FROM transit_key_version) +
+			(SELECT COUNT(*) FROM jwt_key);
+	`
+
+	log.Debug("count stale keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(countKeysQuery)))
+
+	var pending, total int
+	if err := c.db.QueryRowContext(ctx, countKeysQuery, prefix).Scan(&pending, &total); err != nil {
+		log.Error("failed to count stale keys", sl.OpErr(op, err))
+		return 0, 0, errors.New("failed to count stale keys")
+	}
+
+	return pending, total, nil
+}
+
+func (c *Client) UpdateWrappedKey(ctx context.Context, log *slog.Logger, key models.WrappedKey, old string) error {
+	const op = "db.sqlite.UpdateWrappedKey"
+
+	var updateKeyQuery string
+	var args []any
+	switch key.Kind {
+	case models.WrappedKeyVault:
+		updateKeyQuery = `
+			UPDATE vault
+			SET data_key = ?2
+			WHERE id = ?1 AND data_key = ?3;
+		`
+		args = []any{key.ID, key.Key, old}
+	case models.WrappedKeyTransit:
+		updateKeyQuery = `
+			UPDATE transit_key_version
+			SET material = ?3
+			WHERE key_id = (SELECT id FROM transit_key WHERE name = ?1) AND version = ?2 AND material = ?4;
+		`
+		args = []any{key.Name, key.Version, key.Key, old}
+	case models.WrappedKeyJWT:
+		updateKeyQuery = `
+			UPDATE jwt_key
+			SET material = ?2
+			WHERE kid = ?1 AND material = ?3;
+		`
+		args = []any{key.Name, key.Key, old}
+	default:
+		return errors.New("unknown wrapped key kind")
+	}
+
+	log.Debug("update wrapped key query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateKeyQuery)))
+
+	res, err := c.db.ExecContext(ctx, updateKeyQuery, args...)
+	if err != nil {
+		log.Error("failed to update wrapped key", sl.OpErr(op, err))
+		return errors.New("failed to update wrapped key")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("wrapped key changed")
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// vaultSortColumns maps sorts to the columns of their (column, id) index.
+var vaultSortColumns = map[string]string{
+	models.SortName:      "v.name",
+	models.SortCreatedAt: "v.created_at",
+	models.SortID:        "v.id",
+}
+
+func (c *Client) ListVaults(ctx context.Context, log *slog.Logger, filter models.VaultFilter) (models.VaultPage, error) {
+	const op = "db.sqlite.ListVaults"
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("?%d", len(args))
+	}
+
+	conditions := []string{"v.deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "v.deleted_at IS NOT NULL"
+	}
+	now := arg(formatTime(time.Now()))
+	conditions = append(conditions, "(v.expires_at IS NULL OR v.expires_at > "+now+")")
+	if filter.Prefix != "" {
+		conditions = append(conditions, "v.name >= "+arg(filter.Prefix))
+		if end := models.PrefixEnd(filter.Prefix); end != "" {
+			conditions = append(conditions, "v.name < "+arg(end))
+		}
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM vault_tag t WHERE t.vault_id = v.id AND t.tag = "+arg(tag)+")")
+	}
+	if filter.Owner != "" {
+		conditions = append(conditions, "m.owner = "+arg(filter.Owner))
+	}
+	if filter.CreatedBy != "" {
+		conditions = append(conditions, "m.created_by = "+arg(filter.CreatedBy))
+	}
+	for k, value := range filter.Attributes {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM vault_attribute a WHERE a.vault_id = v.id AND a.key = %s AND a.value = %s)", arg(k), arg(value)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "v.created_at >= "+arg(formatTime(filter.CreatedAfter)))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "v.created_at < "+arg(formatTime(filter.CreatedBefore)))
+	}
+
+	column := vaultSortColumns[filter.Sort]
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if after := filter.After; after != nil {
+		switch filter.Sort {
+		case models.SortName:
+			conditions = append(conditions, fmt.Sprintf("(v.name, v.id) %s (%s, %s)", compare, arg(after.Name), arg(after.ID)))
+		case models.SortCreatedAt:
+			conditions = append(conditions, fmt.Sprintf("(v.created_at, v.id) %s (%s, %s)", compare, arg(formatTime(after.CreatedAt)), arg(after.ID)))
+		default:
+			conditions = append(conditions, fmt.Sprintf("v.id %s %s", compare, arg(after.ID)))
+		}
+	}
+
+	order := fmt.Sprintf("%s %s", column, direction)
+	if filter.Sort != models.SortID {
+		order += fmt.Sprintf(", v.id %s", direction)
+	}
+
+	listVaultsQuery := fmt.Sprintf(`
+		SELECT v.id, v.name, v.current_version, v.created_at, v.deleted_at, v.expires_at,
+			(SELECT COUNT(*) FROM value val WHERE val.vault_id = v.id AND val.version = v.current_version AND val.deleted_at IS NULL
+				AND (val.expires_at IS NULL OR val.expires_at > %s)),
+			(SELECT json_group_array(tag) FROM (SELECT t.tag FROM vault_tag t WHERE t.vault_id = v.id ORDER BY t.tag)),
+			m.description, m.owner, m.created_by,
+			(SELECT json_group_object(a.key, a.value) FROM vault_attribute a WHERE a.vault_id = v.id)
+		FROM vault v
+		JOIN vault_metadata m ON m.vault_id = v.id
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s;
+	`, now, strings.Join(conditions, " AND "), order, arg(filter.Limit+1))
+
+	log.Debug("list vaults query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVaultsQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listVaultsQuery, args...)
+	if err != nil {
+		log.Error("failed to list vaults", sl.OpErr(op, err))
+		return models.VaultPage{}, errors.New("failed to list vaults")
+	}
+	defer rows.Close()
+
+	vaults := make([]models.VaultModel, 0)
+	for rows.Next() {
+		var vault models.VaultModel
+		var createdAt, tags, attributes string
+		var deletedAt, expiresAt sql.NullString
+		if err := rows.Scan(&vault.ID, &vault.Name, &vault.Version, &createdAt, &deletedAt, &expiresAt, &vault.KeyCount, &tags,
+			&vault.Description, &vault.Owner, &vault.CreatedBy, &attributes); err != nil {
+			log.Error("failed to scan vaults", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		if vault.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse vault time", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		if vault.DeletedAt, err = parseNullTime(deletedAt); err != nil {
+			log.Error("failed to parse vault time", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		if vault.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+			log.Error("failed to parse vault time", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		if vault.Tags, err = decodeList(tags); err != nil {
+			log.Error("failed to decode vault tags", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		if vault.Attributes, err = decodeMap(attributes); err != nil {
+			log.Error("failed to decode vault attributes", sl.OpErr(op, err))
+			return models.VaultPage{}, errors.New("failed to list vaults")
+		}
+		vaults = append(vaults, vault)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return models.VaultPage{}, errors.New("failed to list vaults")
+	}
+
+	return filter.Page(vaults), nil
+}
+
+// ListChildren returns the next path segment of every vault below the prefix,
+// segments with vaults further down end with /.
+func (c *Client) ListChildren(ctx context.Context, log *slog.Logger, prefix string) ([]string, error) {
+	const op = "db.sqlite.ListChildren"
+
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	listChildrenQuery := `
+		SELECT DISTINCT CASE WHEN instr(rest, '/') > 0 THEN substr(rest, 1, instr(rest, '/')) ELSE rest END AS child
+		FROM (
+			SELECT substr(name, length(?1) + 1) AS rest FROM vault
+			WHERE name >= ?1 AND (?2 = '' OR name < ?2) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?3)
+		)
+		ORDER BY child;
+	`
+
+	log.Debug("list children query", slog.String("op", op), slog.String("query", utils.QueryConvert(listChildrenQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listChildrenQuery, prefix, models.PrefixEnd(prefix), formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to list children", sl.OpErr(op, err))
+		return nil, errors.New("failed to list children")
+	}
+	defer rows.Close()
+
+	children := make([]string, 0)
+	for rows.Next() {
+		var child string
+		if err := rows.Scan(&child); err != nil {
+			log.Error("failed to scan children", sl.OpErr(op, err))
+			return nil, errors.New("failed to list children")
+		}
+		children = append(children, child)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list children")
+	}
+
+	return children, nil
+}
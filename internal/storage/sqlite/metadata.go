@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// GetMetadata returns the metadata of a live vault.
+func (c *Client) GetMetadata(ctx context.Context, log *slog.Logger, id int) (models.MetadataModel, error) {
+	const op = "db.sqlite.GetMetadata"
+
+	getMetadataQuery := `
+		SELECT v.id, v.name, m.description, m.owner, m.contact_email, m.created_by, v.created_at, m.updated_at,
+			(SELECT json_group_array(tag) FROM (SELECT t.tag FROM vault_tag t WHERE t.vault_id = v.id ORDER BY t.tag)),
+			(SELECT json_group_object(a.key, a.value) FROM vault_attribute a WHERE a.vault_id = v.id)
+		FROM vault v
+		JOIN vault_metadata m ON m.vault_id = v.id
+		WHERE v.id = ?1 AND v.deleted_at IS NULL AND (v.expires_at IS NULL OR v.expires_at > ?2);
+	`
+
+	log.Debug("get metadata query", slog.String("op", op), slog.String("query", utils.QueryConvert(getMetadataQuery)))
+
+	var metadata models.MetadataModel
+	var createdAt, updatedAt, tags, attributes string
+	err := c.db.QueryRowContext(ctx, getMetadataQuery, id, formatTime(time.Now())).Scan(&metadata.ID, &metadata.Name, &metadata.Description,
+		&metadata.Owner, &metadata.ContactEmail, &metadata.CreatedBy, &createdAt, &updatedAt, &tags, &attributes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MetadataModel{}, errors.New("vault not found")
+		}
+		log.Error("failed to get vault metadata", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+	if metadata.CreatedAt, err = parseTime(createdAt); err != nil {
+		log.Error("failed to parse vault time", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+	if metadata.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		log.Error("failed to parse vault time", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+	if metadata.Tags, err = decodeList(tags); err != nil {
+		log.Error("failed to decode vault tags", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+	if metadata.Attributes, err = decodeMap(attributes); err != nil {
+		log.Error("failed to decode vault attributes", sl.OpErr(op, err))
+		return models.MetadataModel{}, errors.New("failed to get vault metadata")
+	}
+
+	return metadata, nil
+}
+
+// UpdateMetadata edits the metadata of a live vault, the vault data and version are left alone.
+func (c *Client) UpdateMetadata(ctx context.Context, log *slog.Logger, id int, model models.MetadataDTO) error {
+	const op = "db.sqlite.UpdateMetadata"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	updateMetadataQuery := `
+		UPDATE vault_metadata
+		SET description = COALESCE(?2, description), owner = COALESCE(?3, owner),
+			contact_email = COALESCE(?4, contact_email), updated_at = ?5
+		WHERE vault_id = (
+			SELECT id FROM vault
+			WHERE id = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?5)
+		);
+	`
+
+	log.Debug("update metadata query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateMetadataQuery)))
+
+	res, err := tx.ExecContext(ctx, updateMetadataQuery, id, model.Description, model.Owner, model.ContactEmail, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to update vault metadata", sl.OpErr(op, err))
+		return errors.New("failed to update vault metadata")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("vault not found")
+	}
+
+	if model.Tags != nil {
+		if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+			log.Error("failed to save vault tags", sl.OpErr(op, err))
+			return errors.New("failed to save vault tags")
+		}
+	}
+
+	if model.Attributes != nil {
+		if err := replaceAttributes(ctx, tx, log, id, model.Attributes); err != nil {
+			log.Error("failed to save vault attributes", sl.OpErr(op, err))
+			return errors.New("failed to save vault attributes")
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertMetadata(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, createdBy string, now time.Time) error {
+	createMetadataQuery := `
+		INSERT INTO vault_metadata
+			(vault_id, created_by, updated_at)
+		VALUES (?1, ?2, ?3);
+	`
+
+	log.Debug("create metadata query", slog.String("query", utils.QueryConvert(createMetadataQuery)))
+
+	_, err := tx.ExecContext(ctx, createMetadataQuery, id, createdBy, formatTime(now))
+	return err
+}
+
+// touchMetadata marks the metadata as updated when it is changed together with the vault data.
+func touchMetadata(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int) error {
+	touchMetadataQuery := `
+		UPDATE vault_metadata
+		SET updated_at = ?2
+		WHERE vault_id = ?1;
+	`
+
+	log.Debug("touch metadata query", slog.String("query", utils.QueryConvert(touchMetadataQuery)))
+
+	_, err := tx.ExecContext(ctx, touchMetadataQuery, id, formatTime(time.Now()))
+	return err
+}
+
+// replaceAttributes sets the custom attributes of the vault, the previous attributes are removed.
+func replaceAttributes(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, attributes map[string]string) error {
+	deleteAttributesQuery := `
+		DELETE FROM vault_attribute
+		WHERE vault_id = ?1;
+	`
+
+	log.Debug("delete attributes query", slog.String("query", utils.QueryConvert(deleteAttributesQuery)))
+
+	if _, err := tx.ExecContext(ctx, deleteAttributesQuery, id); err != nil {
+		return err
+	}
+
+	createAttributesQuery := `
+		INSERT INTO vault_attribute
+			(vault_id, key, value)
+		SELECT ?1, key, value FROM json_each(?2);
+	`
+
+	log.Debug("create attributes query", slog.String("query", utils.QueryConvert(createAttributesQuery)))
+
+	_, err := tx.ExecContext(ctx, createAttributesQuery, id, encodeMap(attributes))
+	return err
+}
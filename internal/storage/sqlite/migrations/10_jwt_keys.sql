@@ -0,0 +1,8 @@
+-- signing keys of user tokens, the secret is wrapped like the data keys of vaults
+CREATE TABLE IF NOT EXISTS jwt_key(
+    kid TEXT PRIMARY KEY,
+    state TEXT NOT NULL CHECK (state IN ('active', 'verify', 'retired')),
+    material TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS jwt_key_active_idx ON jwt_key(state) WHERE state = 'active';
@@ -0,0 +1,2 @@
+ALTER TABLE jwt_key ADD COLUMN algorithm TEXT NOT NULL DEFAULT 'HS512';
+ALTER TABLE jwt_key ADD COLUMN public_key TEXT NOT NULL DEFAULT '';
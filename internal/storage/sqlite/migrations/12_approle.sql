@@ -0,0 +1,26 @@
+-- tokens issued through a role are bound like tokens of /root/create-token, lifetimes are in seconds
+CREATE TABLE IF NOT EXISTS approle(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    role_id TEXT NOT NULL UNIQUE,
+    vault_ids TEXT NOT NULL DEFAULT '[]',
+    paths TEXT NOT NULL DEFAULT '[]',
+    transit_keys TEXT NOT NULL DEFAULT '[]',
+    keys TEXT NOT NULL DEFAULT '[]',
+    capabilities TEXT NOT NULL DEFAULT '[]',
+    expires INTEGER NOT NULL,
+    max_expires INTEGER NOT NULL DEFAULT 0,
+    secret_id_expires INTEGER NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL
+);
+-- only the hash of a secret id is stored
+CREATE TABLE IF NOT EXISTS approle_secret_id(
+    approle_id INTEGER NOT NULL REFERENCES approle(id) ON DELETE CASCADE,
+    accessor TEXT NOT NULL,
+    secret_hash TEXT NOT NULL UNIQUE,
+    single_use INTEGER NOT NULL DEFAULT 0,
+    cidrs TEXT NOT NULL DEFAULT '[]',
+    expires_at TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (approle_id, accessor)
+);
@@ -0,0 +1,21 @@
+-- human admin accounts, passwords are stored as argon2id hashes
+CREATE TABLE IF NOT EXISTS operator(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    policies TEXT NOT NULL DEFAULT '[]',
+    failed_logins INTEGER NOT NULL DEFAULT 0,
+    locked_until TEXT,
+    created_at TEXT NOT NULL
+);
+-- sessions and API keys of operators, only the hash of a token is stored
+CREATE TABLE IF NOT EXISTS operator_token(
+    operator_id INTEGER NOT NULL REFERENCES operator(id) ON DELETE CASCADE,
+    accessor TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    expires_at TEXT,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (operator_id, accessor)
+);
@@ -0,0 +1,3 @@
+-- tokens of renewable roles can be renewed until max_ttl seconds after they were issued
+ALTER TABLE approle ADD COLUMN renewable INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE approle ADD COLUMN max_ttl INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- wrapped responses, the payload is encrypted with its own data key and only the hash of the wrapping token is stored
+CREATE TABLE IF NOT EXISTS wrapping(
+    token_hash TEXT PRIMARY KEY,
+    accessor TEXT NOT NULL UNIQUE,
+    creation_path TEXT NOT NULL,
+    data_key TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS inx_wrapping_expires_at ON wrapping(expires_at);
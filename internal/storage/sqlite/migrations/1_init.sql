@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS vault(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    data_key TEXT,
+    current_version INTEGER NOT NULL DEFAULT 1
+);
+CREATE TABLE IF NOT EXISTS vault_version(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (vault_id, version)
+);
+CREATE TABLE IF NOT EXISTS value(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    vault_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    encrypted INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (vault_id, version) REFERENCES vault_version(vault_id, version) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS inx_value_vault_version ON value(vault_id, version);
+CREATE TABLE IF NOT EXISTS seal_config(
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    shares INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    verification TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS token(
+    jti TEXT PRIMARY KEY,
+    issued_at TEXT NOT NULL,
+    expires_at TEXT NOT NULL,
+    revoked_at TEXT,
+    keys TEXT NOT NULL DEFAULT '[]',
+    capabilities TEXT NOT NULL DEFAULT '[]'
+);
+CREATE TABLE IF NOT EXISTS token_vault(
+    jti TEXT NOT NULL REFERENCES token(jti) ON DELETE CASCADE,
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    PRIMARY KEY (jti, vault_id)
+);
+CREATE INDEX IF NOT EXISTS inx_token_vault_vault_id ON token_vault(vault_id);
+CREATE TABLE IF NOT EXISTS policy(
+    name TEXT PRIMARY KEY,
+    document TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS admin_identity(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    token_hash TEXT NOT NULL UNIQUE,
+    policies TEXT NOT NULL DEFAULT '[]',
+    created_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS audit_log(
+    seq INTEGER PRIMARY KEY,
+    document TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
@@ -0,0 +1,10 @@
+ALTER TABLE vault ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+UPDATE vault SET created_at = COALESCE((SELECT MIN(created_at) FROM vault_version WHERE vault_id = vault.id), '');
+CREATE INDEX IF NOT EXISTS inx_vault_name ON vault(name, id);
+CREATE INDEX IF NOT EXISTS inx_vault_created_at ON vault(created_at, id);
+CREATE TABLE IF NOT EXISTS vault_tag(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (vault_id, tag)
+);
+CREATE INDEX IF NOT EXISTS inx_vault_tag_tag ON vault_tag(tag, vault_id);
@@ -0,0 +1,5 @@
+UPDATE vault SET name = name || '-' || id
+WHERE id NOT IN (SELECT MIN(id) FROM vault GROUP BY name);
+DROP INDEX IF EXISTS inx_vault_name;
+CREATE UNIQUE INDEX IF NOT EXISTS inx_vault_name ON vault(name);
+ALTER TABLE token ADD COLUMN paths TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1 @@
+ALTER TABLE vault ADD COLUMN cas_required INTEGER NOT NULL DEFAULT 0;
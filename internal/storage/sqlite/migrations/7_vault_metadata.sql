@@ -0,0 +1,20 @@
+-- metadata is kept in plaintext apart from the encrypted values so listings can filter on it
+CREATE TABLE IF NOT EXISTS vault_metadata(
+    vault_id INTEGER PRIMARY KEY REFERENCES vault(id) ON DELETE CASCADE,
+    description TEXT NOT NULL DEFAULT '',
+    owner TEXT NOT NULL DEFAULT '',
+    contact_email TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    updated_at TEXT NOT NULL
+);
+INSERT OR IGNORE INTO vault_metadata (vault_id, updated_at)
+SELECT id, created_at FROM vault;
+CREATE INDEX IF NOT EXISTS inx_vault_metadata_owner ON vault_metadata(owner) WHERE owner <> '';
+CREATE INDEX IF NOT EXISTS inx_vault_metadata_created_by ON vault_metadata(created_by) WHERE created_by <> '';
+CREATE TABLE IF NOT EXISTS vault_attribute(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (vault_id, key)
+);
+CREATE INDEX IF NOT EXISTS inx_vault_attribute_key_value ON vault_attribute(key, value, vault_id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS transit_key(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    latest_version INTEGER NOT NULL DEFAULT 1,
+    min_decryption_version INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL
+);
+-- key material is wrapped with the master key like the data keys of vaults
+CREATE TABLE IF NOT EXISTS transit_key_version(
+    key_id INTEGER NOT NULL REFERENCES transit_key(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    material TEXT NOT NULL,
+    public_key TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (key_id, version)
+);
+ALTER TABLE token ADD COLUMN transit_keys TEXT NOT NULL DEFAULT '[]';
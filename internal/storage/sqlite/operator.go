@@ -0,0 +1,376 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+const selectOperator = `
+	SELECT o.id, o.name, o.policies, o.failed_logins, o.locked_until, o.created_at FROM operator o
+`
+
+// CreateOperator stores a new operator account, passwordHash is the argon2id hash of its password.
+func (c *Client) CreateOperator(ctx context.Context, log *slog.Logger, model models.OperatorModel, passwordHash string) (int, error) {
+	const op = "db.sqlite.CreateOperator"
+
+	createOperatorQuery := `
+		INSERT INTO operator
+			(name, password_hash, policies, created_at)
+		VALUES (?1, ?2, ?3, ?4);
+	`
+
+	log.Debug("create operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(createOperatorQuery)))
+
+	res, err := c.db.ExecContext(ctx, createOperatorQuery, model.Name, passwordHash, encodeList(model.Policies), formatTime(time.Now()))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, errors.New("operator already exists")
+		}
+		log.Error("failed to save operator", sl.OpErr(op, err))
+		return 0, errors.New("failed to save operator")
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		log.Error("failed to get operator id", sl.OpErr(op, err))
+		return 0, errors.New("failed to save operator")
+	}
+
+	return int(id), nil
+}
+
+func (c *Client) ListOperators(ctx context.Context, log *slog.Logger) ([]models.OperatorModel, error) {
+	const op = "db.sqlite.ListOperators"
+
+	listOperatorsQuery := selectOperator + `ORDER BY o.name;`
+
+	log.Debug("list operators query", slog.String("op", op), slog.String("query", utils.QueryConvert(listOperatorsQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listOperatorsQuery)
+	if err != nil {
+		log.Error("failed to list operators", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operators")
+	}
+	defer rows.Close()
+
+	operators := make([]models.OperatorModel, 0)
+	for rows.Next() {
+		model, err := scanOperator(rows)
+		if err != nil {
+			log.Error("failed to scan operators", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operators")
+		}
+		operators = append(operators, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operators")
+	}
+
+	return operators, nil
+}
+
+// GetOperator returns the operator with the hash of its password.
+func (c *Client) GetOperator(ctx context.Context, log *slog.Logger, name string) (models.OperatorModel, string, error) {
+	const op = "db.sqlite.GetOperator"
+
+	getOperatorQuery := `
+		SELECT id, name, policies, failed_logins, locked_until, created_at, password_hash FROM operator
+		WHERE name = ?1;
+	`
+
+	log.Debug("get operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(getOperatorQuery)))
+
+	var model models.OperatorModel
+	var policies, createdAt, passwordHash string
+	var lockedUntil sql.NullString
+	err := c.db.QueryRowContext(ctx, getOperatorQuery, name).Scan(&model.ID, &model.Name, &policies, &model.FailedLogins, &lockedUntil, &createdAt, &passwordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.OperatorModel{}, "", errors.New("operator not found")
+		}
+		log.Error("failed to get operator", sl.OpErr(op, err))
+		return models.OperatorModel{}, "", errors.New("failed to get operator")
+	}
+
+	if err := decodeOperator(&model, policies, lockedUntil, createdAt); err != nil {
+		log.Error("failed to decode operator", sl.OpErr(op, err))
+		return models.OperatorModel{}, "", errors.New("failed to get operator")
+	}
+
+	return model, passwordHash, nil
+}
+
+// DeleteOperator removes the operator, its sessions and API keys are removed by the foreign key.
+func (c *Client) DeleteOperator(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.DeleteOperator"
+
+	deleteOperatorQuery := `
+		DELETE FROM operator
+		WHERE name = ?1;
+	`
+
+	log.Debug("delete operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteOperatorQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteOperatorQuery, name)
+	if err != nil {
+		log.Error("failed to delete operator", sl.OpErr(op, err))
+		return errors.New("failed to delete operator")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator not found")
+	}
+
+	return nil
+}
+
+// UpdateOperatorPassword replaces the password hash, unlocks the operator and ends its sessions,
+// API keys are kept.
+func (c *Client) UpdateOperatorPassword(ctx context.Context, log *slog.Logger, name string, passwordHash string) error {
+	const op = "db.sqlite.UpdateOperatorPassword"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	updatePasswordQuery := `
+		UPDATE operator
+		SET password_hash = ?2, failed_logins = 0, locked_until = NULL
+		WHERE name = ?1;
+	`
+
+	log.Debug("update operator password query", slog.String("op", op), slog.String("query", utils.QueryConvert(updatePasswordQuery)))
+
+	res, err := tx.ExecContext(ctx, updatePasswordQuery, name, passwordHash)
+	if err != nil {
+		log.Error("failed to update operator password", sl.OpErr(op, err))
+		return errors.New("failed to update operator password")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator not found")
+	}
+
+	deleteSessionsQuery := `
+		DELETE FROM operator_token
+		WHERE kind = ?2 AND operator_id = (SELECT id FROM operator WHERE name = ?1);
+	`
+
+	log.Debug("delete operator sessions query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteSessionsQuery)))
+
+	if _, err := tx.ExecContext(ctx, deleteSessionsQuery, name, models.OperatorSession); err != nil {
+		log.Error("failed to delete operator sessions", sl.OpErr(op, err))
+		return errors.New("failed to update operator password")
+	}
+
+	return tx.Commit()
+}
+
+// UnlockOperator resets the failed logins of the operator and lifts its lock.
+func (c *Client) UnlockOperator(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.UnlockOperator"
+
+	unlockOperatorQuery := `
+		UPDATE operator
+		SET failed_logins = 0, locked_until = NULL
+		WHERE name = ?1;
+	`
+
+	log.Debug("unlock operator query", slog.String("op", op), slog.String("query", utils.QueryConvert(unlockOperatorQuery)))
+
+	res, err := c.db.ExecContext(ctx, unlockOperatorQuery, name)
+	if err != nil {
+		log.Error("failed to unlock operator", sl.OpErr(op, err))
+		return errors.New("failed to unlock operator")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator not found")
+	}
+
+	return nil
+}
+
+// RecordOperatorFailure counts a failed login, the maxFailures-th failure in a row locks
+// the operator until lockedUntil and starts counting again.
+func (c *Client) RecordOperatorFailure(ctx context.Context, log *slog.Logger, name string, maxFailures int, lockedUntil time.Time) error {
+	const op = "db.sqlite.RecordOperatorFailure"
+
+	recordFailureQuery := `
+		UPDATE operator
+		SET failed_logins = CASE WHEN failed_logins + 1 >= ?2 THEN 0 ELSE failed_logins + 1 END,
+			locked_until = CASE WHEN failed_logins + 1 >= ?2 THEN ?3 ELSE locked_until END
+		WHERE name = ?1;
+	`
+
+	log.Debug("record operator failure query", slog.String("op", op), slog.String("query", utils.QueryConvert(recordFailureQuery)))
+
+	res, err := c.db.ExecContext(ctx, recordFailureQuery, name, maxFailures, formatTime(lockedUntil))
+	if err != nil {
+		log.Error("failed to record operator failure", sl.OpErr(op, err))
+		return errors.New("failed to record operator failure")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator not found")
+	}
+
+	return nil
+}
+
+func (c *Client) CreateOperatorToken(ctx context.Context, log *slog.Logger, name string, model models.OperatorTokenModel, tokenHash string) error {
+	const op = "db.sqlite.CreateOperatorToken"
+
+	createTokenQuery := `
+		INSERT INTO operator_token
+			(operator_id, accessor, token_hash, kind, name, expires_at, created_at)
+		SELECT id, ?2, ?3, ?4, ?5, ?6, ?7 FROM operator
+		WHERE name = ?1;
+	`
+
+	log.Debug("create operator token query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenQuery)))
+
+	res, err := c.db.ExecContext(ctx, createTokenQuery,
+		name,
+		model.Accessor,
+		tokenHash,
+		model.Kind,
+		model.Name,
+		formatNullTime(model.ExpiresAt),
+		formatTime(time.Now()),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("operator token already exists")
+		}
+		log.Error("failed to save operator token", sl.OpErr(op, err))
+		return errors.New("failed to save operator token")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator not found")
+	}
+
+	return nil
+}
+
+// ListOperatorTokens returns the sessions and API keys of the operator which did not expire.
+func (c *Client) ListOperatorTokens(ctx context.Context, log *slog.Logger, name string) ([]models.OperatorTokenModel, error) {
+	const op = "db.sqlite.ListOperatorTokens"
+
+	listTokensQuery := `
+		SELECT t.accessor, t.kind, t.name, t.expires_at, t.created_at FROM operator_token t
+		JOIN operator o ON o.id = t.operator_id
+		WHERE o.name = ?1 AND (t.expires_at IS NULL OR t.expires_at > ?2)
+		ORDER BY t.created_at, t.accessor;
+	`
+
+	log.Debug("list operator tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTokensQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listTokensQuery, name, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to list operator tokens", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operator tokens")
+	}
+	defer rows.Close()
+
+	tokens := make([]models.OperatorTokenModel, 0)
+	for rows.Next() {
+		var model models.OperatorTokenModel
+		var expiresAt sql.NullString
+		var createdAt string
+		if err := rows.Scan(&model.Accessor, &model.Kind, &model.Name, &expiresAt, &createdAt); err != nil {
+			log.Error("failed to scan operator tokens", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operator tokens")
+		}
+		if model.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+			log.Error("failed to parse expires_at", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operator tokens")
+		}
+		if model.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse created_at", sl.OpErr(op, err))
+			return nil, errors.New("failed to list operator tokens")
+		}
+		tokens = append(tokens, model)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list operator tokens")
+	}
+
+	return tokens, nil
+}
+
+func (c *Client) DeleteOperatorToken(ctx context.Context, log *slog.Logger, name string, accessor string) error {
+	const op = "db.sqlite.DeleteOperatorToken"
+
+	deleteTokenQuery := `
+		DELETE FROM operator_token
+		WHERE operator_id = (SELECT id FROM operator WHERE name = ?1) AND accessor = ?2;
+	`
+
+	log.Debug("delete operator token query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteTokenQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteTokenQuery, name, accessor)
+	if err != nil {
+		log.Error("failed to delete operator token", sl.OpErr(op, err))
+		return errors.New("failed to delete operator token")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("operator token not found")
+	}
+
+	return nil
+}
+
+// GetOperatorByToken returns the operator of a session or API key which did not expire.
+func (c *Client) GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error) {
+	const op = "db.sqlite.GetOperatorByToken"
+
+	getOperatorQuery := selectOperator + `
+		JOIN operator_token t ON t.operator_id = o.id
+		WHERE t.token_hash = ?1 AND (t.expires_at IS NULL OR t.expires_at > ?2);
+	`
+
+	log.Debug("get operator by token query", slog.String("op", op), slog.String("query", utils.QueryConvert(getOperatorQuery)))
+
+	model, err := scanOperator(c.db.QueryRowContext(ctx, getOperatorQuery, tokenHash, formatTime(time.Now())))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.OperatorModel{}, errors.New("operator not found")
+		}
+		log.Error("failed to get operator", sl.OpErr(op, err))
+		return models.OperatorModel{}, errors.New("failed to get operator")
+	}
+
+	return model, nil
+}
+
+func scanOperator(row scanner) (models.OperatorModel, error) {
+	var model models.OperatorModel
+	var policies, createdAt string
+	var lockedUntil sql.NullString
+	if err := row.Scan(&model.ID, &model.Name, &policies, &model.FailedLogins, &lockedUntil, &createdAt); err != nil {
+		return models.OperatorModel{}, err
+	}
+	return model, decodeOperator(&model, policies, lockedUntil, createdAt)
+}
+
+func decodeOperator(model *models.OperatorModel, policies string, lockedUntil sql.NullString, createdAt string) error {
+	var err error
+	if model.Policies, err = decodeList(policies); err != nil {
+		return err
+	}
+	if model.LockedUntil, err = parseNullTime(lockedUntil); err != nil {
+		return err
+	}
+	model.CreatedAt, err = parseTime(createdAt)
+	return err
+}
@@ -0,0 +1,271 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+func (c *Client) ListPolicies(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.sqlite.ListPolicies"
+
+	listPoliciesQuery := `
+		SELECT name FROM policy
+		ORDER BY name;
+	`
+
+	log.Debug("list policies query", slog.String("op", op), slog.String("query", utils.QueryConvert(listPoliciesQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listPoliciesQuery)
+	if err != nil {
+		log.Error("failed to list policies", sl.OpErr(op, err))
+		return nil, errors.New("failed to list policies")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan policies", sl.OpErr(op, err))
+			return nil, errors.New("failed to list policies")
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (c *Client) GetPolicy(ctx context.Context, log *slog.Logger, name string) (models.PolicyModel, error) {
+	const op = "db.sqlite.GetPolicy"
+
+	getPolicyQuery := `
+		SELECT name, document, updated_at FROM policy
+		WHERE name = ?1;
+	`
+
+	log.Debug("get policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(getPolicyQuery)))
+
+	model, err := scanPolicy(c.db.QueryRowContext(ctx, getPolicyQuery, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PolicyModel{}, errors.New("policy not found")
+		}
+		log.Error("failed to get policy", sl.OpErr(op, err))
+		return models.PolicyModel{}, errors.New("failed to get policy")
+	}
+
+	return model, nil
+}
+
+// GetPolicies returns the policies with the given names, unknown names are skipped.
+func (c *Client) GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error) {
+	const op = "db.sqlite.GetPolicies"
+
+	getPoliciesQuery := `
+		SELECT name, document, updated_at FROM policy
+		WHERE name IN (SELECT value FROM json_each(?1));
+	`
+
+	log.Debug("get policies query", slog.String("op", op), slog.String("query", utils.QueryConvert(getPoliciesQuery)))
+
+	rows, err := c.db.QueryContext(ctx, getPoliciesQuery, encodeList(names))
+	if err != nil {
+		log.Error("failed to get policies", sl.OpErr(op, err))
+		return nil, errors.New("failed to get policies")
+	}
+	defer rows.Close()
+
+	policies := make([]models.PolicyModel, 0, len(names))
+	for rows.Next() {
+		model, err := scanPolicy(rows)
+		if err != nil {
+			log.Error("failed to scan policies", sl.OpErr(op, err))
+			return nil, errors.New("failed to get policies")
+		}
+		policies = append(policies, model)
+	}
+
+	return policies, rows.Err()
+}
+
+// PutPolicy creates the policy or replaces the document of an existing one.
+func (c *Client) PutPolicy(ctx context.Context, log *slog.Logger, model models.PolicyModel) error {
+	const op = "db.sqlite.PutPolicy"
+
+	putPolicyQuery := `
+		INSERT INTO policy
+			(name, document, updated_at)
+		VALUES (?1, ?2, ?3)
+		ON CONFLICT (name) DO UPDATE
+		SET document = excluded.document, updated_at = excluded.updated_at;
+	`
+
+	log.Debug("put policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(putPolicyQuery)))
+
+	if _, err := c.db.ExecContext(ctx, putPolicyQuery, model.Name, model.Document, formatTime(time.Now())); err != nil {
+		log.Error("failed to save policy", sl.OpErr(op, err))
+		return errors.New("failed to save policy")
+	}
+
+	return nil
+}
+
+func (c *Client) DeletePolicy(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.DeletePolicy"
+
+	deletePolicyQuery := `
+		DELETE FROM policy
+		WHERE name = ?1;
+	`
+
+	log.Debug("delete policy query", slog.String("op", op), slog.String("query", utils.QueryConvert(deletePolicyQuery)))
+
+	res, err := c.db.ExecContext(ctx, deletePolicyQuery, name)
+	if err != nil {
+		log.Error("failed to delete policy", sl.OpErr(op, err))
+		return errors.New("failed to delete policy")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("policy not found")
+	}
+
+	return nil
+}
+
+// CreateIdentity stores a new admin identity, only the hash of its token is kept.
+func (c *Client) CreateIdentity(ctx context.Context, log *slog.Logger, model models.IdentityModel, tokenHash string) (int, error) {
+	const op = "db.sqlite.CreateIdentity"
+
+	createIdentityQuery := `
+		INSERT INTO admin_identity
+			(name, token_hash, policies, created_at)
+		VALUES (?1, ?2, ?3, ?4)
+		RETURNING id;
+	`
+
+	log.Debug("create identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(createIdentityQuery)))
+
+	var id int
+	err := c.db.QueryRowContext(ctx, createIdentityQuery, model.Name, tokenHash, encodeList(model.Policies), formatTime(time.Now())).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, errors.New("identity already exists")
+		}
+		log.Error("failed to save identity", sl.OpErr(op, err))
+		return 0, errors.New("failed to save identity")
+	}
+
+	return id, nil
+}
+
+func (c *Client) ListIdentities(ctx context.Context, log *slog.Logger) ([]models.IdentityModel, error) {
+	const op = "db.sqlite.ListIdentities"
+
+	listIdentitiesQuery := `
+		SELECT id, name, policies, created_at FROM admin_identity
+		ORDER BY name;
+	`
+
+	log.Debug("list identities query", slog.String("op", op), slog.String("query", utils.QueryConvert(listIdentitiesQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listIdentitiesQuery)
+	if err != nil {
+		log.Error("failed to list identities", sl.OpErr(op, err))
+		return nil, errors.New("failed to list identities")
+	}
+	defer rows.Close()
+
+	identities := make([]models.IdentityModel, 0)
+	for rows.Next() {
+		model, err := scanIdentity(rows)
+		if err != nil {
+			log.Error("failed to scan identities", sl.OpErr(op, err))
+			return nil, errors.New("failed to list identities")
+		}
+		identities = append(identities, model)
+	}
+
+	return identities, rows.Err()
+}
+
+func (c *Client) DeleteIdentity(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.DeleteIdentity"
+
+	deleteIdentityQuery := `
+		DELETE FROM admin_identity
+		WHERE name = ?1;
+	`
+
+	log.Debug("delete identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteIdentityQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteIdentityQuery, name)
+	if err != nil {
+		log.Error("failed to delete identity", sl.OpErr(op, err))
+		return errors.New("failed to delete identity")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("identity not found")
+	}
+
+	return nil
+}
+
+func (c *Client) GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error) {
+	const op = "db.sqlite.GetIdentityByToken"
+
+	getIdentityQuery := `
+		SELECT id, name, policies, created_at FROM admin_identity
+		WHERE token_hash = ?1;
+	`
+
+	log.Debug("get identity query", slog.String("op", op), slog.String("query", utils.QueryConvert(getIdentityQuery)))
+
+	model, err := scanIdentity(c.db.QueryRowContext(ctx, getIdentityQuery, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.IdentityModel{}, errors.New("identity not found")
+		}
+		log.Error("failed to get identity", sl.OpErr(op, err))
+		return models.IdentityModel{}, errors.New("failed to get identity")
+	}
+
+	return model, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPolicy(row scanner) (models.PolicyModel, error) {
+	var model models.PolicyModel
+	var updatedAt string
+	if err := row.Scan(&model.Name, &model.Document, &updatedAt); err != nil {
+		return models.PolicyModel{}, err
+	}
+
+	var err error
+	model.UpdatedAt, err = parseTime(updatedAt)
+	return model, err
+}
+
+func scanIdentity(row scanner) (models.IdentityModel, error) {
+	var model models.IdentityModel
+	var policies, createdAt string
+	if err := row.Scan(&model.ID, &model.Name, &policies, &createdAt); err != nil {
+		return models.IdentityModel{}, err
+	}
+
+	var err error
+	if model.Policies, err = decodeList(policies); err != nil {
+		return models.IdentityModel{}, err
+	}
+	model.CreatedAt, err = parseTime(createdAt)
+	return model, err
+}
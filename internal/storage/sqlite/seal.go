@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+func (c *Client) GetSealConfig(ctx context.Context, log *slog.Logger) (models.SealConfig, error) {
+	const op = "db.sqlite.GetSealConfig"
+
+	getSealConfigQuery := `
+		SELECT shares, threshold, verification FROM seal_config
+		WHERE id = 1;
+	`
+
+	log.Debug("get seal config query", slog.String("op", op), slog.String("query", utils.QueryConvert(getSealConfigQuery)))
+
+	var config models.SealConfig
+	if err := c.db.QueryRowContext(ctx, getSealConfigQuery).Scan(&config.Shares, &config.Threshold, &config.Verification); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SealConfig{}, errors.New("vault is not initialized")
+		}
+		log.Error("failed to get seal config", sl.OpErr(op, err))
+		return models.SealConfig{}, errors.New("failed to get seal config")
+	}
+
+	return config, nil
+}
+
+func (c *Client) CreateSealConfig(ctx context.Context, log *slog.Logger, config models.SealConfig) error {
+	const op = "db.sqlite.CreateSealConfig"
+
+	createSealConfigQuery := `
+		INSERT INTO seal_config
+			(id, shares, threshold, verification)
+		VALUES (1, ?1, ?2, ?3);
+	`
+
+	log.Debug("create seal config query", slog.String("op", op), slog.String("query", utils.QueryConvert(createSealConfigQuery)))
+
+	if _, err := c.db.ExecContext(ctx, createSealConfigQuery, config.Shares, config.Threshold, config.Verification); err != nil {
+		log.Error("failed to save seal config", sl.OpErr(op, err))
+		return errors.New("failed to save seal config")
+	}
+
+	return nil
+}
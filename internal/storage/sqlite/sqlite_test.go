@@ -0,0 +1,34 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"vault/internal/storage"
+	"vault/internal/storage/sqlite"
+	"vault/internal/storage/storagetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		client, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "vault.db"), storagetest.MaxVersions)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	})
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.db")
+
+	client, err := sqlite.Open(context.Background(), path, 0)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	// applied migrations are skipped
+	client, err = sqlite.Open(context.Background(), path, 0)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+}
@@ -0,0 +1,249 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+func (c *Client) CreateToken(ctx context.Context, log *slog.Logger, model models.TokenInfoModel) error {
+	const op = "db.sqlite.CreateToken"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	createTokenQuery := `
+		INSERT INTO token
+			(jti, issued_at, expires_at, keys, capabilities, paths, transit_keys)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7);
+	`
+
+	log.Debug("create token query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenQuery)))
+
+	if _, err := tx.ExecContext(ctx, createTokenQuery,
+		model.JTI,
+		formatTime(model.IssuedAt),
+		formatTime(model.ExpiresAt),
+		encodeList(model.Keys),
+		encodeList(model.Capabilities),
+		encodeList(model.Paths),
+		encodeList(model.TransitKeys),
+	); err != nil {
+		log.Error("failed to save token", sl.OpErr(op, err))
+		return errors.New("failed to save token")
+	}
+
+	createTokenVaultQuery := `
+		INSERT INTO token_vault
+			(jti, vault_id)
+		VALUES (?1, ?2);
+	`
+
+	log.Debug("create token vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(createTokenVaultQuery)))
+
+	for _, vaultID := range model.VaultIDs {
+		if _, err := tx.ExecContext(ctx, createTokenVaultQuery, model.JTI, vaultID); err != nil {
+			log.Error("failed to save token vault", sl.OpErr(op, err))
+			return errors.New("failed to save token")
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CheckToken returns an error when the token is unknown, revoked or expired.
+func (c *Client) CheckToken(ctx context.Context, log *slog.Logger, jti string) error {
+	const op = "db.sqlite.CheckToken"
+
+	getTokenQuery := `
+		SELECT expires_at, revoked_at FROM token
+		WHERE jti = ?1;
+	`
+
+	log.Debug("get token query", slog.String("op", op), slog.String("query", utils.QueryConvert(getTokenQuery)))
+
+	var expiresAt string
+	var revokedAt sql.NullString
+	if err := c.db.QueryRowContext(ctx, getTokenQuery, jti).Scan(&expiresAt, &revokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("token not found")
+		}
+		log.Error("failed to get token", sl.OpErr(op, err))
+		return errors.New("failed to get token")
+	}
+
+	if revokedAt.Valid {
+		return errors.New("token revoked")
+	}
+	if expiresAt <= formatTime(time.Now()) {
+		return errors.New("token expired")
+	}
+
+	return nil
+}
+
+// RenewToken moves the expiry of an active token, revoked and expired tokens are not found.
+func (c *Client) RenewToken(ctx context.Context, log *slog.Logger, jti string, expiresAt time.Time) error {
+	const op = "db.sqlite.RenewToken"
+
+	renewTokenQuery := `
+		UPDATE token
+		SET expires_at = ?2
+		WHERE jti = ?1 AND revoked_at IS NULL AND expires_at > ?3;
+	`
+
+	log.Debug("renew token query", slog.String("op", op), slog.String("query", utils.QueryConvert(renewTokenQuery)))
+
+	res, err := c.db.ExecContext(ctx, renewTokenQuery, jti, formatTime(expiresAt), formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to renew token", sl.OpErr(op, err))
+		return errors.New("failed to renew token")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("token not found")
+	}
+
+	return nil
+}
+
+func (c *Client) RevokeToken(ctx context.Context, log *slog.Logger, jti string) error {
+	const op = "db.sqlite.RevokeToken"
+
+	revokeTokenQuery := `
+		UPDATE token
+		SET revoked_at = COALESCE(revoked_at, ?2)
+		WHERE jti = ?1;
+	`
+
+	log.Debug("revoke token query", slog.String("op", op), slog.String("query", utils.QueryConvert(revokeTokenQuery)))
+
+	res, err := c.db.ExecContext(ctx, revokeTokenQuery, jti, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to revoke token", sl.OpErr(op, err))
+		return errors.New("failed to revoke token")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("token not found")
+	}
+
+	return nil
+}
+
+// RevokeVaultTokens revokes every active token bound to the vault and returns their count.
+func (c *Client) RevokeVaultTokens(ctx context.Context, log *slog.Logger, vaultID int) (int, error) {
+	const op = "db.sqlite.RevokeVaultTokens"
+
+	revokeTokensQuery := `
+		UPDATE token
+		SET revoked_at = ?2
+		WHERE revoked_at IS NULL AND jti IN (
+			SELECT jti FROM token_vault WHERE vault_id = ?1
+		);
+	`
+
+	log.Debug("revoke tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(revokeTokensQuery)))
+
+	res, err := c.db.ExecContext(ctx, revokeTokensQuery, vaultID, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to revoke tokens", sl.OpErr(op, err))
+		return 0, errors.New("failed to revoke tokens")
+	}
+	affected, _ := res.RowsAffected()
+
+	return int(affected), nil
+}
+
+// ListTokens returns active tokens, vaultID 0 lists tokens of all vaults.
+func (c *Client) ListTokens(ctx context.Context, log *slog.Logger, vaultID int) ([]models.TokenInfoModel, error) {
+	const op = "db.sqlite.ListTokens"
+
+	listTokensQuery := `
+		SELECT t.jti, COALESCE(group_concat(tv.vault_id), ''), t.issued_at, t.expires_at, t.keys, t.capabilities, t.paths,
+			t.transit_keys FROM token t
+		LEFT JOIN token_vault tv ON tv.jti = t.jti
+		WHERE t.revoked_at IS NULL AND t.expires_at > ?2
+			AND (?1 = 0 OR EXISTS (SELECT 1 FROM token_vault f WHERE f.jti = t.jti AND f.vault_id = ?1))
+		GROUP BY t.jti
+		HAVING COUNT(tv.vault_id) > 0 OR t.paths <> '[]' OR t.transit_keys <> '[]'
+		ORDER BY t.issued_at DESC;
+	`
+
+	log.Debug("list tokens query", slog.String("op", op), slog.String("query", utils.QueryConvert(listTokensQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listTokensQuery, vaultID, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to list tokens", sl.OpErr(op, err))
+		return nil, errors.New("failed to list tokens")
+	}
+	defer rows.Close()
+
+	tokens := make([]models.TokenInfoModel, 0)
+	for rows.Next() {
+		var token models.TokenInfoModel
+		var vaultIDs, issuedAt, expiresAt, keys, capabilities, paths, transitKeys string
+		if err := rows.Scan(&token.JTI, &vaultIDs, &issuedAt, &expiresAt, &keys, &capabilities, &paths, &transitKeys); err != nil {
+			log.Error("failed to scan tokens", sl.OpErr(op, err))
+			return nil, errors.New("failed to list tokens")
+		}
+		if err := scanTokenInfo(&token, vaultIDs, issuedAt, expiresAt, keys, capabilities, paths, transitKeys); err != nil {
+			log.Error("failed to decode token", sl.OpErr(op, err))
+			return nil, errors.New("failed to list tokens")
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list tokens")
+	}
+
+	return tokens, nil
+}
+
+func scanTokenInfo(token *models.TokenInfoModel, vaultIDs, issuedAt, expiresAt, keys, capabilities, paths, transitKeys string) error {
+	token.VaultIDs = []int{}
+	for _, id := range strings.Split(vaultIDs, ",") {
+		if id == "" {
+			continue
+		}
+		vaultID, err := strconv.Atoi(id)
+		if err != nil {
+			return err
+		}
+		token.VaultIDs = append(token.VaultIDs, vaultID)
+	}
+	sort.Ints(token.VaultIDs)
+
+	var err error
+	if token.IssuedAt, err = parseTime(issuedAt); err != nil {
+		return err
+	}
+	if token.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return err
+	}
+	if token.Keys, err = decodeList(keys); err != nil {
+		return err
+	}
+	if token.Capabilities, err = decodeList(capabilities); err != nil {
+		return err
+	}
+	if token.Paths, err = decodeList(paths); err != nil {
+		return err
+	}
+	if token.TransitKeys, err = decodeList(transitKeys); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// CreateTransitKey saves the key with its first version.
+func (c *Client) CreateTransitKey(ctx context.Context, log *slog.Logger, model models.TransitKeyModel) error {
+	const op = "db.sqlite.CreateTransitKey"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	createKeyQuery := `
+		INSERT INTO transit_key
+			(name, type, latest_version, min_decryption_version, created_at)
+		VALUES (?1, ?2, 1, 1, ?3)
+		RETURNING id;
+	`
+
+	log.Debug("create transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(createKeyQuery)))
+
+	now := formatTime(time.Now())
+	var id int
+	if err := tx.QueryRowContext(ctx, createKeyQuery, model.Name, model.Type, now).Scan(&id); err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("transit key already exists")
+		}
+		log.Error("failed to save transit key", sl.OpErr(op, err))
+		return errors.New("failed to save transit key")
+	}
+
+	for _, version := range model.Versions {
+		if err := insertTransitKeyVersion(ctx, tx, log, id, 1, version, now); err != nil {
+			log.Error("failed to save transit key version", sl.OpErr(op, err))
+			return errors.New("failed to save transit key")
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (c *Client) GetTransitKey(ctx context.Context, log *slog.Logger, name string) (models.TransitKeyModel, error) {
+	const op = "db.sqlite.GetTransitKey"
+
+	getKeyQuery := `
+		SELECT id, name, type, latest_version, min_decryption_version, created_at FROM transit_key
+		WHERE name = ?1;
+	`
+
+	log.Debug("get transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(getKeyQuery)))
+
+	var key models.TransitKeyModel
+	var id int
+	var createdAt string
+	err := c.db.QueryRowContext(ctx, getKeyQuery, name).Scan(&id, &key.Name, &key.Type, &key.LatestVersion, &key.MinDecryptionVersion, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.TransitKeyModel{}, errors.New("transit key not found")
+		}
+		log.Error("failed to get transit key", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+	if key.CreatedAt, err = parseTime(createdAt); err != nil {
+		log.Error("failed to parse transit key time", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+
+	getVersionsQuery := `
+		SELECT version, material, public_key, created_at FROM transit_key_version
+		WHERE key_id = ?1
+		ORDER BY version;
+	`
+
+	log.Debug("get transit key versions query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVersionsQuery)))
+
+	rows, err := c.db.QueryContext(ctx, getVersionsQuery, id)
+	if err != nil {
+		log.Error("failed to get transit key versions", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+	defer rows.Close()
+
+	key.Versions = make([]models.TransitKeyVersion, 0, key.LatestVersion)
+	for rows.Next() {
+		var version models.TransitKeyVersion
+		var createdAt string
+		if err := rows.Scan(&version.Version, &version.Key, &version.PublicKey, &createdAt); err != nil {
+			log.Error("failed to scan transit key versions", sl.OpErr(op, err))
+			return models.TransitKeyModel{}, errors.New("failed to get transit key")
+		}
+		if version.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse transit key time", sl.OpErr(op, err))
+			return models.TransitKeyModel{}, errors.New("failed to get transit key")
+		}
+		key.Versions = append(key.Versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return models.TransitKeyModel{}, errors.New("failed to get transit key")
+	}
+
+	return key, nil
+}
+
+func (c *Client) ListTransitKeys(ctx context.Context, log *slog.Logger) ([]string, error) {
+	const op = "db.sqlite.ListTransitKeys"
+
+	listKeysQuery := `
+		SELECT name FROM transit_key
+		ORDER BY name;
+	`
+
+	log.Debug("list transit keys query", slog.String("op", op), slog.String("query", utils.QueryConvert(listKeysQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listKeysQuery)
+	if err != nil {
+		log.Error("failed to list transit keys", sl.OpErr(op, err))
+		return nil, errors.New("failed to list transit keys")
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error("failed to scan transit keys", sl.OpErr(op, err))
+			return nil, errors.New("failed to list transit keys")
+		}
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list transit keys")
+	}
+
+	return names, nil
+}
+
+// RotateTransitKey adds the version after the latest one and returns its number.
+func (c *Client) RotateTransitKey(ctx context.Context, log *slog.Logger, name string, version models.TransitKeyVersion) (int, error) {
+	const op = "db.sqlite.RotateTransitKey"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	rotateKeyQuery := `
+		UPDATE transit_key
+		SET latest_version = latest_version + 1
+		WHERE name = ?1
+		RETURNING id, latest_version;
+	`
+
+	log.Debug("rotate transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(rotateKeyQuery)))
+
+	var id, latest int
+	if err := tx.QueryRowContext(ctx, rotateKeyQuery, name).Scan(&id, &latest); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("transit key not found")
+		}
+		log.Error("failed to rotate transit key", sl.OpErr(op, err))
+		return 0, errors.New("failed to rotate transit key")
+	}
+
+	if err := insertTransitKeyVersion(ctx, tx, log, id, latest, version, formatTime(time.Now())); err != nil {
+		log.Error("failed to save transit key version", sl.OpErr(op, err))
+		return 0, errors.New("failed to rotate transit key")
+	}
+
+	return latest, tx.Commit()
+}
+
+func (c *Client) UpdateTransitKey(ctx context.Context, log *slog.Logger, name string, minDecryptionVersion int) error {
+	const op = "db.sqlite.UpdateTransitKey"
+
+	updateKeyQuery := `
+		UPDATE transit_key
+		SET min_decryption_version = ?2
+		WHERE name = ?1;
+	`
+
+	log.Debug("update transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateKeyQuery)))
+
+	res, err := c.db.ExecContext(ctx, updateKeyQuery, name, minDecryptionVersion)
+	if err != nil {
+		log.Error("failed to update transit key", sl.OpErr(op, err))
+		return errors.New("failed to update transit key")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("transit key not found")
+	}
+
+	return nil
+}
+
+// DeleteTransitKey removes the key with every version, data encrypted with it can't be decrypted anymore.
+func (c *Client) DeleteTransitKey(ctx context.Context, log *slog.Logger, name string) error {
+	const op = "db.sqlite.DeleteTransitKey"
+
+	deleteKeyQuery := `
+		DELETE FROM transit_key
+		WHERE name = ?1;
+	`
+
+	log.Debug("delete transit key query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteKeyQuery)))
+
+	res, err := c.db.ExecContext(ctx, deleteKeyQuery, name)
+	if err != nil {
+		log.Error("failed to delete transit key", sl.OpErr(op, err))
+		return errors.New("failed to delete transit key")
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("transit key not found")
+	}
+
+	return nil
+}
+
+// insertTransitKeyVersion saves the wrapped key material of the version number.
+func insertTransitKeyVersion(ctx context.Context, tx *sql.Tx, log *slog.Logger, keyID, number int, version models.TransitKeyVersion, now string) error {
+	createVersionQuery := `
+		INSERT INTO transit_key_version
+			(key_id, version, material, public_key, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5);
+	`
+
+	log.Debug("create transit key version query", slog.String("query", utils.QueryConvert(createVersionQuery)))
+
+	_, err := tx.ExecContext(ctx, createVersionQuery, keyID, number, version.Key, version.PublicKey, now)
+	return err
+}
@@ -0,0 +1,666 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+// NextVaultID reserves the id of a vault which is about to be created by advancing the
+// autoincrement counter of the vault table, ids handed out this way are never reused.
+func (c *Client) NextVaultID(ctx context.Context, log *slog.Logger) (int, error) {
+	const op = "db.sqlite.NextVaultID"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// sqlite_sequence only gets a row for vault once the first vault is inserted
+	initSequenceQuery := `
+		INSERT INTO sqlite_sequence (name, seq)
+		SELECT 'vault', 0 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'vault');
+	`
+
+	log.Debug("init vault sequence query", slog.String("op", op), slog.String("query", utils.QueryConvert(initSequenceQuery)))
+
+	if _, err := tx.ExecContext(ctx, initSequenceQuery); err != nil {
+		log.Error("failed to init vault sequence", sl.OpErr(op, err))
+		return 0, errors.New("failed to reserve vault id")
+	}
+
+	nextVaultIDQuery := `
+		UPDATE sqlite_sequence SET seq = seq + 1
+		WHERE name = 'vault'
+		RETURNING seq;
+	`
+
+	log.Debug("next vault id query", slog.String("op", op), slog.String("query", utils.QueryConvert(nextVaultIDQuery)))
+
+	var id int
+	if err := tx.QueryRowContext(ctx, nextVaultIDQuery).Scan(&id); err != nil {
+		log.Error("failed to reserve vault id", sl.OpErr(op, err))
+		return 0, errors.New("failed to reserve vault id")
+	}
+
+	return id, tx.Commit()
+}
+
+func (c *Client) CreateVault(ctx context.Context, log *slog.Logger, model models.SecretCreateDTO) (int, error) {
+	const op = "db.sqlite.CreateVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := releaseExpiredPath(ctx, tx, log, op, model.Name); err != nil {
+		return 0, err
+	}
+
+	createVaultQuery := `
+		INSERT INTO vault
+			(id, name, data_key, created_at, cas_required, expires_at)
+		VALUES (NULLIF(?6, 0), ?1, NULLIF(?2, ''), ?3, COALESCE(?4, 0), ?5)
+		RETURNING id;
+	`
+
+	log.Debug("create vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(createVaultQuery)))
+
+	now := time.Now()
+	var id int
+	if err := tx.QueryRowContext(ctx, createVaultQuery, model.Name, model.DataKey, formatTime(now), model.CASRequired, formatNullTime(model.ExpiresAt), model.ID).Scan(&id); err != nil {
+		if isUniqueViolation(err) {
+			return 0, errors.New("vault already exists")
+		}
+		log.Error("failed to create new vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to create new vault")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, 1); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+		log.Error("failed to save vault tags", sl.OpErr(op, err))
+		return 0, errors.New("failed to save vault tags")
+	}
+
+	if err := insertMetadata(ctx, tx, log, id, model.CreatedBy, now); err != nil {
+		log.Error("failed to create vault metadata", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault metadata")
+	}
+
+	if err := insertValues(ctx, tx, log, id, 1, model.Data); err != nil {
+		log.Error("failed to insert values to database", sl.OpErr(op, err))
+		return 0, errors.New("failed to insert values to database")
+	}
+
+	return id, tx.Commit()
+}
+
+func (c *Client) GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error) {
+	const op = "db.sqlite.GetVault"
+
+	return c.getVault(ctx, log, op, id, 0)
+}
+
+func (c *Client) GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error) {
+	const op = "db.sqlite.GetVaultVersion"
+
+	return c.getVault(ctx, log, op, id, version)
+}
+
+// getVault reads the given version of the vault data, version 0 reads the current one.
+func (c *Client) getVault(ctx context.Context, log *slog.Logger, op string, id int, version int) (models.SecretModel, error) {
+	tx, err := c.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	getVaultQuery := `
+		SELECT id, name, COALESCE(data_key, ''), current_version, cas_required, expires_at FROM vault
+		WHERE id = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2);
+	`
+
+	log.Debug("get vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultQuery)))
+
+	now := formatTime(time.Now())
+	var vault models.VaultModel
+	var currentVersion int
+	var casRequired bool
+	var expiresAt sql.NullString
+	if err := tx.QueryRowContext(ctx, getVaultQuery, id, now).Scan(&vault.ID, &vault.Name, &vault.DataKey, &currentVersion, &casRequired, &expiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SecretModel{}, errors.New("vault not found")
+		}
+		log.Error("failed to get vault", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault")
+	}
+	if vault.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+		log.Error("failed to parse vault time", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault")
+	}
+
+	if version == 0 {
+		version = currentVersion
+	}
+
+	checkVersionQuery := `
+		SELECT version FROM vault_version
+		WHERE vault_id = ?1 AND version = ?2;
+	`
+
+	log.Debug("check version query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVersionQuery)))
+
+	if err := tx.QueryRowContext(ctx, checkVersionQuery, id, version).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SecretModel{}, errors.New("version not found")
+		}
+		log.Error("failed to get vault version", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault version")
+	}
+
+	getValuesQuery := `
+		SELECT key, value, encrypted, expires_at FROM value
+		WHERE vault_id = ?1 AND version = ?2 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?3);
+	`
+
+	log.Debug("get values query", slog.String("op", op), slog.String("query", utils.QueryConvert(getValuesQuery)))
+
+	rows, err := tx.QueryContext(ctx, getValuesQuery, id, version, now)
+	if err != nil {
+		log.Error("failed to get vault values", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault values")
+	}
+	defer rows.Close()
+
+	var values []models.ValueDTO
+	for rows.Next() {
+		var value models.ValueDTO
+		var expiresAt sql.NullString
+		if err := rows.Scan(&value.Key, &value.Value, &value.Encrypted, &expiresAt); err != nil {
+			log.Error("failed to scan values", sl.OpErr(op, err))
+			return models.SecretModel{}, errors.New("failed to get vault values")
+		}
+		if value.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+			log.Error("failed to parse value time", sl.OpErr(op, err))
+			return models.SecretModel{}, errors.New("failed to get vault values")
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return models.SecretModel{}, errors.New("failed to get vault values")
+	}
+
+	res := models.ConvertDTOToSecretModel(vault, values)
+	res.Version = version
+	res.CASRequired = casRequired
+	return res, nil
+}
+
+func (c *Client) CheckVault(ctx context.Context, log *slog.Logger, id int) error {
+	const op = "db.sqlite.CheckVault"
+
+	checkVaultQuery := `
+		SELECT id FROM vault
+		WHERE id = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2);
+	`
+
+	log.Debug("check vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVaultQuery)))
+
+	if err := c.db.QueryRowContext(ctx, checkVaultQuery, id, formatTime(time.Now())).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("vault not found")
+		}
+		log.Error("failed to get vault", sl.OpErr(op, err))
+		return errors.New("failed to get vault")
+	}
+
+	return nil
+}
+
+// GetVaultName resolves soft deleted vaults as well.
+func (c *Client) GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error) {
+	const op = "db.sqlite.GetVaultName"
+
+	getVaultNameQuery := `
+		SELECT name FROM vault
+		WHERE id = ?1;
+	`
+
+	log.Debug("get vault name query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultNameQuery)))
+
+	var name string
+	if err := c.db.QueryRowContext(ctx, getVaultNameQuery, id).Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("vault not found")
+		}
+		log.Error("failed to get vault name", sl.OpErr(op, err))
+		return "", errors.New("failed to get vault name")
+	}
+
+	return name, nil
+}
+
+// GetVaultID returns the id of the vault stored under the path.
+func (c *Client) GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error) {
+	const op = "db.sqlite.GetVaultID"
+
+	getVaultIDQuery := `
+		SELECT id FROM vault
+		WHERE name = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2);
+	`
+
+	log.Debug("get vault id query", slog.String("op", op), slog.String("query", utils.QueryConvert(getVaultIDQuery)))
+
+	var id int
+	if err := c.db.QueryRowContext(ctx, getVaultIDQuery, path, formatTime(time.Now())).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to get vault id", sl.OpErr(op, err))
+		return 0, errors.New("failed to get vault id")
+	}
+
+	return id, nil
+}
+
+func (c *Client) UpdateVault(ctx context.Context, log *slog.Logger, id int, model models.SecretCreateDTO) (int, error) {
+	const op = "db.sqlite.UpdateVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := checkCAS(ctx, tx, log, op, id, model.CAS); err != nil {
+		return 0, err
+	}
+
+	if err := releaseExpiredPath(ctx, tx, log, op, model.Name); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET name = ?2, data_key = COALESCE(NULLIF(?3, ''), data_key), cas_required = COALESCE(?4, cas_required),
+			expires_at = ?5, current_version = current_version + 1
+		WHERE id = ?1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var version int
+	if err := tx.QueryRowContext(ctx, updateVaultQuery, id, model.Name, model.DataKey, model.CASRequired, formatNullTime(model.ExpiresAt)).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("vault not found")
+		}
+		if isUniqueViolation(err) {
+			return 0, errors.New("vault already exists")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	if err := insertValues(ctx, tx, log, id, version, model.Data); err != nil {
+		log.Error("failed to insert values to database", sl.OpErr(op, err))
+		return 0, errors.New("failed to insert values to database")
+	}
+
+	if model.Tags != nil {
+		if err := replaceTags(ctx, tx, log, id, model.Tags); err != nil {
+			log.Error("failed to save vault tags", sl.OpErr(op, err))
+			return 0, errors.New("failed to save vault tags")
+		}
+		if err := touchMetadata(ctx, tx, log, id); err != nil {
+			log.Error("failed to update vault metadata", sl.OpErr(op, err))
+			return 0, errors.New("failed to update vault metadata")
+		}
+	}
+
+	if err := c.pruneVersions(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return version, tx.Commit()
+}
+
+func (c *Client) PatchVault(ctx context.Context, log *slog.Logger, id int, model models.SecretPatchDTO) (int, error) {
+	const op = "db.sqlite.PatchVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := checkCAS(ctx, tx, log, op, id, model.CAS); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET data_key = COALESCE(NULLIF(?2, ''), data_key), current_version = current_version + 1
+		WHERE id = ?1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var version int
+	if err := tx.QueryRowContext(ctx, updateVaultQuery, id, model.DataKey).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	keys := make([]string, 0, len(model.Data)+len(model.Remove))
+	for _, v := range model.Data {
+		keys = append(keys, v.Key)
+	}
+	keys = append(keys, model.Remove...)
+
+	copyValuesQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, deleted_at, expires_at)
+		SELECT vault_id, ?2, key, value, encrypted, deleted_at, expires_at FROM value
+		WHERE vault_id = ?1 AND version = ?2 - 1 AND key NOT IN (SELECT value FROM json_each(?3));
+	`
+
+	log.Debug("copy values query", slog.String("op", op), slog.String("query", utils.QueryConvert(copyValuesQuery)))
+
+	if _, err := tx.ExecContext(ctx, copyValuesQuery, id, version, encodeList(keys)); err != nil {
+		log.Error("failed to copy vault values", sl.OpErr(op, err))
+		return 0, errors.New("failed to copy vault values")
+	}
+
+	if err := insertValues(ctx, tx, log, id, version, model.Data); err != nil {
+		log.Error("failed to insert values to database", sl.OpErr(op, err))
+		return 0, errors.New("failed to insert values to database")
+	}
+
+	if err := c.pruneVersions(ctx, tx, log, id, version); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return version, tx.Commit()
+}
+
+// DeleteVault soft deletes the vault, it is kept until it is undeleted, destroyed or purged.
+func (c *Client) DeleteVault(ctx context.Context, log *slog.Logger, id int, cas int) error {
+	const op = "db.sqlite.DeleteVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := checkCAS(ctx, tx, log, op, id, cas); err != nil {
+		return err
+	}
+
+	deleteVaultQuery := `
+		UPDATE vault
+		SET deleted_at = ?2
+		WHERE id = ?1;
+	`
+
+	log.Debug("delete vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(deleteVaultQuery)))
+
+	if _, err := tx.ExecContext(ctx, deleteVaultQuery, id, formatTime(time.Now())); err != nil {
+		log.Error("failed to delete vault", sl.OpErr(op, err))
+		return errors.New("failed to delete vault")
+	}
+
+	return tx.Commit()
+}
+
+func (c *Client) ListVersions(ctx context.Context, log *slog.Logger, id int) ([]models.VersionModel, error) {
+	const op = "db.sqlite.ListVersions"
+
+	listVersionsQuery := `
+		SELECT vv.version, vv.created_at, vv.version = v.current_version FROM vault_version vv
+		JOIN vault v ON v.id = vv.vault_id
+		WHERE vv.vault_id = ?1 AND v.deleted_at IS NULL AND (v.expires_at IS NULL OR v.expires_at > ?2)
+		ORDER BY vv.version DESC;
+	`
+
+	log.Debug("list versions query", slog.String("op", op), slog.String("query", utils.QueryConvert(listVersionsQuery)))
+
+	rows, err := c.db.QueryContext(ctx, listVersionsQuery, id, formatTime(time.Now()))
+	if err != nil {
+		log.Error("failed to list vault versions", sl.OpErr(op, err))
+		return nil, errors.New("failed to list vault versions")
+	}
+	defer rows.Close()
+
+	versions := make([]models.VersionModel, 0)
+	for rows.Next() {
+		var version models.VersionModel
+		var createdAt string
+		if err := rows.Scan(&version.Version, &createdAt, &version.Current); err != nil {
+			log.Error("failed to scan versions", sl.OpErr(op, err))
+			return nil, errors.New("failed to list vault versions")
+		}
+		if version.CreatedAt, err = parseTime(createdAt); err != nil {
+			log.Error("failed to parse version time", sl.OpErr(op, err))
+			return nil, errors.New("failed to list vault versions")
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("rows error", sl.OpErr(op, err))
+		return nil, errors.New("failed to list vault versions")
+	}
+
+	if len(versions) == 0 {
+		return nil, errors.New("vault not found")
+	}
+
+	return versions, nil
+}
+
+// RollbackVault promotes a copy of an old version to a new current version and returns its number.
+func (c *Client) RollbackVault(ctx context.Context, log *slog.Logger, id int, version int, cas int) (int, error) {
+	const op = "db.sqlite.RollbackVault"
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", sl.OpErr(op, err))
+		return 0, errors.New("failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := checkCAS(ctx, tx, log, op, id, cas); err != nil {
+		return 0, err
+	}
+
+	updateVaultQuery := `
+		UPDATE vault
+		SET current_version = current_version + 1
+		WHERE id = ?1
+		RETURNING current_version;
+	`
+
+	log.Debug("update vault query", slog.String("op", op), slog.String("query", utils.QueryConvert(updateVaultQuery)))
+
+	var newVersion int
+	if err := tx.QueryRowContext(ctx, updateVaultQuery, id).Scan(&newVersion); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("vault not found")
+		}
+		log.Error("failed to update vault", sl.OpErr(op, err))
+		return 0, errors.New("failed to update vault")
+	}
+
+	checkVersionQuery := `
+		SELECT version FROM vault_version
+		WHERE vault_id = ?1 AND version = ?2;
+	`
+
+	log.Debug("check version query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkVersionQuery)))
+
+	if err := tx.QueryRowContext(ctx, checkVersionQuery, id, version).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("version not found")
+		}
+		log.Error("failed to get vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to get vault version")
+	}
+
+	if err := insertVersion(ctx, tx, log, id, newVersion); err != nil {
+		log.Error("failed to create vault version", sl.OpErr(op, err))
+		return 0, errors.New("failed to create vault version")
+	}
+
+	copyValuesQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, deleted_at, expires_at)
+		SELECT vault_id, ?3, key, value, encrypted, deleted_at, expires_at FROM value
+		WHERE vault_id = ?1 AND version = ?2;
+	`
+
+	log.Debug("copy values query", slog.String("op", op), slog.String("query", utils.QueryConvert(copyValuesQuery)))
+
+	if _, err := tx.ExecContext(ctx, copyValuesQuery, id, version, newVersion); err != nil {
+		log.Error("failed to copy vault values", sl.OpErr(op, err))
+		return 0, errors.New("failed to copy vault values")
+	}
+
+	if err := c.pruneVersions(ctx, tx, log, id, newVersion); err != nil {
+		log.Error("failed to prune vault versions", sl.OpErr(op, err))
+		return 0, errors.New("failed to prune vault versions")
+	}
+
+	return newVersion, tx.Commit()
+}
+
+// checkCAS compares the current vault version with the check-and-set version, the single
+// connection keeps the transaction the only writer until it ends.
+func checkCAS(ctx context.Context, tx *sql.Tx, log *slog.Logger, op string, id int, cas int) error {
+	checkCASQuery := `
+		SELECT current_version, cas_required FROM vault
+		WHERE id = ?1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?2);
+	`
+
+	log.Debug("check cas query", slog.String("op", op), slog.String("query", utils.QueryConvert(checkCASQuery)))
+
+	var current int
+	var required bool
+	if err := tx.QueryRowContext(ctx, checkCASQuery, id, formatTime(time.Now())).Scan(&current, &required); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("vault not found")
+		}
+		log.Error("failed to check vault version", sl.OpErr(op, err))
+		return errors.New("failed to check vault version")
+	}
+
+	return models.CheckCAS(current, required, cas)
+}
+
+func insertVersion(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, version int) error {
+	createVersionQuery := `
+		INSERT INTO vault_version
+			(vault_id, version, created_at)
+		VALUES (?1, ?2, ?3);
+	`
+
+	log.Debug("create version query", slog.String("query", utils.QueryConvert(createVersionQuery)))
+
+	_, err := tx.ExecContext(ctx, createVersionQuery, id, version, formatTime(time.Now()))
+	return err
+}
+
+func insertValues(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, version int, values []models.ValueDTO) error {
+	createValueQuery := `
+		INSERT INTO value
+			(vault_id, version, key, value, encrypted, expires_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6);
+	`
+
+	log.Debug("create value query", slog.String("query", utils.QueryConvert(createValueQuery)))
+
+	for _, v := range values {
+		if _, err := tx.ExecContext(ctx, createValueQuery, id, version, v.Key, v.Value, v.Encrypted, formatNullTime(v.ExpiresAt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceTags sets the tags of the vault, the previous tags are removed.
+func replaceTags(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, tags []string) error {
+	deleteTagsQuery := `
+		DELETE FROM vault_tag
+		WHERE vault_id = ?1;
+	`
+
+	log.Debug("delete tags query", slog.String("query", utils.QueryConvert(deleteTagsQuery)))
+
+	if _, err := tx.ExecContext(ctx, deleteTagsQuery, id); err != nil {
+		return err
+	}
+
+	createTagsQuery := `
+		INSERT OR IGNORE INTO vault_tag
+			(vault_id, tag)
+		SELECT ?1, value FROM json_each(?2);
+	`
+
+	log.Debug("create tags query", slog.String("query", utils.QueryConvert(createTagsQuery)))
+
+	_, err := tx.ExecContext(ctx, createTagsQuery, id, encodeList(tags))
+	return err
+}
+
+// pruneVersions removes versions older than the configured limit, their values are removed by cascade.
+func (c *Client) pruneVersions(ctx context.Context, tx *sql.Tx, log *slog.Logger, id int, current int) error {
+	if c.maxVersions <= 0 {
+		return nil
+	}
+
+	pruneVersionsQuery := `
+		DELETE FROM vault_version
+		WHERE vault_id = ?1 AND version <= ?2;
+	`
+
+	log.Debug("prune versions query", slog.String("query", utils.QueryConvert(pruneVersionsQuery)))
+
+	_, err := tx.ExecContext(ctx, pruneVersionsQuery, id, current-c.maxVersions)
+	return err
+}
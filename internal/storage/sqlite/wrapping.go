@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/utils"
+)
+
+func (c *Client) CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error {
+	const op = "db.sqlite.CreateWrapping"
+
+	createWrappingQuery := `
+		INSERT INTO wrapping
+			(token_hash, accessor, creation_path, data_key, payload, expires_at, created_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7);
+	`
+
+	log.Debug("create wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(createWrappingQuery)))
+
+	_, err := c.db.ExecContext(ctx, createWrappingQuery,
+		tokenHash,
+		model.Accessor,
+		model.CreationPath,
+		model.DataKey,
+		model.Payload,
+		formatTime(model.ExpiresAt),
+		formatTime(model.CreatedAt),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.New("wrapping token already exists")
+		}
+		log.Error("failed to save wrapping", sl.OpErr(op, err))
+		return errors.New("failed to save wrapping")
+	}
+
+	return nil
+}
+
+func (c *Client) LookupWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappingModel, error) {
+	const op = "db.sqlite.LookupWrapping"
+
+	lookupWrappingQuery := `
+		SELECT accessor, creation_path, '', '', expires_at, created_at FROM wrapping
+		WHERE token_hash = ?1 AND expires_at > ?2;
+	`
+
+	log.Debug("lookup wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(lookupWrappingQuery)))
+
+	model, err := scanWrapping(c.db.QueryRowContext(ctx, lookupWrappingQuery, tokenHash, formatTime(time.Now())))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.WrappingModel{}, errors.New("wrapping token not found")
+		}
+		log.Error("failed to lookup wrapping", sl.OpErr(op, err))
+		return models.WrappingModel{}, errors.New("failed to lookup wrapping")
+	}
+
+	return model.WrappingModel, nil
+}
+
+func (c *Client) TakeWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappedResponse, error) {
+	const op = "db.sqlite.TakeWrapping"
+
+	takeWrappingQuery := `
+		DELETE FROM wrapping
+		WHERE token_hash = ?1 AND expires_at > ?2
+		RETURNING accessor, creation_path, data_key, payload, expires_at, created_at;
+	`
+
+	log.Debug("take wrapping query", slog.String("op", op), slog.String("query", utils.QueryConvert(takeWrappingQuery)))
+
+	model, err := scanWrapping(c.db.QueryRowContext(ctx, takeWrappingQuery, tokenHash, formatTime(time.Now())))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.WrappedResponse{}, errors.New("wrapping token not found")
+		}
+		log.Error("failed to take wrapping", sl.OpErr(op, err))
+		return models.WrappedResponse{}, errors.New("failed to take wrapping")
+	}
+
+	return model, nil
+}
+
+func (c *Client) PurgeWrappings(ctx context.Context, log *slog.Logger, before time.Time) (int, error) {
+	const op = "db.sqlite.PurgeWrappings"
+
+	purgeWrappingsQuery := `
+		DELETE FROM wrapping
+		WHERE expires_at <= ?1;
+	`
+
+	log.Debug("purge wrappings query", slog.String("op", op), slog.String("query", utils.QueryConvert(purgeWrappingsQuery)))
+
+	res, err := c.db.ExecContext(ctx, purgeWrappingsQuery, formatTime(before))
+	if err != nil {
+		log.Error("failed to purge wrappings", sl.OpErr(op, err))
+		return 0, errors.New("failed to purge expired wrappings")
+	}
+	purged, _ := res.RowsAffected()
+
+	return int(purged), nil
+}
+
+func scanWrapping(row scanner) (models.WrappedResponse, error) {
+	var (
+		model                models.WrappedResponse
+		expiresAt, createdAt string
+	)
+	if err := row.Scan(&model.Accessor, &model.CreationPath, &model.DataKey, &model.Payload, &expiresAt, &createdAt); err != nil {
+		return models.WrappedResponse{}, err
+	}
+
+	var err error
+	if model.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return models.WrappedResponse{}, err
+	}
+	if model.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.WrappedResponse{}, err
+	}
+	model.TTL = model.ExpiresAt.Sub(model.CreatedAt) / time.Second
+
+	return model, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"vault/internal/audit"
+	"vault/internal/auth"
+	"vault/internal/config"
+	"vault/internal/db"
+	"vault/internal/root"
+	"vault/internal/storage/memory"
+	"vault/internal/storage/sqlite"
+	"vault/internal/sys"
+	"vault/internal/transit"
+	"vault/internal/user"
+	"vault/pkg/database/postgresql"
+)
+
+const (
+	TypePostgres = "postgres"
+	TypeSQLite   = "sqlite"
+	TypeMemory   = "memory"
+)
+
+// Storage is implemented by every backend, it serves all the handler packages.
+type Storage interface {
+	root.RootDB
+	user.UserDB
+	sys.SysDB
+	transit.TransitDB
+	auth.AuthDB
+	audit.Store
+
+	// PurgeDeleted permanently removes vaults and keys soft deleted before the time,
+	// it returns the number of removed vaults and values.
+	PurgeDeleted(ctx context.Context, log *slog.Logger, before time.Time) (int, int, error)
+	// PurgeExpired removes at most limit vaults and limit values which expired before the time,
+	// it returns the number of removed vaults and values.
+	PurgeExpired(ctx context.Context, log *slog.Logger, before time.Time, limit int) (int, int, error)
+	// PurgeWrappings removes wrapped responses which expired before the time, it returns their number.
+	PurgeWrappings(ctx context.Context, log *slog.Logger, before time.Time) (int, error)
+}
+
+// New opens the backend selected by storage.type, close releases it.
+func New(ctx context.Context, log *slog.Logger, cfg *config.Config) (store Storage, close func(), err error) {
+	switch cfg.Storage.Type {
+	case TypePostgres, "":
+		pool := postgresql.NewClient(ctx, log, postgresql.DatabaseConfig{
+			Host:     cfg.Database.Host,
+			Port:     cfg.Database.Port,
+			User:     cfg.Database.User,
+			Password: cfg.Database.Password,
+			Name:     cfg.Database.Name,
+			SSLMode:  cfg.Database.SSLMode,
+			Attemps:  cfg.Database.Attemps,
+			Timeout:  cfg.Database.Timeout,
+			Delay:    cfg.Database.Delay,
+		})
+		if pool == nil {
+			return nil, nil, fmt.Errorf("failed to connect to postgres")
+		}
+		return db.NewClient(pool, cfg.MaxVersions), pool.Close, nil
+	case TypeSQLite:
+		client, err := sqlite.Open(ctx, cfg.Storage.Path, cfg.MaxVersions)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { client.Close() }, nil
+	case TypeMemory:
+		return memory.New(cfg.MaxVersions), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage type %q", cfg.Storage.Type)
+	}
+}
@@ -0,0 +1,1354 @@
+// Package storagetest is the conformance suite every storage backend has to pass.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+	"vault/internal/models"
+	"vault/internal/storage"
+	"vault/pkg/lib/logger/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MaxVersions is the version limit the factory has to configure.
+const MaxVersions = 3
+
+// Factory returns an empty storage configured with MaxVersions.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the whole suite, every subtest gets a fresh storage.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		Name string
+		Test func(t *testing.T, s storage.Storage)
+	}{
+		{"Vaults", testVaults},
+		{"ReservedIDs", testReservedIDs},
+		{"Patch", testPatch},
+		{"Versions", testVersions},
+		{"Delete", testDelete},
+		{"DeleteKeys", testDeleteKeys},
+		{"Expiry", testExpiry},
+		{"CAS", testCAS},
+		{"Listing", testListing},
+		{"Metadata", testMetadata},
+		{"Paths", testPaths},
+		{"Seal", testSeal},
+		{"Keyring", testKeyring},
+		{"JWTKeys", testJWTKeys},
+		{"Tokens", testTokens},
+		{"Transit", testTransit},
+		{"Policies", testPolicies},
+		{"Identities", testIdentities},
+		{"AppRoles", testAppRoles},
+		{"Operators", testOperators},
+		{"Wrappings", testWrappings},
+		{"Audit", testAudit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tt.Test(t, factory(t))
+		})
+	}
+}
+
+var (
+	ctx = context.Background()
+	log = slogdiscard.NewDiscardLogger()
+)
+
+func values(data map[string]string) []models.ValueDTO {
+	res := make([]models.ValueDTO, 0, len(data))
+	for k, v := range data {
+		res = append(res, models.ValueDTO{Key: k, Value: v, Encrypted: true})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+	return res
+}
+
+func createVault(t *testing.T, s storage.Storage, name string, data map[string]string) int {
+	t.Helper()
+
+	id, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: name},
+		DataKey:  "wrapped",
+		Data:     values(data),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, id)
+	return id
+}
+
+func testVaults(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"user": "admin", "password": "secret"})
+	other := createVault(t, s, "billing", map[string]string{"token": "t"})
+	assert.NotEqual(t, id, other)
+
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.SecretModel{
+		ID:      id,
+		Name:    "payments",
+		Version: 1,
+		Data:    map[string]string{"user": "admin", "password": "secret"},
+		DataKey: "wrapped",
+	}, vault)
+
+	name, err := s.GetVaultName(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, "payments", name)
+	assert.NoError(t, s.CheckVault(ctx, log, id))
+
+	_, err = s.GetVault(ctx, log, 9999)
+	assert.EqualError(t, err, "vault not found")
+	_, err = s.GetVaultName(ctx, log, 9999)
+	assert.EqualError(t, err, "vault not found")
+	assert.EqualError(t, s.CheckVault(ctx, log, 9999), "vault not found")
+
+	version, err := s.UpdateVault(ctx, log, id, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: "payments-prod"},
+		Data:     values(map[string]string{"api": "key"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, "payments-prod", vault.Name)
+	assert.Equal(t, "wrapped", vault.DataKey, "an empty data key keeps the stored one")
+	assert.Equal(t, map[string]string{"api": "key"}, vault.Data)
+
+	_, err = s.UpdateVault(ctx, log, 9999, models.SecretCreateDTO{VaultDTO: models.VaultDTO{Name: "x"}})
+	assert.EqualError(t, err, "vault not found")
+}
+
+func testReservedIDs(t *testing.T, s storage.Storage) {
+	first := createVault(t, s, "payments", map[string]string{"user": "admin"})
+
+	reserved, err := s.NextVaultID(ctx, log)
+	require.NoError(t, err)
+	assert.Greater(t, reserved, first)
+
+	next := createVault(t, s, "billing", map[string]string{"token": "t"})
+	assert.Greater(t, next, reserved, "reserved ids are not handed out again")
+
+	id, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		ID:       reserved,
+		VaultDTO: models.VaultDTO{Name: "legacy"},
+		Data:     []models.ValueDTO{{Key: "old", Value: "plain"}, {Key: "new", Value: "sealed", Encrypted: true}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, reserved, id)
+
+	vault, err := s.GetVault(ctx, log, reserved)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"old": true}, vault.Plaintext, "values stored unencrypted are flagged")
+
+	_, err = s.CreateVault(ctx, log, models.SecretCreateDTO{
+		ID:       reserved,
+		VaultDTO: models.VaultDTO{Name: "other"},
+		Data:     values(map[string]string{"k": "v"}),
+	})
+	assert.Error(t, err, "a reserved id is used once")
+}
+
+func testPatch(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"a": "1", "b": "2", "c": "3"})
+
+	version, err := s.PatchVault(ctx, log, id, models.SecretPatchDTO{
+		DataKey: "rotated",
+		Data:    values(map[string]string{"b": "20", "d": "4"}),
+		Remove:  []string{"c"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "20", "d": "4"}, vault.Data)
+	assert.Equal(t, "rotated", vault.DataKey)
+
+	_, err = s.PatchVault(ctx, log, 9999, models.SecretPatchDTO{Remove: []string{"a"}})
+	assert.EqualError(t, err, "vault not found")
+}
+
+func testVersions(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"v": "1"})
+	for i := 2; i <= 4; i++ {
+		version, err := s.UpdateVault(ctx, log, id, models.SecretCreateDTO{
+			VaultDTO: models.VaultDTO{Name: "payments"},
+			Data:     values(map[string]string{"v": fmt.Sprint(i)}),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, i, version)
+	}
+
+	versions, err := s.ListVersions(ctx, log, id)
+	require.NoError(t, err)
+	require.Len(t, versions, MaxVersions)
+	assert.Equal(t, []int{4, 3, 2}, []int{versions[0].Version, versions[1].Version, versions[2].Version})
+	assert.True(t, versions[0].Current)
+	assert.False(t, versions[1].Current)
+	assert.False(t, versions[0].CreatedAt.IsZero())
+
+	old, err := s.GetVaultVersion(ctx, log, id, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, old.Version)
+	assert.Equal(t, map[string]string{"v": "2"}, old.Data)
+
+	_, err = s.GetVaultVersion(ctx, log, id, 1)
+	assert.EqualError(t, err, "version not found", "pruned versions are removed")
+
+	version, err := s.RollbackVault(ctx, log, id, 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, version)
+
+	current, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, 5, current.Version)
+	assert.Equal(t, map[string]string{"v": "3"}, current.Data)
+
+	_, err = s.RollbackVault(ctx, log, id, 1, 0)
+	assert.EqualError(t, err, "version not found")
+	_, err = s.RollbackVault(ctx, log, 9999, 1, 0)
+	assert.EqualError(t, err, "vault not found")
+	_, err = s.ListVersions(ctx, log, 9999)
+	assert.EqualError(t, err, "vault not found")
+}
+
+func testDelete(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"a": "1"})
+	other := createVault(t, s, "billing", map[string]string{"a": "1"})
+
+	require.NoError(t, s.CreateToken(ctx, log, tokenInfo("shared", []int{id, other}, time.Hour)))
+	require.NoError(t, s.DeleteVault(ctx, log, id, 0))
+
+	_, err := s.GetVault(ctx, log, id)
+	assert.EqualError(t, err, "vault not found")
+	_, err = s.GetVaultID(ctx, log, "payments")
+	assert.EqualError(t, err, "vault not found")
+	assert.EqualError(t, s.CheckVault(ctx, log, id), "vault not found")
+	assert.EqualError(t, s.DeleteVault(ctx, log, id, 0), "vault not found")
+	name, err := s.GetVaultName(ctx, log, id)
+	require.NoError(t, err, "policies are evaluated on soft deleted vaults")
+	assert.Equal(t, "payments", name)
+
+	page, err := s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.Equal(t, other, page.Vaults[0].ID)
+	page, err = s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10, Deleted: true})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.Equal(t, id, page.Vaults[0].ID)
+	assert.NotNil(t, page.Vaults[0].DeletedAt)
+
+	taken := createVault(t, s, "payments", map[string]string{"b": "2"})
+	assert.EqualError(t, s.UndeleteVault(ctx, log, id), "vault already exists", "the path was reused")
+	require.NoError(t, s.DestroyVault(ctx, log, taken))
+
+	require.NoError(t, s.UndeleteVault(ctx, log, id))
+	assert.EqualError(t, s.UndeleteVault(ctx, log, id), "vault not found", "only deleted vaults are undeleted")
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1"}, vault.Data)
+
+	require.NoError(t, s.DeleteVault(ctx, log, id, 0))
+	assert.True(t, hasVaultKey(t, s, id), "deleted vaults keep their data key")
+	require.NoError(t, s.DestroyVault(ctx, log, id))
+	assert.False(t, hasVaultKey(t, s, id), "destroyed vaults are crypto-shredded")
+	assert.EqualError(t, s.UndeleteVault(ctx, log, id), "vault not found")
+	assert.EqualError(t, s.DestroyVault(ctx, log, id), "vault not found")
+	_, err = s.GetVaultName(ctx, log, id)
+	assert.EqualError(t, err, "vault not found")
+
+	tokens, err := s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, []int{other}, tokens[0].VaultIDs, "destroyed vaults are unbound from tokens")
+}
+
+// hasVaultKey reports whether the wrapped data key of the vault is still stored.
+func hasVaultKey(t *testing.T, s storage.Storage, id int) bool {
+	t.Helper()
+
+	keys, err := s.ListStaleKeys(ctx, log, "k2:", 100)
+	require.NoError(t, err)
+	for _, key := range keys {
+		if key.Kind == models.WrappedKeyVault && key.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func testDeleteKeys(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"a": "1", "b": "2", "c": "3"})
+	_, err := s.PatchVault(ctx, log, id, models.SecretPatchDTO{Data: values(map[string]string{"d": "4"})})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteKeys(ctx, log, id, []string{"a", "b", "unknown"}))
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"c": "3", "d": "4"}, vault.Data)
+	old, err := s.GetVaultVersion(ctx, log, id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"c": "3"}, old.Data, "keys are deleted in every version")
+
+	page, err := s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.Equal(t, 2, page.Vaults[0].KeyCount)
+
+	version, err := s.PatchVault(ctx, log, id, models.SecretPatchDTO{Data: values(map[string]string{"c": "5"})})
+	require.NoError(t, err)
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, version, vault.Version)
+	assert.Equal(t, map[string]string{"c": "5", "d": "4"}, vault.Data, "deleted keys stay deleted in new versions")
+
+	require.NoError(t, s.UndeleteKeys(ctx, log, id, []string{"a"}))
+	require.NoError(t, s.DestroyKeys(ctx, log, id, []string{"b"}))
+	require.NoError(t, s.UndeleteKeys(ctx, log, id, []string{"b"}))
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "5", "d": "4"}, vault.Data, "destroyed keys can't be undeleted")
+
+	purgedVaults, purgedValues, err := s.PurgeDeleted(ctx, log, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purgedVaults)
+	assert.Zero(t, purgedValues, "nothing is left to purge")
+
+	require.NoError(t, s.DeleteKeys(ctx, log, id, []string{"d"}))
+	purgedVaults, purgedValues, err = s.PurgeDeleted(ctx, log, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purgedVaults+purgedValues, "recent deletions are retained")
+
+	other := createVault(t, s, "billing", map[string]string{"a": "1"})
+	require.NoError(t, s.DeleteVault(ctx, log, other, 0))
+	purgedVaults, purgedValues, err = s.PurgeDeleted(ctx, log, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purgedVaults)
+	assert.Equal(t, 2, purgedValues, "d is removed from both versions holding it")
+
+	require.NoError(t, s.UndeleteKeys(ctx, log, id, []string{"d"}))
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "5"}, vault.Data)
+	_, err = s.GetVaultName(ctx, log, other)
+	assert.EqualError(t, err, "vault not found")
+
+	assert.EqualError(t, s.DeleteKeys(ctx, log, 9999, []string{"a"}), "vault not found")
+	require.NoError(t, s.DeleteVault(ctx, log, id, 0))
+	assert.EqualError(t, s.UndeleteKeys(ctx, log, id, []string{"a"}), "vault not found", "keys of deleted vaults are left alone")
+}
+
+func testExpiry(t *testing.T, s storage.Storage) {
+	now := time.Now().UTC().Truncate(time.Second)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+
+	data := values(map[string]string{"a": "1", "b": "2", "c": "3"})
+	data[1].ExpiresAt = &future
+	data[2].ExpiresAt = &past
+	id, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO:  models.VaultDTO{Name: "partners"},
+		DataKey:   "wrapped",
+		Data:      data,
+		ExpiresAt: &future,
+	})
+	require.NoError(t, err)
+
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, vault.Data, "expired keys are omitted")
+	require.NotNil(t, vault.ExpiresAt)
+	assert.True(t, future.Equal(*vault.ExpiresAt))
+	assert.InDelta(t, time.Hour.Seconds(), vault.TTL, 5)
+	require.Len(t, vault.KeyExpiry, 1)
+	assert.True(t, future.Equal(*vault.KeyExpiry["b"].ExpiresAt))
+
+	_, err = s.PatchVault(ctx, log, id, models.SecretPatchDTO{Data: values(map[string]string{"d": "4"})})
+	require.NoError(t, err)
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "d": "4"}, vault.Data)
+	require.Contains(t, vault.KeyExpiry, "b", "kept keys keep their expiry")
+	assert.True(t, future.Equal(*vault.KeyExpiry["b"].ExpiresAt))
+
+	page, err := s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.Equal(t, 3, page.Vaults[0].KeyCount)
+	require.NotNil(t, page.Vaults[0].ExpiresAt)
+
+	_, err = s.UpdateVault(ctx, log, id, models.SecretCreateDTO{VaultDTO: models.VaultDTO{Name: "partners"}, Data: values(map[string]string{"a": "1"})})
+	require.NoError(t, err)
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Nil(t, vault.ExpiresAt, "replacing the vault replaces its expiry")
+	assert.Nil(t, vault.KeyExpiry)
+
+	expired, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO:  models.VaultDTO{Name: "team/old"},
+		Data:      values(map[string]string{"a": "1"}),
+		ExpiresAt: &past,
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.CreateToken(ctx, log, tokenInfo("old", []int{expired, id}, time.Hour)))
+
+	_, err = s.GetVault(ctx, log, expired)
+	assert.EqualError(t, err, "vault not found")
+	_, err = s.GetVaultID(ctx, log, "team/old")
+	assert.EqualError(t, err, "vault not found")
+	assert.EqualError(t, s.CheckVault(ctx, log, expired), "vault not found")
+	_, err = s.PatchVault(ctx, log, expired, models.SecretPatchDTO{Data: values(map[string]string{"a": "2"})})
+	assert.EqualError(t, err, "vault not found")
+	children, err := s.ListChildren(ctx, log, "team")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+	page, err = s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Vaults, 1)
+
+	purgedVaults, purgedValues, err := s.PurgeExpired(ctx, log, now, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purgedVaults)
+	assert.Equal(t, 1, purgedValues, "the expired key of the kept versions is removed in batches")
+	purgedVaults, purgedValues, err = s.PurgeExpired(ctx, log, now, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, purgedVaults)
+	assert.Equal(t, 1, purgedValues)
+	purgedVaults, purgedValues, err = s.PurgeExpired(ctx, log, now, 1)
+	require.NoError(t, err)
+	assert.Zero(t, purgedVaults+purgedValues)
+
+	_, err = s.GetVaultName(ctx, log, expired)
+	assert.EqualError(t, err, "vault not found")
+	tokens, err := s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, []int{id}, tokens[0].VaultIDs, "purged vaults are unbound from tokens")
+
+	taken, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO:  models.VaultDTO{Name: "partners-old"},
+		Data:      values(map[string]string{"a": "1"}),
+		ExpiresAt: &past,
+	})
+	require.NoError(t, err)
+	reused := createVault(t, s, "partners-old", map[string]string{"b": "2"})
+	assert.NotEqual(t, taken, reused, "the path of an expired vault can be taken before it is purged")
+	_, err = s.GetVaultName(ctx, log, taken)
+	assert.EqualError(t, err, "vault not found")
+}
+
+func testCAS(t *testing.T, s storage.Storage) {
+	id := createVault(t, s, "payments", map[string]string{"a": "1"})
+	update := func(cas int, required *bool) (int, error) {
+		return s.UpdateVault(ctx, log, id, models.SecretCreateDTO{
+			VaultDTO:    models.VaultDTO{Name: "payments"},
+			Data:        values(map[string]string{"a": "2"}),
+			CAS:         cas,
+			CASRequired: required,
+		})
+	}
+
+	_, err := update(2, nil)
+	assert.EqualError(t, err, "vault version mismatch")
+	version, err := update(1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	required := true
+	version, err = update(0, &required)
+	require.NoError(t, err, "the flag applies from the next write")
+	assert.Equal(t, 3, version)
+
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.True(t, vault.CASRequired)
+
+	_, err = update(0, nil)
+	assert.EqualError(t, err, "check-and-set version required")
+	_, err = s.PatchVault(ctx, log, id, models.SecretPatchDTO{Data: values(map[string]string{"b": "1"})})
+	assert.EqualError(t, err, "check-and-set version required")
+	_, err = s.RollbackVault(ctx, log, id, 1, 0)
+	assert.EqualError(t, err, "check-and-set version required")
+	assert.EqualError(t, s.DeleteVault(ctx, log, id, 0), "check-and-set version required")
+
+	version, err = s.PatchVault(ctx, log, id, models.SecretPatchDTO{Data: values(map[string]string{"b": "1"}), CAS: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, version)
+	_, err = s.RollbackVault(ctx, log, id, 2, 3)
+	assert.EqualError(t, err, "vault version mismatch")
+
+	vault, err = s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, 4, vault.Version, "failed writes leave the vault untouched")
+	assert.Equal(t, map[string]string{"a": "2", "b": "1"}, vault.Data)
+
+	assert.EqualError(t, s.DeleteVault(ctx, log, id, 3), "vault version mismatch")
+	require.NoError(t, s.DeleteVault(ctx, log, id, 4))
+	_, err = update(1, nil)
+	assert.EqualError(t, err, "vault not found")
+}
+
+func createTaggedVault(t *testing.T, s storage.Storage, name string, keys int, tags ...string) int {
+	t.Helper()
+
+	data := map[string]string{}
+	for i := 0; i < keys; i++ {
+		data[fmt.Sprintf("key%d", i)] = "v"
+	}
+	id, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: name},
+		Data:     values(data),
+		Tags:     tags,
+	})
+	require.NoError(t, err)
+	return id
+}
+
+// listAll follows the cursors of the filter and returns the names of every page.
+func listAll(t *testing.T, s storage.Storage, filter models.VaultFilter) [][]string {
+	t.Helper()
+
+	var pages [][]string
+	for {
+		page, err := s.ListVaults(ctx, log, filter)
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(page.Vaults))
+		for _, vault := range page.Vaults {
+			names = append(names, vault.Name)
+		}
+		pages = append(pages, names)
+
+		if page.NextCursor == "" {
+			return pages
+		}
+		filter.After, err = models.DecodeVaultCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+}
+
+func testListing(t *testing.T, s storage.Storage) {
+	page, err := s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortName, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Vaults)
+	assert.Empty(t, page.NextCursor)
+
+	start := time.Now().Add(-time.Second)
+	first := createTaggedVault(t, s, "app/b", 2, "prod", "team:payments")
+	createTaggedVault(t, s, "billing", 1, "prod")
+	createTaggedVault(t, s, "app/a", 3)
+	createTaggedVault(t, s, "app/c", 0, "dev")
+	createTaggedVault(t, s, "apple", 1, "dev", "prod")
+
+	page, err = s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.NotEmpty(t, page.NextCursor)
+	vault := page.Vaults[0]
+	assert.Equal(t, first, vault.ID)
+	assert.Equal(t, "app/b", vault.Name)
+	assert.Equal(t, []string{"prod", "team:payments"}, vault.Tags)
+	assert.Equal(t, 1, vault.Version)
+	assert.Equal(t, 2, vault.KeyCount)
+	assert.True(t, vault.CreatedAt.After(start))
+
+	tests := []struct {
+		Name   string
+		Filter models.VaultFilter
+		Pages  [][]string
+	}{
+		{
+			Name:   "by name",
+			Filter: models.VaultFilter{Sort: models.SortName, Limit: 2},
+			Pages:  [][]string{{"app/a", "app/b"}, {"app/c", "apple"}, {"billing"}},
+		},
+		{
+			Name:   "by name desc",
+			Filter: models.VaultFilter{Sort: models.SortName, Desc: true, Limit: 3},
+			Pages:  [][]string{{"billing", "apple", "app/c"}, {"app/b", "app/a"}},
+		},
+		{
+			Name:   "by id",
+			Filter: models.VaultFilter{Sort: models.SortID, Limit: 5},
+			Pages:  [][]string{{"app/b", "billing", "app/a", "app/c", "apple"}},
+		},
+		{
+			Name:   "by created at desc",
+			Filter: models.VaultFilter{Sort: models.SortCreatedAt, Desc: true, Limit: 4},
+			Pages:  [][]string{{"apple", "app/c", "app/a", "billing"}, {"app/b"}},
+		},
+		{
+			Name:   "prefix",
+			Filter: models.VaultFilter{Prefix: "app/", Sort: models.SortName, Limit: 2},
+			Pages:  [][]string{{"app/a", "app/b"}, {"app/c"}},
+		},
+		{
+			Name:   "tags",
+			Filter: models.VaultFilter{Tags: []string{"prod", "dev"}, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"apple"}},
+		},
+		{
+			Name:   "created after",
+			Filter: models.VaultFilter{CreatedAfter: time.Now().Add(time.Hour), Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{}},
+		},
+		{
+			Name:   "created between",
+			Filter: models.VaultFilter{CreatedAfter: start, CreatedBefore: time.Now().Add(time.Hour), Tags: []string{"dev"}, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"app/c", "apple"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Pages, listAll(t, s, tt.Filter))
+		})
+	}
+
+	_, err = s.UpdateVault(ctx, log, first, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: "app/b"},
+		Data:     values(map[string]string{"a": "1"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"app/b", "billing", "apple"}}, listAll(t, s, models.VaultFilter{Tags: []string{"prod"}, Sort: models.SortID, Limit: 10}), "tags are kept without new tags")
+
+	_, err = s.UpdateVault(ctx, log, first, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: "app/b"},
+		Data:     values(map[string]string{"a": "1"}),
+		Tags:     []string{},
+	})
+	require.NoError(t, err)
+	page, err = s.ListVaults(ctx, log, models.VaultFilter{Prefix: "app/b", Sort: models.SortName, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 1)
+	assert.Empty(t, page.Vaults[0].Tags)
+	assert.Equal(t, 3, page.Vaults[0].Version)
+	assert.Equal(t, 1, page.Vaults[0].KeyCount)
+}
+
+func testMetadata(t *testing.T, s storage.Storage) {
+	start := time.Now().Add(-time.Second)
+	id, err := s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO:  models.VaultDTO{Name: "payments"},
+		Data:      values(map[string]string{"a": "1"}),
+		Tags:      []string{"prod"},
+		CreatedBy: "ci",
+	})
+	require.NoError(t, err)
+	other := createTaggedVault(t, s, "billing", 1)
+
+	metadata, err := s.GetMetadata(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, metadata.ID)
+	assert.Equal(t, "payments", metadata.Name)
+	assert.Equal(t, "ci", metadata.CreatedBy)
+	assert.Equal(t, []string{"prod"}, metadata.Tags)
+	assert.Empty(t, metadata.Attributes)
+	assert.Empty(t, metadata.Owner)
+	assert.True(t, metadata.CreatedAt.After(start))
+	assert.False(t, metadata.UpdatedAt.Before(metadata.CreatedAt))
+
+	description, owner, email := "card processing", "team-payments", "payments@example.com"
+	require.NoError(t, s.UpdateMetadata(ctx, log, id, models.MetadataDTO{
+		Description:  &description,
+		Owner:        &owner,
+		ContactEmail: &email,
+		Attributes:   map[string]string{"cost-center": "42", "tier": "1"},
+	}))
+
+	metadata, err = s.GetMetadata(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, description, metadata.Description)
+	assert.Equal(t, owner, metadata.Owner)
+	assert.Equal(t, email, metadata.ContactEmail)
+	assert.Equal(t, []string{"prod"}, metadata.Tags, "tags are kept without new tags")
+	assert.Equal(t, map[string]string{"cost-center": "42", "tier": "1"}, metadata.Attributes)
+
+	cleared := ""
+	require.NoError(t, s.UpdateMetadata(ctx, log, id, models.MetadataDTO{
+		ContactEmail: &cleared,
+		Tags:         []string{"pci", "prod"},
+		Attributes:   map[string]string{"tier": "0"},
+	}))
+
+	metadata, err = s.GetMetadata(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, description, metadata.Description, "nil fields keep the stored value")
+	assert.Empty(t, metadata.ContactEmail)
+	assert.Equal(t, []string{"pci", "prod"}, metadata.Tags)
+	assert.Equal(t, map[string]string{"tier": "0"}, metadata.Attributes)
+
+	vault, err := s.GetVault(ctx, log, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, vault.Version, "metadata edits don't create versions")
+	assert.Equal(t, map[string]string{"a": "1"}, vault.Data)
+
+	page, err := s.ListVaults(ctx, log, models.VaultFilter{Sort: models.SortID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Vaults, 2)
+	assert.Equal(t, description, page.Vaults[0].Description)
+	assert.Equal(t, owner, page.Vaults[0].Owner)
+	assert.Equal(t, "ci", page.Vaults[0].CreatedBy)
+	assert.Equal(t, map[string]string{"tier": "0"}, page.Vaults[0].Attributes)
+	assert.Empty(t, page.Vaults[1].Attributes)
+
+	tests := []struct {
+		Name   string
+		Filter models.VaultFilter
+		Pages  [][]string
+	}{
+		{
+			Name:   "owner",
+			Filter: models.VaultFilter{Owner: owner, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"payments"}},
+		},
+		{
+			Name:   "created by",
+			Filter: models.VaultFilter{CreatedBy: "ci", Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"payments"}},
+		},
+		{
+			Name:   "attributes",
+			Filter: models.VaultFilter{Attributes: map[string]string{"tier": "0"}, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"payments"}},
+		},
+		{
+			Name:   "attribute value",
+			Filter: models.VaultFilter{Attributes: map[string]string{"tier": "1"}, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{}},
+		},
+		{
+			Name:   "metadata tags",
+			Filter: models.VaultFilter{Tags: []string{"pci"}, Sort: models.SortName, Limit: 10},
+			Pages:  [][]string{{"payments"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Pages, listAll(t, s, tt.Filter))
+		})
+	}
+
+	assert.EqualError(t, s.UpdateMetadata(ctx, log, 9999, models.MetadataDTO{Owner: &owner}), "vault not found")
+	require.NoError(t, s.DeleteVault(ctx, log, other, 0))
+	_, err = s.GetMetadata(ctx, log, other)
+	assert.EqualError(t, err, "vault not found")
+	assert.EqualError(t, s.UpdateMetadata(ctx, log, other, models.MetadataDTO{Owner: &owner}), "vault not found")
+}
+
+func testPaths(t *testing.T, s storage.Storage) {
+	db := createVault(t, s, "team/payments/prod/db", map[string]string{"a": "1"})
+	createVault(t, s, "team/payments/prod", map[string]string{"a": "1"})
+	createVault(t, s, "team/payments/staging/db", map[string]string{"a": "1"})
+	createVault(t, s, "team/billing", map[string]string{"a": "1"})
+	other := createVault(t, s, "shared", map[string]string{"a": "1"})
+
+	_, err := s.CreateVault(ctx, log, models.SecretCreateDTO{VaultDTO: models.VaultDTO{Name: "shared"}})
+	assert.EqualError(t, err, "vault already exists")
+	_, err = s.UpdateVault(ctx, log, other, models.SecretCreateDTO{VaultDTO: models.VaultDTO{Name: "team/billing"}})
+	assert.EqualError(t, err, "vault already exists")
+
+	id, err := s.GetVaultID(ctx, log, "team/payments/prod/db")
+	require.NoError(t, err)
+	assert.Equal(t, db, id)
+	_, err = s.GetVaultID(ctx, log, "team/payments")
+	assert.EqualError(t, err, "vault not found")
+
+	tests := []struct {
+		Prefix   string
+		Children []string
+	}{
+		{"", []string{"shared", "team/"}},
+		{"team", []string{"billing", "payments/"}},
+		{"team/payments", []string{"prod", "prod/", "staging/"}},
+		{"team/payments/prod", []string{"db"}},
+		{"team/pay", []string{}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		children, err := s.ListChildren(ctx, log, tt.Prefix)
+		require.NoError(t, err)
+		assert.Equal(t, tt.Children, children, tt.Prefix)
+	}
+
+	token := tokenInfo("paths", nil, time.Hour)
+	token.Paths = []string{"team/payments/*"}
+	require.NoError(t, s.CreateToken(ctx, log, token))
+
+	tokens, err := s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1, "tokens bound to paths only are listed")
+	assert.Empty(t, tokens[0].VaultIDs)
+	assert.Equal(t, []string{"team/payments/*"}, tokens[0].Paths)
+}
+
+func testSeal(t *testing.T, s storage.Storage) {
+	_, err := s.GetSealConfig(ctx, log)
+	assert.EqualError(t, err, "vault is not initialized")
+
+	config := models.SealConfig{Shares: 5, Threshold: 3, Verification: "canary"}
+	require.NoError(t, s.CreateSealConfig(ctx, log, config))
+
+	stored, err := s.GetSealConfig(ctx, log)
+	require.NoError(t, err)
+	assert.Equal(t, config, stored)
+
+	assert.Error(t, s.CreateSealConfig(ctx, log, config), "the seal config is created only once")
+}
+
+func testKeyring(t *testing.T, s storage.Storage) {
+	keyring, err := s.GetKeyring(ctx, log)
+	require.NoError(t, err)
+	assert.Empty(t, keyring)
+
+	require.NoError(t, s.AddKeyringTerm(ctx, log, models.KeyringTerm{Term: 3, Key: "term-3"}))
+	require.NoError(t, s.AddKeyringTerm(ctx, log, models.KeyringTerm{Term: 2, Key: "term-2"}))
+	assert.EqualError(t, s.AddKeyringTerm(ctx, log, models.KeyringTerm{Term: 2, Key: "other"}), "keyring term already exists")
+
+	keyring, err = s.GetKeyring(ctx, log)
+	require.NoError(t, err)
+	require.Len(t, keyring, 2)
+	for i, term := range keyring {
+		assert.Equal(t, i+2, term.Term)
+		assert.Equal(t, fmt.Sprintf("term-%d", i+2), term.Key)
+		assert.False(t, term.CreatedAt.IsZero())
+	}
+
+	first := createVault(t, s, "payments", map[string]string{"a": "1"})
+	second := createVault(t, s, "billing", map[string]string{"a": "1"})
+	require.NoError(t, s.DeleteVault(ctx, log, second, 0))
+	_, err = s.CreateVault(ctx, log, models.SecretCreateDTO{
+		VaultDTO: models.VaultDTO{Name: "current"},
+		DataKey:  "k2:wrapped",
+		Data:     values(map[string]string{"a": "1"}),
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.CreateTransitKey(ctx, log, models.TransitKeyModel{
+		Name:     "orders",
+		Type:     "aes256-gcm96",
+		Versions: []models.TransitKeyVersion{{Key: "material"}},
+	}))
+
+	pending, total, err := s.CountStaleKeys(ctx, log, "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending, "nothing is stale while the master key is the only term")
+	assert.Equal(t, 4, total)
+
+	pending, total, err = s.CountStaleKeys(ctx, log, "k2:")
+	require.NoError(t, err)
+	assert.Equal(t, 3, pending)
+	assert.Equal(t, 4, total)
+
+	keys, err := s.ListStaleKeys(ctx, log, "k2:", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.WrappedKey{
+		{Kind: models.WrappedKeyVault, ID: first, Key: "wrapped"},
+		{Kind: models.WrappedKeyVault, ID: second, Key: "wrapped"},
+	}, keys, "deleted vaults are rewrapped as well")
+
+	for _, key := range keys {
+		key.Key = "k2:" + key.Key
+		require.NoError(t, s.UpdateWrappedKey(ctx, log, key, "wrapped"))
+	}
+	assert.EqualError(t, s.UpdateWrappedKey(ctx, log, keys[0], "wrapped"), "wrapped key changed")
+
+	keys, err = s.ListStaleKeys(ctx, log, "k2:", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.WrappedKey{
+		{Kind: models.WrappedKeyTransit, Name: "orders", Version: 1, Key: "material"},
+	}, keys)
+
+	keys[0].Key = "k2:material"
+	require.NoError(t, s.UpdateWrappedKey(ctx, log, keys[0], "material"))
+
+	pending, _, err = s.CountStaleKeys(ctx, log, "k2:")
+	require.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	vault, err := s.GetVault(ctx, log, first)
+	require.NoError(t, err)
+	assert.Equal(t, "k2:wrapped", vault.DataKey)
+
+	transitKey, err := s.GetTransitKey(ctx, log, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, "k2:material", transitKey.Versions[0].Key)
+}
+
+func testJWTKeys(t *testing.T, s storage.Storage) {
+	keys, err := s.ListJWTKeys(ctx, log)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, s.CreateJWTKey(ctx, log, models.JWTKeyModel{ID: "first", Algorithm: "HS512", State: models.JWTKeyVerify, Key: "wrapped-1"}))
+	require.NoError(t, s.CreateJWTKey(ctx, log, models.JWTKeyModel{ID: "second", Algorithm: "EdDSA", State: models.JWTKeyVerify, Key: "wrapped-2", PublicKey: "public-2"}))
+	assert.EqualError(t, s.CreateJWTKey(ctx, log, models.JWTKeyModel{ID: "first", State: models.JWTKeyVerify, Key: "other"}), "jwt key already exists")
+
+	require.NoError(t, s.PromoteJWTKey(ctx, log, "first"))
+	require.NoError(t, s.PromoteJWTKey(ctx, log, "second"))
+	assert.EqualError(t, s.PromoteJWTKey(ctx, log, "missing"), "jwt key not found")
+	assert.EqualError(t, s.RetireJWTKey(ctx, log, "second"), "jwt key is active")
+	require.NoError(t, s.RetireJWTKey(ctx, log, "first"))
+	assert.EqualError(t, s.PromoteJWTKey(ctx, log, "first"), "jwt key is retired")
+	assert.EqualError(t, s.RetireJWTKey(ctx, log, "missing"), "jwt key not found")
+
+	keys, err = s.ListJWTKeys(ctx, log)
+	require.NoError(t, err)
+	states := map[string]string{}
+	algorithms := map[string]string{}
+	for _, key := range keys {
+		states[key.ID] = key.State
+		algorithms[key.ID] = key.Algorithm + "/" + key.PublicKey
+		assert.False(t, key.CreatedAt.IsZero())
+	}
+	assert.Equal(t, map[string]string{"first": models.JWTKeyRetired, "second": models.JWTKeyActive}, states)
+	assert.Equal(t, map[string]string{"first": "HS512/", "second": "EdDSA/public-2"}, algorithms)
+
+	stale, err := s.ListStaleKeys(ctx, log, "k2:", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.WrappedKey{
+		{Kind: models.WrappedKeyJWT, Name: "first", Key: "wrapped-1"},
+		{Kind: models.WrappedKeyJWT, Name: "second", Key: "wrapped-2"},
+	}, stale, "retired keys are rewrapped as well")
+
+	require.NoError(t, s.UpdateWrappedKey(ctx, log, models.WrappedKey{Kind: models.WrappedKeyJWT, Name: "second", Key: "k2:wrapped-2"}, "wrapped-2"))
+	pending, total, err := s.CountStaleKeys(ctx, log, "k2:")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, 2, total)
+}
+
+func tokenInfo(jti string, vaultIDs []int, ttl time.Duration) models.TokenInfoModel {
+	now := time.Now().UTC().Truncate(time.Second)
+	return models.TokenInfoModel{
+		JTI:       jti,
+		VaultIDs:  vaultIDs,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+		TokenScope: models.TokenScope{
+			Keys:         []string{"db_*"},
+			Capabilities: []string{models.CapabilityRead},
+		},
+	}
+}
+
+func testTokens(t *testing.T, s storage.Storage) {
+	first := createVault(t, s, "payments", map[string]string{"a": "1"})
+	second := createVault(t, s, "billing", map[string]string{"a": "1"})
+
+	active := tokenInfo("active", []int{second, first}, time.Hour)
+	require.NoError(t, s.CreateToken(ctx, log, active))
+	require.NoError(t, s.CreateToken(ctx, log, tokenInfo("second", []int{second}, time.Hour)))
+	require.NoError(t, s.CreateToken(ctx, log, tokenInfo("expired", []int{first}, -time.Minute)))
+
+	assert.NoError(t, s.CheckToken(ctx, log, "active"))
+	assert.EqualError(t, s.CheckToken(ctx, log, "expired"), "token expired")
+	assert.EqualError(t, s.CheckToken(ctx, log, "unknown"), "token not found")
+
+	tokens, err := s.ListTokens(ctx, log, first)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "active", tokens[0].JTI)
+	assert.ElementsMatch(t, []int{first, second}, tokens[0].VaultIDs)
+	assert.Equal(t, active.TokenScope, tokens[0].TokenScope)
+	assert.True(t, active.ExpiresAt.Equal(tokens[0].ExpiresAt))
+
+	tokens, err = s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+
+	renewedAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, s.RenewToken(ctx, log, "active", renewedAt))
+	tokens, err = s.ListTokens(ctx, log, first)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.True(t, renewedAt.Equal(tokens[0].ExpiresAt))
+	assert.EqualError(t, s.RenewToken(ctx, log, "expired", renewedAt), "token not found", "expired tokens can't be renewed")
+	assert.EqualError(t, s.RenewToken(ctx, log, "unknown", renewedAt), "token not found")
+
+	require.NoError(t, s.RevokeToken(ctx, log, "active"))
+	require.NoError(t, s.RevokeToken(ctx, log, "active"), "revoking twice is allowed")
+	assert.EqualError(t, s.CheckToken(ctx, log, "active"), "token revoked")
+	assert.EqualError(t, s.RevokeToken(ctx, log, "unknown"), "token not found")
+	assert.EqualError(t, s.RenewToken(ctx, log, "active", renewedAt), "token not found", "revoked tokens can't be renewed")
+
+	count, err := s.RevokeVaultTokens(ctx, log, second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	tokens, err = s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func testTransit(t *testing.T, s storage.Storage) {
+	key := models.TransitKeyModel{
+		Name:     "payments",
+		Type:     "ed25519",
+		Versions: []models.TransitKeyVersion{{Key: "wrapped-1", PublicKey: "public-1"}},
+	}
+	require.NoError(t, s.CreateTransitKey(ctx, log, key))
+	require.NoError(t, s.CreateTransitKey(ctx, log, models.TransitKeyModel{
+		Name:     "billing",
+		Type:     "aes256-gcm96",
+		Versions: []models.TransitKeyVersion{{Key: "wrapped"}},
+	}))
+	assert.EqualError(t, s.CreateTransitKey(ctx, log, key), "transit key already exists")
+
+	names, err := s.ListTransitKeys(ctx, log)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing", "payments"}, names)
+
+	version, err := s.RotateTransitKey(ctx, log, "payments", models.TransitKeyVersion{Key: "wrapped-2", PublicKey: "public-2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+	require.NoError(t, s.UpdateTransitKey(ctx, log, "payments", 2))
+
+	stored, err := s.GetTransitKey(ctx, log, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "ed25519", stored.Type)
+	assert.Equal(t, 2, stored.LatestVersion)
+	assert.Equal(t, 2, stored.MinDecryptionVersion)
+	assert.False(t, stored.CreatedAt.IsZero())
+	require.Len(t, stored.Versions, 2)
+	for i, v := range stored.Versions {
+		assert.Equal(t, i+1, v.Version)
+		assert.Equal(t, fmt.Sprintf("wrapped-%d", i+1), v.Key)
+		assert.Equal(t, fmt.Sprintf("public-%d", i+1), v.PublicKey)
+		assert.False(t, v.CreatedAt.IsZero())
+	}
+
+	_, err = s.RotateTransitKey(ctx, log, "missing", models.TransitKeyVersion{Key: "wrapped"})
+	assert.EqualError(t, err, "transit key not found")
+	assert.EqualError(t, s.UpdateTransitKey(ctx, log, "missing", 1), "transit key not found")
+
+	require.NoError(t, s.DeleteTransitKey(ctx, log, "payments"))
+	assert.EqualError(t, s.DeleteTransitKey(ctx, log, "payments"), "transit key not found")
+	_, err = s.GetTransitKey(ctx, log, "payments")
+	assert.EqualError(t, err, "transit key not found")
+
+	token := tokenInfo("transit", nil, time.Hour)
+	token.TransitKeys = []string{"billing"}
+	require.NoError(t, s.CreateToken(ctx, log, token))
+
+	tokens, err := s.ListTokens(ctx, log, 0)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1, "tokens bound only to transit keys are listed")
+	assert.Equal(t, []string{"billing"}, tokens[0].TransitKeys)
+}
+
+func testPolicies(t *testing.T, s storage.Storage) {
+	names, err := s.ListPolicies(ctx, log)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	require.NoError(t, s.PutPolicy(ctx, log, models.PolicyModel{Name: "ops", Document: "rules: []"}))
+	require.NoError(t, s.PutPolicy(ctx, log, models.PolicyModel{Name: "ci", Document: "v1"}))
+	require.NoError(t, s.PutPolicy(ctx, log, models.PolicyModel{Name: "ci", Document: "v2"}))
+
+	names, err = s.ListPolicies(ctx, log)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci", "ops"}, names)
+
+	policy, err := s.GetPolicy(ctx, log, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", policy.Document)
+	assert.False(t, policy.UpdatedAt.IsZero())
+
+	policies, err := s.GetPolicies(ctx, log, []string{"ci", "missing"})
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "ci", policies[0].Name)
+
+	require.NoError(t, s.DeletePolicy(ctx, log, "ci"))
+	_, err = s.GetPolicy(ctx, log, "ci")
+	assert.EqualError(t, err, "policy not found")
+	assert.EqualError(t, s.DeletePolicy(ctx, log, "ci"), "policy not found")
+}
+
+func testIdentities(t *testing.T, s storage.Storage) {
+	id, err := s.CreateIdentity(ctx, log, models.IdentityModel{Name: "deploy", Policies: []string{"ci", "ops"}}, "hash-1")
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	_, err = s.CreateIdentity(ctx, log, models.IdentityModel{Name: "deploy", Policies: []string{"ci"}}, "hash-2")
+	assert.EqualError(t, err, "identity already exists")
+
+	_, err = s.CreateIdentity(ctx, log, models.IdentityModel{Name: "backup", Policies: []string{"ops"}}, "hash-3")
+	require.NoError(t, err)
+
+	identity, err := s.GetIdentityByToken(ctx, log, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, id, identity.ID)
+	assert.Equal(t, "deploy", identity.Name)
+	assert.Equal(t, []string{"ci", "ops"}, identity.Policies)
+
+	_, err = s.GetIdentityByToken(ctx, log, "unknown")
+	assert.EqualError(t, err, "identity not found")
+
+	identities, err := s.ListIdentities(ctx, log)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, "backup", identities[0].Name)
+
+	require.NoError(t, s.DeleteIdentity(ctx, log, "deploy"))
+	assert.EqualError(t, s.DeleteIdentity(ctx, log, "deploy"), "identity not found")
+	_, err = s.GetIdentityByToken(ctx, log, "hash-1")
+	assert.EqualError(t, err, "identity not found")
+}
+
+func testAppRoles(t *testing.T, s storage.Storage) {
+	role := models.AppRoleModel{
+		Name:       "billing",
+		RoleID:     "role-1",
+		VaultIDs:   []int{1, 2},
+		Paths:      []string{"billing/*"},
+		TokenScope: models.TokenScope{Capabilities: []string{models.CapabilityRead}},
+		Expires:    300,
+		MaxExpires: 3600,
+		Renewable:  true,
+		MaxTTL:     86400,
+	}
+	require.NoError(t, s.PutAppRole(ctx, log, role))
+	require.NoError(t, s.PutAppRole(ctx, log, models.AppRoleModel{Name: "backup", RoleID: "role-2", Expires: 60}))
+
+	stored, err := s.GetAppRole(ctx, log, "billing")
+	require.NoError(t, err)
+	assert.Equal(t, "role-1", stored.RoleID)
+	assert.Equal(t, []int{1, 2}, stored.VaultIDs)
+	assert.Equal(t, []string{"billing/*"}, stored.Paths)
+	assert.Equal(t, []string{models.CapabilityRead}, stored.Capabilities)
+	assert.Equal(t, time.Duration(300), stored.Expires)
+	assert.Equal(t, time.Duration(3600), stored.MaxExpires)
+	assert.True(t, stored.Renewable)
+	assert.Equal(t, time.Duration(86400), stored.MaxTTL)
+	assert.False(t, stored.CreatedAt.IsZero())
+
+	role.RoleID, role.Expires = "ignored", 600
+	require.NoError(t, s.PutAppRole(ctx, log, role))
+	stored, err = s.GetAppRoleByRoleID(ctx, log, "role-1")
+	require.NoError(t, err, "updates keep the role id")
+	assert.Equal(t, "billing", stored.Name)
+	assert.Equal(t, time.Duration(600), stored.Expires)
+	_, err = s.GetAppRoleByRoleID(ctx, log, "ignored")
+	assert.EqualError(t, err, "approle not found")
+	_, err = s.GetAppRole(ctx, log, "missing")
+	assert.EqualError(t, err, "approle not found")
+
+	names, err := s.ListAppRoles(ctx, log)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup", "billing"}, names)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, s.CreateSecretID(ctx, log, "billing", models.SecretIDModel{Accessor: "a-1", SingleUse: true, CIDRs: []string{"10.0.0.0/8"}, ExpiresAt: &expiresAt}, "hash-1"))
+	require.NoError(t, s.CreateSecretID(ctx, log, "billing", models.SecretIDModel{Accessor: "a-2"}, "hash-2"))
+	assert.EqualError(t, s.CreateSecretID(ctx, log, "missing", models.SecretIDModel{Accessor: "a-3"}, "hash-3"), "approle not found")
+
+	secretID, err := s.GetSecretID(ctx, log, "billing", "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "a-1", secretID.Accessor)
+	assert.True(t, secretID.SingleUse)
+	assert.Equal(t, []string{"10.0.0.0/8"}, secretID.CIDRs)
+	require.NotNil(t, secretID.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*secretID.ExpiresAt))
+	_, err = s.GetSecretID(ctx, log, "backup", "hash-1")
+	assert.EqualError(t, err, "secret id not found", "secret ids belong to their role")
+
+	secretIDs, err := s.ListSecretIDs(ctx, log, "billing")
+	require.NoError(t, err)
+	require.Len(t, secretIDs, 2)
+	assert.Nil(t, secretIDs[1].ExpiresAt)
+
+	require.NoError(t, s.DeleteSecretID(ctx, log, "billing", "a-1"))
+	assert.EqualError(t, s.DeleteSecretID(ctx, log, "billing", "a-1"), "secret id not found")
+	_, err = s.GetSecretID(ctx, log, "billing", "hash-1")
+	assert.EqualError(t, err, "secret id not found")
+
+	require.NoError(t, s.DeleteAppRole(ctx, log, "billing"))
+	assert.EqualError(t, s.DeleteAppRole(ctx, log, "billing"), "approle not found")
+	_, err = s.GetSecretID(ctx, log, "billing", "hash-2")
+	assert.EqualError(t, err, "secret id not found", "secret ids are removed with their role")
+}
+
+func testOperators(t *testing.T, s storage.Storage) {
+	id, err := s.CreateOperator(ctx, log, models.OperatorModel{Name: "alice", Policies: []string{"admin"}}, "hash-a")
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+	_, err = s.CreateOperator(ctx, log, models.OperatorModel{Name: "alice", Policies: []string{"admin"}}, "hash-b")
+	assert.EqualError(t, err, "operator already exists")
+	_, err = s.CreateOperator(ctx, log, models.OperatorModel{Name: "bob", Policies: []string{"read"}}, "hash-b")
+	require.NoError(t, err)
+
+	operator, hash, err := s.GetOperator(ctx, log, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, id, operator.ID)
+	assert.Equal(t, "hash-a", hash)
+	assert.Equal(t, []string{"admin"}, operator.Policies)
+	assert.Nil(t, operator.LockedUntil)
+	assert.False(t, operator.CreatedAt.IsZero())
+	_, _, err = s.GetOperator(ctx, log, "missing")
+	assert.EqualError(t, err, "operator not found")
+
+	operators, err := s.ListOperators(ctx, log)
+	require.NoError(t, err)
+	require.Len(t, operators, 2)
+	assert.Equal(t, "alice", operators[0].Name)
+	assert.Equal(t, "bob", operators[1].Name)
+
+	lockedUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, s.RecordOperatorFailure(ctx, log, "alice", 2, lockedUntil))
+	operator, _, err = s.GetOperator(ctx, log, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, operator.FailedLogins)
+	assert.Nil(t, operator.LockedUntil)
+	require.NoError(t, s.RecordOperatorFailure(ctx, log, "alice", 2, lockedUntil))
+	operator, _, err = s.GetOperator(ctx, log, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 0, operator.FailedLogins, "locking starts counting again")
+	require.NotNil(t, operator.LockedUntil)
+	assert.True(t, lockedUntil.Equal(*operator.LockedUntil))
+	assert.True(t, operator.Locked(time.Now()))
+	assert.EqualError(t, s.RecordOperatorFailure(ctx, log, "missing", 2, lockedUntil), "operator not found")
+
+	require.NoError(t, s.UnlockOperator(ctx, log, "alice"))
+	operator, _, err = s.GetOperator(ctx, log, "alice")
+	require.NoError(t, err)
+	assert.Nil(t, operator.LockedUntil)
+	assert.EqualError(t, s.UnlockOperator(ctx, log, "missing"), "operator not found")
+
+	require.NoError(t, s.RecordOperatorFailure(ctx, log, "alice", 5, lockedUntil))
+	require.NoError(t, s.UpdateOperatorPassword(ctx, log, "alice", "hash-c"))
+	operator, hash, err = s.GetOperator(ctx, log, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hash-c", hash)
+	assert.Equal(t, 0, operator.FailedLogins, "a new password resets failed logins")
+	assert.EqualError(t, s.UpdateOperatorPassword(ctx, log, "missing", "hash"), "operator not found")
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	expiredAt := time.Now().Add(-time.Minute)
+	require.NoError(t, s.CreateOperatorToken(ctx, log, "alice", models.OperatorTokenModel{Accessor: "s-1", Kind: models.OperatorSession, ExpiresAt: &expiresAt}, "token-1"))
+	require.NoError(t, s.CreateOperatorToken(ctx, log, "alice", models.OperatorTokenModel{Accessor: "k-1", Kind: models.OperatorAPIKey, Name: "ci"}, "token-2"))
+	require.NoError(t, s.CreateOperatorToken(ctx, log, "alice", models.OperatorTokenModel{Accessor: "s-0", Kind: models.OperatorSession, ExpiresAt: &expiredAt}, "token-3"))
+	assert.EqualError(t, s.CreateOperatorToken(ctx, log, "missing", models.OperatorTokenModel{Accessor: "s-2", Kind: models.OperatorSession}, "token-4"), "operator not found")
+
+	operator, err = s.GetOperatorByToken(ctx, log, "token-1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", operator.Name)
+	assert.Equal(t, []string{"admin"}, operator.Policies)
+	_, err = s.GetOperatorByToken(ctx, log, "token-3")
+	assert.EqualError(t, err, "operator not found", "expired sessions are rejected")
+	_, err = s.GetOperatorByToken(ctx, log, "missing")
+	assert.EqualError(t, err, "operator not found")
+
+	tokens, err := s.ListOperatorTokens(ctx, log, "alice")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2, "expired sessions are not listed")
+	byAccessor := map[string]models.OperatorTokenModel{}
+	for _, token := range tokens {
+		byAccessor[token.Accessor] = token
+	}
+	assert.Equal(t, models.OperatorAPIKey, byAccessor["k-1"].Kind)
+	assert.Equal(t, "ci", byAccessor["k-1"].Name)
+	assert.Nil(t, byAccessor["k-1"].ExpiresAt)
+	require.NotNil(t, byAccessor["s-1"].ExpiresAt)
+	assert.True(t, expiresAt.Equal(*byAccessor["s-1"].ExpiresAt))
+
+	require.NoError(t, s.UpdateOperatorPassword(ctx, log, "alice", "hash-d"))
+	_, err = s.GetOperatorByToken(ctx, log, "token-1")
+	assert.EqualError(t, err, "operator not found", "a new password ends the sessions")
+	operator, err = s.GetOperatorByToken(ctx, log, "token-2")
+	require.NoError(t, err, "api keys outlive a new password")
+	assert.Equal(t, "alice", operator.Name)
+
+	assert.EqualError(t, s.DeleteOperatorToken(ctx, log, "bob", "k-1"), "operator token not found", "tokens belong to their operator")
+	require.NoError(t, s.DeleteOperatorToken(ctx, log, "alice", "k-1"))
+	assert.EqualError(t, s.DeleteOperatorToken(ctx, log, "alice", "k-1"), "operator token not found")
+	_, err = s.GetOperatorByToken(ctx, log, "token-2")
+	assert.EqualError(t, err, "operator not found")
+
+	require.NoError(t, s.CreateOperatorToken(ctx, log, "alice", models.OperatorTokenModel{Accessor: "s-2", Kind: models.OperatorSession, ExpiresAt: &expiresAt}, "token-5"))
+	require.NoError(t, s.DeleteOperator(ctx, log, "alice"))
+	assert.EqualError(t, s.DeleteOperator(ctx, log, "alice"), "operator not found")
+	_, err = s.GetOperatorByToken(ctx, log, "token-5")
+	assert.EqualError(t, err, "operator not found", "tokens are removed with their operator")
+}
+
+func testWrappings(t *testing.T, s storage.Storage) {
+	now := time.Now().UTC().Truncate(time.Second)
+	wrapped := models.WrappedResponse{
+		WrappingModel: models.WrappingModel{
+			Accessor:     "acc-1",
+			CreationPath: "root/create-token",
+			CreatedAt:    now,
+			ExpiresAt:    now.Add(5 * time.Minute),
+		},
+		DataKey: "wrapped-key",
+		Payload: "ciphertext",
+	}
+	require.NoError(t, s.CreateWrapping(ctx, log, wrapped, "hash-1"))
+	assert.EqualError(t, s.CreateWrapping(ctx, log, wrapped, "hash-1"), "wrapping token already exists")
+
+	expired := wrapped
+	expired.Accessor = "acc-2"
+	expired.CreatedAt, expired.ExpiresAt = now.Add(-time.Hour), now.Add(-time.Minute)
+	require.NoError(t, s.CreateWrapping(ctx, log, expired, "hash-2"))
+
+	lookup, err := s.LookupWrapping(ctx, log, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "acc-1", lookup.Accessor)
+	assert.Equal(t, "root/create-token", lookup.CreationPath)
+	assert.Equal(t, time.Duration(300), lookup.TTL)
+	assert.True(t, now.Equal(lookup.CreatedAt))
+	assert.True(t, wrapped.ExpiresAt.Equal(lookup.ExpiresAt))
+	_, err = s.LookupWrapping(ctx, log, "hash-2")
+	assert.EqualError(t, err, "wrapping token not found", "expired wrappings can't be looked up")
+	_, err = s.LookupWrapping(ctx, log, "missing")
+	assert.EqualError(t, err, "wrapping token not found")
+
+	taken, err := s.TakeWrapping(ctx, log, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "acc-1", taken.Accessor)
+	assert.Equal(t, "wrapped-key", taken.DataKey)
+	assert.Equal(t, "ciphertext", taken.Payload)
+	_, err = s.TakeWrapping(ctx, log, "hash-1")
+	assert.EqualError(t, err, "wrapping token not found", "a wrapping is taken only once")
+	_, err = s.LookupWrapping(ctx, log, "hash-1")
+	assert.EqualError(t, err, "wrapping token not found")
+	_, err = s.TakeWrapping(ctx, log, "hash-2")
+	assert.EqualError(t, err, "wrapping token not found", "expired wrappings can't be taken")
+
+	purged, err := s.PurgeWrappings(ctx, log, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	purged, err = s.PurgeWrappings(ctx, log, now)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
+
+func testAudit(t *testing.T, s storage.Storage) {
+	_, err := s.LastAuditEntry(ctx, log)
+	assert.EqualError(t, err, "audit log is empty")
+
+	for seq := int64(1); seq <= 5; seq++ {
+		require.NoError(t, s.CreateAuditEntry(ctx, log, seq, fmt.Sprintf(`{"seq":%d}`, seq)))
+	}
+	assert.Error(t, s.CreateAuditEntry(ctx, log, 5, `{"seq":5}`), "sequence numbers are unique")
+
+	last, err := s.LastAuditEntry(ctx, log)
+	require.NoError(t, err)
+	assert.Equal(t, `{"seq":5}`, last)
+
+	page, err := s.ListAuditEntries(ctx, log, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"seq":3}`, `{"seq":4}`}, page)
+}
@@ -0,0 +1,1268 @@
+package sys
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"vault/internal/audit"
+	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/policy"
+	"vault/internal/seal"
+	"vault/internal/signing"
+	"vault/pkg/handlers"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+	mwAuth "vault/pkg/lib/middleware"
+	"vault/pkg/lib/opaque"
+	"vault/pkg/lib/password"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrNotInitialized        = "vault is not initialized"
+	ErrPolicyNotFound        = "policy not found"
+	ErrIdentityNotFound      = "identity not found"
+	ErrIdentityDuplicate     = "identity already exists"
+	ErrTermExists            = "keyring term already exists"
+	ErrJWTKeyNotFound        = "jwt key not found"
+	ErrJWTKeyRetired         = "jwt key is retired"
+	ErrJWTKeyActive          = "jwt key is active"
+	ErrOperatorNotFound      = "operator not found"
+	ErrOperatorDuplicate     = "operator already exists"
+	ErrOperatorTokenNotFound = "operator token not found"
+	ErrWrappingNotFound      = "wrapping token not found"
+)
+
+var identityKey mwAuth.ContextKey = "identity"
+
+// Policy resources of the sys endpoints.
+const (
+	SealResource       = "sys/seal"
+	RotateResource     = "sys/rotate"
+	PoliciesResource   = "sys/policies"
+	IdentitiesResource = "sys/identities"
+	JWTKeysResource    = "sys/jwt/keys"
+	OperatorsResource  = "sys/operators"
+)
+
+// maxPolicySize limits the size of a policy document.
+const maxPolicySize = 64 << 10
+
+type SysHandlerClient struct {
+	sysDBClient SysDB
+	log         *slog.Logger
+	seal        *seal.Seal
+	signer      *signing.Signer
+	masterKey   []byte
+}
+
+func AddSysRouter(r chi.Router, sysClient SysDB, log *slog.Logger, cfg *config.Config, vaultSeal *seal.Seal, signer *signing.Signer) func(r chi.Router) {
+	client := NewSysHandlerClient(sysClient, log, vaultSeal, signer, cfg.MasterKey)
+
+	return func(r chi.Router) {
+		r.Get("/seal-status", client.SealStatus(context.TODO()))
+		r.Post("/init", client.Init(context.TODO()))
+		r.Post("/unseal", client.Unseal(context.TODO()))
+
+		// wrapping tokens are bearer credentials of their own, holding one is enough to unwrap it
+		r.Post("/wrapping/lookup", client.LookupWrapping(context.TODO()))
+		r.With(mwAuth.Unsealed(log, vaultSeal)).Post("/wrapping/unwrap", client.Unwrap(context.TODO()))
+
+		r.Group(func(r chi.Router) {
+			r.Use(mwAuth.RootAuth(log, cfg.RootToken, sysClient))
+
+			authorize := func(capability string, resource string) func(http.Handler) http.Handler {
+				return mwAuth.Authorize(log, capability, mwAuth.Resource(resource))
+			}
+			wrap := mwAuth.Wrap(log, sysClient, vaultSeal, cfg.Wrapping.MaxTTL)
+
+			r.With(authorize(policy.Update, SealResource)).Post("/seal", client.Seal(context.TODO()))
+			r.With(authorize(policy.Update, RotateResource), mwAuth.Unsealed(log, vaultSeal)).Post("/rotate", client.Rotate(context.TODO()))
+			r.With(authorize(policy.Read, RotateResource)).Get("/key-status", client.KeyStatus(context.TODO()))
+
+			r.With(authorize(policy.List, PoliciesResource)).Get("/policies", client.ListPolicies(context.TODO()))
+			r.With(authorize(policy.Read, PoliciesResource)).Get("/policies/{name}", client.GetPolicy(context.TODO()))
+			r.With(authorize(policy.Update, PoliciesResource)).Put("/policies/{name}", client.PutPolicy(context.TODO()))
+			r.With(authorize(policy.Delete, PoliciesResource)).Delete("/policies/{name}", client.DeletePolicy(context.TODO()))
+
+			r.With(authorize(policy.List, JWTKeysResource)).Get("/jwt/keys", client.ListJWTKeys(context.TODO()))
+			r.With(authorize(policy.Create, JWTKeysResource), mwAuth.Unsealed(log, vaultSeal)).Post("/jwt/keys", client.CreateJWTKey(context.TODO()))
+			r.With(authorize(policy.Update, JWTKeysResource)).Post("/jwt/keys/{kid}/promote", client.PromoteJWTKey(context.TODO()))
+			r.With(authorize(policy.Delete, JWTKeysResource)).Post("/jwt/keys/{kid}/retire", client.RetireJWTKey(context.TODO()))
+
+			r.With(authorize(policy.List, IdentitiesResource)).Get("/identities", client.ListIdentities(context.TODO()))
+			r.With(authorize(policy.Create, IdentitiesResource), wrap).Post("/identities", client.CreateIdentity(context.TODO()))
+			r.With(authorize(policy.Delete, IdentitiesResource)).Delete("/identities/{name}", client.DeleteIdentity(context.TODO()))
+
+			r.With(authorize(policy.List, OperatorsResource)).Get("/operators", client.ListOperators(context.TODO()))
+			r.With(authorize(policy.Create, OperatorsResource)).Post("/operators", client.CreateOperator(context.TODO()))
+			r.With(authorize(policy.Delete, OperatorsResource)).Delete("/operators/{name}", client.DeleteOperator(context.TODO()))
+			r.With(authorize(policy.Update, OperatorsResource)).Put("/operators/{name}/password", client.UpdateOperatorPassword(context.TODO()))
+			r.With(authorize(policy.Update, OperatorsResource)).Post("/operators/{name}/unlock", client.UnlockOperator(context.TODO()))
+			r.With(authorize(policy.List, OperatorsResource)).Get("/operators/{name}/api-keys", client.ListAPIKeys(context.TODO()))
+			r.With(authorize(policy.Create, OperatorsResource), wrap).Post("/operators/{name}/api-keys", client.CreateAPIKey(context.TODO()))
+			r.With(authorize(policy.Delete, OperatorsResource)).Delete("/operators/{name}/api-keys/{accessor}", client.DeleteAPIKey(context.TODO()))
+		})
+	}
+}
+
+// AddWellKnownRouter publishes the public keys of token signing keys, it needs neither a token
+// nor an unsealed vault so gateways can always validate tokens offline.
+func AddWellKnownRouter(r chi.Router, sysClient SysDB, log *slog.Logger, cfg *config.Config) func(r chi.Router) {
+	client := NewSysHandlerClient(sysClient, log, nil, nil, nil)
+
+	return func(r chi.Router) {
+		// the URLFormat middleware strips the .json extension of /jwks.json before routing
+		r.Get("/jwks", client.JWKS(context.TODO(), cfg.JWT.Refresh))
+	}
+}
+
+// NewSysHandlerClient creates the seal handlers, masterKey is an optional existing key to split on init.
+func NewSysHandlerClient(sysClient SysDB, log *slog.Logger, vaultSeal *seal.Seal, signer *signing.Signer, masterKey []byte) *SysHandlerClient {
+	return &SysHandlerClient{
+		sysDBClient: sysClient,
+		log:         log,
+		seal:        vaultSeal,
+		signer:      signer,
+		masterKey:   masterKey,
+	}
+}
+
+func (h *SysHandlerClient) SealStatus(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.SealStatus"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		status, err := h.status(ctx)
+		if err != nil {
+			h.log.Error("failed to get seal status", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, status)
+	}
+}
+
+func (h *SysHandlerClient) Init(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.Init"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.InitSealDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		_, err := h.sysDBClient.GetSealConfig(ctx, h.log)
+		if err == nil {
+			h.log.Error("vault is already initialized")
+			handlers.ErrorResponse(w, r, 400, "vault is already initialized")
+			return
+		}
+		if err.Error() != ErrNotInitialized {
+			h.log.Error("failed to get seal config", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		sealConfig, keys, err := seal.Initialize(h.masterKey, model.Shares, model.Threshold)
+		if err != nil {
+			h.log.Error("failed to split master key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to split master key")
+			return
+		}
+
+		if err := h.sysDBClient.CreateSealConfig(ctx, h.log, sealConfig); err != nil {
+			h.log.Error("failed to save seal config", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		encoded := make([]string, len(keys))
+		for i, key := range keys {
+			encoded[i] = base64.StdEncoding.EncodeToString(key)
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message":   "vault successfully initialized",
+			"keys":      encoded,
+			"shares":    sealConfig.Shares,
+			"threshold": sealConfig.Threshold,
+		})
+		h.log.Info("vault successfully initialized", "shares", sealConfig.Shares, "threshold", sealConfig.Threshold)
+	}
+}
+
+func (h *SysHandlerClient) Unseal(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.Unseal"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.UnsealDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		sealConfig, err := h.sysDBClient.GetSealConfig(ctx, h.log)
+		if err != nil {
+			if err.Error() == ErrNotInitialized {
+				h.log.Error("vault is not initialized", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 400, err.Error())
+				return
+			}
+			h.log.Error("failed to get seal config", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		if model.Reset {
+			h.seal.Reset()
+		} else {
+			key, err := base64.StdEncoding.DecodeString(model.Key)
+			if err != nil {
+				h.log.Error("failed to decode unseal key", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 400, "unseal key must be base64 encoded")
+				return
+			}
+
+			keyring, err := h.sysDBClient.GetKeyring(ctx, h.log)
+			if err != nil {
+				h.log.Error("failed to get keyring", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 500, err.Error())
+				return
+			}
+
+			if err := h.seal.Unseal(sealConfig, keyring, key); err != nil {
+				if errors.Is(err, seal.ErrInvalidShares) {
+					h.log.Error("invalid unseal keys", sl.OpErr(op, err))
+					handlers.ErrorResponse(w, r, 400, err.Error())
+					return
+				}
+				h.log.Error("failed to unseal vault", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 500, "failed to unseal vault")
+				return
+			}
+		}
+
+		status, err := h.status(ctx)
+		if err != nil {
+			h.log.Error("failed to get seal status", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, status)
+		if !status.Sealed {
+			h.log.Info("vault successfully unsealed")
+		}
+	}
+}
+
+func (h *SysHandlerClient) Seal(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.Seal"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		h.seal.Seal()
+		h.signer.Invalidate()
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "vault successfully sealed",
+		})
+		h.log.Info("vault successfully sealed")
+	}
+}
+
+// Rotate adds a keyring term after the latest one, new data keys are wrapped with it right away
+// and the rewrap job moves existing keys to it in the background. Unseal keys don't change.
+func (h *SysHandlerClient) Rotate(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.Rotate"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// terms added by other instances have to be installed before picking the next term
+		keyring, err := h.sysDBClient.GetKeyring(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to get keyring", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+		if err := h.seal.Install(keyring); err != nil {
+			h.log.Error("failed to install keyring", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, "encryption key is unavailable")
+			return
+		}
+
+		envelope, err := h.seal.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, "encryption key is unavailable")
+			return
+		}
+
+		term, key, err := envelope.NewTerm()
+		if err != nil {
+			h.log.Error("failed to generate keyring term", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to generate keyring term")
+			return
+		}
+
+		model := models.KeyringTerm{Term: term, Key: key}
+		if err := h.sysDBClient.AddKeyringTerm(ctx, h.log, model); err != nil {
+			if err.Error() == ErrTermExists {
+				h.log.Error("keyring term already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			h.log.Error("failed to save keyring term", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		if err := h.seal.Install([]models.KeyringTerm{model}); err != nil {
+			h.log.Error("failed to install keyring term", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, "encryption key is unavailable")
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "encryption key successfully rotated",
+			"term":    term,
+		})
+		h.log.Info("encryption key successfully rotated", slog.Int("term", term))
+	}
+}
+
+// KeyStatus reports the latest keyring term and the progress of rewrapping keys of older terms.
+func (h *SysHandlerClient) KeyStatus(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.KeyStatus"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		keyring, err := h.sysDBClient.GetKeyring(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to get keyring", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		status := models.KeyStatus{Term: 1}
+		if len(keyring) > 0 {
+			latest := keyring[len(keyring)-1]
+			status.Term, status.InstallTime = latest.Term, &latest.CreatedAt
+		}
+
+		pending, total, err := h.sysDBClient.CountStaleKeys(ctx, h.log, encryption.TermPrefix(status.Term))
+		if err != nil {
+			h.log.Error("failed to count stale keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+		status.Rewrap = models.RewrapProgress{Pending: pending, Total: total}
+
+		handlers.SuccessResponse(w, r, 200, status)
+	}
+}
+
+func (h *SysHandlerClient) ListJWTKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.ListJWTKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		keys, err := h.sysDBClient.ListJWTKeys(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list jwt keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"keys": keys,
+		})
+	}
+}
+
+// CreateJWTKey adds a key which only verifies tokens, it has to be promoted to sign them.
+// Waiting for every instance to load the key before promoting it keeps new tokens valid fleet wide.
+func (h *SysHandlerClient) CreateJWTKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.CreateJWTKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		model, err := h.signer.NewKey()
+		if err != nil {
+			h.log.Error("failed to generate jwt key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to generate jwt key")
+			return
+		}
+
+		if err := h.sysDBClient.CreateJWTKey(ctx, h.log, model); err != nil {
+			h.log.Error("failed to save jwt key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+		h.signer.Invalidate()
+
+		handlers.SuccessResponse(w, r, 201, map[string]any{
+			"message": "jwt key successfully created",
+			"kid":     model.ID,
+			"state":   model.State,
+		})
+		h.log.Info("jwt key successfully created", slog.String("kid", model.ID))
+	}
+}
+
+// PromoteJWTKey makes the key sign new tokens, the previous key keeps verifying its tokens.
+func (h *SysHandlerClient) PromoteJWTKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.PromoteJWTKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		kid := chi.URLParam(r, "kid")
+		if err := h.sysDBClient.PromoteJWTKey(ctx, h.log, kid); err != nil {
+			h.jwtKeyError(w, r, op, err)
+			return
+		}
+		h.signer.Invalidate()
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "jwt key successfully promoted",
+		})
+		h.log.Info("jwt key successfully promoted", slog.String("kid", kid))
+	}
+}
+
+// RetireJWTKey rejects every token signed with the key from now on, the active key can't be retired.
+func (h *SysHandlerClient) RetireJWTKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.RetireJWTKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		kid := chi.URLParam(r, "kid")
+		if err := h.sysDBClient.RetireJWTKey(ctx, h.log, kid); err != nil {
+			h.jwtKeyError(w, r, op, err)
+			return
+		}
+		h.signer.Invalidate()
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "jwt key successfully retired",
+		})
+		h.log.Info("jwt key successfully retired", slog.String("kid", kid))
+	}
+}
+
+func (h *SysHandlerClient) jwtKeyError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch err.Error() {
+	case ErrJWTKeyNotFound:
+		h.log.Error("jwt key not found", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 404, err.Error())
+	case ErrJWTKeyRetired, ErrJWTKeyActive:
+		h.log.Error("jwt key state conflict", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 409, err.Error())
+	default:
+		h.log.Error("failed to update jwt key", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+	}
+}
+
+// JWKS returns the JSON Web Key Set of the EdDSA and RS256 keys which are not retired.
+// Keys are published as soon as they are created, before they sign any token.
+func (h *SysHandlerClient) JWKS(ctx context.Context, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.JWKS"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		keys, err := h.sysDBClient.ListJWTKeys(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list jwt keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		set := make([]jwt.JWK, 0, len(keys))
+		for _, key := range keys {
+			if key.State == models.JWTKeyRetired || key.PublicKey == "" {
+				continue
+			}
+			der, err := base64.StdEncoding.DecodeString(key.PublicKey)
+			if err != nil {
+				h.log.Error("failed to decode public key", sl.OpErr(op, err), slog.String("kid", key.ID))
+				continue
+			}
+			jwk, err := jwt.PublicJWK(key.ID, key.Algorithm, der)
+			if err != nil {
+				h.log.Error("failed to encode public key", sl.OpErr(op, err), slog.String("kid", key.ID))
+				continue
+			}
+			set = append(set, jwk)
+		}
+
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"keys": set,
+		})
+	}
+}
+
+func (h *SysHandlerClient) ListPolicies(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.ListPolicies"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		names, err := h.sysDBClient.ListPolicies(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list policies", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"policies": names,
+		})
+	}
+}
+
+func (h *SysHandlerClient) GetPolicy(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.GetPolicy"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		model, err := h.sysDBClient.GetPolicy(ctx, h.log, chi.URLParam(r, "name"))
+		if err != nil {
+			if err.Error() == ErrPolicyNotFound {
+				h.log.Error("policy not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to get policy", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, model)
+	}
+}
+
+// PutPolicy creates or replaces a policy, the request body is the YAML or JSON document.
+func (h *SysHandlerClient) PutPolicy(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.PutPolicy"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		document, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPolicySize))
+		if err != nil {
+			h.log.Error("failed to read policy document", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to read policy document")
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		if _, err := policy.Parse(name, document); err != nil {
+			h.log.Error("invalid policy", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		if err := h.sysDBClient.PutPolicy(ctx, h.log, models.PolicyModel{Name: name, Document: string(document)}); err != nil {
+			h.log.Error("failed to save policy", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "policy successfully saved",
+			"name":    name,
+		})
+		h.log.Info("policy successfully saved", "name", name)
+	}
+}
+
+func (h *SysHandlerClient) DeletePolicy(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.DeletePolicy"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.sysDBClient.DeletePolicy(ctx, h.log, name); err != nil {
+			if err.Error() == ErrPolicyNotFound {
+				h.log.Error("policy not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete policy", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "policy successfully deleted",
+			"name":    name,
+		})
+		h.log.Info("policy successfully deleted", "name", name)
+	}
+}
+
+func (h *SysHandlerClient) ListIdentities(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.ListIdentities"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		identities, err := h.sysDBClient.ListIdentities(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list identities", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"identities": identities,
+		})
+	}
+}
+
+// CreateIdentity creates an admin identity bound to policies, its token is only returned once.
+func (h *SysHandlerClient) CreateIdentity(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.CreateIdentity"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.CreateIdentityDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		if !h.grantable(ctx, w, r, op, model.Policies) {
+			return
+		}
+
+		token, err := opaque.New("adm.")
+		if err != nil {
+			h.log.Error("failed to create identity token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create identity token")
+			return
+		}
+
+		identity := models.IdentityModel{Name: model.Name, Policies: model.Policies}
+		id, err := h.sysDBClient.CreateIdentity(ctx, h.log, identity, opaque.Hash(token))
+		if err != nil {
+			if err.Error() == ErrIdentityDuplicate {
+				h.log.Error("identity already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			h.log.Error("failed to save identity", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 201, map[string]any{
+			"message":  "identity successfully created",
+			"id":       id,
+			"name":     model.Name,
+			"policies": model.Policies,
+			"token":    token,
+		})
+		h.log.Info("identity successfully created", "name", model.Name, "policies", model.Policies)
+	}
+}
+
+func (h *SysHandlerClient) DeleteIdentity(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.DeleteIdentity"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.sysDBClient.DeleteIdentity(ctx, h.log, name); err != nil {
+			if err.Error() == ErrIdentityNotFound {
+				h.log.Error("identity not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete identity", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "identity successfully deleted",
+			"name":    name,
+		})
+		h.log.Info("identity successfully deleted", "name", name)
+	}
+}
+
+func (h *SysHandlerClient) ListOperators(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.ListOperators"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		operators, err := h.sysDBClient.ListOperators(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list operators", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"operators": operators,
+		})
+	}
+}
+
+// CreateOperator creates a human admin account bound to policies, it logs in through /auth/userpass/login.
+func (h *SysHandlerClient) CreateOperator(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.CreateOperator"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.CreateOperatorDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		if !h.grantable(ctx, w, r, op, model.Policies) {
+			return
+		}
+
+		hash, err := password.Hash(model.Password)
+		if err != nil {
+			h.log.Error("failed to hash password", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to hash password")
+			return
+		}
+
+		operator := models.OperatorModel{Name: model.Name, Policies: model.Policies}
+		id, err := h.sysDBClient.CreateOperator(ctx, h.log, operator, hash)
+		if err != nil {
+			if err.Error() == ErrOperatorDuplicate {
+				h.log.Error("operator already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			h.log.Error("failed to save operator", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 201, map[string]any{
+			"message":  "operator successfully created",
+			"id":       id,
+			"name":     model.Name,
+			"policies": model.Policies,
+		})
+		h.log.Info("operator successfully created", "name", model.Name, "policies", model.Policies)
+	}
+}
+
+func (h *SysHandlerClient) DeleteOperator(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.DeleteOperator"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.sysDBClient.DeleteOperator(ctx, h.log, name); err != nil {
+			h.operatorError(w, r, op, "failed to delete operator", err)
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "operator successfully deleted",
+			"name":    name,
+		})
+		h.log.Info("operator successfully deleted", "name", name)
+	}
+}
+
+// UpdateOperatorPassword sets a new password, it also lifts a lockout of the operator.
+func (h *SysHandlerClient) UpdateOperatorPassword(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.UpdateOperatorPassword"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.OperatorPasswordDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		if !h.operatorGrantable(ctx, w, r, op, name) {
+			return
+		}
+
+		hash, err := password.Hash(model.Password)
+		if err != nil {
+			h.log.Error("failed to hash password", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to hash password")
+			return
+		}
+
+		if err := h.sysDBClient.UpdateOperatorPassword(ctx, h.log, name, hash); err != nil {
+			h.operatorError(w, r, op, "failed to update operator password", err)
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "operator password successfully updated",
+			"name":    name,
+		})
+		h.log.Info("operator password successfully updated", "name", name)
+	}
+}
+
+// UnlockOperator lifts the lockout after repeated failed logins.
+func (h *SysHandlerClient) UnlockOperator(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.UnlockOperator"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.sysDBClient.UnlockOperator(ctx, h.log, name); err != nil {
+			h.operatorError(w, r, op, "failed to unlock operator", err)
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message": "operator successfully unlocked",
+			"name":    name,
+		})
+		h.log.Info("operator successfully unlocked", "name", name)
+	}
+}
+
+// ListAPIKeys lists the API keys and sessions of the operator which did not expire.
+func (h *SysHandlerClient) ListAPIKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.ListAPIKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		tokens, err := h.sysDBClient.ListOperatorTokens(ctx, h.log, name)
+		if err != nil {
+			h.log.Error("failed to list api keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"name":   name,
+			"tokens": tokens,
+		})
+	}
+}
+
+// CreateAPIKey creates an API key of the operator, the key is only returned once.
+func (h *SysHandlerClient) CreateAPIKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.CreateAPIKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.CreateAPIKeyDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil && !errors.Is(err, io.EOF) {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		if !h.operatorGrantable(ctx, w, r, op, name) {
+			return
+		}
+
+		key, err := opaque.New(models.OperatorAPIKeyPrefix)
+		if err != nil {
+			h.log.Error("failed to create api key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create api key")
+			return
+		}
+		accessor, err := jwt.NewID()
+		if err != nil {
+			h.log.Error("failed to create accessor", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create api key")
+			return
+		}
+
+		apiKey := models.OperatorTokenModel{Accessor: accessor, Kind: models.OperatorAPIKey, Name: model.Name}
+		if model.Expires > 0 {
+			expiresAt := time.Now().Add(model.Expires * time.Second)
+			apiKey.ExpiresAt = &expiresAt
+		}
+
+		if err := h.sysDBClient.CreateOperatorToken(ctx, h.log, name, apiKey, opaque.Hash(key)); err != nil {
+			h.operatorError(w, r, op, "failed to save api key", err)
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 201, map[string]any{
+			"message":    "api key successfully created",
+			"name":       name,
+			"key":        key,
+			"accessor":   accessor,
+			"expires_at": apiKey.ExpiresAt,
+		})
+		h.log.Info("api key successfully created", "name", name, "accessor", accessor)
+	}
+}
+
+// DeleteAPIKey revokes an API key or session of the operator by its accessor.
+func (h *SysHandlerClient) DeleteAPIKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.DeleteAPIKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name, accessor := chi.URLParam(r, "name"), chi.URLParam(r, "accessor")
+		if err := h.sysDBClient.DeleteOperatorToken(ctx, h.log, name, accessor); err != nil {
+			h.operatorError(w, r, op, "failed to delete api key", err)
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]string{
+			"message":  "api key successfully deleted",
+			"name":     name,
+			"accessor": accessor,
+		})
+		h.log.Info("api key successfully deleted", "name", name, "accessor", accessor)
+	}
+}
+
+// LookupWrapping describes the wrapped response of a token without unwrapping it.
+func (h *SysHandlerClient) LookupWrapping(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.LookupWrapping"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.WrappingTokenDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		wrapping, err := h.sysDBClient.LookupWrapping(ctx, h.log, opaque.Hash(model.Token))
+		if err != nil {
+			h.wrappingError(w, r, op, "failed to lookup wrapping", err)
+			return
+		}
+		audit.FromContext(r.Context()).SetActor(audit.Actor{Type: "wrapping", Name: wrapping.Accessor})
+
+		handlers.SuccessResponse(w, r, 200, wrapping)
+		h.log.Info("wrapping successfully looked up", "accessor", wrapping.Accessor)
+	}
+}
+
+// Unwrap returns the wrapped response and removes it, every later unwrap of the token fails.
+func (h *SysHandlerClient) Unwrap(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "sys.handlers.Unwrap"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.WrappingTokenDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		envelope, err := h.seal.Envelope()
+		if err != nil {
+			h.log.Error("failed to get envelope", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 503, "encryption key is unavailable")
+			return
+		}
+
+		wrapped, err := h.sysDBClient.TakeWrapping(ctx, h.log, opaque.Hash(model.Token))
+		if err != nil {
+			h.wrappingError(w, r, op, "failed to take wrapping", err)
+			return
+		}
+		audit.FromContext(r.Context()).SetActor(audit.Actor{Type: "wrapping", Name: wrapped.Accessor})
+
+		dataKey, err := envelope.UnwrapDataKey(wrapped.DataKey)
+		if err != nil {
+			h.log.Error("failed to unwrap data key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to unwrap response")
+			return
+		}
+		payload, err := encryption.DecryptValue(dataKey, wrapped.Payload, models.WrappingAAD(wrapped.Accessor))
+		if err != nil {
+			h.log.Error("failed to decrypt response", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to unwrap response")
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, json.RawMessage(payload))
+		h.log.Info("response successfully unwrapped", "accessor", wrapped.Accessor, "creation_path", wrapped.CreationPath)
+	}
+}
+
+// wrappingError reports unknown, expired and already unwrapped tokens as 404 and anything else as 500.
+func (h *SysHandlerClient) wrappingError(w http.ResponseWriter, r *http.Request, op string, msg string, err error) {
+	if err.Error() == ErrWrappingNotFound {
+		h.log.Error(err.Error(), sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 404, err.Error())
+		return
+	}
+	h.log.Error(msg, sl.OpErr(op, err))
+	handlers.ErrorResponse(w, r, 500, err.Error())
+}
+
+// operatorError reports missing operators and tokens as 404 and anything else as 500.
+// grantable checks the caller holds every rule of the policies it hands out, so nobody creates
+// or takes over an identity or operator with more than its own ACL. Policies which don't exist
+// can't be checked and are only bound by root. On failure the error response is already written.
+func (h *SysHandlerClient) grantable(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, names []string) bool {
+	identity, ok := r.Context().Value(identityKey).(policy.Identity)
+	if !ok {
+		h.log.Error("failed to get identity from context", slog.String("op", op))
+		handlers.ErrorResponse(w, r, 401, "unauthorized")
+		return false
+	}
+	if identity.ACL.IsRoot() {
+		return true
+	}
+
+	stored, err := h.sysDBClient.GetPolicies(ctx, h.log, names)
+	if err != nil {
+		h.log.Error("failed to get policies", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to get policies")
+		return false
+	}
+
+	granted := make(map[string]bool, len(stored))
+	for _, model := range stored {
+		p, err := policy.Parse(model.Name, []byte(model.Document))
+		if err != nil {
+			h.log.Error("failed to parse policy", sl.OpErr(op, err), slog.String("policy", model.Name))
+			handlers.ErrorResponse(w, r, 500, "failed to get policies")
+			return false
+		}
+		granted[model.Name] = identity.ACL.Covers(p)
+	}
+	for _, name := range names {
+		if !granted[name] {
+			h.log.Error("policy exceeds the caller", slog.String("op", op), slog.String("identity", identity.Name), slog.String("policy", name))
+			handlers.ErrorResponse(w, r, 403, "permission denied")
+			return false
+		}
+	}
+	return true
+}
+
+// operatorGrantable checks the caller holds the policies of the operator before it hands out
+// credentials of the operator, unknown operators are left to the handler to report.
+func (h *SysHandlerClient) operatorGrantable(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, name string) bool {
+	operators, err := h.sysDBClient.ListOperators(ctx, h.log)
+	if err != nil {
+		h.log.Error("failed to list operators", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to list operators")
+		return false
+	}
+	for _, operator := range operators {
+		if operator.Name == name {
+			return h.grantable(ctx, w, r, op, operator.Policies)
+		}
+	}
+	return true
+}
+
+func (h *SysHandlerClient) operatorError(w http.ResponseWriter, r *http.Request, op string, msg string, err error) {
+	if err.Error() == ErrOperatorNotFound || err.Error() == ErrOperatorTokenNotFound {
+		h.log.Error(err.Error(), sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 404, err.Error())
+		return
+	}
+	h.log.Error(msg, sl.OpErr(op, err))
+	handlers.ErrorResponse(w, r, 500, err.Error())
+}
+
+func (h *SysHandlerClient) status(ctx context.Context) (models.SealStatus, error) {
+	sealConfig, err := h.sysDBClient.GetSealConfig(ctx, h.log)
+	if err != nil {
+		if err.Error() == ErrNotInitialized {
+			return models.SealStatus{Sealed: true}, nil
+		}
+		return models.SealStatus{}, err
+	}
+
+	return models.SealStatus{
+		Initialized: true,
+		Sealed:      h.seal.Sealed(),
+		Shares:      sealConfig.Shares,
+		Threshold:   sealConfig.Threshold,
+		Progress:    h.seal.Progress(),
+	}, nil
+}
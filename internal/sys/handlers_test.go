@@ -0,0 +1,115 @@
+package sys_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/seal"
+	"vault/internal/storage/memory"
+	"vault/internal/sys"
+	"vault/pkg/lib/logger/slogdiscard"
+	"vault/pkg/lib/opaque"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantPolicies(t *testing.T) {
+	ctx := context.Background()
+	log := slogdiscard.NewDiscardLogger()
+	store := memory.New(10)
+
+	policies := map[string]string{
+		"ops":  "rules:\n  - path: sys/operators\n    capabilities: [create, update]\n  - path: sys/identities\n    capabilities: [create]\n  - path: team/*\n    capabilities: [read]\n",
+		"team": "rules:\n  - path: team/payments/*\n    capabilities: [read]\n",
+		"wide": "rules:\n  - path: \"*\"\n    capabilities: [create, read, update, delete]\n",
+	}
+	for name, document := range policies {
+		require.NoError(t, store.PutPolicy(ctx, log, models.PolicyModel{Name: name, Document: document}))
+	}
+
+	for name, policy := range map[string]string{"ops": "ops", "admin": "wide"} {
+		_, err := store.CreateOperator(ctx, log, models.OperatorModel{Name: name, Policies: []string{policy}}, "hash")
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.CreateOperatorToken(ctx, log, "ops", models.OperatorTokenModel{Accessor: "ops", Kind: models.OperatorAPIKey}, opaque.Hash("opk.ops")))
+
+	router := chi.NewRouter()
+	router.Route("/sys", sys.AddSysRouter(router, store, log, &config.Config{RootToken: "root"}, seal.New(), nil))
+
+	tests := []struct {
+		testName string
+		token    string
+		method   string
+		path     string
+		input    string
+		code     int
+	}{
+		{
+			testName: "operator with covered policy",
+			token:    "opk.ops",
+			method:   http.MethodPost,
+			path:     "/sys/operators",
+			input:    `{"name": "payments", "password": "correct-horse-battery", "policies": ["team"]}`,
+			code:     201,
+		},
+		{
+			testName: "operator with wider policy",
+			token:    "opk.ops",
+			method:   http.MethodPost,
+			path:     "/sys/operators",
+			input:    `{"name": "escalated", "password": "correct-horse-battery", "policies": ["wide"]}`,
+			code:     403,
+		},
+		{
+			testName: "identity with wider policy",
+			token:    "opk.ops",
+			method:   http.MethodPost,
+			path:     "/sys/identities",
+			input:    `{"name": "escalated", "policies": ["team", "wide"]}`,
+			code:     403,
+		},
+		{
+			testName: "identity with unknown policy",
+			token:    "opk.ops",
+			method:   http.MethodPost,
+			path:     "/sys/identities",
+			input:    `{"name": "later", "policies": ["missing"]}`,
+			code:     403,
+		},
+		{
+			testName: "password of a wider operator",
+			token:    "opk.ops",
+			method:   http.MethodPut,
+			path:     "/sys/operators/admin/password",
+			input:    `{"password": "correct-horse-battery"}`,
+			code:     403,
+		},
+		{
+			testName: "root",
+			token:    "root",
+			method:   http.MethodPost,
+			path:     "/sys/identities",
+			input:    `{"name": "admin", "policies": ["wide"]}`,
+			code:     201,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.input))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package sys
+
+import (
+	"context"
+	"log/slog"
+	"vault/internal/models"
+)
+
+type SysDB interface {
+	GetSealConfig(ctx context.Context, log *slog.Logger) (models.SealConfig, error)
+	CreateSealConfig(ctx context.Context, log *slog.Logger, config models.SealConfig) error
+	GetKeyring(ctx context.Context, log *slog.Logger) ([]models.KeyringTerm, error)
+	AddKeyringTerm(ctx context.Context, log *slog.Logger, term models.KeyringTerm) error
+	// ListStaleKeys returns at most limit wrapped keys which don't start with the prefix of the latest term.
+	ListStaleKeys(ctx context.Context, log *slog.Logger, prefix string, limit int) ([]models.WrappedKey, error)
+	// CountStaleKeys returns the number of wrapped keys which don't start with the prefix and the number of all wrapped keys.
+	CountStaleKeys(ctx context.Context, log *slog.Logger, prefix string) (int, int, error)
+	// UpdateWrappedKey replaces the wrapped key only if it still holds the old value.
+	UpdateWrappedKey(ctx context.Context, log *slog.Logger, key models.WrappedKey, old string) error
+	ListJWTKeys(ctx context.Context, log *slog.Logger) ([]models.JWTKeyModel, error)
+	CreateJWTKey(ctx context.Context, log *slog.Logger, model models.JWTKeyModel) error
+	// PromoteJWTKey makes the key active, the previously active key is kept for verification.
+	PromoteJWTKey(ctx context.Context, log *slog.Logger, kid string) error
+	RetireJWTKey(ctx context.Context, log *slog.Logger, kid string) error
+	ListPolicies(ctx context.Context, log *slog.Logger) ([]string, error)
+	GetPolicy(ctx context.Context, log *slog.Logger, name string) (models.PolicyModel, error)
+	GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error)
+	PutPolicy(ctx context.Context, log *slog.Logger, model models.PolicyModel) error
+	DeletePolicy(ctx context.Context, log *slog.Logger, name string) error
+	CreateIdentity(ctx context.Context, log *slog.Logger, model models.IdentityModel, tokenHash string) (int, error)
+	ListIdentities(ctx context.Context, log *slog.Logger) ([]models.IdentityModel, error)
+	DeleteIdentity(ctx context.Context, log *slog.Logger, name string) error
+	GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error)
+	CreateOperator(ctx context.Context, log *slog.Logger, model models.OperatorModel, passwordHash string) (int, error)
+	ListOperators(ctx context.Context, log *slog.Logger) ([]models.OperatorModel, error)
+	// DeleteOperator removes the operator with its sessions and API keys.
+	DeleteOperator(ctx context.Context, log *slog.Logger, name string) error
+	// UpdateOperatorPassword replaces the password hash, unlocks the operator and ends its sessions.
+	UpdateOperatorPassword(ctx context.Context, log *slog.Logger, name string, passwordHash string) error
+	UnlockOperator(ctx context.Context, log *slog.Logger, name string) error
+	CreateOperatorToken(ctx context.Context, log *slog.Logger, name string, model models.OperatorTokenModel, tokenHash string) error
+	// ListOperatorTokens returns the sessions and API keys of the operator which did not expire.
+	ListOperatorTokens(ctx context.Context, log *slog.Logger, name string) ([]models.OperatorTokenModel, error)
+	DeleteOperatorToken(ctx context.Context, log *slog.Logger, name string, accessor string) error
+	GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error)
+	CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error
+	// LookupWrapping returns the wrapped response of the token without its payload while it did not expire.
+	LookupWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappingModel, error)
+	// TakeWrapping removes the wrapped response of the token and returns it, a token can only be taken once.
+	TakeWrapping(ctx context.Context, log *slog.Logger, tokenHash string) (models.WrappedResponse, error)
+}
@@ -0,0 +1,736 @@
+package transit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"vault/internal/audit"
+	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/policy"
+	"vault/internal/seal"
+	"vault/pkg/handlers"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+	mwAuth "vault/pkg/lib/middleware"
+	libTransit "vault/pkg/lib/transit"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+var (
+	ErrKeyNotFound = "transit key not found"
+	ErrKeyExists   = "transit key already exists"
+)
+
+// KeyPrefix starts the policy resource of a transit key, like transit/payments.
+const KeyPrefix = "transit/"
+
+// KeyResource returns the policy resource of the transit key.
+func KeyResource(name string) string {
+	return KeyPrefix + name
+}
+
+var identityKey mwAuth.ContextKey = "identity"
+
+// transitKeysKey holds the names of the transit keys the user token is bound to.
+var transitKeysKey mwAuth.ContextKey = "transitKeys"
+
+type TransitHandlerClient struct {
+	transitDBClient TransitDB
+	log             *slog.Logger
+	keeper          encryption.Keeper
+}
+
+func AddTransitRouter(r chi.Router, transitClient TransitDB, log *slog.Logger, cfg *config.Config, vaultSeal *seal.Seal, signer jwt.Signer) func(r chi.Router) {
+	client := NewTransitHandlerClient(transitClient, log, vaultSeal)
+
+	return func(r chi.Router) {
+		r.Use(mwAuth.Unsealed(log, vaultSeal))
+
+		r.Group(func(r chi.Router) {
+			r.Use(mwAuth.RootAuth(log, cfg.RootToken, transitClient))
+
+			authorize := func(capability string, resolve mwAuth.Resolver) func(http.Handler) http.Handler {
+				return mwAuth.Authorize(log, capability, resolve)
+			}
+
+			r.With(mwAuth.DecodeBody[models.CreateTransitKeyDTO](log), authorize(policy.Create, keyFromBody)).Post("/keys", client.CreateKey(context.TODO()))
+			r.Get("/keys", client.ListKeys(context.TODO()))
+			r.With(authorize(policy.Read, keyFromParam)).Get("/keys/{name}", client.GetKey(context.TODO()))
+			r.With(authorize(policy.Update, keyFromParam)).Post("/keys/{name}/rotate", client.RotateKey(context.TODO()))
+			r.With(authorize(policy.Update, keyFromParam)).Put("/keys/{name}/config", client.ConfigKey(context.TODO()))
+			r.With(authorize(policy.Delete, keyFromParam)).Delete("/keys/{name}", client.DeleteKey(context.TODO()))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(mwAuth.UserAuth(log, signer, transitClient))
+
+			r.Post("/encrypt/{key}", client.Encrypt(context.TODO()))
+			r.Post("/decrypt/{key}", client.Decrypt(context.TODO()))
+			r.Post("/rewrap/{key}", client.Rewrap(context.TODO()))
+			r.Post("/sign/{key}", client.Sign(context.TODO()))
+			r.Post("/verify/{key}", client.Verify(context.TODO()))
+		})
+	}
+}
+
+func NewTransitHandlerClient(transitClient TransitDB, log *slog.Logger, keeper encryption.Keeper) *TransitHandlerClient {
+	return &TransitHandlerClient{
+		transitDBClient: transitClient,
+		log:             log,
+		keeper:          keeper,
+	}
+}
+
+// keyFromParam resolves the transit key of the {name} url param.
+func keyFromParam(r *http.Request) ([]string, error) {
+	return []string{KeyResource(chi.URLParam(r, "name"))}, nil
+}
+
+// keyFromBody resolves the name of a transit key about to be created.
+func keyFromBody(r *http.Request) ([]string, error) {
+	model, err := mwAuth.Body[models.CreateTransitKeyDTO](r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+	}
+	if model.Name == "" {
+		return nil, fmt.Errorf("%w: key name is required", mwAuth.ErrInvalidRequest)
+	}
+	return []string{KeyResource(model.Name)}, nil
+}
+
+func (h *TransitHandlerClient) CreateKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.CreateKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		model, err := mwAuth.Body[models.CreateTransitKeyDTO](r)
+		if err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		version, ok := h.newVersion(w, r, op, model.Type)
+		if !ok {
+			return
+		}
+
+		key := models.TransitKeyModel{
+			Name:     model.Name,
+			Type:     model.Type,
+			Versions: []models.TransitKeyVersion{version},
+		}
+		if err := h.transitDBClient.CreateTransitKey(ctx, h.log, key); err != nil {
+			if err.Error() == ErrKeyExists {
+				h.log.Error("transit key already exists", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 409, err.Error())
+				return
+			}
+			h.log.Error("failed to create transit key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		key, err = h.transitDBClient.GetTransitKey(ctx, h.log, model.Name)
+		if err != nil {
+			h.log.Error("failed to get transit key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 201, key)
+		h.log.Info("transit key successfully created", "name", model.Name)
+	}
+}
+
+// ListKeys returns the transit keys the identity may list.
+func (h *TransitHandlerClient) ListKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.ListKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		identity, ok := r.Context().Value(identityKey).(policy.Identity)
+		if !ok {
+			h.log.Error("failed to get identity from context", slog.String("op", op))
+			handlers.ErrorResponse(w, r, 401, "unauthorized")
+			return
+		}
+
+		names, err := h.transitDBClient.ListTransitKeys(ctx, h.log)
+		if err != nil {
+			h.log.Error("failed to list transit keys", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		keys := make([]string, 0, len(names))
+		for _, name := range names {
+			if identity.ACL.Allowed(policy.List, KeyResource(name)) {
+				keys = append(keys, name)
+			}
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"keys": keys,
+		})
+		h.log.Info("transit keys successfully listed", "count", len(keys))
+	}
+}
+
+func (h *TransitHandlerClient) GetKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.GetKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		key, ok := h.getKey(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, key)
+		h.log.Info("transit key successfully getted", "name", key.Name)
+	}
+}
+
+// RotateKey adds a key version, new ciphertexts and signatures use it while older versions
+// keep decrypting and verifying down to min_decryption_version.
+func (h *TransitHandlerClient) RotateKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.RotateKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		key, ok := h.getKey(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+
+		version, ok := h.newVersion(w, r, op, key.Type)
+		if !ok {
+			return
+		}
+
+		latest, err := h.transitDBClient.RotateTransitKey(ctx, h.log, key.Name, version)
+		if err != nil {
+			if err.Error() == ErrKeyNotFound {
+				h.log.Error("transit key not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to rotate transit key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(latest)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"name":           key.Name,
+			"latest_version": latest,
+		})
+		h.log.Info("transit key successfully rotated", "name", key.Name, "version", latest)
+	}
+}
+
+func (h *TransitHandlerClient) ConfigKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.ConfigKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.TransitKeyConfigDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		key, ok := h.getKey(ctx, w, r, op, chi.URLParam(r, "name"))
+		if !ok {
+			return
+		}
+		if model.MinDecryptionVersion > key.LatestVersion {
+			h.log.Error("min decryption version exceeds the latest version", slog.Int("min_decryption_version", model.MinDecryptionVersion))
+			handlers.ErrorResponse(w, r, 422, fmt.Sprintf("min_decryption_version must not exceed the latest version %d", key.LatestVersion))
+			return
+		}
+
+		if err := h.transitDBClient.UpdateTransitKey(ctx, h.log, key.Name, model.MinDecryptionVersion); err != nil {
+			if err.Error() == ErrKeyNotFound {
+				h.log.Error("transit key not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to update transit key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+		key.MinDecryptionVersion = model.MinDecryptionVersion
+
+		handlers.SuccessResponse(w, r, 200, key)
+		h.log.Info("transit key successfully configured", "name", key.Name, "min_decryption_version", key.MinDecryptionVersion)
+	}
+}
+
+func (h *TransitHandlerClient) DeleteKey(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.DeleteKey"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if err := h.transitDBClient.DeleteTransitKey(ctx, h.log, name); err != nil {
+			if err.Error() == ErrKeyNotFound {
+				h.log.Error("transit key not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 404, err.Error())
+				return
+			}
+			h.log.Error("failed to delete transit key", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"message": "transit key successfully deleted",
+			"name":    name,
+		})
+		h.log.Info("transit key successfully deleted", "name", name)
+	}
+}
+
+func (h *TransitHandlerClient) Encrypt(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.Encrypt"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.EncryptDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		plaintext, additional, err := model.Decode()
+		if err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		key, ok := h.boundKey(ctx, w, r, op, models.CapabilityEncrypt)
+		if !ok {
+			return
+		}
+		material, ok := h.material(w, r, op, key, key.LatestVersion)
+		if !ok {
+			return
+		}
+
+		ciphertext, err := libTransit.Encrypt(key.Type, material, plaintext, additional)
+		if err != nil {
+			h.operationError(w, r, op, err)
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(key.LatestVersion)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"ciphertext":  libTransit.FormatValue(key.LatestVersion, ciphertext),
+			"key_version": key.LatestVersion,
+		})
+		h.log.Info("data successfully encrypted", "name", key.Name, "version", key.LatestVersion)
+	}
+}
+
+func (h *TransitHandlerClient) Decrypt(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.Decrypt"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		model, ok := h.decodeCiphertext(w, r, op)
+		if !ok {
+			return
+		}
+
+		key, ok := h.boundKey(ctx, w, r, op, models.CapabilityDecrypt)
+		if !ok {
+			return
+		}
+		plaintext, version, ok := h.decrypt(w, r, op, key, model)
+		if !ok {
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(version)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"plaintext": plaintext,
+		})
+		h.log.Info("data successfully decrypted", "name", key.Name, "version", version)
+	}
+}
+
+// Rewrap decrypts the ciphertext and encrypts it again with the latest key version,
+// the plaintext never leaves the server.
+func (h *TransitHandlerClient) Rewrap(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.Rewrap"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		model, ok := h.decodeCiphertext(w, r, op)
+		if !ok {
+			return
+		}
+
+		key, ok := h.boundKey(ctx, w, r, op, models.CapabilityRewrap)
+		if !ok {
+			return
+		}
+		plaintext, _, ok := h.decrypt(w, r, op, key, model)
+		if !ok {
+			return
+		}
+		material, ok := h.material(w, r, op, key, key.LatestVersion)
+		if !ok {
+			return
+		}
+
+		additional, _ := model.Decode()
+		ciphertext, err := libTransit.Encrypt(key.Type, material, plaintext, additional)
+		if err != nil {
+			h.operationError(w, r, op, err)
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(key.LatestVersion)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"ciphertext":  libTransit.FormatValue(key.LatestVersion, ciphertext),
+			"key_version": key.LatestVersion,
+		})
+		h.log.Info("data successfully rewrapped", "name", key.Name, "version", key.LatestVersion)
+	}
+}
+
+func (h *TransitHandlerClient) Sign(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.Sign"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.SignDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		input, err := model.Decode()
+		if err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		key, ok := h.boundKey(ctx, w, r, op, models.CapabilitySign)
+		if !ok {
+			return
+		}
+		material, ok := h.material(w, r, op, key, key.LatestVersion)
+		if !ok {
+			return
+		}
+
+		signature, err := libTransit.Sign(key.Type, material, input)
+		if err != nil {
+			h.operationError(w, r, op, err)
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(key.LatestVersion)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"signature":   libTransit.FormatValue(key.LatestVersion, signature),
+			"key_version": key.LatestVersion,
+		})
+		h.log.Info("data successfully signed", "name", key.Name, "version", key.LatestVersion)
+	}
+}
+
+func (h *TransitHandlerClient) Verify(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "transit.handlers.Verify"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var model models.SignDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		input, err := model.Decode()
+		if err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+		if model.Signature == "" {
+			h.log.Error("validate error", slog.String("op", op))
+			handlers.ErrorResponse(w, r, 422, "validation error: field signature is a required")
+			return
+		}
+
+		key, ok := h.boundKey(ctx, w, r, op, models.CapabilityVerify)
+		if !ok {
+			return
+		}
+		version, signature, ok := h.parseValue(w, r, op, key, model.Signature)
+		if !ok {
+			return
+		}
+		material, ok := h.material(w, r, op, key, version)
+		if !ok {
+			return
+		}
+
+		valid, err := libTransit.Verify(key.Type, material, input, signature)
+		if err != nil {
+			h.operationError(w, r, op, err)
+			return
+		}
+
+		audit.FromContext(r.Context()).SetVersion(version)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"valid": valid,
+		})
+		h.log.Info("signature successfully verified", "name", key.Name, "version", version, "valid", valid)
+	}
+}
+
+// getKey loads the transit key, on failure the error response is already written.
+func (h *TransitHandlerClient) getKey(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, name string) (models.TransitKeyModel, bool) {
+	key, err := h.transitDBClient.GetTransitKey(ctx, h.log, name)
+	if err != nil {
+		if err.Error() == ErrKeyNotFound {
+			h.log.Error("transit key not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return models.TransitKeyModel{}, false
+		}
+		h.log.Error("failed to get transit key", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+		return models.TransitKeyModel{}, false
+	}
+	return key, true
+}
+
+// boundKey loads the transit key of the {key} url param after checking the user token is bound
+// to it and allows the capability. On failure the error response is already written.
+func (h *TransitHandlerClient) boundKey(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, capability string) (models.TransitKeyModel, bool) {
+	var scopeKey mwAuth.ContextKey = "tokenScope"
+
+	name := chi.URLParam(r, "key")
+	names, _ := r.Context().Value(transitKeysKey).([]string)
+	if !slices.Contains(names, name) {
+		h.log.Error("token is not bound to transit key", slog.String("name", name))
+		handlers.ErrorResponse(w, r, 403, "token is not bound to this transit key")
+		return models.TransitKeyModel{}, false
+	}
+
+	scope, ok := r.Context().Value(scopeKey).(models.TokenScope)
+	if !ok {
+		h.log.Error("failed to get token scope from context")
+		handlers.ErrorResponse(w, r, 500, "internal server error")
+		return models.TransitKeyModel{}, false
+	}
+	if !scope.Allows(capability) {
+		h.log.Error("capability is out of token scope", slog.String("capability", capability))
+		handlers.ErrorResponse(w, r, 403, fmt.Sprintf("token does not allow %s", capability))
+		return models.TransitKeyModel{}, false
+	}
+
+	return h.getKey(ctx, w, r, op, name)
+}
+
+// newVersion generates the key material of a new version wrapped with the master key,
+// on failure the error response is already written.
+func (h *TransitHandlerClient) newVersion(w http.ResponseWriter, r *http.Request, op string, keyType string) (models.TransitKeyVersion, bool) {
+	envelope, err := h.keeper.Envelope()
+	if err != nil {
+		h.log.Error("failed to get envelope", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 503, err.Error())
+		return models.TransitKeyVersion{}, false
+	}
+
+	material, wrapped, err := envelope.GenerateDataKey()
+	if err != nil {
+		h.log.Error("failed to generate transit key", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to generate transit key")
+		return models.TransitKeyVersion{}, false
+	}
+	public, err := libTransit.PublicKey(keyType, material)
+	if err != nil {
+		h.log.Error("failed to derive public key", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to generate transit key")
+		return models.TransitKeyVersion{}, false
+	}
+
+	return models.TransitKeyVersion{Key: wrapped, PublicKey: public}, true
+}
+
+// material unwraps the key material of the version, on failure the error response is already written.
+func (h *TransitHandlerClient) material(w http.ResponseWriter, r *http.Request, op string, key models.TransitKeyModel, version int) ([]byte, bool) {
+	stored, ok := key.Version(version)
+	if !ok {
+		h.log.Error("transit key version not found", slog.Int("version", version))
+		handlers.ErrorResponse(w, r, 500, "transit key version not found")
+		return nil, false
+	}
+
+	envelope, err := h.keeper.Envelope()
+	if err != nil {
+		h.log.Error("failed to get envelope", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 503, err.Error())
+		return nil, false
+	}
+
+	material, err := envelope.UnwrapDataKey(stored.Key)
+	if err != nil {
+		h.log.Error("failed to unwrap transit key", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to unwrap transit key")
+		return nil, false
+	}
+	return material, true
+}
+
+// parseValue splits a ciphertext or signature into the key version and the raw value,
+// the version has to be between min_decryption_version and the latest version.
+// On failure the error response is already written.
+func (h *TransitHandlerClient) parseValue(w http.ResponseWriter, r *http.Request, op string, key models.TransitKeyModel, value string) (int, []byte, bool) {
+	version, raw, err := libTransit.ParseValue(value)
+	if err != nil {
+		h.log.Error("failed to parse value", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, err.Error())
+		return 0, nil, false
+	}
+	if version > key.LatestVersion {
+		h.log.Error("key version not found", slog.Int("version", version))
+		handlers.ErrorResponse(w, r, 400, "key version not found")
+		return 0, nil, false
+	}
+	if version < key.MinDecryptionVersion {
+		h.log.Error("key version is below min_decryption_version", slog.Int("version", version))
+		handlers.ErrorResponse(w, r, 400, "key version is below min_decryption_version")
+		return 0, nil, false
+	}
+	return version, raw, true
+}
+
+func (h *TransitHandlerClient) decodeCiphertext(w http.ResponseWriter, r *http.Request, op string) (models.DecryptDTO, bool) {
+	var model models.DecryptDTO
+	if err := render.DecodeJSON(r.Body, &model); err != nil {
+		h.log.Error("failed to decode model", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, "failed to decode model")
+		return models.DecryptDTO{}, false
+	}
+	if err := model.Validate(); err != nil {
+		h.log.Error("validate error", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 422, err.Error())
+		return models.DecryptDTO{}, false
+	}
+	if _, err := model.Decode(); err != nil {
+		h.log.Error("validate error", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 422, err.Error())
+		return models.DecryptDTO{}, false
+	}
+	return model, true
+}
+
+// decrypt returns the plaintext of the ciphertext and the key version which encrypted it,
+// on failure the error response is already written.
+func (h *TransitHandlerClient) decrypt(w http.ResponseWriter, r *http.Request, op string, key models.TransitKeyModel, model models.DecryptDTO) ([]byte, int, bool) {
+	version, ciphertext, ok := h.parseValue(w, r, op, key, model.Ciphertext)
+	if !ok {
+		return nil, 0, false
+	}
+	material, ok := h.material(w, r, op, key, version)
+	if !ok {
+		return nil, 0, false
+	}
+
+	additional, _ := model.Decode()
+	plaintext, err := libTransit.Decrypt(key.Type, material, ciphertext, additional)
+	if err != nil {
+		h.operationError(w, r, op, err)
+		return nil, 0, false
+	}
+	return plaintext, version, true
+}
+
+// operationError writes the response of a failed transit operation.
+func (h *TransitHandlerClient) operationError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch {
+	case errors.Is(err, libTransit.ErrKeyType), errors.Is(err, libTransit.ErrValue), errors.Is(err, libTransit.ErrDecrypt):
+		h.log.Error("transit operation rejected", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, err.Error())
+	default:
+		h.log.Error("transit operation failed", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "transit operation failed")
+	}
+}
@@ -0,0 +1,20 @@
+package transit
+
+import (
+	"context"
+	"log/slog"
+	"vault/internal/models"
+)
+
+type TransitDB interface {
+	CreateTransitKey(ctx context.Context, log *slog.Logger, model models.TransitKeyModel) error
+	GetTransitKey(ctx context.Context, log *slog.Logger, name string) (models.TransitKeyModel, error)
+	ListTransitKeys(ctx context.Context, log *slog.Logger) ([]string, error)
+	RotateTransitKey(ctx context.Context, log *slog.Logger, name string, version models.TransitKeyVersion) (int, error)
+	UpdateTransitKey(ctx context.Context, log *slog.Logger, name string, minDecryptionVersion int) error
+	DeleteTransitKey(ctx context.Context, log *slog.Logger, name string) error
+	CheckToken(ctx context.Context, log *slog.Logger, jti string) error
+	GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error)
+	GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error)
+	GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error)
+}
@@ -2,39 +2,76 @@ package user
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"vault/internal/audit"
 	"vault/internal/config"
+	"vault/internal/models"
+	"vault/internal/seal"
 
 	"vault/pkg/handlers"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
 	"vault/pkg/lib/logger/sl"
 	mwAuth "vault/pkg/lib/middleware"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
 )
 
 var (
-	ErrNotFound = "vault not found"
+	ErrNotFound        = "vault not found"
+	ErrVersionNotFound = "version not found"
+	ErrTokenNotFound   = "token not found"
 )
 
+// vaultsKey holds the ids of the vaults the user token is bound to.
+var vaultsKey mwAuth.ContextKey = "vaultIDs"
+
+// pathsKey holds the vault paths and subtrees the user token is bound to.
+var pathsKey mwAuth.ContextKey = "tokenPaths"
+
+// claimsKey holds the claims of the user token.
+var claimsKey mwAuth.ContextKey = "tokenClaims"
+
 type UserHandlerClient struct {
 	userDBClient UserDB
 	log          *slog.Logger
-	secret       string
+	keeper       encryption.Keeper
+	signer       jwt.Signer
 }
 
-func AddUserRouter(r chi.Router, userClient UserDB, log *slog.Logger, cfg *config.Config) func(r chi.Router) {
+func AddUserRouter(r chi.Router, userClient UserDB, log *slog.Logger, cfg *config.Config, vaultSeal *seal.Seal, signer jwt.Signer) func(r chi.Router) {
 	client := UserHandlerClient{
 		userDBClient: userClient,
 		log:          log,
-		secret:       cfg.Secret,
+		keeper:       vaultSeal,
+		signer:       signer,
 	}
 
 	return func(r chi.Router) {
-		r.Use(mwAuth.UserAuth(log, cfg.Secret))
+		r.Use(mwAuth.Unsealed(log, vaultSeal))
+		r.Use(mwAuth.UserAuth(log, signer, userClient))
+
+		wrap := mwAuth.Wrap(log, userClient, vaultSeal, cfg.Wrapping.MaxTTL)
 
-		r.Get("/get", client.GetVault(context.TODO()))
+		r.With(wrap).Get("/get", client.GetVault(context.TODO()))
+		r.Get("/keys", client.ListKeys(context.TODO()))
+		r.With(wrap).Get("/vaults/{id}", client.GetVaultByID(context.TODO()))
+		r.Get("/vaults/{id}/keys", client.ListVaultKeys(context.TODO()))
+		r.With(wrap).Get("/by-path/*", client.GetVaultByPath(context.TODO()))
+		r.Get("/keys/by-path/*", client.ListVaultKeysByPath(context.TODO()))
+		r.Get("/token/lookup-self", client.LookupSelf(context.TODO()))
+		r.With(wrap).Post("/token/renew", client.RenewToken(context.TODO()))
 	}
 }
 
@@ -47,29 +84,428 @@ func (h *UserHandlerClient) GetVault(ctx context.Context) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		var key mwAuth.ContextKey = "vaultID"
-		h.log.Debug("vault id on context value", slog.Any("vault_id", r.Context().Value(key)))
+		id, ok := h.singleVault(w, r)
+		if !ok {
+			return
+		}
 
-		id, ok := r.Context().Value(key).(int)
+		h.readVault(ctx, w, r, op, id)
+	}
+}
+
+func (h *UserHandlerClient) GetVaultByID(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.GetVaultByID"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.boundVault(w, r, op)
 		if !ok {
-			h.log.Error("failed to convert id to int")
-			handlers.ErrorResponse(w, r, 500, "internal server error")
 			return
 		}
 
-		vault, err := h.userDBClient.GetVault(ctx, h.log, id)
+		h.readVault(ctx, w, r, op, id)
+	}
+}
+
+func (h *UserHandlerClient) GetVaultByPath(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.GetVaultByPath"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.pathVault(ctx, w, r, op)
+		if !ok {
+			return
+		}
+
+		h.readVault(ctx, w, r, op, id)
+	}
+}
+
+func (h *UserHandlerClient) ListKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.ListKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.singleVault(w, r)
+		if !ok {
+			return
+		}
+
+		h.listKeys(ctx, w, r, op, id)
+	}
+}
+
+func (h *UserHandlerClient) ListVaultKeys(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.ListVaultKeys"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.boundVault(w, r, op)
+		if !ok {
+			return
+		}
+
+		h.listKeys(ctx, w, r, op, id)
+	}
+}
+
+func (h *UserHandlerClient) ListVaultKeysByPath(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.ListVaultKeysByPath"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := h.pathVault(ctx, w, r, op)
+		if !ok {
+			return
+		}
+
+		h.listKeys(ctx, w, r, op, id)
+	}
+}
+
+// LookupSelf describes the token of the request: its bindings, scope and remaining lifetime.
+func (h *UserHandlerClient) LookupSelf(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.LookupSelf"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := h.claims(w, r)
+		if !ok {
+			return
+		}
+
+		handlers.SuccessResponse(w, r, 200, claims.Lookup(time.Now()))
+	}
+}
+
+// RenewToken issues the token again with a later expiry, the new token keeps the jti and the bindings.
+// Only renewable tokens can be renewed and never past their max_ttl.
+func (h *UserHandlerClient) RenewToken(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "user.handlers.RenewToken"
+
+		h.log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		claims, ok := h.claims(w, r)
+		if !ok {
+			return
+		}
+
+		var model models.RenewTokenDTO
+		if err := render.DecodeJSON(r.Body, &model); err != nil && !errors.Is(err, io.EOF) {
+			h.log.Error("failed to decode model", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 400, "failed to decode model")
+			return
+		}
+		if err := model.Validate(); err != nil {
+			h.log.Error("validate error", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 422, err.Error())
+			return
+		}
+
+		jti := claims.RegisteredClaims.ID
+		renewed, err := claims.Renew(time.Now(), model.Increment)
+		if err != nil {
+			h.log.Error("failed to renew token", sl.OpErr(op, err), slog.String("jti", jti))
+			handlers.ErrorResponse(w, r, 400, err.Error())
+			return
+		}
+
+		token, err := h.signer.CreateToken(ctx, renewed)
 		if err != nil {
-			if err.Error() == ErrNotFound {
-				h.log.Error("vault not found", sl.OpErr(op, err))
-				handlers.ErrorResponse(w, r, 404, err.Error())
+			h.log.Error("failed to create new token", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, "failed to create new token")
+			return
+		}
+
+		if err := h.userDBClient.RenewToken(ctx, h.log, jti, renewed.ExpiresAt.Time); err != nil {
+			if err.Error() == ErrTokenNotFound {
+				h.log.Error("token not found", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 401, "unauthorized")
 				return
 			}
-			h.log.Error("failed to get vault", sl.OpErr(op, err))
+			h.log.Error("failed to renew token", sl.OpErr(op, err))
 			handlers.ErrorResponse(w, r, 500, err.Error())
 			return
 		}
 
-		handlers.SuccessResponse(w, r, 200, vault)
-		h.log.Info("vault successfully getted")
+		audit.FromContext(r.Context()).SetVaults(renewed.Vaults()...)
+
+		handlers.SuccessResponse(w, r, 200, map[string]any{
+			"token":      token,
+			"jti":        jti,
+			"expires_at": renewed.ExpiresAt.Time,
+			"ttl":        int64(time.Until(renewed.ExpiresAt.Time) / time.Second),
+		})
+		h.log.Info("token successfully renewed", "jti", jti, "expires_at", renewed.ExpiresAt.Time)
+	}
+}
+
+// readVault writes the vault data allowed by the token scope.
+func (h *UserHandlerClient) readVault(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, id int) {
+	scope, ok := h.scope(w, r, models.CapabilityRead)
+	if !ok {
+		return
+	}
+
+	var requested []string
+	if param := r.URL.Query().Get("keys"); param != "" {
+		requested = strings.Split(param, ",")
+		for _, k := range requested {
+			if !scope.AllowsKey(k) {
+				h.log.Error("key is out of token scope", slog.String("key", k))
+				handlers.ErrorResponse(w, r, 403, fmt.Sprintf("access to key %q is forbidden", k))
+				return
+			}
+		}
+	}
+
+	var version int
+	if param := r.URL.Query().Get("version"); param != "" {
+		v, err := strconv.Atoi(param)
+		if err != nil || v < 1 {
+			h.log.Error("invalid version query param", slog.String("version", param))
+			handlers.ErrorResponse(w, r, 400, "version must be a positive integer")
+			return
+		}
+		version = v
+	}
+
+	var vault models.SecretModel
+	var err error
+	if version == 0 {
+		vault, err = h.userDBClient.GetVault(ctx, h.log, id)
+	} else {
+		vault, err = h.userDBClient.GetVaultVersion(ctx, h.log, id, version)
+	}
+	if err != nil {
+		if err.Error() == ErrNotFound || err.Error() == ErrVersionNotFound {
+			h.log.Error("vault not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return
+		}
+		h.log.Error("failed to get vault", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+		return
+	}
+
+	vault.Data = scope.Filter(vault.Data)
+	if requested != nil {
+		data := make(map[string]string, len(requested))
+		for _, k := range requested {
+			if v, ok := vault.Data[k]; ok {
+				data[k] = v
+			}
+		}
+		vault.Data = data
+	}
+	vault.TrimKeyExpiry()
+
+	envelope, err := h.keeper.Envelope()
+	if err != nil {
+		h.log.Error("failed to get envelope", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 503, err.Error())
+		return
+	}
+
+	if err := vault.Decrypt(envelope); err != nil {
+		h.log.Error("failed to decrypt vault data", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, "failed to decrypt vault data")
+		return
+	}
+
+	record := audit.FromContext(r.Context())
+	record.SetVaults(id)
+	record.SetVersion(vault.Version)
+	record.SetKeys(audit.MapKeys(vault.Data))
+
+	handlers.SuccessResponse(w, r, 200, vault)
+	h.log.Info("vault successfully getted", "id", id)
+}
+
+// listKeys writes the key names allowed by the token scope.
+func (h *UserHandlerClient) listKeys(ctx context.Context, w http.ResponseWriter, r *http.Request, op string, id int) {
+	scope, ok := h.scope(w, r, models.CapabilityList)
+	if !ok {
+		return
+	}
+
+	vault, err := h.userDBClient.GetVault(ctx, h.log, id)
+	if err != nil {
+		if err.Error() == ErrNotFound {
+			h.log.Error("vault not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return
+		}
+		h.log.Error("failed to get vault", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 500, err.Error())
+		return
+	}
+
+	keys := make([]string, 0, len(vault.Data))
+	for k := range scope.Filter(vault.Data) {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	audit.FromContext(r.Context()).SetVaults(id)
+
+	handlers.SuccessResponse(w, r, 200, map[string]any{
+		"id":   vault.ID,
+		"keys": keys,
+	})
+	h.log.Info("vault keys successfully listed", "id", id)
+}
+
+// singleVault returns the vault of a token bound to exactly one vault,
+// on failure the error response is already written.
+func (h *UserHandlerClient) singleVault(w http.ResponseWriter, r *http.Request) (int, bool) {
+	h.log.Debug("vault ids on context value", slog.Any("vault_ids", r.Context().Value(vaultsKey)))
+
+	ids, ok := r.Context().Value(vaultsKey).([]int)
+	if !ok {
+		h.log.Error("failed to get vault ids from context")
+		handlers.ErrorResponse(w, r, 500, "internal server error")
+		return 0, false
+	}
+
+	paths, _ := r.Context().Value(pathsKey).([]string)
+
+	if len(ids) != 1 || len(paths) > 0 {
+		h.log.Error("token is not bound to a single vault", slog.Any("vault_ids", ids), slog.Any("paths", paths))
+		handlers.ErrorResponse(w, r, 400, "token is bound to multiple vaults, use /user/vaults/{id} or /user/by-path/{path}")
+		return 0, false
+	}
+
+	return ids[0], true
+}
+
+// boundVault reads the {id} url param and checks the token is bound to it,
+// on failure the error response is already written.
+func (h *UserHandlerClient) boundVault(w http.ResponseWriter, r *http.Request, op string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Error("failed to convert id to integer", sl.OpErr(op, err))
+		handlers.ErrorResponse(w, r, 400, "query parameter must be int")
+		return 0, false
+	}
+
+	ids, ok := r.Context().Value(vaultsKey).([]int)
+	if !ok {
+		h.log.Error("failed to get vault ids from context")
+		handlers.ErrorResponse(w, r, 500, "internal server error")
+		return 0, false
+	}
+
+	if slices.Contains(ids, id) {
+		return id, true
+	}
+
+	if paths, _ := r.Context().Value(pathsKey).([]string); len(paths) > 0 {
+		name, err := h.userDBClient.GetVaultName(r.Context(), h.log, id)
+		if err != nil && err.Error() != ErrNotFound {
+			h.log.Error("failed to get vault name", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return 0, false
+		}
+		if err == nil && models.MatchPaths(paths, name) {
+			return id, true
+		}
+	}
+
+	h.log.Error("token is not bound to vault", slog.Int("vault_id", id))
+	handlers.ErrorResponse(w, r, 403, "token is not bound to this vault")
+	return 0, false
+}
+
+// pathVault resolves the vault path of the * url param, the token has to be bound to the path
+// or to the id of the vault. On failure the error response is already written.
+func (h *UserHandlerClient) pathVault(ctx context.Context, w http.ResponseWriter, r *http.Request, op string) (int, bool) {
+	path := chi.URLParam(r, "*")
+	paths, _ := r.Context().Value(pathsKey).([]string)
+	ids, _ := r.Context().Value(vaultsKey).([]int)
+	bound := models.MatchPaths(paths, path)
+
+	id, err := h.userDBClient.GetVaultID(ctx, h.log, path)
+	if err != nil {
+		// unknown paths outside of the token paths are forbidden so they don't reveal what exists
+		if err.Error() == ErrNotFound && bound {
+			h.log.Error("vault not found", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 404, err.Error())
+			return 0, false
+		}
+		if err.Error() != ErrNotFound {
+			h.log.Error("failed to get vault id", sl.OpErr(op, err))
+			handlers.ErrorResponse(w, r, 500, err.Error())
+			return 0, false
+		}
+	}
+
+	if err != nil || (!bound && !slices.Contains(ids, id)) {
+		h.log.Error("token is not bound to vault path", slog.String("path", path))
+		handlers.ErrorResponse(w, r, 403, "token is not bound to this vault")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// claims returns the claims of the user token from the request context,
+// on failure the error response is already written.
+func (h *UserHandlerClient) claims(w http.ResponseWriter, r *http.Request) (models.TokenModel, bool) {
+	claims, ok := r.Context().Value(claimsKey).(models.TokenModel)
+	if !ok {
+		h.log.Error("failed to get token claims from context")
+		handlers.ErrorResponse(w, r, 500, "internal server error")
+		return models.TokenModel{}, false
+	}
+	return claims, true
+}
+
+// scope returns the token scope from the request context and checks the capability,
+// on failure the error response is already written.
+func (h *UserHandlerClient) scope(w http.ResponseWriter, r *http.Request, capability string) (models.TokenScope, bool) {
+	var key mwAuth.ContextKey = "tokenScope"
+
+	scope, ok := r.Context().Value(key).(models.TokenScope)
+	if !ok {
+		h.log.Error("failed to get token scope from context")
+		handlers.ErrorResponse(w, r, 500, "internal server error")
+		return models.TokenScope{}, false
 	}
+
+	if !scope.Allows(capability) {
+		h.log.Error("capability is out of token scope", slog.String("capability", capability))
+		handlers.ErrorResponse(w, r, 403, fmt.Sprintf("token does not allow %s", capability))
+		return models.TokenScope{}, false
+	}
+
+	return scope, true
 }
@@ -3,9 +3,17 @@ package user
 import (
 	"context"
 	"log/slog"
+	"time"
 	"vault/internal/models"
 )
 
 type UserDB interface {
 	GetVault(ctx context.Context, log *slog.Logger, id int) (models.SecretModel, error)
+	GetVaultVersion(ctx context.Context, log *slog.Logger, id int, version int) (models.SecretModel, error)
+	GetVaultName(ctx context.Context, log *slog.Logger, id int) (string, error)
+	GetVaultID(ctx context.Context, log *slog.Logger, path string) (int, error)
+	CheckToken(ctx context.Context, log *slog.Logger, jti string) error
+	// RenewToken moves the expiry of an active token.
+	RenewToken(ctx context.Context, log *slog.Logger, jti string, expiresAt time.Time) error
+	CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error
 }
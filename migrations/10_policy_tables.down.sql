@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_identity;
+DROP TABLE IF EXISTS policy;
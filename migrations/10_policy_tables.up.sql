@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS policy(
+    name VARCHAR PRIMARY KEY,
+    document TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE TABLE IF NOT EXISTS admin_identity(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    policies VARCHAR[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS audit_log(
+    seq BIGINT PRIMARY KEY,
+    document TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
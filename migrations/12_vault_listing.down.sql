@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS vault_tag;
+DROP INDEX IF EXISTS inx_vault_created_at;
+DROP INDEX IF EXISTS inx_vault_name;
+ALTER TABLE vault DROP COLUMN IF EXISTS created_at;
+ALTER TABLE vault ALTER COLUMN name TYPE VARCHAR COLLATE "default";
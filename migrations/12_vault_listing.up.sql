@@ -0,0 +1,14 @@
+-- byte order keeps name ranges and sorting index backed and equal on every backend
+ALTER TABLE vault ALTER COLUMN name TYPE VARCHAR COLLATE "C";
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE vault SET created_at = vv.created_at
+FROM (SELECT vault_id, MIN(created_at) AS created_at FROM vault_version GROUP BY vault_id) vv
+WHERE vv.vault_id = vault.id;
+CREATE INDEX IF NOT EXISTS inx_vault_name ON vault(name, id);
+CREATE INDEX IF NOT EXISTS inx_vault_created_at ON vault(created_at, id);
+CREATE TABLE IF NOT EXISTS vault_tag(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    tag VARCHAR NOT NULL,
+    PRIMARY KEY (vault_id, tag)
+);
+CREATE INDEX IF NOT EXISTS inx_vault_tag_tag ON vault_tag(tag, vault_id);
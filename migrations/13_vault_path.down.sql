@@ -0,0 +1,3 @@
+ALTER TABLE token DROP COLUMN IF EXISTS paths;
+DROP INDEX IF EXISTS inx_vault_name;
+CREATE INDEX IF NOT EXISTS inx_vault_name ON vault(name, id);
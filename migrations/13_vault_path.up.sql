@@ -0,0 +1,6 @@
+-- vault names become unique paths, duplicates are renamed with their id
+UPDATE vault SET name = name || '-' || id
+WHERE id NOT IN (SELECT MIN(id) FROM vault GROUP BY name);
+DROP INDEX IF EXISTS inx_vault_name;
+CREATE UNIQUE INDEX IF NOT EXISTS inx_vault_name ON vault(name);
+ALTER TABLE token ADD COLUMN IF NOT EXISTS paths VARCHAR[] NOT NULL DEFAULT '{}';
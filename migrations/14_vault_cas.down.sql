@@ -0,0 +1 @@
+ALTER TABLE vault DROP COLUMN IF EXISTS cas_required;
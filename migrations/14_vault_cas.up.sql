@@ -0,0 +1 @@
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS cas_required BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+-- soft deleted data can't be told apart without the columns, it is purged
+DELETE FROM vault WHERE deleted_at IS NOT NULL;
+DELETE FROM value WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS inx_value_deleted_at;
+DROP INDEX IF EXISTS inx_vault_deleted_at;
+DROP INDEX IF EXISTS inx_vault_name;
+CREATE UNIQUE INDEX IF NOT EXISTS inx_vault_name ON vault(name);
+ALTER TABLE value DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE vault DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE value ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+-- soft deleted vaults keep their rows but release their path
+DROP INDEX IF EXISTS inx_vault_name;
+CREATE UNIQUE INDEX IF NOT EXISTS inx_vault_name ON vault(name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS inx_vault_deleted_at ON vault(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS inx_value_deleted_at ON value(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+-- expiry can't be enforced without the columns, expired data is removed
+DELETE FROM vault WHERE expires_at <= NOW();
+DELETE FROM value WHERE expires_at <= NOW();
+DROP INDEX IF EXISTS inx_value_expires_at;
+DROP INDEX IF EXISTS inx_vault_expires_at;
+ALTER TABLE value DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE vault DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,4 @@
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE value ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS inx_vault_expires_at ON vault(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS inx_value_expires_at ON value(expires_at) WHERE expires_at IS NOT NULL;
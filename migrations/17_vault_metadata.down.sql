@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS vault_attribute;
+DROP TABLE IF EXISTS vault_metadata;
@@ -0,0 +1,21 @@
+-- metadata is kept in plaintext apart from the encrypted values so listings can filter on it
+CREATE TABLE IF NOT EXISTS vault_metadata(
+    vault_id INTEGER PRIMARY KEY REFERENCES vault(id) ON DELETE CASCADE,
+    description VARCHAR NOT NULL DEFAULT '',
+    owner VARCHAR NOT NULL DEFAULT '',
+    contact_email VARCHAR NOT NULL DEFAULT '',
+    created_by VARCHAR NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+INSERT INTO vault_metadata (vault_id, updated_at)
+SELECT id, created_at FROM vault
+ON CONFLICT DO NOTHING;
+CREATE INDEX IF NOT EXISTS inx_vault_metadata_owner ON vault_metadata(owner) WHERE owner <> '';
+CREATE INDEX IF NOT EXISTS inx_vault_metadata_created_by ON vault_metadata(created_by) WHERE created_by <> '';
+CREATE TABLE IF NOT EXISTS vault_attribute(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    key VARCHAR NOT NULL,
+    value VARCHAR NOT NULL,
+    PRIMARY KEY (vault_id, key)
+);
+CREATE INDEX IF NOT EXISTS inx_vault_attribute_key_value ON vault_attribute(key, value, vault_id);
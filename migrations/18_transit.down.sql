@@ -0,0 +1,3 @@
+ALTER TABLE token DROP COLUMN IF EXISTS transit_keys;
+DROP TABLE IF EXISTS transit_key_version;
+DROP TABLE IF EXISTS transit_key;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS transit_key(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    type VARCHAR NOT NULL,
+    latest_version INTEGER NOT NULL DEFAULT 1,
+    min_decryption_version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- key material is wrapped with the master key like the data keys of vaults
+CREATE TABLE IF NOT EXISTS transit_key_version(
+    key_id INTEGER NOT NULL REFERENCES transit_key(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    material VARCHAR NOT NULL,
+    public_key VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key_id, version)
+);
+ALTER TABLE token ADD COLUMN IF NOT EXISTS transit_keys VARCHAR[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+-- terms added by rotations, the keys are wrapped with the master key which is term 1
+CREATE TABLE IF NOT EXISTS keyring(
+    term INTEGER PRIMARY KEY CHECK (term > 1),
+    material VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
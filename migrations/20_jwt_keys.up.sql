@@ -0,0 +1,8 @@
+-- signing keys of user tokens, the secret is wrapped like the data keys of vaults
+CREATE TABLE IF NOT EXISTS jwt_key(
+    kid VARCHAR PRIMARY KEY,
+    state VARCHAR NOT NULL CHECK (state IN ('active', 'verify', 'retired')),
+    material VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS jwt_key_active_idx ON jwt_key(state) WHERE state = 'active';
@@ -0,0 +1,2 @@
+ALTER TABLE jwt_key DROP COLUMN IF EXISTS public_key;
+ALTER TABLE jwt_key DROP COLUMN IF EXISTS algorithm;
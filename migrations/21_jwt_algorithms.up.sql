@@ -0,0 +1,2 @@
+ALTER TABLE jwt_key ADD COLUMN IF NOT EXISTS algorithm VARCHAR NOT NULL DEFAULT 'HS512';
+ALTER TABLE jwt_key ADD COLUMN IF NOT EXISTS public_key VARCHAR NOT NULL DEFAULT '';
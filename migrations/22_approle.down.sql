@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS approle_secret_id;
+DROP TABLE IF EXISTS approle;
@@ -0,0 +1,26 @@
+-- tokens issued through a role are bound like tokens of /root/create-token, lifetimes are in seconds
+CREATE TABLE IF NOT EXISTS approle(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    role_id VARCHAR NOT NULL UNIQUE,
+    vault_ids INTEGER[] NOT NULL DEFAULT '{}',
+    paths VARCHAR[] NOT NULL DEFAULT '{}',
+    transit_keys VARCHAR[] NOT NULL DEFAULT '{}',
+    keys VARCHAR[] NOT NULL DEFAULT '{}',
+    capabilities VARCHAR[] NOT NULL DEFAULT '{}',
+    expires BIGINT NOT NULL,
+    max_expires BIGINT NOT NULL DEFAULT 0,
+    secret_id_expires BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- only the hash of a secret id is stored
+CREATE TABLE IF NOT EXISTS approle_secret_id(
+    approle_id INTEGER NOT NULL REFERENCES approle(id) ON DELETE CASCADE,
+    accessor VARCHAR NOT NULL,
+    secret_hash VARCHAR NOT NULL UNIQUE,
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    cidrs VARCHAR[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (approle_id, accessor)
+);
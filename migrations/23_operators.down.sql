@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS operator_token;
+DROP TABLE IF EXISTS operator;
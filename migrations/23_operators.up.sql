@@ -0,0 +1,21 @@
+-- human admin accounts, passwords are stored as argon2id hashes
+CREATE TABLE IF NOT EXISTS operator(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE,
+    password_hash VARCHAR NOT NULL,
+    policies VARCHAR[] NOT NULL DEFAULT '{}',
+    failed_logins INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- sessions and API keys of operators, only the hash of a token is stored
+CREATE TABLE IF NOT EXISTS operator_token(
+    operator_id INTEGER NOT NULL REFERENCES operator(id) ON DELETE CASCADE,
+    accessor VARCHAR NOT NULL,
+    token_hash VARCHAR NOT NULL UNIQUE,
+    kind VARCHAR NOT NULL,
+    name VARCHAR NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (operator_id, accessor)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE approle DROP COLUMN IF EXISTS max_ttl;
+ALTER TABLE approle DROP COLUMN IF EXISTS renewable;
@@ -0,0 +1,3 @@
+-- tokens of renewable roles can be renewed until max_ttl seconds after they were issued
+ALTER TABLE approle ADD COLUMN IF NOT EXISTS renewable BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE approle ADD COLUMN IF NOT EXISTS max_ttl BIGINT NOT NULL DEFAULT 0;
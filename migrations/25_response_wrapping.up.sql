@@ -0,0 +1,11 @@
+-- wrapped responses, the payload is encrypted with its own data key and only the hash of the wrapping token is stored
+CREATE TABLE IF NOT EXISTS wrapping(
+    token_hash VARCHAR PRIMARY KEY,
+    accessor VARCHAR NOT NULL UNIQUE,
+    creation_path VARCHAR NOT NULL,
+    data_key VARCHAR NOT NULL,
+    payload VARCHAR NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS inx_wrapping_expires_at ON wrapping(expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS inx_value_plaintext;
+ALTER TABLE value DROP COLUMN IF EXISTS encrypted;
+ALTER TABLE vault DROP COLUMN IF EXISTS data_key;
@@ -0,0 +1,4 @@
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS data_key VARCHAR;
+-- rows written before encryption keep encrypted = FALSE and can be found for re-encryption
+ALTER TABLE value ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS inx_value_plaintext ON value(vault_id) WHERE encrypted = FALSE;
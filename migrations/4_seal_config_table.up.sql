@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS seal_config(
+    id INTEGER PRIMARY KEY NOT NULL CHECK (id = 1),
+    shares INTEGER NOT NULL,
+    threshold INTEGER NOT NULL,
+    verification VARCHAR NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS inx_value_vault_id;
+ALTER TABLE value DROP CONSTRAINT IF EXISTS value_vault_id_fkey;
+ALTER TABLE value ADD CONSTRAINT value_vault_id_fkey FOREIGN KEY (vault_id) REFERENCES vault(id);
@@ -0,0 +1,3 @@
+ALTER TABLE value DROP CONSTRAINT IF EXISTS value_vault_id_fkey;
+ALTER TABLE value ADD CONSTRAINT value_vault_id_fkey FOREIGN KEY (vault_id) REFERENCES vault(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS inx_value_vault_id ON value(vault_id);
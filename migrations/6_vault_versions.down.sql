@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS inx_value_vault_version;
+CREATE INDEX IF NOT EXISTS inx_value_vault_id ON value(vault_id);
+ALTER TABLE value DROP CONSTRAINT IF EXISTS value_vault_version_fkey;
+DELETE FROM value v USING vault WHERE v.vault_id = vault.id AND v.version <> vault.current_version;
+ALTER TABLE value DROP COLUMN IF EXISTS version;
+DROP TABLE IF EXISTS vault_version;
+ALTER TABLE vault DROP COLUMN IF EXISTS current_version;
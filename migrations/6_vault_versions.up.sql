@@ -0,0 +1,14 @@
+ALTER TABLE vault ADD COLUMN IF NOT EXISTS current_version INTEGER NOT NULL DEFAULT 1;
+CREATE TABLE IF NOT EXISTS vault_version(
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (vault_id, version)
+);
+INSERT INTO vault_version (vault_id, version)
+SELECT id, 1 FROM vault
+ON CONFLICT DO NOTHING;
+ALTER TABLE value ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE value ADD CONSTRAINT value_vault_version_fkey FOREIGN KEY (vault_id, version) REFERENCES vault_version(vault_id, version) ON DELETE CASCADE;
+DROP INDEX IF EXISTS inx_value_vault_id;
+CREATE INDEX IF NOT EXISTS inx_value_vault_version ON value(vault_id, version);
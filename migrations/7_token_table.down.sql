@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS inx_token_vault_id;
+DROP TABLE IF EXISTS token;
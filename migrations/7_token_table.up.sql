@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS token(
+    jti VARCHAR PRIMARY KEY NOT NULL,
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS inx_token_vault_id ON token(vault_id);
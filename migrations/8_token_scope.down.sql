@@ -0,0 +1,2 @@
+ALTER TABLE token DROP COLUMN IF EXISTS capabilities;
+ALTER TABLE token DROP COLUMN IF EXISTS keys;
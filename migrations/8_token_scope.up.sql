@@ -0,0 +1,2 @@
+ALTER TABLE token ADD COLUMN IF NOT EXISTS keys VARCHAR[] NOT NULL DEFAULT '{}';
+ALTER TABLE token ADD COLUMN IF NOT EXISTS capabilities VARCHAR[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+ALTER TABLE token ADD COLUMN IF NOT EXISTS vault_id INTEGER REFERENCES vault(id) ON DELETE CASCADE;
+UPDATE token t SET vault_id = (SELECT MIN(tv.vault_id) FROM token_vault tv WHERE tv.jti = t.jti);
+DELETE FROM token WHERE vault_id IS NULL;
+ALTER TABLE token ALTER COLUMN vault_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS inx_token_vault_id ON token(vault_id);
+DROP TABLE IF EXISTS token_vault;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS token_vault(
+    jti VARCHAR NOT NULL REFERENCES token(jti) ON DELETE CASCADE,
+    vault_id INTEGER NOT NULL REFERENCES vault(id) ON DELETE CASCADE,
+    PRIMARY KEY (jti, vault_id)
+);
+CREATE INDEX IF NOT EXISTS inx_token_vault_vault_id ON token_vault(vault_id);
+INSERT INTO token_vault (jti, vault_id)
+SELECT jti, vault_id FROM token
+ON CONFLICT DO NOTHING;
+DROP INDEX IF EXISTS inx_token_vault_id;
+ALTER TABLE token DROP COLUMN IF EXISTS vault_id;
@@ -0,0 +1,265 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size of master and data keys in bytes (AES-256).
+const KeySize = 32
+
+// Values encrypted with a data key carry a version prefix. v1 values were sealed without
+// additional data, v2 values are bound to where they are stored by the aad passed in.
+const (
+	valuePrefixV1 = "vault:v1:"
+	valuePrefixV2 = "vault:v2:"
+)
+
+// termPrefix marks data keys wrapped with a keyring term after the first one, the first term
+// is the master key itself and its wrapped keys carry no prefix. "k" is a base64 character,
+// keys are told apart by the separator which base64 never contains.
+const (
+	termPrefix    = "k"
+	termSeparator = ":"
+)
+
+var (
+	ErrKeySize    = fmt.Errorf("encryption key must be %d bytes", KeySize)
+	ErrCiphertext = errors.New("malformed ciphertext")
+	ErrNoDataKey  = errors.New("data key is required to decrypt value")
+	ErrNoTerm     = errors.New("keyring term of the data key is not installed")
+)
+
+// Keeper provides the envelope while the master key is available.
+type Keeper interface {
+	Envelope() (*Envelope, error)
+}
+
+// Envelope wraps per-vault data keys with the latest term of the keyring. Older terms
+// are kept to unwrap keys which have not been rewrapped yet. An envelope is never
+// modified, adding terms returns a copy.
+type Envelope struct {
+	keys map[int][]byte
+	term int
+}
+
+func NewEnvelope(masterKey []byte) (*Envelope, error) {
+	if len(masterKey) != KeySize {
+		return nil, ErrKeySize
+	}
+	key := make([]byte, KeySize)
+	copy(key, masterKey)
+	return &Envelope{keys: map[int][]byte{1: key}, term: 1}, nil
+}
+
+// Term returns the latest term, new data keys are wrapped with it.
+func (e *Envelope) Term() int {
+	return e.term
+}
+
+// NewTerm generates the key of the term after the latest one, the key is returned
+// wrapped with the master key to be stored and installed with WithTerms.
+func (e *Envelope) NewTerm() (int, string, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return 0, "", err
+	}
+	wrapped, err := Encrypt(e.keys[1], key)
+	if err != nil {
+		return 0, "", err
+	}
+	return e.term + 1, base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// WithTerms returns a copy of the envelope with the stored terms installed,
+// terms which are already installed are skipped.
+func (e *Envelope) WithTerms(terms map[int]string) (*Envelope, error) {
+	next := &Envelope{keys: make(map[int][]byte, len(e.keys)+len(terms)), term: e.term}
+	for term, key := range e.keys {
+		next.keys[term] = key
+	}
+
+	for term, wrapped := range terms {
+		if _, ok := next.keys[term]; ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(wrapped)
+		if err != nil {
+			return nil, ErrCiphertext
+		}
+		key, err := Decrypt(e.keys[1], raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap keyring term %d: %w", term, err)
+		}
+		next.keys[term] = key
+		if term > next.term {
+			next.term = term
+		}
+	}
+	return next, nil
+}
+
+// Prefix returns the prefix of data keys wrapped with the latest term,
+// it is empty while the master key is the only term.
+func (e *Envelope) Prefix() string {
+	return TermPrefix(e.term)
+}
+
+// GenerateDataKey returns a new data key and its copy wrapped with the latest term.
+func (e *Envelope) GenerateDataKey() ([]byte, string, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	wrapped, err := e.WrapKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, wrapped, nil
+}
+
+// UnwrapDataKey opens a data key wrapped with any installed term.
+func (e *Envelope) UnwrapDataKey(wrapped string) ([]byte, error) {
+	term := 1
+	if prefix, rest, ok := strings.Cut(wrapped, termSeparator); ok {
+		number, found := strings.CutPrefix(prefix, termPrefix)
+		n, err := strconv.Atoi(number)
+		if !found || err != nil || n < 2 {
+			return nil, ErrCiphertext
+		}
+		term, wrapped = n, rest
+	}
+
+	key, ok := e.keys[term]
+	if !ok {
+		return nil, ErrNoTerm
+	}
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrCiphertext
+	}
+	return Decrypt(key, raw)
+}
+
+// Rewrap wraps the data key again with the latest term.
+func (e *Envelope) Rewrap(wrapped string) (string, error) {
+	key, err := e.UnwrapDataKey(wrapped)
+	if err != nil {
+		return "", err
+	}
+	return e.WrapKey(key)
+}
+
+// WrapKey wraps any key with the latest term, UnwrapDataKey opens it.
+func (e *Envelope) WrapKey(key []byte) (string, error) {
+	wrapped, err := Encrypt(e.keys[e.term], key)
+	if err != nil {
+		return "", err
+	}
+	return e.Prefix() + base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// TermPrefix returns the prefix of data keys wrapped with the term, base64 has no colon
+// so prefixed keys never collide with keys of the first term.
+func TermPrefix(term int) string {
+	if term <= 1 {
+		return ""
+	}
+	return termPrefix + strconv.Itoa(term) + termSeparator
+}
+
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt seals plaintext with AES-256-GCM, the random nonce is prepended to the result.
+func Encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	return seal(key, plaintext, nil)
+}
+
+func Decrypt(key []byte, ciphertext []byte) ([]byte, error) {
+	return open(key, ciphertext, nil)
+}
+
+// EncryptValue seals a value with the data key, aad binds the value to where it is stored
+// and must be passed unchanged to DecryptValue.
+func EncryptValue(dataKey []byte, value string, aad []byte) (string, error) {
+	ciphertext, err := seal(dataKey, []byte(value), aad)
+	if err != nil {
+		return "", err
+	}
+	return valuePrefixV2 + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptValue opens a value produced by EncryptValue. Values written before the aad was
+// introduced are opened without it. Whether a stored value is encrypted at all is recorded
+// by the caller, plaintext values must not be passed in.
+func DecryptValue(dataKey []byte, value string, aad []byte) (string, error) {
+	var encoded string
+	switch {
+	case strings.HasPrefix(value, valuePrefixV2):
+		encoded = strings.TrimPrefix(value, valuePrefixV2)
+	case strings.HasPrefix(value, valuePrefixV1):
+		encoded, aad = strings.TrimPrefix(value, valuePrefixV1), nil
+	default:
+		return "", ErrCiphertext
+	}
+	if dataKey == nil {
+		return "", ErrNoDataKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrCiphertext
+	}
+	plaintext, err := open(dataKey, raw, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrCiphertext
+	}
+	nonce, data := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, data, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
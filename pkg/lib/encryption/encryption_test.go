@@ -0,0 +1,131 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"vault/pkg/lib/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+
+	envelope, err := encryption.NewEnvelope(masterKey)
+	require.NoError(t, err)
+
+	dataKey, wrapped, err := envelope.GenerateDataKey()
+	require.NoError(t, err)
+
+	unwrapped, err := envelope.UnwrapDataKey(wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+
+	aad := []byte("vault:1:password")
+	value, err := encryption.EncryptValue(dataKey, "some secret", aad)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "vault:v2:"))
+	assert.NotContains(t, value, "some secret")
+
+	plaintext, err := encryption.DecryptValue(dataKey, value, aad)
+	require.NoError(t, err)
+	assert.Equal(t, "some secret", plaintext)
+
+	_, err = encryption.DecryptValue(dataKey, value, []byte("vault:2:password"))
+	assert.Error(t, err, "values can't be moved to another vault or key")
+
+	otherKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	_, err = encryption.DecryptValue(otherKey, value, aad)
+	assert.Error(t, err)
+
+	raw, err := encryption.Encrypt(dataKey, []byte("old secret"))
+	require.NoError(t, err)
+	legacy, err := encryption.DecryptValue(dataKey, "vault:v1:"+base64.StdEncoding.EncodeToString(raw), aad)
+	require.NoError(t, err)
+	assert.Equal(t, "old secret", legacy, "v1 values were written without aad")
+
+	_, err = encryption.DecryptValue(dataKey, "plain value", aad)
+	assert.ErrorIs(t, err, encryption.ErrCiphertext, "plaintext is never guessed from the value")
+
+	_, err = encryption.NewEnvelope([]byte("short"))
+	assert.ErrorIs(t, err, encryption.ErrKeySize)
+}
+
+func TestKeyring(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+
+	envelope, err := encryption.NewEnvelope(masterKey)
+	require.NoError(t, err)
+	assert.Equal(t, 1, envelope.Term())
+	assert.Empty(t, envelope.Prefix())
+
+	dataKey, legacy, err := envelope.GenerateDataKey()
+	require.NoError(t, err)
+
+	term, wrappedTerm, err := envelope.NewTerm()
+	require.NoError(t, err)
+	assert.Equal(t, 2, term)
+
+	rotated, err := envelope.WithTerms(map[int]string{term: wrappedTerm})
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.Term())
+	assert.Equal(t, 1, envelope.Term(), "the original envelope is left untouched")
+
+	_, wrapped, err := rotated.GenerateDataKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, rotated.Prefix()))
+
+	_, err = envelope.UnwrapDataKey(wrapped)
+	assert.ErrorIs(t, err, encryption.ErrNoTerm)
+
+	rewrapped, err := rotated.Rewrap(legacy)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rewrapped, "k2:"))
+
+	for _, w := range []string{legacy, rewrapped} {
+		unwrapped, err := rotated.UnwrapDataKey(w)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+	}
+
+	for _, w := range []string{"kx:abc", "x2:abc", "k1:abc"} {
+		_, err = rotated.UnwrapDataKey(w)
+		assert.ErrorIs(t, err, encryption.ErrCiphertext, w)
+	}
+
+	otherKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	other, err := encryption.NewEnvelope(otherKey)
+	require.NoError(t, err)
+	_, err = other.WithTerms(map[int]string{term: wrappedTerm})
+	assert.Error(t, err, "terms are wrapped with the master key")
+}
+
+func TestUnwrapFirstTermKeys(t *testing.T) {
+	masterKey, err := encryption.GenerateKey()
+	require.NoError(t, err)
+	envelope, err := encryption.NewEnvelope(masterKey)
+	require.NoError(t, err)
+
+	term, wrappedTerm, err := envelope.NewTerm()
+	require.NoError(t, err)
+	rotated, err := envelope.WithTerms(map[int]string{term: wrappedTerm})
+	require.NoError(t, err)
+
+	// about 1 in 64 keys of the first term start with the "k" of the term prefix
+	for i := 0; i < 2000; i++ {
+		dataKey, wrapped, err := envelope.GenerateDataKey()
+		require.NoError(t, err)
+
+		for _, e := range []*encryption.Envelope{envelope, rotated} {
+			unwrapped, err := e.UnwrapDataKey(wrapped)
+			require.NoError(t, err, wrapped)
+			assert.Equal(t, dataKey, unwrapped)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package generate creates random secrets with crypto/rand.
+package generate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+const (
+	lower   = "abcdefghijklmnopqrstuvwxyz"
+	upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digits  = "0123456789"
+	symbols = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+// Charsets are the named alphabets of passwords.
+var Charsets = map[string]string{
+	"alpha":         lower + upper,
+	"alnum":         lower + upper + digits,
+	"alnum+symbols": lower + upper + digits + symbols,
+	"digits":        digits,
+	"hex":           digits + "abcdef",
+}
+
+// Password returns length characters of the charset, every character is picked uniformly.
+func Password(length int, charset string) (string, error) {
+	alphabet, ok := Charsets[charset]
+	if !ok {
+		return "", fmt.Errorf("unknown charset: %q", charset)
+	}
+
+	max := big.NewInt(int64(len(alphabet)))
+	res := make([]byte, length)
+	for i := range res {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		res[i] = alphabet[n.Int64()]
+	}
+	return string(res), nil
+}
+
+// Bytes returns size random bytes.
+func Bytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Hex returns size random bytes hex encoded.
+func Hex(size int) (string, error) {
+	b, err := Bytes(size)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Base64 returns size random bytes in standard base64.
+func Base64(size int) (string, error) {
+	b, err := Bytes(size)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// UUID returns a random (version 4) UUID.
+func UUID() (string, error) {
+	b, err := Bytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// RSA returns a new key pair of the size in bits, the private key is a PKCS #8
+// PEM block and the public key a PKIX PEM block.
+func RSA(bits int) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeKeyPair(key, &key.PublicKey)
+}
+
+// Ed25519 returns a new key pair encoded the same way as RSA.
+func Ed25519() (string, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeKeyPair(private, public)
+}
+
+func encodeKeyPair(private, public any) (string, string, error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", "", err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", "", err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM), nil
+}
@@ -0,0 +1,88 @@
+package generate_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+	"vault/pkg/lib/generate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	for charset, alphabet := range generate.Charsets {
+		t.Run(charset, func(t *testing.T) {
+			password, err := generate.Password(64, charset)
+			require.NoError(t, err)
+			assert.Len(t, password, 64)
+			for _, c := range password {
+				assert.True(t, strings.ContainsRune(alphabet, c), "%q is not in %s", c, charset)
+			}
+
+			other, err := generate.Password(64, charset)
+			require.NoError(t, err)
+			assert.NotEqual(t, password, other)
+		})
+	}
+
+	_, err := generate.Password(8, "emoji")
+	assert.EqualError(t, err, `unknown charset: "emoji"`)
+}
+
+func TestEncodings(t *testing.T) {
+	value, err := generate.Hex(16)
+	require.NoError(t, err)
+	b, err := hex.DecodeString(value)
+	require.NoError(t, err)
+	assert.Len(t, b, 16)
+
+	value, err = generate.Base64(24)
+	require.NoError(t, err)
+	b, err = base64.StdEncoding.DecodeString(value)
+	require.NoError(t, err)
+	assert.Len(t, b, 24)
+
+	value, err = generate.UUID()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), value)
+}
+
+func parseKeyPair(t *testing.T, private, public string) (any, any) {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(private))
+	require.NotNil(t, block)
+	assert.Equal(t, "PRIVATE KEY", block.Type)
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	block, _ = pem.Decode([]byte(public))
+	require.NotNil(t, block)
+	assert.Equal(t, "PUBLIC KEY", block.Type)
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+
+	return privateKey, publicKey
+}
+
+func TestKeyPairs(t *testing.T) {
+	private, public, err := generate.RSA(2048)
+	require.NoError(t, err)
+	privateKey, publicKey := parseKeyPair(t, private, public)
+	require.IsType(t, &rsa.PrivateKey{}, privateKey)
+	assert.Equal(t, 2048, privateKey.(*rsa.PrivateKey).N.BitLen())
+	assert.True(t, privateKey.(*rsa.PrivateKey).PublicKey.Equal(publicKey))
+
+	private, public, err = generate.Ed25519()
+	require.NoError(t, err)
+	privateKey, publicKey = parseKeyPair(t, private, public)
+	require.IsType(t, ed25519.PrivateKey{}, privateKey)
+	assert.True(t, privateKey.(ed25519.PrivateKey).Public().(ed25519.PublicKey).Equal(publicKey))
+}
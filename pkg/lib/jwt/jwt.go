@@ -1,21 +1,106 @@
 package jwt
 
 import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"time"
+	"math/big"
 	"vault/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func CreateToken(vaultID int, secret string, expires time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS512)
+// Signing algorithms of keys, HS512 keys are shared secrets while EdDSA and RS256
+// keys publish their public key so tokens can be verified offline.
+const (
+	AlgHS512 = "HS512"
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// SecretSize is the size of generated HS512 secrets in bytes.
+const SecretSize = 64
+
+// rsaBits is the size of generated RSA keys.
+const rsaBits = 2048
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrUnknownAlgorithm = errors.New("unknown signing algorithm")
+)
+
+// Signer issues and verifies vault tokens.
+type Signer interface {
+	CreateToken(ctx context.Context, model models.TokenModel) (string, error)
+	DecodeToken(ctx context.Context, token string) (models.TokenModel, error)
+}
+
+// Key is a signing key of the keyring, the legacy secret has an empty ID
+// and its tokens carry no kid header. Secret is the HS512 secret or the
+// PKCS #8 DER private key of EdDSA and RS256 keys.
+type Key struct {
+	ID        string
+	Algorithm string
+	Secret    []byte
+}
+
+// JWK is a public key of the JSON Web Key Set.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// Keyring signs tokens with the active key and verifies them with the key named by their kid.
+type Keyring struct {
+	active string
+	keys   map[string]parsedKey
+}
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims["vault_id"] = vaultID
-	claims["exp"] = time.Now().Add(expires * time.Second).Unix()
+type parsedKey struct {
+	method jwt.SigningMethod
+	sign   any
+	verify any
+}
+
+// NewKeyring returns a keyring signing with active, the active key is always valid for verification.
+func NewKeyring(active Key, keys ...Key) (*Keyring, error) {
+	k := &Keyring{active: active.ID, keys: make(map[string]parsedKey, len(keys)+1)}
+	for _, key := range append(keys, active) {
+		parsed, err := parse(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", key.ID, err)
+		}
+		k.keys[key.ID] = parsed
+	}
+	return k, nil
+}
 
-	tokenString, err := token.SignedString([]byte(secret))
+// Static returns a signer which only knows the single HS512 secret.
+func Static(secret string) Signer {
+	return &Keyring{keys: map[string]parsedKey{"": {jwt.SigningMethodHS512, []byte(secret), []byte(secret)}}}
+}
+
+func (k *Keyring) CreateToken(ctx context.Context, model models.TokenModel) (string, error) {
+	key := k.keys[k.active]
+	token := jwt.NewWithClaims(key.method, model)
+	if k.active != "" {
+		token.Header["kid"] = k.active
+	}
+
+	tokenString, err := token.SignedString(key.sign)
 	if err != nil {
 		return "", err
 	}
@@ -23,16 +108,137 @@ func CreateToken(vaultID int, secret string, expires time.Duration) (string, err
 	return tokenString, nil
 }
 
-func DecodeToken(token string, secret string) (int, error) {
+func (k *Keyring) DecodeToken(ctx context.Context, token string) (models.TokenModel, error) {
 	var model models.TokenModel
 
 	jwtToken, err := jwt.ParseWithClaims(token, &model, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		// the algorithm of the key wins over the header
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, ErrUnknownAlgorithm
+		}
+		return key.verify, nil
+	}, jwt.WithValidMethods([]string{AlgHS512, AlgEdDSA, AlgRS256}))
 
 	if err != nil || !jwtToken.Valid {
-		return 0, fmt.Errorf("failed to decode token: %w", err)
+		return models.TokenModel{}, fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	return model, nil
+}
+
+func ValidAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case AlgHS512, AlgEdDSA, AlgRS256:
+		return true
 	}
+	return false
+}
 
-	return model.ID, nil
+// GenerateKey returns a new secret of the algorithm and the PKIX DER public key,
+// HS512 keys have no public key.
+func GenerateKey(algorithm string) ([]byte, []byte, error) {
+	var private, public any
+	switch algorithm {
+	case AlgHS512:
+		secret := make([]byte, SecretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, nil, err
+		}
+		return secret, nil, nil
+	case AlgEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		private, public = priv, pub
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaBits)
+		if err != nil {
+			return nil, nil, err
+		}
+		private, public = priv, &priv.PublicKey
+	default:
+		return nil, nil, ErrUnknownAlgorithm
+	}
+
+	secret, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, der, nil
+}
+
+// PublicJWK returns the JSON Web Key of a PKIX DER public key.
+func PublicJWK(kid, algorithm string, der []byte) (JWK, error) {
+	public, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk := JWK{Kid: kid, Alg: algorithm, Use: "sig"}
+	switch pub := public.(type) {
+	case ed25519.PublicKey:
+		if algorithm != AlgEdDSA {
+			return JWK{}, ErrUnknownAlgorithm
+		}
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		if algorithm != AlgRS256 {
+			return JWK{}, ErrUnknownAlgorithm
+		}
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	default:
+		return JWK{}, ErrUnknownAlgorithm
+	}
+	return jwk, nil
+}
+
+// NewID returns a random token id used as the jti claim.
+func NewID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func parse(key Key) (parsedKey, error) {
+	if key.Algorithm == AlgHS512 || key.Algorithm == "" {
+		return parsedKey{jwt.SigningMethodHS512, key.Secret, key.Secret}, nil
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(key.Secret)
+	if err != nil {
+		return parsedKey{}, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return parsedKey{}, ErrUnknownAlgorithm
+	}
+
+	switch key.Algorithm {
+	case AlgEdDSA:
+		if _, ok := private.(ed25519.PrivateKey); !ok {
+			return parsedKey{}, ErrUnknownAlgorithm
+		}
+		return parsedKey{jwt.SigningMethodEdDSA, private, signer.Public()}, nil
+	case AlgRS256:
+		if _, ok := private.(*rsa.PrivateKey); !ok {
+			return parsedKey{}, ErrUnknownAlgorithm
+		}
+		return parsedKey{jwt.SigningMethodRS256, private, signer.Public()}, nil
+	default:
+		return parsedKey{}, ErrUnknownAlgorithm
+	}
 }
@@ -0,0 +1,98 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/lib/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyring(t *testing.T, active jwt.Key, keys ...jwt.Key) *jwt.Keyring {
+	t.Helper()
+
+	keyring, err := jwt.NewKeyring(active, keys...)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	model := models.NewTokenModel("jti", []int{1}, nil, time.Minute, models.TokenScope{})
+
+	legacy := jwt.Key{Secret: []byte("secret")}
+	old := jwt.Key{ID: "old", Secret: []byte("old-secret")}
+	current := jwt.Key{ID: "current", Secret: []byte("current-secret")}
+
+	legacyToken, err := newKeyring(t, legacy).CreateToken(ctx, model)
+	require.NoError(t, err)
+	oldToken, err := newKeyring(t, old, legacy).CreateToken(ctx, model)
+	require.NoError(t, err)
+
+	keyring := newKeyring(t, current, legacy, old)
+	token, err := keyring.CreateToken(ctx, model)
+	require.NoError(t, err)
+
+	for _, tt := range []string{legacyToken, oldToken, token} {
+		claims, err := keyring.DecodeToken(ctx, tt)
+		require.NoError(t, err)
+		assert.Equal(t, "jti", claims.RegisteredClaims.ID)
+	}
+
+	_, err = newKeyring(t, current, legacy).DecodeToken(ctx, oldToken)
+	assert.ErrorIs(t, err, jwt.ErrUnknownKey, "tokens of removed keys are rejected")
+
+	forged, err := newKeyring(t, jwt.Key{ID: "current", Secret: []byte("guess")}).CreateToken(ctx, model)
+	require.NoError(t, err)
+	_, err = keyring.DecodeToken(ctx, forged)
+	assert.Error(t, err)
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	ctx := context.Background()
+	model := models.NewTokenModel("jti", []int{1}, nil, time.Minute, models.TokenScope{})
+	legacy := jwt.Key{Algorithm: jwt.AlgHS512, Secret: []byte("secret")}
+
+	for _, tt := range []struct {
+		algorithm string
+		kty       string
+	}{
+		{jwt.AlgEdDSA, "OKP"},
+		{jwt.AlgRS256, "RSA"},
+	} {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			secret, public, err := jwt.GenerateKey(tt.algorithm)
+			require.NoError(t, err)
+			require.NotEmpty(t, public)
+
+			key := jwt.Key{ID: "kid", Algorithm: tt.algorithm, Secret: secret}
+			keyring := newKeyring(t, key, legacy)
+
+			token, err := keyring.CreateToken(ctx, model)
+			require.NoError(t, err)
+			claims, err := keyring.DecodeToken(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, "jti", claims.RegisteredClaims.ID)
+
+			jwk, err := jwt.PublicJWK("kid", tt.algorithm, public)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kty, jwk.Kty)
+			assert.Equal(t, "kid", jwk.Kid)
+			assert.Equal(t, tt.algorithm, jwk.Alg)
+
+			// an HS512 token signed with the public key must not pass as the asymmetric key
+			forged, err := newKeyring(t, jwt.Key{ID: "kid", Algorithm: jwt.AlgHS512, Secret: public}).CreateToken(ctx, model)
+			require.NoError(t, err)
+			_, err = keyring.DecodeToken(ctx, forged)
+			assert.ErrorIs(t, err, jwt.ErrUnknownAlgorithm)
+		})
+	}
+
+	_, _, err := jwt.GenerateKey("none")
+	assert.ErrorIs(t, err, jwt.ErrUnknownAlgorithm)
+	_, err = jwt.NewKeyring(jwt.Key{ID: "kid", Algorithm: jwt.AlgEdDSA, Secret: []byte("secret")})
+	assert.Error(t, err)
+}
@@ -1,18 +1,42 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"strings"
+	"vault/internal/audit"
+	"vault/internal/models"
+	"vault/internal/policy"
 	"vault/pkg/handlers"
 	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/lib/opaque"
 )
 
 type ContextKey string
 
-func RootAuth(log *slog.Logger, rootToken string) func(next http.Handler) http.Handler {
+// TokenStore reports whether an issued token is still active.
+type TokenStore interface {
+	CheckToken(ctx context.Context, log *slog.Logger, jti string) error
+}
+
+// IdentityStore resolves admin identities, operators and the policies bound to them.
+type IdentityStore interface {
+	GetIdentityByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.IdentityModel, error)
+	GetOperatorByToken(ctx context.Context, log *slog.Logger, tokenHash string) (models.OperatorModel, error)
+	GetPolicies(ctx context.Context, log *slog.Logger, names []string) ([]models.PolicyModel, error)
+}
+
+// RootAuth authenticates admin requests and puts the policy.Identity on the context.
+// The root token is a break-glass credential and is granted every capability.
+func RootAuth(log *slog.Logger, rootToken string, store IdentityStore) func(next http.Handler) http.Handler {
 	const op = "middleware.auth.RootAuth"
 
 	return func(next http.Handler) http.Handler {
@@ -26,17 +50,182 @@ func RootAuth(log *slog.Logger, rootToken string) func(next http.Handler) http.H
 			}()
 
 			token := strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", "")
-			if token != rootToken {
-				log.Error("unauthorized", slog.String("op", op), slog.String("token", token))
+			if token == "" {
+				log.Error("unauthorized", slog.String("op", op))
 				handlers.ErrorResponse(w, r, 401, "unauthorized")
 				return
 			}
+
+			var identity policy.Identity
+			if rootToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(rootToken)) == 1 {
+				log.Warn("break-glass root token used", slog.String("op", op), slog.String("path", r.URL.Path))
+				identity = policy.Identity{Name: policy.RootName, ACL: policy.RootACL()}
+				audit.FromContext(r.Context()).SetActor(audit.Actor{Type: "root", Name: policy.RootName})
+			} else {
+				actor, policies, err := adminByToken(r.Context(), log, store, token)
+				if err != nil {
+					log.Error("unauthorized", slog.String("op", op), sl.Err(err))
+					handlers.ErrorResponse(w, r, 401, "unauthorized")
+					return
+				}
+
+				acl, err := identityACL(r.Context(), log, store, policies)
+				if err != nil {
+					log.Error("failed to load identity policies", slog.String("op", op), slog.String(actor.Type, actor.Name), sl.Err(err))
+					handlers.ErrorResponse(w, r, 500, "internal server error")
+					return
+				}
+				identity = policy.Identity{Name: actor.Name, ACL: acl}
+				audit.FromContext(r.Context()).SetActor(actor)
+			}
+
+			var key ContextKey = "identity"
+			r = r.WithContext(context.WithValue(r.Context(), key, identity))
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func UserAuth(log *slog.Logger, secret string) func(next http.Handler) http.Handler {
+// adminByToken resolves an operator session or API key by its prefix, any other token is an admin identity token.
+func adminByToken(ctx context.Context, log *slog.Logger, store IdentityStore, token string) (audit.Actor, []string, error) {
+	if strings.HasPrefix(token, models.OperatorSessionPrefix) || strings.HasPrefix(token, models.OperatorAPIKeyPrefix) {
+		model, err := store.GetOperatorByToken(ctx, log, opaque.Hash(token))
+		if err != nil {
+			return audit.Actor{}, nil, err
+		}
+		return audit.Actor{Type: "operator", Name: model.Name}, model.Policies, nil
+	}
+
+	model, err := store.GetIdentityByToken(ctx, log, opaque.Hash(token))
+	if err != nil {
+		return audit.Actor{}, nil, err
+	}
+	return audit.Actor{Type: "identity", Name: model.Name}, model.Policies, nil
+}
+
+func identityACL(ctx context.Context, log *slog.Logger, store IdentityStore, names []string) (*policy.ACL, error) {
+	stored, err := store.GetPolicies(ctx, log, names)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]policy.Policy, 0, len(stored))
+	for _, model := range stored {
+		p, err := policy.Parse(model.Name, []byte(model.Document))
+		if err != nil {
+			return nil, fmt.Errorf("policy %q: %w", model.Name, err)
+		}
+		policies = append(policies, p)
+	}
+
+	return policy.NewACL(policies...), nil
+}
+
+// Resolver returns the resources a request acts on.
+// No resources and no error means the target does not exist, the handler reports it.
+// Requests which can't be resolved are rejected with an error wrapping ErrInvalidRequest.
+type Resolver func(r *http.Request) ([]string, error)
+
+// ErrInvalidRequest is wrapped by resolvers when the request itself is malformed,
+// Authorize rejects such requests with 400 instead of passing them to the handler.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Resource resolves to a fixed resource name.
+func Resource(name string) Resolver {
+	return func(r *http.Request) ([]string, error) {
+		return []string{name}, nil
+	}
+}
+
+// Authorize checks the identity set by RootAuth is granted the capability on every resource of the request.
+func Authorize(log *slog.Logger, capability string, resolve Resolver) func(next http.Handler) http.Handler {
+	const op = "middleware.auth.Authorize"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key ContextKey = "identity"
+
+			identity, ok := r.Context().Value(key).(policy.Identity)
+			if !ok {
+				log.Error("failed to get identity from context", slog.String("op", op))
+				handlers.ErrorResponse(w, r, 401, "unauthorized")
+				return
+			}
+			if identity.ACL.IsRoot() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resources, err := resolve(r)
+			if errors.Is(err, ErrInvalidRequest) {
+				log.Error("failed to resolve resources", slog.String("op", op), sl.Err(err))
+				handlers.ErrorResponse(w, r, 400, err.Error())
+				return
+			}
+			if err != nil {
+				log.Error("failed to resolve resources", slog.String("op", op), sl.Err(err))
+				handlers.ErrorResponse(w, r, 500, "internal server error")
+				return
+			}
+
+			for _, resource := range resources {
+				if !identity.ACL.Allowed(capability, resource) {
+					log.Error("permission denied",
+						slog.String("op", op),
+						slog.String("identity", identity.Name),
+						slog.String("capability", capability),
+						slog.String("resource", resource),
+					)
+					handlers.ErrorResponse(w, r, 403, "permission denied")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DecodeBody decodes the JSON body into T once and keeps it in the context, resolvers and the
+// handler read it with Body so both act on the same model. Malformed bodies are rejected with 400.
+func DecodeBody[T any](log *slog.Logger) func(next http.Handler) http.Handler {
+	const op = "middleware.auth.DecodeBody"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key ContextKey = "body"
+
+			model, err := Body[T](r)
+			if err != nil {
+				log.Error("failed to decode model", slog.String("op", op), sl.Err(err))
+				handlers.ErrorResponse(w, r, 400, "failed to decode model")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key, model)))
+		})
+	}
+}
+
+// Body returns the model decoded by DecodeBody. Without the middleware the body is decoded
+// here and restored, trailing data after the JSON value is an error either way.
+func Body[T any](r *http.Request) (T, error) {
+	var key ContextKey = "body"
+
+	if model, ok := r.Context().Value(key).(T); ok {
+		return model, nil
+	}
+
+	var model T
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return model, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return model, json.Unmarshal(body, &model)
+}
+
+func UserAuth(log *slog.Logger, signer jwt.Signer, store TokenStore) func(next http.Handler) http.Handler {
 	const op = "middleware.auth.UserAuth"
 
 	return func(next http.Handler) http.Handler {
@@ -51,22 +240,38 @@ func UserAuth(log *slog.Logger, secret string) func(next http.Handler) http.Hand
 
 			token := strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", "")
 			if token == "" {
-				log.Error("unauthorized", slog.String("op", op), slog.String("token", token))
+				log.Error("unauthorized", slog.String("op", op))
 				handlers.ErrorResponse(w, r, 401, "unauthorized")
 				return
 			}
 
-			id, err := jwt.DecodeToken(token, secret)
-			log.Debug("vault id from token", "id", id)
-
+			claims, err := signer.DecodeToken(r.Context(), token)
 			if err != nil {
-				log.Error("failed to decode token", slog.String("op", op), slog.String("token", token))
+				log.Error("failed to decode token", slog.String("op", op), sl.Err(err))
 				handlers.ErrorResponse(w, r, 401, "unauthorized")
+				return
 			}
+			log.Debug("vault ids from token", "ids", claims.Vaults())
+
+			if err := store.CheckToken(r.Context(), log, claims.RegisteredClaims.ID); err != nil {
+				log.Error("token rejected by token store", slog.String("op", op), slog.String("jti", claims.RegisteredClaims.ID), sl.Err(err))
+				handlers.ErrorResponse(w, r, 401, "unauthorized")
+				return
+			}
+
+			audit.FromContext(r.Context()).SetActor(audit.Actor{Type: "token", JTI: claims.RegisteredClaims.ID})
 
-			var key ContextKey = "vaultID"
+			var key ContextKey = "vaultIDs"
+			var pathsKey ContextKey = "tokenPaths"
+			var scopeKey ContextKey = "tokenScope"
+			var transitKey ContextKey = "transitKeys"
+			var claimsKey ContextKey = "tokenClaims"
 
-			ctx := context.WithValue(r.Context(), key, id)
+			ctx := context.WithValue(r.Context(), key, claims.Vaults())
+			ctx = context.WithValue(ctx, pathsKey, claims.Paths)
+			ctx = context.WithValue(ctx, scopeKey, claims.TokenScope)
+			ctx = context.WithValue(ctx, transitKey, claims.TransitKeys)
+			ctx = context.WithValue(ctx, claimsKey, claims)
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
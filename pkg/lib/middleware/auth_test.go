@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vault/internal/models"
+	"vault/internal/policy"
+	"vault/pkg/lib/logger/slogdiscard"
+	mwAuth "vault/pkg/lib/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeBody(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	identity := policy.Identity{Name: "ci", ACL: policy.NewACL(policy.Policy{Name: "ci", Rules: []policy.Rule{
+		{Path: "team/*", Capabilities: []string{policy.Create}},
+	}})}
+
+	resolve := func(r *http.Request) ([]string, error) {
+		model, err := mwAuth.Body[models.SecretCreateModel](r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decode model", mwAuth.ErrInvalidRequest)
+		}
+		return []string{model.Name}, nil
+	}
+
+	tests := []struct {
+		testName  string
+		input     string
+		decode    bool
+		code      int
+		outputStr string
+	}{
+		{
+			testName:  "allowed",
+			input:     `{"name": "team/payments", "data": {"k": "v"}}`,
+			decode:    true,
+			code:      200,
+			outputStr: "team/payments",
+		},
+		{
+			testName:  "denied",
+			input:     `{"name": "billing", "data": {"k": "v"}}`,
+			decode:    true,
+			code:      403,
+			outputStr: `{"status":"error","detail":"permission denied"}`,
+		},
+		{
+			testName:  "trailing data",
+			input:     `{"name": "team/payments", "data": {"k": "v"}} {"name": "billing"}`,
+			decode:    true,
+			code:      400,
+			outputStr: `{"status":"error","detail":"failed to decode model"}`,
+		},
+		{
+			testName:  "trailing data without decode body",
+			input:     `{"name": "team/payments", "data": {"k": "v"}} x`,
+			code:      400,
+			outputStr: `{"status":"error","detail":"invalid request: failed to decode model"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				model, err := mwAuth.Body[models.SecretCreateModel](r)
+				require.NoError(t, err)
+				w.Write([]byte(model.Name))
+			})
+			handler = mwAuth.Authorize(log, policy.Create, resolve)(handler)
+			if tt.decode {
+				handler = mwAuth.DecodeBody[models.SecretCreateModel](log)(handler)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/create", strings.NewReader(tt.input))
+			require.NoError(t, err)
+			req = req.WithContext(context.WithValue(req.Context(), mwAuth.ContextKey("identity"), identity))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.outputStr, strings.TrimSpace(rr.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	mwAuth "vault/pkg/lib/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := mwAuth.ParseProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		testName   string
+		remoteAddr string
+		realIP     string
+		forwarded  string
+		output     string
+	}{
+		{
+			testName:   "untrusted peer",
+			remoteAddr: "203.0.113.7:4000",
+			realIP:     "10.1.2.3",
+			forwarded:  "10.1.2.3",
+			output:     "203.0.113.7:4000",
+		},
+		{
+			testName:   "trusted peer without headers",
+			remoteAddr: "10.0.0.1:4000",
+			output:     "10.0.0.1:4000",
+		},
+		{
+			testName:   "x-real-ip from trusted peer",
+			remoteAddr: "192.168.1.1:4000",
+			realIP:     "198.51.100.2",
+			output:     "198.51.100.2",
+		},
+		{
+			testName:   "prepended x-forwarded-for",
+			remoteAddr: "10.0.0.1:4000",
+			forwarded:  "10.9.9.9, 198.51.100.2, 10.0.0.2",
+			output:     "198.51.100.2",
+		},
+		{
+			testName:   "only trusted hops",
+			remoteAddr: "10.0.0.1:4000",
+			forwarded:  "10.0.0.3, 10.0.0.2",
+			output:     "10.0.0.3",
+		},
+		{
+			testName:   "malformed x-forwarded-for",
+			remoteAddr: "10.0.0.1:4000",
+			forwarded:  "198.51.100.2, bogus",
+			output:     "10.0.0.1:4000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var remoteAddr string
+			handler := mwAuth.RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remoteAddr = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.output, remoteAddr)
+		})
+	}
+
+	_, err = mwAuth.ParseProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"vault/pkg/handlers"
+)
+
+type SealChecker interface {
+	Sealed() bool
+}
+
+func Unsealed(log *slog.Logger, checker SealChecker) func(next http.Handler) http.Handler {
+	const op = "middleware.seal.Unsealed"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if checker.Sealed() {
+				log.Error("vault is sealed", slog.String("op", op))
+				handlers.ErrorResponse(w, r, 503, "vault is sealed")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"vault/internal/models"
+	"vault/pkg/handlers"
+	"vault/pkg/lib/encryption"
+	"vault/pkg/lib/jwt"
+	"vault/pkg/lib/logger/sl"
+	"vault/pkg/lib/opaque"
+)
+
+// WrappingStore keeps wrapped responses until they are unwrapped or expire.
+type WrappingStore interface {
+	CreateWrapping(ctx context.Context, log *slog.Logger, model models.WrappedResponse, tokenHash string) error
+}
+
+// Wrap replaces successful responses of requests with the X-Vault-Wrap-TTL header by a single use
+// wrapping token, the response is stored encrypted and returned once by /sys/wrapping/unwrap.
+// limit caps the ttl of wrapping tokens unless it is 0.
+func Wrap(log *slog.Logger, store WrappingStore, keeper encryption.Keeper, limit time.Duration) func(next http.Handler) http.Handler {
+	const op = "middleware.wrap.Wrap"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(models.WrapTTLHeader)
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ttl, err := models.ParseWrapTTL(header, limit)
+			if err != nil {
+				log.Error("invalid wrap ttl", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 400, err.Error())
+				return
+			}
+
+			// the envelope is checked first so handlers never issue credentials which can't be wrapped
+			envelope, err := keeper.Envelope()
+			if err != nil {
+				log.Error("vault is sealed", slog.String("op", op))
+				handlers.ErrorResponse(w, r, 503, "vault is sealed")
+				return
+			}
+
+			buf := &bufferedWriter{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(buf, r)
+
+			// errors are not worth wrapping and tell the caller why nothing was wrapped
+			if buf.status < 200 || buf.status >= 300 {
+				buf.flush(w)
+				return
+			}
+
+			info, err := wrapResponse(r.Context(), log, store, envelope, strings.TrimPrefix(r.URL.Path, "/"), buf.body.Bytes(), ttl)
+			if err != nil {
+				log.Error("failed to wrap response", sl.OpErr(op, err))
+				handlers.ErrorResponse(w, r, 500, "failed to wrap response")
+				return
+			}
+
+			handlers.SuccessResponse(w, r, 200, map[string]any{"wrap_info": info})
+			log.Info("response wrapped", slog.String("accessor", info.Accessor), slog.String("creation_path", info.CreationPath))
+		})
+	}
+}
+
+func wrapResponse(ctx context.Context, log *slog.Logger, store WrappingStore, envelope *encryption.Envelope, path string, body []byte, ttl time.Duration) (models.WrapInfoModel, error) {
+	token, err := opaque.New(models.WrappingTokenPrefix)
+	if err != nil {
+		return models.WrapInfoModel{}, err
+	}
+	accessor, err := jwt.NewID()
+	if err != nil {
+		return models.WrapInfoModel{}, err
+	}
+
+	dataKey, wrappedKey, err := envelope.GenerateDataKey()
+	if err != nil {
+		return models.WrapInfoModel{}, err
+	}
+	payload, err := encryption.EncryptValue(dataKey, string(body), models.WrappingAAD(accessor))
+	if err != nil {
+		return models.WrapInfoModel{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	model := models.WrappedResponse{
+		WrappingModel: models.WrappingModel{
+			Accessor:     accessor,
+			CreationPath: path,
+			TTL:          ttl / time.Second,
+			CreatedAt:    now,
+			ExpiresAt:    now.Add(ttl),
+		},
+		DataKey: wrappedKey,
+		Payload: payload,
+	}
+	if err := store.CreateWrapping(ctx, log, model, opaque.Hash(token)); err != nil {
+		return models.WrapInfoModel{}, err
+	}
+
+	return models.WrapInfoModel{Token: token, WrappingModel: model.WrappingModel}, nil
+}
+
+// bufferedWriter holds back the response of a handler until it is known whether it gets wrapped.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.wrote {
+		return
+	}
+	b.status, b.wrote = status, true
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.wrote = true
+	return b.body.Write(p)
+}
+
+// flush writes the held back response unchanged.
+func (b *bufferedWriter) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
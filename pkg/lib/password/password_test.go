@@ -0,0 +1,31 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+	"vault/pkg/lib/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := password.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$"))
+
+	other, err := password.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "hashes are salted")
+
+	ok, err := password.Verify("correct horse battery staple", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = password.Verify("wrong", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = password.Verify("wrong", "$2a$10$notargon")
+	assert.ErrorIs(t, err, password.ErrInvalidHash)
+}
@@ -0,0 +1,171 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// Shares are the y coordinates of every secret byte followed by the share x coordinate.
+
+var (
+	ErrParts       = errors.New("parts must be between threshold and 255")
+	ErrThreshold   = errors.New("threshold must be between 2 and 255")
+	ErrEmptySecret = errors.New("cannot split an empty secret")
+	ErrShares      = errors.New("at least two shares of equal length are required")
+	ErrDuplicate   = errors.New("duplicate share detected")
+)
+
+// Split divides secret into parts shares, any threshold of them reconstruct it.
+func Split(secret []byte, parts int, threshold int) ([][]byte, error) {
+	if threshold < 2 || threshold > 255 {
+		return nil, ErrThreshold
+	}
+	if parts < threshold || parts > 255 {
+		return nil, ErrParts
+	}
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+
+	xs, err := randomCoordinates(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]byte, parts)
+	for i := range out {
+		out[i] = make([]byte, len(secret)+1)
+		out[i][len(secret)] = xs[i]
+	}
+
+	coefficients := make([]byte, threshold)
+	for idx, b := range secret {
+		coefficients[0] = b
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range out {
+			out[i][idx] = evaluate(coefficients, xs[i])
+		}
+	}
+
+	return out, nil
+}
+
+// Combine reconstructs the secret from threshold or more shares.
+func Combine(parts [][]byte) ([]byte, error) {
+	if len(parts) < 2 {
+		return nil, ErrShares
+	}
+	size := len(parts[0])
+	if size < 2 {
+		return nil, ErrShares
+	}
+
+	xs := make([]byte, len(parts))
+	seen := make(map[byte]bool, len(parts))
+	for i, part := range parts {
+		if len(part) != size {
+			return nil, ErrShares
+		}
+		x := part[size-1]
+		if seen[x] {
+			return nil, ErrDuplicate
+		}
+		seen[x] = true
+		xs[i] = x
+	}
+
+	secret := make([]byte, size-1)
+	ys := make([]byte, len(parts))
+	for idx := range secret {
+		for i, part := range parts {
+			ys[i] = part[idx]
+		}
+		secret[idx] = interpolate(xs, ys)
+	}
+
+	return secret, nil
+}
+
+// randomCoordinates returns distinct non-zero x coordinates, zero holds the secret itself.
+func randomCoordinates(n int) ([]byte, error) {
+	perm := make([]byte, 255)
+	for i := range perm {
+		perm[i] = byte(i + 1)
+	}
+	buf := make([]byte, 1)
+	for i := len(perm) - 1; i > 0; i-- {
+		if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+			return nil, err
+		}
+		j := int(buf[0]) % (i + 1)
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	return perm[:n], nil
+}
+
+// evaluate computes the polynomial value at x with Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var out byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		out = add(mul(out, x), coefficients[i])
+	}
+	return out
+}
+
+// interpolate returns the Lagrange polynomial value at zero.
+func interpolate(xs []byte, ys []byte) byte {
+	var result byte
+	for i := range xs {
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(xs[j], add(xs[i], xs[j])))
+		}
+		result = add(result, mul(ys[i], basis))
+	}
+	return result
+}
+
+func add(a, b byte) byte {
+	return a ^ b
+}
+
+// mul multiplies in GF(2^8) with the AES polynomial without data dependent branches.
+func mul(a, b byte) byte {
+	var r byte
+	for i := 0; i < 8; i++ {
+		mask := -(b & 1)
+		r ^= a & mask
+		carry := -(a >> 7)
+		a = (a << 1) ^ (0x1b & carry)
+		b >>= 1
+	}
+	return r
+}
+
+func div(a, b byte) byte {
+	if b == 0 {
+		panic("shamir: divide by zero")
+	}
+	return mul(a, inverse(b))
+}
+
+// inverse uses a^254 = a^-1 in GF(2^8).
+func inverse(a byte) byte {
+	b := mul(a, a)
+	c := mul(a, b)
+	b = mul(c, c)
+	b = mul(b, b)
+	c = mul(b, c)
+	b = mul(b, b)
+	b = mul(b, b)
+	b = mul(b, c)
+	b = mul(b, b)
+	b = mul(a, b)
+	return mul(b, b)
+}
@@ -0,0 +1,45 @@
+package shamir_test
+
+import (
+	"testing"
+	"vault/pkg/lib/shamir"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	parts, err := shamir.Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, parts, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var shares [][]byte
+		for _, i := range subset {
+			shares = append(shares, parts[i])
+		}
+		got, err := shamir.Combine(shares)
+		require.NoError(t, err)
+		assert.Equal(t, secret, got)
+	}
+
+	got, err := shamir.Combine(parts[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, got)
+
+	_, err = shamir.Combine([][]byte{parts[0], parts[0]})
+	assert.ErrorIs(t, err, shamir.ErrDuplicate)
+}
+
+func TestSplitInvalid(t *testing.T) {
+	_, err := shamir.Split([]byte("secret"), 3, 1)
+	assert.ErrorIs(t, err, shamir.ErrThreshold)
+
+	_, err = shamir.Split([]byte("secret"), 2, 3)
+	assert.ErrorIs(t, err, shamir.ErrParts)
+
+	_, err = shamir.Split(nil, 3, 2)
+	assert.ErrorIs(t, err, shamir.ErrEmptySecret)
+}
@@ -0,0 +1,181 @@
+// Package transit encrypts, decrypts and signs data with versioned named keys
+// without storing the data.
+package transit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	TypeAES256GCM        = "aes256-gcm96"
+	TypeChaCha20Poly1305 = "chacha20-poly1305"
+	TypeEd25519          = "ed25519"
+	TypeHMAC             = "hmac"
+)
+
+// Types lists every key type.
+var Types = []string{TypeAES256GCM, TypeChaCha20Poly1305, TypeEd25519, TypeHMAC}
+
+// KeySize is the size of the key material of every type in bytes,
+// ed25519 keys are derived from it as a seed.
+const KeySize = 32
+
+// valuePrefix starts ciphertexts and signatures, the key version follows it.
+const valuePrefix = "vault:v"
+
+var (
+	ErrKeySize = fmt.Errorf("transit key must be %d bytes", KeySize)
+	ErrKeyType = errors.New("unsupported operation for the key type")
+	ErrValue   = errors.New("malformed ciphertext or signature")
+	ErrDecrypt = errors.New("failed to decrypt ciphertext")
+)
+
+// CanEncrypt reports whether keys of the type encrypt data.
+func CanEncrypt(keyType string) bool {
+	return keyType == TypeAES256GCM || keyType == TypeChaCha20Poly1305
+}
+
+// CanSign reports whether keys of the type sign data.
+func CanSign(keyType string) bool {
+	return keyType == TypeEd25519 || keyType == TypeHMAC
+}
+
+// ValidType reports whether the key type is supported.
+func ValidType(keyType string) bool {
+	return slices.Contains(Types, keyType)
+}
+
+func aead(keyType string, key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	switch keyType {
+	case TypeAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case TypeChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, ErrKeyType
+}
+
+// Encrypt seals the plaintext with a random nonce, context is authenticated but not encrypted.
+func Encrypt(keyType string, key, plaintext, context []byte) ([]byte, error) {
+	gcm, err := aead(keyType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, context), nil
+}
+
+// Decrypt opens a ciphertext of Encrypt, the context has to match.
+func Decrypt(keyType string, key, ciphertext, context []byte) ([]byte, error) {
+	gcm, err := aead(keyType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrValue
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, context)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Sign returns the ed25519 signature or the HMAC-SHA256 of the input.
+func Sign(keyType string, key, input []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	switch keyType {
+	case TypeEd25519:
+		return ed25519.Sign(ed25519.NewKeyFromSeed(key), input), nil
+	case TypeHMAC:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+	return nil, ErrKeyType
+}
+
+// Verify checks a signature of Sign.
+func Verify(keyType string, key, input, signature []byte) (bool, error) {
+	if len(key) != KeySize {
+		return false, ErrKeySize
+	}
+	switch keyType {
+	case TypeEd25519:
+		public := ed25519.NewKeyFromSeed(key).Public().(ed25519.PublicKey)
+		return ed25519.Verify(public, input, signature), nil
+	case TypeHMAC:
+		expected, err := Sign(keyType, key, input)
+		if err != nil {
+			return false, err
+		}
+		return hmac.Equal(expected, signature), nil
+	}
+	return false, ErrKeyType
+}
+
+// PublicKey returns the base64 public key of ed25519 keys, other types have none.
+func PublicKey(keyType string, key []byte) (string, error) {
+	if keyType != TypeEd25519 {
+		return "", nil
+	}
+	if len(key) != KeySize {
+		return "", ErrKeySize
+	}
+	public := ed25519.NewKeyFromSeed(key).Public().(ed25519.PublicKey)
+	return base64.StdEncoding.EncodeToString(public), nil
+}
+
+// FormatValue prefixes the base64 ciphertext or signature with the key version, like vault:v2:...
+func FormatValue(version int, raw []byte) string {
+	return valuePrefix + strconv.Itoa(version) + ":" + base64.StdEncoding.EncodeToString(raw)
+}
+
+// ParseValue returns the key version and the raw ciphertext or signature.
+func ParseValue(value string) (int, []byte, error) {
+	rest, ok := strings.CutPrefix(value, valuePrefix)
+	if !ok {
+		return 0, nil, ErrValue
+	}
+	version, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, nil, ErrValue
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return 0, nil, ErrValue
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, nil, ErrValue
+	}
+	return v, raw, nil
+}
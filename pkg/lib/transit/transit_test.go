@@ -0,0 +1,96 @@
+package transit_test
+
+import (
+	"crypto/rand"
+	"testing"
+	"vault/pkg/lib/transit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, transit.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncrypt(t *testing.T) {
+	for _, keyType := range []string{transit.TypeAES256GCM, transit.TypeChaCha20Poly1305} {
+		t.Run(keyType, func(t *testing.T) {
+			key := newKey(t)
+
+			ciphertext, err := transit.Encrypt(keyType, key, []byte("4111 1111 1111 1111"), []byte("customer:42"))
+			require.NoError(t, err)
+			assert.NotContains(t, string(ciphertext), "4111")
+
+			other, err := transit.Encrypt(keyType, key, []byte("4111 1111 1111 1111"), []byte("customer:42"))
+			require.NoError(t, err)
+			assert.NotEqual(t, ciphertext, other, "every encryption takes a new nonce")
+
+			plaintext, err := transit.Decrypt(keyType, key, ciphertext, []byte("customer:42"))
+			require.NoError(t, err)
+			assert.Equal(t, "4111 1111 1111 1111", string(plaintext))
+
+			_, err = transit.Decrypt(keyType, key, ciphertext, []byte("customer:43"))
+			assert.ErrorIs(t, err, transit.ErrDecrypt, "the context is authenticated")
+			_, err = transit.Decrypt(keyType, newKey(t), ciphertext, []byte("customer:42"))
+			assert.ErrorIs(t, err, transit.ErrDecrypt)
+			_, err = transit.Decrypt(keyType, key, ciphertext[:4], nil)
+			assert.ErrorIs(t, err, transit.ErrValue)
+		})
+	}
+
+	_, err := transit.Encrypt(transit.TypeHMAC, newKey(t), []byte("data"), nil)
+	assert.ErrorIs(t, err, transit.ErrKeyType)
+	_, err = transit.Encrypt(transit.TypeAES256GCM, []byte("short"), []byte("data"), nil)
+	assert.ErrorIs(t, err, transit.ErrKeySize)
+}
+
+func TestSign(t *testing.T) {
+	for _, keyType := range []string{transit.TypeEd25519, transit.TypeHMAC} {
+		t.Run(keyType, func(t *testing.T) {
+			key := newKey(t)
+
+			signature, err := transit.Sign(keyType, key, []byte("payload"))
+			require.NoError(t, err)
+
+			valid, err := transit.Verify(keyType, key, []byte("payload"), signature)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			valid, err = transit.Verify(keyType, key, []byte("tampered"), signature)
+			require.NoError(t, err)
+			assert.False(t, valid)
+
+			valid, err = transit.Verify(keyType, newKey(t), []byte("payload"), signature)
+			require.NoError(t, err)
+			assert.False(t, valid)
+		})
+	}
+
+	public, err := transit.PublicKey(transit.TypeEd25519, newKey(t))
+	require.NoError(t, err)
+	assert.Len(t, public, 44)
+
+	_, err = transit.Sign(transit.TypeAES256GCM, newKey(t), []byte("payload"))
+	assert.ErrorIs(t, err, transit.ErrKeyType)
+}
+
+func TestValue(t *testing.T) {
+	value := transit.FormatValue(3, []byte("raw"))
+	assert.Equal(t, "vault:v3:cmF3", value)
+
+	version, raw, err := transit.ParseValue(value)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.Equal(t, []byte("raw"), raw)
+
+	for _, value := range []string{"cmF3", "vault:v0:cmF3", "vault:vx:cmF3", "vault:v1", "vault:v1:***"} {
+		_, _, err := transit.ParseValue(value)
+		assert.ErrorIs(t, err, transit.ErrValue, value)
+	}
+}